@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/internal/encryption"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
@@ -30,8 +31,8 @@ type UploadFiles struct {
 
 type IAttachDeps interface {
 	GetFileSize(file string) int64
-	GetReader(file string) (*bytes.Reader, error)
-	GetWrapper(endpoint string, file *bytes.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper
+	GetReader(file string) (io.ReadSeeker, error)
+	GetWrapper(endpoint string, file io.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper
 	GetUrlsToReturn(res *http.Response) (*string, error)
 }
 
@@ -51,12 +52,33 @@ func Upload(endpoint string, identifyingKey string, timestamp string, dependenci
 	log.Debugf("Attempting to attach file with key: %s\n", identifyingKey)
 	var filesToUpload []UploadFiles
 
-	zipfile := getFilesForUpload(identifyingKey, timestamp, "zip", dependencies)
-	jsonfile := getFilesForUpload(identifyingKey, timestamp, "json", dependencies)
+	for _, filename := range OutputFilenames() {
+		filesToUpload = append(filesToUpload, getFilesForUpload(config.Flags.OutputPath, filename, timestamp, dependencies))
+	}
 
-	filesToUpload = append(filesToUpload, zipfile)
-	filesToUpload = append(filesToUpload, jsonfile)
+	uploadAndPrintUrls(endpoint, filesToUpload, identifyingKey, dependencies)
+}
 
+// UploadExisting - uploads a zip from an earlier run, along with the nrdiag-output.json next to it when present.
+// An interrupted upload of the same zip is resumed.
+func UploadExisting(zipPath string, endpoint string, identifyingKey string, timestamp string, dependencies IAttachDeps) {
+	dir, filename := filepath.Split(zipPath)
+	if dir == "" {
+		dir = "."
+	}
+	if _, err := os.Stat(zipPath); err != nil {
+		log.Fatalf("Unable to upload %s: %s", zipPath, err.Error())
+	}
+	filesToUpload := []UploadFiles{getFilesForUpload(dir, filename, timestamp, dependencies)}
+	jsonPath := SiblingJSON(zipPath)
+	if _, err := os.Stat(jsonPath); err == nil {
+		filesToUpload = append(filesToUpload, getFilesForUpload(dir, filepath.Base(jsonPath), timestamp, dependencies))
+	}
+
+	uploadAndPrintUrls(endpoint, filesToUpload, identifyingKey, dependencies)
+}
+
+func uploadAndPrintUrls(endpoint string, filesToUpload []UploadFiles, identifyingKey string, dependencies IAttachDeps) {
 	if len(filesToUpload) == 0 {
 		log.Debug("No files to upload.")
 		return
@@ -82,12 +104,37 @@ func Upload(endpoint string, identifyingKey string, timestamp string, dependenci
 
 }
 
-func getFilesForUpload(identifyingKey string, timestamp string, filetype string, deps IAttachDeps) UploadFiles {
-	thisFileName := "nrdiag-output." + filetype
-	thisFile := UploadFiles{Path: config.Flags.OutputPath, Filename: thisFileName}
-	thisFile.Filesize = deps.GetFileSize(thisFile.Path + "/" + thisFile.Filename)
-	extension := filepath.Ext(thisFileName)
-	shortName := thisFileName[0 : len(thisFileName)-len(extension)]
+// OutputFilenames - the names of the zip and json written to -output-path, which are encrypted with -encrypt-to
+func OutputFilenames() []string {
+	filenames := []string{"nrdiag-output.zip", "nrdiag-output.json"}
+	if config.Flags.EncryptTo != "" {
+		for i := range filenames {
+			filenames[i] += encryption.FileExtension
+		}
+	}
+	return filenames
+}
+
+// SiblingJSON - the nrdiag-output.json written next to a zip, encrypted when the zip is
+func SiblingJSON(zipPath string) string {
+	shortName, extension := splitExtension(zipPath)
+	if strings.HasSuffix(extension, encryption.FileExtension) {
+		return shortName + ".json" + encryption.FileExtension
+	}
+	return shortName + ".json"
+}
+
+// splitExtension splits a file name before its extension, keeping the encryption extension with the one before it
+func splitExtension(filename string) (string, string) {
+	trimmed := strings.TrimSuffix(filename, encryption.FileExtension)
+	extension := filepath.Ext(trimmed) + filename[len(trimmed):]
+	return filename[:len(filename)-len(extension)], extension
+}
+
+func getFilesForUpload(path string, thisFileName string, timestamp string, deps IAttachDeps) UploadFiles {
+	thisFile := UploadFiles{Path: path, Filename: thisFileName}
+	thisFile.Filesize = deps.GetFileSize(filepath.Join(thisFile.Path, thisFile.Filename))
+	shortName, extension := splitExtension(thisFileName)
 	thisFile.NewFilename = shortName + "-" + timestamp + extension
 	log.Debug("Renamed file from", thisFileName, " to ", thisFile.NewFilename)
 	return thisFile
@@ -95,15 +142,32 @@ func getFilesForUpload(identifyingKey string, timestamp string, filetype string,
 
 func uploadFilesToAccount(endpoint string, filesToUpload []UploadFiles, attachmentKey string, deps IAttachDeps) ([]string, error) {
 	var urlsToReturn []string
+	var zipUrls []string
 	for _, files := range filesToUpload {
-		newUrl, err := uploadFile(endpoint, files, attachmentKey, deps)
+		var newUrl *string
+		var err error
+		if files.Filesize > multipartThreshold {
+			newUrl, err = uploadMultipart(endpoint, files, attachmentKey)
+			if errors.Is(err, errMultipartUnsupported) {
+				log.Debug("Falling back to a single request upload:", err)
+				newUrl, err = uploadFile(endpoint, files, attachmentKey, deps)
+			}
+		} else {
+			newUrl, err = uploadFile(endpoint, files, attachmentKey, deps)
+		}
 		if err != nil {
 			return nil, err
 		}
 		if !strings.Contains(files.Filename, ".zip") {
 			urlsToReturn = append(urlsToReturn, *newUrl)
+		} else if newUrl != nil {
+			zipUrls = append(zipUrls, *newUrl)
 		}
 	}
+	// the json upload links to the run, only fall back to the zip link when no json was uploaded
+	if len(urlsToReturn) == 0 {
+		return zipUrls, nil
+	}
 	return urlsToReturn, nil
 }
 
@@ -114,8 +178,24 @@ func uploadFile(endpoint string, files UploadFiles, attachmentKey string, deps I
 		log.Info("Error uploading", err)
 		return nil, err
 	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	digest, err := fileSHA256(reader)
+	if err == nil {
+		_, err = reader.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		log.Info("Error reading file for upload", err)
+		return nil, err
+	}
 
 	wrapper := deps.GetWrapper(endpoint, reader, files.Filesize, files.NewFilename, attachmentKey)
+	if wrapper.Headers == nil {
+		wrapper.Headers = make(map[string]string)
+	}
+	wrapper.Headers[contentSHA256Header] = digest
 
 	log.Debug("Starting upload")
 	res, err := makeRequest(wrapper)
@@ -132,6 +212,15 @@ func uploadFile(endpoint string, files UploadFiles, attachmentKey string, deps I
 		return nil, errors.New(res.Status)
 	}
 	log.Debug("Upload finished with status:  ", res.Status)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyUploadChecksum(body, digest, files.Filename); err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
 	newUrl, urlError := deps.GetUrlsToReturn(res)
 	if urlError != nil {
 		return nil, urlError
@@ -139,6 +228,22 @@ func uploadFile(endpoint string, files UploadFiles, attachmentKey string, deps I
 	return newUrl, nil
 }
 
+// verifyUploadChecksum compares the SHA-256 the service computed for the upload with the local one.
+// The service echoes it as sha256 in the response, older services that do not are only logged.
+func verifyUploadChecksum(body []byte, digest string, filename string) error {
+	var response struct {
+		SHA256 string `json:"sha256"`
+	}
+	if json.Unmarshal(body, &response) != nil || response.SHA256 == "" {
+		log.Debug("The attachment service did not return a checksum for", filename, ", the upload was not verified")
+		return nil
+	}
+	if !strings.EqualFold(response.SHA256, digest) {
+		return fmt.Errorf("checksum mismatch for %s: uploaded %s, local %s", filename, response.SHA256, digest)
+	}
+	return nil
+}
+
 func getAttachmentsEndpoint() string {
 	if config.Flags.AttachmentEndpoint != "" { //If local development flag is supplied
 		return config.Flags.AttachmentEndpoint
@@ -162,16 +267,17 @@ func (a AttachDeps) GetFileSize(file string) int64 {
 	return stat.Size()
 }
 
-func (a AttachDeps) GetReader(file string) (*bytes.Reader, error) {
-	data, err := os.ReadFile(file)
+// GetReader opens the file so it is streamed from disk instead of read into memory
+func (a AttachDeps) GetReader(file string) (io.ReadSeeker, error) {
+	f, err := os.Open(file)
 	if err != nil {
 		log.Info("Error uploading", err)
 		return nil, err
 	}
-	return bytes.NewReader(data), err
+	return f, nil
 }
 
-func (a AttachDeps) GetWrapper(endpoint string, file *bytes.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper {
+func (a AttachDeps) GetWrapper(endpoint string, file io.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper {
 	wrapper := httpHelper.RequestWrapper{
 		Method:         "POST",
 		URL:            getAttachmentsEndpoint() + "/" + endpoint,
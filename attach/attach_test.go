@@ -423,6 +423,25 @@ var _ = Describe("GetAttachmentEndpoints", func() {
 
 })
 
+func TestOutputFilenames(t *testing.T) {
+	assert.Equal(t, []string{"nrdiag-output.zip", "nrdiag-output.json"}, OutputFilenames())
+	config.Flags.EncryptTo = "support.pub"
+	defer func() { config.Flags.EncryptTo = "" }()
+	assert.Equal(t, []string{"nrdiag-output.zip.enc", "nrdiag-output.json.enc"}, OutputFilenames())
+}
+
+func TestSiblingJSON(t *testing.T) {
+	assert.Equal(t, "out/nrdiag-output.json", SiblingJSON("out/nrdiag-output.zip"))
+	assert.Equal(t, "out/nrdiag-output.json.enc", SiblingJSON("out/nrdiag-output.zip.enc"))
+}
+
+func Test_getFilesForUploadEncrypted(t *testing.T) {
+	deps := new(mocks.MAttachDeps)
+	deps.On("GetFileSize", mock.Anything).Return(int64(10))
+	got := getFilesForUpload("out", "nrdiag-output.zip.enc", "ts", deps)
+	assert.Equal(t, "nrdiag-output-ts.zip.enc", got.NewFilename)
+}
+
 func TestBadAttachmentUploadURL(t *testing.T) {
 	r := thisRouter(FailureGetRequest)
 
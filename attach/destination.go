@@ -0,0 +1,204 @@
+package attach
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+)
+
+// Destination is a place other than the New Relic attachment service that output artifacts can be uploaded to
+type Destination interface {
+	// Upload copies the file at path to the destination under name and returns where it landed
+	Upload(path string, name string, size int64) (string, error)
+	// RequiresNetwork is false for destinations that can be used in offline mode
+	RequiresNetwork() bool
+}
+
+// NewDestination returns the Destination for an -upload-to value:
+//
+//	https://host/path/       HTTP(S) PUT, or POST when method is POST
+//	s3://bucket/prefix       S3 compatible object storage, ?endpoint= and ?region= select a non AWS service
+//	file:///path or a path   a local or network mounted directory
+func NewDestination(rawURL string, method string, headers map[string]string) (Destination, error) {
+	if !strings.Contains(rawURL, "://") {
+		return DirectoryDestination{Dir: rawURL}, nil
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		method = strings.ToUpper(method)
+		if method == "" {
+			method = http.MethodPut
+		}
+		if method != http.MethodPut && method != http.MethodPost {
+			return nil, fmt.Errorf("unsupported upload method %q, use PUT or POST", method)
+		}
+		return HTTPDestination{URL: parsed, Method: method, Headers: headers}, nil
+	case "s3":
+		return newS3Destination(parsed)
+	case "file":
+		return DirectoryDestination{Dir: filepath.FromSlash(parsed.Path)}, nil
+	}
+	return nil, fmt.Errorf("unsupported upload destination scheme %q, use http, https, s3 or file", parsed.Scheme)
+}
+
+// ParseHeaders parses the comma separated "Name: value" pairs of the -upload-headers flag
+func ParseHeaders(value string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, headerValue, found := strings.Cut(pair, ":")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("upload header %q should be in the format 'Name: value'", strings.TrimSpace(pair))
+		}
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(headerValue)
+	}
+	return headers, nil
+}
+
+// UploadToDestination - uploads each file to the destination, naming it with the run timestamp so earlier runs are not overwritten
+func UploadToDestination(dest Destination, paths []string, timestamp string) error {
+	log.Info(color.ColorString(color.White, "Uploading results to "+destinationName(dest)))
+	for _, path := range paths {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		filename := filepath.Base(path)
+		shortName, extension := splitExtension(filename)
+		name := shortName + "-" + timestamp + extension
+
+		location, err := dest.Upload(path, name, stat.Size())
+		if err != nil {
+			return fmt.Errorf("unable to upload %s: %w", filename, err)
+		}
+		log.Infof("%s", color.ColorString(color.LightBlue, fmt.Sprintf("\t%s\n", location)))
+	}
+	return nil
+}
+
+func destinationName(dest Destination) string {
+	switch d := dest.(type) {
+	case HTTPDestination:
+		return d.URL.Redacted()
+	case S3Destination:
+		return "s3://" + d.Bucket + "/" + d.Prefix
+	case DirectoryDestination:
+		return d.Dir
+	}
+	return "upload destination"
+}
+
+// HTTPDestination uploads to a generic HTTP(S) endpoint. PUT requests go to the URL with the file name appended,
+// POST requests go to the URL as is with the file name in the filename query parameter.
+type HTTPDestination struct {
+	URL     *url.URL
+	Method  string
+	Headers map[string]string
+}
+
+// RequiresNetwork - HTTP destinations can not be used in offline mode
+func (d HTTPDestination) RequiresNetwork() bool {
+	return true
+}
+
+// Upload streams the file in a single request, showing the httpHelper progress bar
+func (d HTTPDestination) Upload(path string, name string, size int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	target := *d.URL
+	query := target.Query()
+	if d.Method == http.MethodPut {
+		target.Path = strings.TrimSuffix(target.Path, "/") + "/" + name
+		target.RawPath = ""
+	} else {
+		query.Set("filename", name)
+	}
+
+	wrapper := httpHelper.NewHTTPRequestWrapper()
+	wrapper.Method = d.Method
+	wrapper.URL = target.String()
+	wrapper.Params = query
+	wrapper.Payload = file
+	wrapper.Length = size
+	wrapper.TimeoutSeconds = awsUploadTimeoutSeconds
+	wrapper.Headers["Content-Type"] = "application/octet-stream"
+	for name, value := range d.Headers {
+		wrapper.Headers[name] = value
+	}
+
+	res, err := makeRequest(wrapper)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		log.Debug("Upload response body was", string(body))
+		return "", errors.New(res.Status)
+	}
+	target.RawQuery = ""
+	return target.Redacted(), nil
+}
+
+// DirectoryDestination copies artifacts into a local or network mounted directory
+type DirectoryDestination struct {
+	Dir string
+}
+
+// RequiresNetwork - a directory can be written in offline mode
+func (d DirectoryDestination) RequiresNetwork() bool {
+	return false
+}
+
+// Upload copies the file through a temporary file so readers of the directory never see a partial artifact
+func (d DirectoryDestination) Upload(path string, name string, size int64) (string, error) {
+	if err := os.MkdirAll(d.Dir, 0755); err != nil {
+		return "", err
+	}
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	target := filepath.Join(d.Dir, name)
+	temp, err := os.CreateTemp(d.Dir, "."+name+".*")
+	if err != nil {
+		return "", err
+	}
+	written, err := io.Copy(temp, source)
+	closeErr := temp.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && written != size {
+		err = fmt.Errorf("copied %d of %d bytes", written, size)
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+	return target, nil
+}
@@ -0,0 +1,222 @@
+package attach
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// S3 rejects single PUT uploads above 5 GiB
+const s3MaxPutSize = 5 << 30
+
+// S3Destination uploads to S3 or S3 compatible object storage such as MinIO with a SigV4 signed PUT.
+// Credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and the optional AWS_SESSION_TOKEN.
+type S3Destination struct {
+	Bucket string
+	Prefix string
+	Region string
+	// Endpoint is set for S3 compatible services, which are addressed path style
+	Endpoint    *url.URL
+	Credentials s3Credentials
+	now         func() time.Time
+}
+
+type s3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// newS3Destination parses s3://bucket/prefix?region=<region>&endpoint=<url>
+func newS3Destination(parsed *url.URL) (S3Destination, error) {
+	dest := S3Destination{
+		Bucket: parsed.Host,
+		Prefix: strings.Trim(parsed.Path, "/"),
+		Region: parsed.Query().Get("region"),
+		Credentials: s3Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		now: time.Now,
+	}
+	if dest.Bucket == "" {
+		return dest, errors.New("the s3 upload destination is missing a bucket, use s3://bucket/prefix")
+	}
+	if dest.Region == "" {
+		dest.Region = os.Getenv("AWS_REGION")
+	}
+	if dest.Region == "" {
+		dest.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if dest.Region == "" {
+		dest.Region = "us-east-1"
+	}
+	if endpoint := parsed.Query().Get("endpoint"); endpoint != "" {
+		endpointURL, err := url.Parse(endpoint)
+		if err != nil || endpointURL.Host == "" {
+			return dest, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+		}
+		dest.Endpoint = endpointURL
+	}
+	if dest.Credentials.AccessKeyID == "" || dest.Credentials.SecretAccessKey == "" {
+		return dest, errors.New("uploading to s3 requires the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables")
+	}
+	return dest, nil
+}
+
+// RequiresNetwork - object storage can not be used in offline mode
+func (d S3Destination) RequiresNetwork() bool {
+	return true
+}
+
+// Upload streams the file in a single signed PUT. The payload hash is computed up front so the
+// body does not have to be held in memory to sign it.
+func (d S3Destination) Upload(path string, name string, size int64) (string, error) {
+	if size > s3MaxPutSize {
+		return "", fmt.Errorf("%s is larger than the 5 GiB S3 single upload limit", name)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	payloadHash, err := fileSHA256(file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	key := name
+	if d.Prefix != "" {
+		key = d.Prefix + "/" + name
+	}
+	objectURL := d.objectURL(key)
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/octet-stream")
+	headers.Set("X-Amz-Content-Sha256", payloadHash)
+	signV4(http.MethodPut, objectURL, headers, payloadHash, d.Credentials, d.Region, "s3", d.now())
+
+	wrapper := httpHelper.NewHTTPRequestWrapper()
+	wrapper.Method = http.MethodPut
+	wrapper.URL = objectURL.String()
+	wrapper.Payload = file
+	wrapper.Length = size
+	wrapper.TimeoutSeconds = awsUploadTimeoutSeconds
+	for name := range headers {
+		wrapper.Headers[name] = headers.Get(name)
+	}
+
+	res, err := makeRequest(wrapper)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		log.Debug("S3 response body was", string(body))
+		return "", errors.New(res.Status)
+	}
+	return "s3://" + d.Bucket + "/" + key, nil
+}
+
+// objectURL addresses the object path style on a custom endpoint and virtual host style on AWS
+func (d S3Destination) objectURL(key string) *url.URL {
+	escapedKey := s3EscapePath(key)
+	if d.Endpoint != nil {
+		object := *d.Endpoint
+		basePath := strings.TrimSuffix(object.EscapedPath(), "/")
+		object.Path = strings.TrimSuffix(object.Path, "/") + "/" + d.Bucket + "/" + key
+		object.RawPath = basePath + "/" + s3EscapePath(d.Bucket) + "/" + escapedKey
+		object.RawQuery = ""
+		return &object
+	}
+	return &url.URL{
+		Scheme:  "https",
+		Host:    d.Bucket + ".s3." + d.Region + ".amazonaws.com",
+		Path:    "/" + key,
+		RawPath: "/" + escapedKey,
+	}
+}
+
+// signV4 adds the AWS Signature Version 4 Authorization header for a request. The host, any
+// x-amz-* headers and Content-Type are signed.
+func signV4(method string, target *url.URL, headers http.Header, payloadHash string, creds s3Credentials, region string, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	headers.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		headers.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	canonicalHeaders := map[string]string{"host": target.Host}
+	for name, values := range headers {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			canonicalHeaders[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(canonicalHeaders))
+	for name := range canonicalHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var headerLines strings.Builder
+	for _, name := range names {
+		headerLines.WriteString(name + ":" + canonicalHeaders[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalURI := target.EscapedPath()
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+	canonicalQuery := strings.ReplaceAll(target.Query().Encode(), "+", "%20")
+	canonicalRequest := strings.Join([]string{method, canonicalURI, canonicalQuery, headerLines.String(), signedHeaders, payloadHash}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	headers.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.AccessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath percent encodes everything but unreserved characters and slashes, the way SigV4 expects object keys
+func s3EscapePath(key string) string {
+	var escaped strings.Builder
+	for _, b := range []byte(key) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9', strings.IndexByte("-_.~/", b) >= 0:
+			escaped.WriteByte(b)
+		default:
+			fmt.Fprintf(&escaped, "%%%02X", b)
+		}
+	}
+	return escaped.String()
+}
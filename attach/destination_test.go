@@ -0,0 +1,286 @@
+package attach
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewDestination(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-1")
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		method  string
+		want    string
+		wantErr bool
+	}{
+		{name: "https defaults to PUT", rawURL: "https://example.com/uploads/", want: "attach.HTTPDestination PUT"},
+		{name: "http POST", rawURL: "http://example.com/uploads", method: "post", want: "attach.HTTPDestination POST"},
+		{name: "unsupported method", rawURL: "https://example.com/", method: "PATCH", wantErr: true},
+		{name: "s3 with region from the environment", rawURL: "s3://bucket/some/prefix/", want: "attach.S3Destination bucket some/prefix eu-west-1"},
+		{name: "s3 with region parameter", rawURL: "s3://bucket?region=us-west-2&endpoint=http://localhost:9000", want: "attach.S3Destination bucket  us-west-2"},
+		{name: "s3 without bucket", rawURL: "s3:///prefix", wantErr: true},
+		{name: "file URL", rawURL: "file:///mnt/share", want: "attach.DirectoryDestination /mnt/share"},
+		{name: "plain path", rawURL: "/mnt/share", want: "attach.DirectoryDestination /mnt/share"},
+		{name: "unsupported scheme", rawURL: "ftp://example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest, err := NewDestination(tt.rawURL, tt.method, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDestination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got string
+			switch d := dest.(type) {
+			case HTTPDestination:
+				got = "attach.HTTPDestination " + d.Method
+			case S3Destination:
+				got = "attach.S3Destination " + d.Bucket + " " + d.Prefix + " " + d.Region
+			case DirectoryDestination:
+				got = "attach.DirectoryDestination " + d.Dir
+			}
+			if got != tt.want {
+				t.Errorf("NewDestination() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDestinationS3RequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := NewDestination("s3://bucket", "", nil); err == nil {
+		t.Error("NewDestination() expected an error without AWS credentials")
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := ParseHeaders("Authorization: Bearer abc, X-Team:support,")
+	if err != nil {
+		t.Fatalf("ParseHeaders() error = %v", err)
+	}
+	if len(got) != 2 || got["Authorization"] != "Bearer abc" || got["X-Team"] != "support" {
+		t.Errorf("ParseHeaders() = %v", got)
+	}
+	if _, err := ParseHeaders("no separator"); err == nil {
+		t.Error("ParseHeaders() expected an error for a header without a colon")
+	}
+}
+
+func TestHTTPDestinationUpload(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		status   int
+		wantPath string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "PUT appends the file name", method: http.MethodPut, path: "/uploads", status: http.StatusCreated, wantPath: "/uploads/nrdiag-output-ts.zip"},
+		{name: "POST sends the file name as a parameter", method: http.MethodPost, path: "/uploads", status: http.StatusOK, wantPath: "/uploads", wantName: "nrdiag-output-ts.zip"},
+		{name: "error status", method: http.MethodPut, path: "/", status: http.StatusForbidden, wantPath: "/nrdiag-output-ts.zip", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRequest *http.Request
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRequest = r
+				gotBody, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			path := filepath.Join(t.TempDir(), "nrdiag-output.zip")
+			os.WriteFile(path, []byte("zip content"), 0600)
+			target, _ := url.Parse(server.URL + tt.path + "?token=abc")
+			dest := HTTPDestination{URL: target, Method: tt.method, Headers: map[string]string{"Authorization": "Bearer abc"}}
+
+			_, err := dest.Upload(path, "nrdiag-output-ts.zip", 11)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if gotRequest.Method != tt.method || gotRequest.URL.Path != tt.wantPath {
+				t.Errorf("Upload() sent %s %s, want %s %s", gotRequest.Method, gotRequest.URL.Path, tt.method, tt.wantPath)
+			}
+			if gotRequest.URL.Query().Get("token") != "abc" || gotRequest.URL.Query().Get("filename") != tt.wantName {
+				t.Errorf("Upload() sent query %q", gotRequest.URL.RawQuery)
+			}
+			if gotRequest.Header.Get("Authorization") != "Bearer abc" {
+				t.Errorf("Upload() did not send the custom header")
+			}
+			if string(gotBody) != "zip content" {
+				t.Errorf("Upload() sent body %q", gotBody)
+			}
+		})
+	}
+}
+
+func TestDirectoryDestinationUpload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nrdiag-output.zip")
+	os.WriteFile(path, []byte("zip content"), 0600)
+	dir := filepath.Join(t.TempDir(), "share", "nrdiag")
+
+	got, err := DirectoryDestination{Dir: dir}.Upload(path, "nrdiag-output-ts.zip", 11)
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if got != filepath.Join(dir, "nrdiag-output-ts.zip") {
+		t.Errorf("Upload() = %q", got)
+	}
+	content, _ := os.ReadFile(got)
+	if string(content) != "zip content" {
+		t.Errorf("Upload() copied %q", content)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Upload() left %d files in the directory, want only the artifact", len(entries))
+	}
+}
+
+func TestUploadToDestination(t *testing.T) {
+	dir := t.TempDir()
+	zip := filepath.Join(dir, "nrdiag-output.zip")
+	json := filepath.Join(dir, "nrdiag-output.json")
+	os.WriteFile(zip, []byte("zip"), 0600)
+	os.WriteFile(json, []byte("{}"), 0600)
+	target := t.TempDir()
+
+	if err := UploadToDestination(DirectoryDestination{Dir: target}, []string{zip, json}, "20261018T120000Z"); err != nil {
+		t.Fatalf("UploadToDestination() error = %v", err)
+	}
+	for _, name := range []string{"nrdiag-output-20261018T120000Z.zip", "nrdiag-output-20261018T120000Z.json"} {
+		if _, err := os.Stat(filepath.Join(target, name)); err != nil {
+			t.Errorf("UploadToDestination() did not write %s", name)
+		}
+	}
+	if err := UploadToDestination(DirectoryDestination{Dir: target}, []string{filepath.Join(dir, "missing.zip")}, "ts"); err == nil {
+		t.Error("UploadToDestination() expected an error for a missing file")
+	}
+}
+
+// Test vector get-vanilla from the AWS Signature Version 4 test suite
+func Test_signV4(t *testing.T) {
+	target, _ := url.Parse("https://example.amazonaws.com/")
+	headers := http.Header{}
+	emptyHash := sha256.Sum256(nil)
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	creds := s3Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	signV4(http.MethodGet, target, headers, hex.EncodeToString(emptyHash[:]), creds, "us-east-1", "service", now)
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := headers.Get("Authorization"); got != want {
+		t.Errorf("signV4() Authorization = %q, want %q", got, want)
+	}
+}
+
+// s3Stub is a MinIO style path addressed object store that checks the signature of every PUT
+type s3Stub struct {
+	mu      sync.Mutex
+	secret  string
+	objects map[string][]byte
+}
+
+func (s *s3Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	digest := sha256.Sum256(body)
+	if r.Method != http.MethodPut || r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(digest[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	date, _ := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	expected := http.Header{}
+	for _, name := range []string{"Content-Type", "X-Amz-Content-Sha256", "X-Amz-Security-Token"} {
+		if value := r.Header.Get(name); value != "" {
+			expected.Set(name, value)
+		}
+	}
+	target := &url.URL{Host: r.Host, Path: r.URL.Path, RawPath: r.URL.RawPath}
+	creds := s3Credentials{AccessKeyID: "minio", SecretAccessKey: s.secret, SessionToken: r.Header.Get("X-Amz-Security-Token")}
+	signV4(r.Method, target, expected, r.Header.Get("X-Amz-Content-Sha256"), creds, "us-east-1", "s3", date)
+	if r.Header.Get("Authorization") != expected.Get("Authorization") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	s.mu.Lock()
+	s.objects[r.URL.Path] = body
+	s.mu.Unlock()
+}
+
+func TestS3DestinationUpload(t *testing.T) {
+	stub := &s3Stub{secret: "minio-secret", objects: map[string][]byte{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "nrdiag-output.zip")
+	os.WriteFile(path, []byte("zip content"), 0600)
+
+	tests := []struct {
+		name    string
+		secret  string
+		token   string
+		wantErr bool
+	}{
+		{name: "signed upload", secret: "minio-secret"},
+		{name: "signed upload with a session token", secret: "minio-secret", token: "session"},
+		{name: "wrong secret", secret: "wrong", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, _ := url.Parse(server.URL)
+			dest := S3Destination{
+				Bucket:      "diagnostics",
+				Prefix:      "runs/host 1",
+				Region:      "us-east-1",
+				Endpoint:    endpoint,
+				Credentials: s3Credentials{AccessKeyID: "minio", SecretAccessKey: tt.secret, SessionToken: tt.token},
+				now:         time.Now,
+			}
+			got, err := dest.Upload(path, "nrdiag-output-ts.zip", 11)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != "s3://diagnostics/runs/host 1/nrdiag-output-ts.zip" {
+				t.Errorf("Upload() = %q", got)
+			}
+			if !bytes.Equal(stub.objects["/diagnostics/runs/host 1/nrdiag-output-ts.zip"], []byte("zip content")) {
+				t.Errorf("Upload() stored objects %v", stub.objects)
+			}
+		})
+	}
+}
+
+func TestS3DestinationObjectURL(t *testing.T) {
+	dest := S3Destination{Bucket: "bucket", Region: "eu-west-1"}
+	if got := dest.objectURL("a b/c+d.zip").String(); got != "https://bucket.s3.eu-west-1.amazonaws.com/a%20b/c%2Bd.zip" {
+		t.Errorf("objectURL() = %q", got)
+	}
+	endpoint, _ := url.Parse("http://localhost:9000/minio/")
+	dest.Endpoint = endpoint
+	if got := dest.objectURL("run.zip").String(); !strings.HasPrefix(got, "http://localhost:9000/minio/bucket/run.zip") {
+		t.Errorf("objectURL() = %q", got)
+	}
+}
@@ -0,0 +1,259 @@
+package attach
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// Files above multipartThreshold are uploaded in parts that are retried individually
+// and can be resumed by a later run through the state file kept next to the file.
+const (
+	multipartThreshold    = 64 << 20
+	defaultPartSize       = 16 << 20
+	maxPartAttempts       = 3
+	partTimeoutSeconds    = 600
+	uploadStateFileSuffix = ".upload-state.json"
+	partRetryBaseWait     = 2 * time.Second
+	contentSHA256Header   = "Content-SHA256"
+)
+
+var errMultipartUnsupported = errors.New("the attachment service could not start a multipart upload")
+
+// partRetryWait waits between attempts at a part, replaced in tests
+var partRetryWait = time.Sleep
+
+// uploadState is persisted after every part so an interrupted upload can resume
+type uploadState struct {
+	NewFilename    string
+	Filesize       int64
+	ModTime        time.Time
+	SHA256         string
+	UploadID       string
+	PartSize       int64
+	CompletedParts []int
+}
+
+type multipartStartResponse struct {
+	UploadID string `json:"uploadId"`
+	PartSize int64  `json:"partSize"`
+}
+
+type multipartCompleteResponse struct {
+	AttachResponse
+	SHA256 string `json:"sha256"`
+}
+
+// uploadMultipart uploads a large file in parts, resuming a previous attempt when the state file matches the file on disk
+func uploadMultipart(endpoint string, files UploadFiles, attachmentKey string) (*string, error) {
+	path := filepath.Join(files.Path, files.Filename)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	statePath := path + uploadStateFileSuffix
+	state, resumed := loadUploadState(statePath, stat)
+	if !resumed {
+		digest, err := fileSHA256(file)
+		if err != nil {
+			return nil, err
+		}
+		state = uploadState{NewFilename: files.NewFilename, Filesize: stat.Size(), ModTime: stat.ModTime(), SHA256: digest}
+		start, err := startMultipart(endpoint, state, attachmentKey)
+		if err != nil {
+			return nil, err
+		}
+		state.UploadID = start.UploadID
+		state.PartSize = start.PartSize
+		if state.PartSize <= 0 {
+			state.PartSize = defaultPartSize
+		}
+		saveUploadState(statePath, state)
+	} else {
+		log.Infof("Resuming upload of %s, %d parts already uploaded\n", files.Filename, len(state.CompletedParts))
+	}
+
+	base := getAttachmentsEndpoint() + "/" + endpoint + "/multipart/" + url.PathEscape(state.UploadID)
+	partCount := int((state.Filesize + state.PartSize - 1) / state.PartSize)
+	for part := 1; part <= partCount; part++ {
+		if containsPart(state.CompletedParts, part) {
+			continue
+		}
+		offset := int64(part-1) * state.PartSize
+		size := state.PartSize
+		if offset+size > state.Filesize {
+			size = state.Filesize - offset
+		}
+		if err := uploadPart(base, part, io.NewSectionReader(file, offset, size), size, attachmentKey); err != nil {
+			return nil, fmt.Errorf("part %d of %d: %w. Run again with -upload-only %s to resume", part, partCount, err, path)
+		}
+		state.CompletedParts = append(state.CompletedParts, part)
+		saveUploadState(statePath, state)
+		log.Debugf("Uploaded part %d of %d\n", part, partCount)
+	}
+
+	complete, err := completeMultipart(base, attachmentKey)
+	if err != nil {
+		return nil, err
+	}
+	if complete.SHA256 == "" {
+		log.Debug("The attachment service did not return a checksum for", files.Filename, ", the upload was not verified")
+	} else if !strings.EqualFold(complete.SHA256, state.SHA256) {
+		// the parts on the service are unusable, start over on the next attempt
+		os.Remove(statePath)
+		return nil, fmt.Errorf("checksum mismatch for %s: uploaded %s, local %s", files.Filename, complete.SHA256, state.SHA256)
+	}
+	os.Remove(statePath)
+	return &complete.URL, nil
+}
+
+func startMultipart(endpoint string, state uploadState, attachmentKey string) (multipartStartResponse, error) {
+	var start multipartStartResponse
+	wrapper := multipartWrapper(http.MethodPost, getAttachmentsEndpoint()+"/"+endpoint+"/multipart", attachmentKey)
+	wrapper.Params.Add("filename", state.NewFilename)
+	wrapper.Params.Add("filesize", strconv.FormatInt(state.Filesize, 10))
+	wrapper.Params.Add("sha256", state.SHA256)
+
+	// any failure to start means the service has no usable multipart API, the caller then falls back to a single request
+	res, err := makeRequest(wrapper)
+	if err != nil {
+		return start, fmt.Errorf("%w: %s", errMultipartUnsupported, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return start, fmt.Errorf("%w: %s", errMultipartUnsupported, res.Status)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&start); err != nil {
+		return start, fmt.Errorf("%w: %s", errMultipartUnsupported, err)
+	}
+	if start.UploadID == "" {
+		return start, fmt.Errorf("%w: no upload ID was returned", errMultipartUnsupported)
+	}
+	return start, nil
+}
+
+// uploadPart sends one part, retrying with a growing wait when the connection drops or the service fails
+func uploadPart(base string, part int, section *io.SectionReader, size int64, attachmentKey string) error {
+	digest, err := fileSHA256(section)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for attempt := 1; attempt <= maxPartAttempts; attempt++ {
+		if attempt > 1 {
+			partRetryWait(partRetryBaseWait * time.Duration(attempt-1))
+		}
+		if _, err := section.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		wrapper := multipartWrapper(http.MethodPut, base+"/parts/"+strconv.Itoa(part), attachmentKey)
+		wrapper.Payload = section
+		wrapper.Length = size
+		wrapper.Headers[contentSHA256Header] = digest
+
+		res, err := makeRequest(wrapper)
+		if err != nil {
+			lastErr = err
+			log.Debug("Upload of part", part, "failed:", err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			return nil
+		}
+		lastErr = errors.New(res.Status)
+		if res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+			return lastErr
+		}
+	}
+	return lastErr
+}
+
+func completeMultipart(base string, attachmentKey string) (multipartCompleteResponse, error) {
+	var complete multipartCompleteResponse
+	res, err := makeRequest(multipartWrapper(http.MethodPost, base+"/complete", attachmentKey))
+	if err != nil {
+		return complete, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return complete, errors.New(res.Status)
+	}
+	err = json.NewDecoder(res.Body).Decode(&complete)
+	return complete, err
+}
+
+func multipartWrapper(method string, endpoint string, attachmentKey string) httpHelper.RequestWrapper {
+	return httpHelper.RequestWrapper{
+		Method:         method,
+		URL:            endpoint,
+		TimeoutSeconds: partTimeoutSeconds,
+		Params:         url.Values{},
+		Headers:        map[string]string{"Attachment-Key": attachmentKey},
+	}
+}
+
+// loadUploadState returns the saved state if it belongs to the same, unmodified file
+func loadUploadState(statePath string, stat os.FileInfo) (uploadState, bool) {
+	var state uploadState
+	content, err := os.ReadFile(statePath)
+	if err != nil {
+		return state, false
+	}
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Debug("Ignoring unreadable upload state", statePath, err)
+		return state, false
+	}
+	if state.UploadID == "" || state.PartSize <= 0 || state.Filesize != stat.Size() || !state.ModTime.Equal(stat.ModTime()) {
+		log.Debug("Ignoring upload state for a different file", statePath)
+		return state, false
+	}
+	return state, true
+}
+
+func saveUploadState(statePath string, state uploadState) {
+	content, err := json.Marshal(state)
+	if err == nil {
+		err = os.WriteFile(statePath, content, 0600)
+	}
+	if err != nil {
+		log.Debug("Unable to save upload state, this upload cannot be resumed:", err)
+	}
+}
+
+func containsPart(parts []int, part int) bool {
+	for _, p := range parts {
+		if p == part {
+			return true
+		}
+	}
+	return false
+}
+
+// fileSHA256 returns the hex SHA-256 of everything left in the reader
+func fileSHA256(reader io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,232 @@
+package attach
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+// multipartService is an in-memory attachment service implementing the multipart protocol
+type multipartService struct {
+	mu          sync.Mutex
+	parts       map[int][]byte
+	failParts   map[int]int // part number to remaining failures
+	partPuts    int
+	unsupported bool
+	corrupt     bool
+	noChecksum  bool
+}
+
+func (m *multipartService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/upload_api/multipart")
+	switch {
+	case m.unsupported:
+		w.WriteHeader(http.StatusNotFound)
+	case path == "" && r.Method == http.MethodPost:
+		m.parts = map[int][]byte{}
+		json.NewEncoder(w).Encode(multipartStartResponse{UploadID: "upload-1", PartSize: 4})
+	case strings.HasPrefix(path, "/upload-1/parts/") && r.Method == http.MethodPut:
+		part, _ := strconv.Atoi(strings.TrimPrefix(path, "/upload-1/parts/"))
+		m.partPuts++
+		if m.failParts[part] > 0 {
+			m.failParts[part]--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		digest := sha256.Sum256(body.Bytes())
+		if r.Header.Get(contentSHA256Header) != hex.EncodeToString(digest[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.parts[part] = body.Bytes()
+	case path == "/upload-1/complete" && r.Method == http.MethodPost:
+		assembled := new(bytes.Buffer)
+		for part := 1; part <= len(m.parts); part++ {
+			assembled.Write(m.parts[part])
+		}
+		if m.corrupt {
+			assembled.WriteString("!")
+		}
+		digest := sha256.Sum256(assembled.Bytes())
+		complete := multipartCompleteResponse{AttachResponse: AttachResponse{URL: "https://newrelic.com/run", Success: true}}
+		if !m.noChecksum {
+			complete.SHA256 = hex.EncodeToString(digest[:])
+		}
+		json.NewEncoder(w).Encode(complete)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_uploadMultipart(t *testing.T) {
+	partRetryWait = func(time.Duration) {}
+	defer func() { partRetryWait = time.Sleep }()
+
+	tests := []struct {
+		name         string
+		service      *multipartService
+		wantErr      string
+		wantPartPuts int
+		wantState    bool
+	}{
+		{
+			name:         "uploads every part and retries a transient failure",
+			service:      &multipartService{failParts: map[int]int{2: 1}},
+			wantPartPuts: 4,
+		},
+		{
+			name:         "keeps the state file when a part keeps failing",
+			service:      &multipartService{failParts: map[int]int{2: maxPartAttempts}},
+			wantErr:      "part 2 of 3: 502 Bad Gateway",
+			wantPartPuts: 1 + maxPartAttempts,
+			wantState:    true,
+		},
+		{
+			name:    "reports a service without multipart support",
+			service: &multipartService{unsupported: true},
+			wantErr: errMultipartUnsupported.Error(),
+		},
+		{
+			name:         "accepts a completion without a checksum",
+			service:      &multipartService{noChecksum: true},
+			wantPartPuts: 3,
+		},
+		{
+			name:         "fails when the uploaded checksum does not match",
+			service:      &multipartService{corrupt: true},
+			wantErr:      "checksum mismatch",
+			wantPartPuts: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.service)
+			defer server.Close()
+			config.Flags.AttachmentEndpoint = server.URL
+			defer func() { config.Flags.AttachmentEndpoint = "" }()
+
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "nrdiag-output.zip"), []byte("0123456789"), 0600)
+			files := UploadFiles{Path: dir, Filename: "nrdiag-output.zip", NewFilename: "nrdiag-output-ts.zip", Filesize: 10}
+
+			got, err := uploadMultipart("upload_api", files, "key")
+			if tt.wantErr == "" && (err != nil || got == nil || *got != "https://newrelic.com/run") {
+				t.Errorf("uploadMultipart() = %v, %v, want the run URL", got, err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("uploadMultipart() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if tt.service.partPuts != tt.wantPartPuts {
+				t.Errorf("uploadMultipart() sent %d parts, want %d", tt.service.partPuts, tt.wantPartPuts)
+			}
+			_, statErr := os.Stat(filepath.Join(dir, "nrdiag-output.zip"+uploadStateFileSuffix))
+			if (statErr == nil) != tt.wantState {
+				t.Errorf("upload state file exists = %v, want %v", statErr == nil, tt.wantState)
+			}
+		})
+	}
+}
+
+func Test_uploadMultipartResume(t *testing.T) {
+	partRetryWait = func(time.Duration) {}
+	defer func() { partRetryWait = time.Sleep }()
+	service := &multipartService{failParts: map[int]int{3: maxPartAttempts}}
+	server := httptest.NewServer(service)
+	defer server.Close()
+	config.Flags.AttachmentEndpoint = server.URL
+	defer func() { config.Flags.AttachmentEndpoint = "" }()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "nrdiag-output.zip"), []byte("0123456789"), 0600)
+	files := UploadFiles{Path: dir, Filename: "nrdiag-output.zip", NewFilename: "nrdiag-output-ts.zip", Filesize: 10}
+
+	if _, err := uploadMultipart("upload_api", files, "key"); err == nil {
+		t.Fatal("Expected the first upload to fail")
+	}
+	service.partPuts = 0
+	files.NewFilename = "nrdiag-output-later.zip"
+	got, err := uploadMultipart("upload_api", files, "key")
+	if err != nil || got == nil {
+		t.Fatalf("uploadMultipart() resume error = %v", err)
+	}
+	if service.partPuts != 1 {
+		t.Errorf("uploadMultipart() resume sent %d parts, want only the missing one", service.partPuts)
+	}
+}
+
+func Test_startMultipartFallback(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusInternalServerError} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+			config.Flags.AttachmentEndpoint = server.URL
+			defer func() { config.Flags.AttachmentEndpoint = "" }()
+
+			_, err := startMultipart("upload_api", uploadState{NewFilename: "nrdiag-output-ts.zip"}, "key")
+			if !errors.Is(err, errMultipartUnsupported) {
+				t.Errorf("startMultipart() error = %v, want it to fall back to a single request", err)
+			}
+		})
+	}
+}
+
+func Test_uploadFileChecksum(t *testing.T) {
+	content := []byte("0123456789")
+	digest := sha256.Sum256(content)
+	local := hex.EncodeToString(digest[:])
+	tests := []struct {
+		name    string
+		echoed  string
+		wantErr string
+	}{
+		{name: "accepts a matching checksum", echoed: local},
+		{name: "accepts a service that does not return a checksum"},
+		{name: "fails on a checksum mismatch", echoed: strings.Repeat("0", 64), wantErr: "checksum mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sentDigest string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sentDigest = r.Header.Get(contentSHA256Header)
+				json.NewEncoder(w).Encode(map[string]interface{}{"url": "https://newrelic.com/run", "success": true, "sha256": tt.echoed})
+			}))
+			defer server.Close()
+			config.Flags.AttachmentEndpoint = server.URL
+			defer func() { config.Flags.AttachmentEndpoint = "" }()
+
+			dir := t.TempDir()
+			os.WriteFile(filepath.Join(dir, "nrdiag-output.json"), content, 0600)
+			files := UploadFiles{Path: dir, Filename: "nrdiag-output.json", NewFilename: "nrdiag-output-ts.json", Filesize: int64(len(content))}
+
+			got, err := uploadFile("upload_api", files, "key", AttachDeps{})
+			if sentDigest != local {
+				t.Errorf("uploadFile() sent %s = %q, want %q", contentSHA256Header, sentDigest, local)
+			}
+			if tt.wantErr == "" && (err != nil || got == nil || *got != "https://newrelic.com/run") {
+				t.Errorf("uploadFile() = %v, %v, want the run URL", got, err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("uploadFile() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
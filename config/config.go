@@ -44,6 +44,12 @@ type userFlags struct {
 	UsageOptOut        bool
 	Run                bool
 	ListScripts        bool
+	Offline            bool
+	UploadOnly         string
+	UploadTo           string
+	UploadHeaders      string
+	UploadMethod       string
+	EncryptTo          string
 	Proxy              string
 	ProxyUser          string
 	ProxyPassword      string
@@ -88,6 +94,7 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		SkipVersionCheck  bool
 		Run               bool
 		ListScripts       bool
+		Offline           bool
 		Tasks             string
 		ConfigFile        string
 		Override          string
@@ -113,6 +120,7 @@ func (f userFlags) MarshalJSON() ([]byte, error) {
 		SkipVersionCheck:  f.SkipVersionCheck,
 		Run:               f.Run,
 		ListScripts:       f.ListScripts,
+		Offline:           f.Offline,
 		Tasks:             f.Tasks,
 		ConfigFile:        f.ConfigFile,
 		Override:          f.Override,
@@ -175,6 +183,12 @@ var JPHaberdasherURL string
 // HaberdasherURL is the base url for the Haberdasher service
 var HaberdasherURL string
 
+// RequestedRegion is the region given with -region or NEW_RELIC_REGION, NoRegion when neither was given
+var RequestedRegion = NoRegion
+
+// UpdateSigningKey is the base64 encoded Ed25519 public key that signs the release checksums verified by 'nrdiag update'
+var UpdateSigningKey string
+
 // BuildTimestamp stores when the build was done
 var BuildTimestamp string
 
@@ -230,7 +244,7 @@ func ParseFlags() {
 	flag.BoolVar(&Flags.Quiet, "q", false, "Quiet output; only prints the high level results and not the explanatory output. Suppresses file addition warnings if '-y' is also used. Does not contradict '-v'")
 	flag.BoolVar(&Flags.VeryQuiet, "qq", false, "Very quiet output; only prints a single summary line for output (implies '-q'). Suppresses file addition warnings if '-y' is also used. Does not contradict '-v'. Inclusion filters are ignored.")
 
-	flag.StringVar(&Flags.BrowserURL, "browser-url", defaultString, "Specify a URL to check for the presence of a New Relic Browser agent")
+	flag.StringVar(&Flags.BrowserURL, "browser-url", defaultString, "Specify a URL, a comma separated list of URLs or a sitemap to check for the presence of a New Relic Browser agent")
 
 	flag.StringVar(&Flags.K8sNamespace, "k8s-namespace", defaultString, "Specify the namespace from where to scrape the New Relic resources. If you are using Agent-control, you can also set the '-ac-agents-namespace' flag to specify the namespace where Agent-control Agents are running.")
 
@@ -238,6 +252,14 @@ func ParseFlags() {
 
 	flag.BoolVar(&Flags.UsageOptOut, "usage-opt-out", false, "Decline to send anonymous New Relic Diagnostic tool usage data to New Relic for this run")
 
+	flag.BoolVar(&Flags.Offline, "offline", false, "Skip every check that requires network access, such as license key validation and collector connectivity. Also disables usage data, the version check, uploads and the script catalog. Enabled automatically when New Relic hostnames cannot be resolved.")
+
+	flag.StringVar(&Flags.UploadOnly, "upload-only", defaultString, "Upload an existing nrdiag-output.zip, and the nrdiag-output.json next to it, without running diagnostics. Resumes an interrupted upload of the same file. Requires '-api-key' unless '-upload-to' is set.")
+	flag.StringVar(&Flags.UploadTo, "upload-to", defaultString, "Upload nrdiag-output.zip and nrdiag-output.json to a destination of your own: an http(s):// URL, an s3://bucket/prefix for S3 compatible storage (credentials are read from the AWS_* environment variables, add ?endpoint=<url> for services such as MinIO), or a directory path. Directories can be written in offline mode.")
+	flag.StringVar(&Flags.UploadHeaders, "upload-headers", defaultString, "Comma separated 'Name: value' headers to send to an http(s) '-upload-to' destination.")
+	flag.StringVar(&Flags.UploadMethod, "upload-method", "PUT", "HTTP method for an http(s) '-upload-to' destination: PUT appends the file name to the URL, POST sends it in the filename query parameter.")
+	flag.StringVar(&Flags.EncryptTo, "encrypt-to", defaultString, "Encrypt nrdiag-output.zip and nrdiag-output.json to the public key in this file. The files are written and uploaded with a .enc extension and can only be opened with 'nrdiag decrypt' and the matching private key. The plain file list and script outputs, which are inside the zip, are removed.")
+
 	flag.StringVar(&Flags.Include, "include", defaultString, "Include a file or directory (including subdirectories) in the nrdiag-output.zip. Limit 4GB. To upload the results to New Relic also use the '-a' flag.")
 
 	flag.StringVar(&Flags.Region, "r", defaultString, "alias for -region")
@@ -273,12 +295,15 @@ func ParseFlags() {
 	}
 
 	if Flags.BrowserURL != "" {
-		Flags.Override = "Browser/Agent/GetSource.url=" + Flags.BrowserURL + "," + Flags.Override
 		Flags.Tasks = "Browser/Agent/Detect," + Flags.Tasks
 	}
 
 	// Set the endpoints based on region
-	switch parseRegionFlagAndEnv(Flags.Region, os.Getenv("NEW_RELIC_REGION")) {
+	region := parseRegionFlagAndEnv(Flags.Region, os.Getenv("NEW_RELIC_REGION"))
+	if stringToRegion(Flags.Region) != NoRegion || stringToRegion(os.Getenv("NEW_RELIC_REGION")) != NoRegion {
+		RequestedRegion = region
+	}
+	switch region {
 	case EURegion:
 		UsageEndpoint = EUUsageEndpoint
 		// Only set AttachmentEndpoint if the `-attachment-endpoint` flag was not used
@@ -331,6 +356,12 @@ func (f userFlags) UsagePayload() []ConfigFlag {
 		{Name: "include", Value: f.Include},
 		{Name: "region", Value: f.Region},
 		{Name: "script", Value: f.Script},
+		{Name: "offline", Value: f.Offline},
+		{Name: "uploadOnly", Value: boolifyFlag(f.UploadOnly)},
+		{Name: "uploadTo", Value: boolifyFlag(f.UploadTo)},
+		{Name: "uploadHeaders", Value: boolifyFlag(f.UploadHeaders)},
+		{Name: "uploadMethod", Value: f.UploadMethod},
+		{Name: "encryptTo", Value: boolifyFlag(f.EncryptTo)},
 		{Name: "k8sNamespace", Value: f.K8sNamespace},
 		{Name: "aCAgentsNamespace", Value: f.ACAgentsNamespace},
 	}
@@ -397,4 +428,3 @@ func stringToRegion(region string) Region {
 	}
 	return NoRegion
 }
-
@@ -35,6 +35,12 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		APIKey             string
 		Region             string
 		Script             string
+		Offline            bool
+		UploadOnly         string
+		UploadTo           string
+		UploadHeaders      string
+		UploadMethod       string
+		EncryptTo          string
 		K8sNamespace       string
 		ACAgentsNamespace  string
 	}
@@ -64,6 +70,12 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		APIKey:             "string",
 		Region:             "string",
 		Script:             "string",
+		Offline:            true,
+		UploadOnly:         "string",
+		UploadTo:           "string",
+		UploadHeaders:      "string",
+		UploadMethod:       "PUT",
+		EncryptTo:          "string",
 		K8sNamespace:       "string",
 		ACAgentsNamespace:  "string",
 	}
@@ -92,6 +104,12 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 		{Name: "include", Value: "string"},
 		{Name: "region", Value: "string"},
 		{Name: "script", Value: "string"},
+		{Name: "offline", Value: true},
+		{Name: "uploadOnly", Value: true},
+		{Name: "uploadTo", Value: true},
+		{Name: "uploadHeaders", Value: true},
+		{Name: "uploadMethod", Value: "PUT"},
+		{Name: "encryptTo", Value: true},
 		{Name: "k8sNamespace", Value: "string"},
 		{Name: "aCAgentsNamespace", Value: "string"},
 	}
@@ -135,6 +153,12 @@ func Test_userFlags_UsagePayload(t *testing.T) {
 				APIKey:             tt.fields.APIKey,
 				Region:             tt.fields.Region,
 				Script:             tt.fields.Script,
+				Offline:            tt.fields.Offline,
+				UploadOnly:         tt.fields.UploadOnly,
+				UploadTo:           tt.fields.UploadTo,
+				UploadHeaders:      tt.fields.UploadHeaders,
+				UploadMethod:       tt.fields.UploadMethod,
+				EncryptTo:          tt.fields.EncryptTo,
 				K8sNamespace:       tt.fields.K8sNamespace,
 				ACAgentsNamespace:  tt.fields.ACAgentsNamespace,
 			}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"crypto/ecdh"
+	"net"
 	"os"
 	"sync"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/internal/encryption"
 	"github.com/newrelic/newrelic-diagnostics-cli/internal/haberdasher"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output"
@@ -15,6 +18,15 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "decrypt":
+			os.Exit(runDecrypt(os.Args[2:]))
+		case "update":
+			os.Exit(runUpdate(os.Args[2:]))
+		}
+	}
+
 	runID := generateRunID()
 	config.ParseFlags()
 	log.Debug("---------------------------------------------------------------------------------------------")
@@ -23,7 +35,7 @@ func main() {
 	log.Debug("nrdiag was run with options", os.Args)
 
 	//Error setting proxy and they specifically included one so let's break out of the program before we attempt any non-proxied calls.
-	_, err := processHTTPProxy()
+	proxySet, err := processHTTPProxy()
 	if err != nil {
 		log.Info("Proxy configuration found, but unable to use. \nError: " + err.Error() + "\nExiting program.")
 		os.Exit(3)
@@ -31,10 +43,25 @@ func main() {
 
 	options, overrides := processOverrides()
 
+	// read the key up front so a bad -encrypt-to fails before diagnostics run rather than after
+	var recipient *ecdh.PublicKey
+	if config.Flags.EncryptTo != "" {
+		recipient, err = encryption.ReadPublicKey(config.Flags.EncryptTo)
+		if err != nil {
+			log.Fatalf("Unable to read the -encrypt-to public key: %s", err.Error())
+		}
+	}
+
 	// Setup Haberdasher client
 	haberdasher.InitializeDefaultClient()
 	haberdasher.DefaultClient.SetRunID(runID)
 	haberdasher.DefaultClient.SetUserAgent("Nrdiag_/" + config.Version)
+	if proxySet {
+		// use the validated proxy explicitly, http.ProxyFromEnvironment only reads the environment once
+		if err := haberdasher.DefaultClient.SetProxy(os.Getenv("HTTP_PROXY")); err != nil {
+			log.Debug("Unable to set the Haberdasher client proxy:", err)
+		}
+	}
 
 	if config.HaberdasherURL == "" && !config.Flags.Quiet {
 		log.Info("No Haberdasher base URL set. Defaulting to localhost")
@@ -52,11 +79,28 @@ func main() {
 		}
 	}
 
+	// A proxy may resolve hostnames on our behalf, so only probe DNS when connecting directly
+	probeNetwork := !config.Flags.Offline && !proxySet && !config.Flags.Help && !config.Flags.Version && config.Flags.UploadOnly == ""
+	if probeNetwork && isNetworkUnavailable(net.DefaultResolver.LookupHost) {
+		log.Info("Unable to resolve New Relic hostnames. Running in offline mode, network dependent checks will be skipped.")
+		config.Flags.Offline = true
+	}
+
+	if config.Flags.UploadOnly != "" {
+		processUploadOnly()
+		os.Exit(0)
+	}
+
 	// Set up script catalog
 	scriptCatalog := &scriptrunner.Catalog{
 		Deps: &scriptrunner.CatalogDependencies{},
 	}
 
+	if config.Flags.Offline && (config.Flags.ListScripts || config.Flags.Script != "") {
+		log.Info("The script catalog is not available in offline mode.")
+		os.Exit(1)
+	}
+
 	// List available scripts
 	if config.Flags.ListScripts {
 		printScriptList(scriptCatalog)
@@ -161,14 +205,18 @@ func main() {
 		// ...and close it out
 		output.CloseZip(zipfile)
 
+		if recipient != nil {
+			encryptOutput(recipient, scriptData)
+		}
+
 		// upload any files (zip and json)
 		processUploads()
 
 		// deal with haberdasher data
-		if !config.Flags.UsageOptOut {
+		if !config.Flags.UsageOptOut && !config.Flags.Offline {
 			usage.SendUsageData(outputResults, runID)
 		}
-		if !config.Flags.SkipVersionCheck {
+		if !config.Flags.SkipVersionCheck && !config.Flags.Offline {
 			version.ProcessAutoVersionCheck()
 		}
 
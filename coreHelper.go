@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -59,6 +60,23 @@ func ProxyParseNSet() (set bool) {
 
 }
 
+// offlineProbeTimeout bounds the DNS probe so air-gapped hosts do not wait on resolver timeouts
+const offlineProbeTimeout = 2 * time.Second
+
+// isNetworkUnavailable - resolves a New Relic hostname and returns true if it cannot be resolved within offlineProbeTimeout
+func isNetworkUnavailable(lookupHost func(context.Context, string) ([]string, error)) bool {
+	endpoint, _ := tasks.GetEndpoint(tasks.RegionUS, tasks.ProductAPMCollector)
+	ctx, cancel := context.WithTimeout(context.Background(), offlineProbeTimeout)
+	defer cancel()
+
+	addrs, err := lookupHost(ctx, endpoint.Host)
+	if err != nil {
+		log.Debug("Unable to resolve", endpoint.Host, err)
+		return true
+	}
+	return len(addrs) == 0
+}
+
 // similar to tasks - This takes the input string as the query to the end users and waits for a response
 func promptUser(msg string) bool {
 	if config.Flags.YesToAll {
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	tasks "github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -59,3 +61,21 @@ func testOverridesEqual(a, b []override) bool {
 
 	return true
 }
+
+func Test_isNetworkUnavailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		lookupHost func(context.Context, string) ([]string, error)
+		want       bool
+	}{
+		{"resolved", func(context.Context, string) ([]string, error) { return []string{"162.247.241.2"}, nil }, false},
+		{"dnsFailure", func(context.Context, string) ([]string, error) { return nil, errors.New("no such host") }, true},
+		{"noAddresses", func(context.Context, string) ([]string, error) { return nil, nil }, true},
+		{"timeout", func(ctx context.Context, _ string) ([]string, error) { <-ctx.Done(); return nil, ctx.Err() }, true},
+	}
+	for _, tt := range tests {
+		if got := isNetworkUnavailable(tt.lookupHost); got != tt.want {
+			t.Errorf("Test %v failed: isNetworkUnavailable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
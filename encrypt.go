@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/ecdh"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/internal/encryption"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
+	"github.com/newrelic/newrelic-diagnostics-cli/scriptrunner"
+)
+
+const decryptUsage = `Usage:
+  nrdiag decrypt -key <private key file> [-out <directory>] <file.enc>...
+  nrdiag decrypt -generate-key <private key file>
+
+Decrypts files written with -encrypt-to. -generate-key writes a new private key and, next to it
+with a .pub extension, the public key to pass to -encrypt-to.
+`
+
+// encryptOutput - replaces nrdiag-output.zip and nrdiag-output.json with copies encrypted to the -encrypt-to key.
+// The file list and script outputs are already in the zip, so their plain copies are removed.
+func encryptOutput(recipient *ecdh.PublicKey, scriptData *scriptrunner.ScriptData) {
+	log.Info(color.ColorString(color.White, "Encrypting output files"))
+	for _, filename := range []string{"nrdiag-output.zip", "nrdiag-output.json"} {
+		path := filepath.Join(config.Flags.OutputPath, filename)
+		if _, err := encryption.EncryptFile(path, recipient); err != nil {
+			// never leave output in plain form when encryption was asked for
+			os.Remove(path)
+			log.Fatalf("Unable to encrypt %s: %s", filename, err.Error())
+		}
+	}
+
+	plainCopies := []string{filepath.Join(config.Flags.OutputPath, "nrdiag-filelist.txt")}
+	if scriptData != nil {
+		plainCopies = append(plainCopies, scriptData.OutputPath)
+		plainCopies = append(plainCopies, scriptData.AddtlFiles...)
+	}
+	for _, path := range plainCopies {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Unable to remove the unencrypted %s: %s", path, err.Error())
+		}
+	}
+}
+
+// runDecrypt - the nrdiag decrypt command, returns the exit code
+func runDecrypt(args []string) int {
+	return decrypt(args, os.Stdout, os.Stderr)
+}
+
+func decrypt(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, decryptUsage) }
+	keyPath := flags.String("key", "", "private key file")
+	outDir := flags.String("out", "", "directory to write decrypted files to, defaults to the directory of each file")
+	generateKey := flags.String("generate-key", "", "write a new private key to this file and its public key next to it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *generateKey != "" {
+		if err := writeKeyPair(*generateKey); err != nil {
+			fmt.Fprintln(stderr, "Unable to generate a key pair:", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Wrote private key %s and public key %s.pub\n", *generateKey, *generateKey)
+		return 0
+	}
+	if *keyPath == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	identity, err := encryption.ReadPrivateKey(*keyPath)
+	if err != nil {
+		fmt.Fprintln(stderr, "Unable to read the private key:", err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, path := range flags.Args() {
+		outPath := strings.TrimSuffix(path, encryption.FileExtension)
+		if outPath == path {
+			outPath += ".decrypted"
+		}
+		if *outDir != "" {
+			outPath = filepath.Join(*outDir, filepath.Base(outPath))
+		}
+		if _, err := os.Stat(outPath); err == nil {
+			fmt.Fprintf(stderr, "Not decrypting %s: %s already exists\n", path, outPath)
+			exitCode = 1
+			continue
+		}
+		if err := encryption.DecryptFile(path, outPath, identity); err != nil {
+			fmt.Fprintf(stderr, "Unable to decrypt %s: %s\n", path, err)
+			exitCode = 1
+			continue
+		}
+		fmt.Fprintf(stdout, "Decrypted %s to %s\n", path, outPath)
+	}
+	return exitCode
+}
+
+// writeKeyPair writes a new private key readable only by the owner, refusing to overwrite an existing key
+func writeKeyPair(privatePath string) error {
+	publicKey, privateKey, err := encryption.GenerateKeyPair()
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{privatePath, privatePath + ".pub"} {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+	}
+	if err := os.WriteFile(privatePath, privateKey, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(privatePath+".pub", publicKey, 0644)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/internal/encryption"
+	"github.com/newrelic/newrelic-diagnostics-cli/scriptrunner"
+)
+
+func TestEncryptOutputAndDecrypt(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "support.key")
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if code := decrypt([]string{"-generate-key", keyPath}, stdout, stderr); code != 0 {
+		t.Fatalf("decrypt -generate-key exit code = %d, stderr %s", code, stderr)
+	}
+	if code := decrypt([]string{"-generate-key", keyPath}, stdout, stderr); code == 0 {
+		t.Errorf("decrypt -generate-key overwrote an existing key")
+	}
+
+	outputPath := filepath.Join(dir, "output")
+	os.Mkdir(outputPath, 0755)
+	os.WriteFile(filepath.Join(outputPath, "nrdiag-output.zip"), []byte("zip content"), 0600)
+	os.WriteFile(filepath.Join(outputPath, "nrdiag-output.json"), []byte("{}"), 0600)
+	os.WriteFile(filepath.Join(outputPath, "nrdiag-filelist.txt"), []byte("Original path:/etc/newrelic-infra.yml"), 0600)
+	os.WriteFile(filepath.Join(outputPath, "script.out"), []byte("host-1"), 0600)
+	os.WriteFile(filepath.Join(outputPath, "script-extra.txt"), []byte("host-1"), 0600)
+	scriptData := &scriptrunner.ScriptData{
+		OutputPath: filepath.Join(outputPath, "script.out"),
+		AddtlFiles: []string{filepath.Join(outputPath, "script-extra.txt")},
+	}
+	config.Flags.OutputPath = outputPath
+	defer func() { config.Flags.OutputPath = "" }()
+
+	recipient, err := encryption.ReadPublicKey(keyPath + ".pub")
+	if err != nil {
+		t.Fatalf("ReadPublicKey() error = %v", err)
+	}
+	encryptOutput(recipient, scriptData)
+
+	entries, _ := os.ReadDir(outputPath)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, ",") != "nrdiag-output.json.enc,nrdiag-output.zip.enc" {
+		t.Fatalf("encryptOutput() left %v, want only the encrypted zip and json", names)
+	}
+
+	decrypted := filepath.Join(dir, "decrypted")
+	os.Mkdir(decrypted, 0755)
+	args := []string{"-key", keyPath, "-out", decrypted, filepath.Join(outputPath, "nrdiag-output.zip.enc"), filepath.Join(outputPath, "nrdiag-output.json.enc")}
+	if code := decrypt(args, stdout, stderr); code != 0 {
+		t.Fatalf("decrypt exit code = %d, stderr %s", code, stderr)
+	}
+	if content, _ := os.ReadFile(filepath.Join(decrypted, "nrdiag-output.zip")); string(content) != "zip content" {
+		t.Errorf("decrypt wrote %q", content)
+	}
+	if code := decrypt(args, stdout, stderr); code == 0 {
+		t.Errorf("decrypt overwrote an existing file")
+	}
+}
+
+func TestDecryptUsage(t *testing.T) {
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if code := decrypt([]string{"file.enc"}, stdout, stderr); code != 2 || !strings.Contains(stderr.String(), "Usage") {
+		t.Errorf("decrypt without -key = %d, %q", code, stderr)
+	}
+}
@@ -0,0 +1,426 @@
+package httpHelper
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// NetworkStage - one step of establishing a connection to an endpoint
+type NetworkStage string
+
+// The stages of a connection in the order they are attempted
+const (
+	StageURL   NetworkStage = "URL"
+	StageDNS   NetworkStage = "DNS"
+	StageTCP   NetworkStage = "TCP"
+	StageProxy NetworkStage = "Proxy CONNECT"
+	StageTLS   NetworkStage = "TLS"
+	StageHTTP  NetworkStage = "HTTP"
+)
+
+// publicCAOrganizations are the certificate authorities that issue certificates for New Relic endpoints.
+// A chain rooted anywhere else usually means a TLS inspecting proxy re-signed the connection.
+var publicCAOrganizations = []string{
+	"Amazon",
+	"Baltimore",
+	"COMODO",
+	"DigiCert",
+	"Entrust",
+	"GlobalSign",
+	"GoDaddy",
+	"Google Trust Services",
+	"Internet Security Research Group",
+	"Let's Encrypt",
+	"Sectigo",
+	"Starfield",
+}
+
+const resolvConfPath = "/etc/resolv.conf"
+
+// StageResult - the outcome and timing of a single network stage
+type StageResult struct {
+	Stage      NetworkStage
+	Target     string
+	Success    bool
+	DurationMs int64
+	Detail     string
+	Error      string
+}
+
+// CertificateInfo - the identifying fields of a certificate presented during the TLS handshake
+type CertificateInfo struct {
+	Subject   string
+	Issuer    string
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// TLSDetails - what was negotiated during the TLS handshake
+type TLSDetails struct {
+	ServerName       string
+	Version          string
+	CertificateChain []CertificateInfo
+	VerifyError      string
+	Intercepted      bool
+	PeerCertificates []*x509.Certificate `json:"-"`
+}
+
+// ConnectionDiagnosis - the stage by stage result of connecting to a URL
+type ConnectionDiagnosis struct {
+	URL         string
+	Proxy       string
+	Resolvers   []string
+	ResolvedIPs []string
+	Stages      []StageResult
+	FailedStage NetworkStage
+	TLS         *TLSDetails
+	StatusCode  int
+}
+
+// DiagnoseFunc - allows DiagnoseConnection to be dependency injected
+type DiagnoseFunc func(string) ConnectionDiagnosis
+
+// NetworkDiagnoser - walks through each stage of a connection to find where it fails
+type NetworkDiagnoser struct {
+	Timeout time.Duration
+	// RootCAs verifies the server certificate chain, the system pool is used when nil
+	RootCAs *x509.CertPool
+	// Proxy returns the proxy to use for a request, http.ProxyFromEnvironment is used when nil
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// DiagnoseConnection - diagnoses a connection to the URL using the system trust store and the proxy from the environment
+func DiagnoseConnection(rawURL string) ConnectionDiagnosis {
+	return NetworkDiagnoser{Timeout: defaultTimeoutSeconds * time.Second}.Diagnose(rawURL)
+}
+
+// Diagnose - attempts DNS resolution, TCP connect, proxy CONNECT, TLS handshake and an HTTP request in turn, stopping at the first failing stage
+func (n NetworkDiagnoser) Diagnose(rawURL string) ConnectionDiagnosis {
+	d := ConnectionDiagnosis{URL: rawURL}
+	if n.Timeout == 0 {
+		n.Timeout = defaultTimeoutSeconds * time.Second
+	}
+
+	target, err := url.Parse(rawURL)
+	if err == nil && target.Hostname() == "" {
+		err = errors.New("no host in URL")
+	}
+	if !d.record(StageURL, rawURL, time.Now(), err, "") {
+		return d
+	}
+
+	proxyURL, err := n.proxyFor(target)
+	if err != nil {
+		d.record(StageProxy, "", time.Now(), err, "")
+		return d
+	}
+
+	dialHost, dialPort := target.Hostname(), portFor(target)
+	if proxyURL != nil {
+		d.Proxy = proxyURL.Redacted()
+		dialHost, dialPort = proxyURL.Hostname(), portFor(proxyURL)
+	}
+
+	ips := d.resolve(dialHost, n.Timeout)
+	if len(ips) == 0 {
+		return d
+	}
+
+	conn := d.connectTCP(ips, dialPort, n.Timeout)
+	if conn == nil {
+		return d
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(n.Timeout))
+
+	if proxyURL != nil {
+		if proxyURL.Scheme == "https" {
+			tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname(), RootCAs: n.RootCAs})
+			start := time.Now()
+			if !d.record(StageProxy, proxyURL.Host, start, tlsConn.Handshake(), "TLS handshake with the proxy") {
+				return d
+			}
+			conn = tlsConn
+		}
+		if target.Scheme == "https" && !d.proxyConnect(conn, proxyURL, target) {
+			return d
+		}
+	}
+
+	if target.Scheme == "https" {
+		tlsConn := d.handshake(conn, target.Hostname(), n.RootCAs)
+		if tlsConn == nil {
+			return d
+		}
+		conn = tlsConn
+	}
+
+	d.request(conn, target, proxyURL != nil && target.Scheme != "https")
+	return d
+}
+
+// Summary - describes each attempted stage on its own line
+func (d ConnectionDiagnosis) Summary() string {
+	var lines []string
+	if d.FailedStage == "" {
+		lines = append(lines, fmt.Sprintf("Network diagnosis for %s completed every stage:", d.URL))
+	} else {
+		lines = append(lines, fmt.Sprintf("Network diagnosis for %s failed at the %s stage:", d.URL, d.FailedStage))
+	}
+	for _, stage := range d.Stages {
+		line := fmt.Sprintf("\t%s %s: ", stage.Stage, stage.Target)
+		if stage.Success {
+			line += fmt.Sprintf("OK (%dms)", stage.DurationMs)
+		} else {
+			line += fmt.Sprintf("FAILED (%dms) %s", stage.DurationMs, stage.Error)
+		}
+		if stage.Detail != "" {
+			line += " - " + stage.Detail
+		}
+		lines = append(lines, line)
+	}
+	if d.TLS != nil && d.TLS.Intercepted {
+		lines = append(lines, "\tThe certificate chain is not issued by a public certificate authority, a proxy or security appliance may be intercepting TLS traffic.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// record appends a stage result and returns whether the stage succeeded
+func (d *ConnectionDiagnosis) record(stage NetworkStage, target string, start time.Time, err error, detail string) bool {
+	result := StageResult{
+		Stage:      stage,
+		Target:     target,
+		Success:    err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+		Detail:     detail,
+	}
+	if err != nil {
+		result.Error = err.Error()
+		d.FailedStage = stage
+		log.Debugf("Network diagnosis of %s failed at %s: %s\n", d.URL, stage, err.Error())
+	}
+	d.Stages = append(d.Stages, result)
+	return err == nil
+}
+
+func (n NetworkDiagnoser) proxyFor(target *url.URL) (*url.URL, error) {
+	proxy := n.Proxy
+	if proxy == nil {
+		proxy = http.ProxyFromEnvironment
+	}
+	return proxy(&http.Request{URL: target})
+}
+
+func portFor(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "http" {
+		return "80"
+	}
+	return "443"
+}
+
+func (d *ConnectionDiagnosis) resolve(host string, timeout time.Duration) []string {
+	d.Resolvers = systemResolvers()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	var ips []string
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	if err == nil && len(ips) == 0 {
+		err = errors.New("no addresses returned")
+	}
+	d.ResolvedIPs = ips
+	detail := "resolver " + strings.Join(d.Resolvers, ", ")
+	if err == nil {
+		detail += " returned " + strings.Join(ips, ", ")
+	}
+	d.record(StageDNS, host, start, err, detail)
+	return ips
+}
+
+// systemResolvers returns the nameservers from resolv.conf, which Go's resolver uses on Unix systems
+func systemResolvers() []string {
+	file, err := os.Open(resolvConfPath)
+	if err != nil {
+		return []string{"system resolver"}
+	}
+	defer file.Close()
+
+	var resolvers []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && fields[0] == "nameserver" {
+			resolvers = append(resolvers, fields[1])
+		}
+	}
+	if len(resolvers) == 0 {
+		return []string{"system resolver"}
+	}
+	return resolvers
+}
+
+// connectTCP tries every resolved address and keeps the first connection that succeeds
+func (d *ConnectionDiagnosis) connectTCP(ips []string, port string, timeout time.Duration) net.Conn {
+	for _, ip := range ips {
+		address := net.JoinHostPort(ip, port)
+		start := time.Now()
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if d.record(StageTCP, address, start, err, "") {
+			// an earlier address may have failed, the connection still succeeded
+			d.FailedStage = ""
+			return conn
+		}
+	}
+	return nil
+}
+
+func (d *ConnectionDiagnosis) proxyConnect(conn net.Conn, proxyURL *url.URL, target *url.URL) bool {
+	address := net.JoinHostPort(target.Hostname(), portFor(target))
+	start := time.Now()
+
+	request := "CONNECT " + address + " HTTP/1.1\r\nHost: " + address + "\r\n"
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		request += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	request += "\r\n"
+
+	if _, err := conn.Write([]byte(request)); err != nil {
+		return d.record(StageProxy, proxyURL.Host, start, err, "")
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err != nil {
+		return d.record(StageProxy, proxyURL.Host, start, err, "")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("proxy answered CONNECT %s with %s", address, resp.Status)
+		if resp.StatusCode == http.StatusProxyAuthRequired {
+			err = fmt.Errorf("%s, check the proxy user and password", err.Error())
+		}
+	}
+	return d.record(StageProxy, proxyURL.Host, start, err, "CONNECT "+address)
+}
+
+// handshake completes the TLS handshake without verification so the presented chain can be reported, then verifies it separately
+func (d *ConnectionDiagnosis) handshake(conn net.Conn, serverName string, rootCAs *x509.CertPool) net.Conn {
+	start := time.Now()
+	tlsConn := tls.Client(conn, &tls.Config{ServerName: serverName, InsecureSkipVerify: true})
+	if err := tlsConn.Handshake(); err != nil {
+		d.record(StageTLS, serverName, start, err, "SNI "+serverName)
+		return nil
+	}
+
+	state := tlsConn.ConnectionState()
+	details := &TLSDetails{
+		ServerName:       serverName,
+		Version:          tls.VersionName(state.Version),
+		PeerCertificates: state.PeerCertificates,
+	}
+	for _, cert := range state.PeerCertificates {
+		details.CertificateChain = append(details.CertificateChain, CertificateInfo{
+			Subject:   cert.Subject.String(),
+			Issuer:    cert.Issuer.String(),
+			NotBefore: cert.NotBefore,
+			NotAfter:  cert.NotAfter,
+		})
+	}
+	d.TLS = details
+
+	err := VerifyChain(state.PeerCertificates, serverName, rootCAs)
+	if err != nil {
+		details.VerifyError = err.Error()
+	}
+	var unknownAuthority x509.UnknownAuthorityError
+	if len(state.PeerCertificates) > 0 {
+		top := state.PeerCertificates[len(state.PeerCertificates)-1]
+		details.Intercepted = errors.As(err, &unknownAuthority) || !issuedByPublicCA(top)
+	}
+
+	detail := fmt.Sprintf("SNI %s, %s", serverName, details.Version)
+	if len(state.PeerCertificates) > 0 {
+		detail += ", issued by " + state.PeerCertificates[0].Issuer.String()
+	}
+	if !d.record(StageTLS, serverName, start, err, detail) {
+		return nil
+	}
+	return tlsConn
+}
+
+// VerifyChain - verifies a presented certificate chain for the server name against the roots, the system pool is used when roots is nil
+func VerifyChain(certs []*x509.Certificate, serverName string, rootCAs *x509.CertPool) error {
+	if len(certs) == 0 {
+		return errors.New("the server did not present a certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         rootCAs,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+func issuedByPublicCA(cert *x509.Certificate) bool {
+	names := append([]string{cert.Issuer.CommonName}, cert.Issuer.Organization...)
+	for _, name := range names {
+		for _, org := range publicCAOrganizations {
+			if strings.Contains(name, org) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// request sends a GET over the established connection, any HTTP response means the endpoint is reachable
+func (d *ConnectionDiagnosis) request(conn net.Conn, target *url.URL, viaProxy bool) {
+	start := time.Now()
+	req, err := http.NewRequest("GET", target.String(), nil)
+	if err != nil {
+		d.record(StageHTTP, target.String(), start, err, "")
+		return
+	}
+	req.Header.Set("Connection", "close")
+	if viaProxy {
+		err = req.WriteProxy(conn)
+	} else {
+		err = req.Write(conn)
+	}
+	if err != nil {
+		d.record(StageHTTP, target.String(), start, err, "")
+		return
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		d.record(StageHTTP, target.String(), start, err, "")
+		return
+	}
+	resp.Body.Close()
+	d.StatusCode = resp.StatusCode
+	d.record(StageHTTP, target.String(), start, nil, resp.Status)
+}
@@ -0,0 +1,111 @@
+package httpHelper
+
+import (
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func noProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
+func TestNetworkDiagnoser_Diagnose(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddress := closed.Addr().String()
+	closed.Close()
+
+	tests := []struct {
+		name           string
+		url            string
+		diagnoser      NetworkDiagnoser
+		wantFailed     NetworkStage
+		wantStatusCode int
+		wantStages     int
+	}{
+		{
+			name:           "should complete every stage for a trusted server",
+			url:            server.URL,
+			diagnoser:      NetworkDiagnoser{RootCAs: trusted, Proxy: noProxy},
+			wantFailed:     "",
+			wantStatusCode: http.StatusNotFound,
+			wantStages:     5,
+		},
+		{
+			name:       "should fail at the TLS stage for an untrusted certificate",
+			url:        server.URL,
+			diagnoser:  NetworkDiagnoser{RootCAs: x509.NewCertPool(), Proxy: noProxy},
+			wantFailed: StageTLS,
+			wantStages: 4,
+		},
+		{
+			name:       "should fail at the TCP stage when nothing is listening",
+			url:        "https://" + closedAddress,
+			diagnoser:  NetworkDiagnoser{Proxy: noProxy},
+			wantFailed: StageTCP,
+			wantStages: 3,
+		},
+		{
+			name:       "should fail at the proxy stage when the proxy rejects CONNECT",
+			url:        "https://collector.newrelic.com/status/mongrel",
+			diagnoser:  NetworkDiagnoser{Proxy: http.ProxyURL(proxyURL)},
+			wantFailed: StageProxy,
+			wantStages: 4,
+		},
+		{
+			name:       "should fail at the DNS stage for an unresolvable host",
+			url:        "https://collector.nrdiag.invalid",
+			diagnoser:  NetworkDiagnoser{Proxy: noProxy},
+			wantFailed: StageDNS,
+			wantStages: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.diagnoser.Timeout = 5 * time.Second
+			got := tt.diagnoser.Diagnose(tt.url)
+			if got.FailedStage != tt.wantFailed {
+				t.Errorf("Diagnose() FailedStage = %v, want %v\n%s", got.FailedStage, tt.wantFailed, got.Summary())
+			}
+			if got.StatusCode != tt.wantStatusCode {
+				t.Errorf("Diagnose() StatusCode = %v, want %v", got.StatusCode, tt.wantStatusCode)
+			}
+			if len(got.Stages) != tt.wantStages {
+				t.Errorf("Diagnose() recorded %d stages, want %d\n%s", len(got.Stages), tt.wantStages, got.Summary())
+			}
+		})
+	}
+}
+
+func TestNetworkDiagnoser_DiagnoseInterception(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	got := NetworkDiagnoser{RootCAs: x509.NewCertPool(), Proxy: noProxy, Timeout: 5 * time.Second}.Diagnose(server.URL)
+	if got.TLS == nil {
+		t.Fatalf("Diagnose() did not record TLS details\n%s", got.Summary())
+	}
+	if !got.TLS.Intercepted {
+		t.Errorf("Diagnose() did not flag a certificate from a private authority as intercepted")
+	}
+	if len(got.TLS.CertificateChain) == 0 || got.TLS.VerifyError == "" {
+		t.Errorf("Diagnose() TLS details = %+v, want a certificate chain and verify error", got.TLS)
+	}
+}
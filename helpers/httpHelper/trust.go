@@ -0,0 +1,120 @@
+package httpHelper
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// EndpointChain - the certificate chain an endpoint presented, as seen from this host
+type EndpointChain struct {
+	URL          string
+	ServerName   string
+	Certificates []CertificateInfo
+	PEM          []string
+	Intercepted  bool
+	Error        string
+}
+
+// TrustCheck - whether a trust store accepts the chain presented by an endpoint
+type TrustCheck struct {
+	Store      string
+	Source     string
+	ServerName string
+	Trusted    bool
+	Reason     string
+}
+
+// GetEndpointChain - connects to the URL through any configured proxy and returns the certificate chain it presented
+func GetEndpointChain(rawURL string) EndpointChain {
+	return chainFromDiagnosis(DiagnoseConnection(rawURL))
+}
+
+func chainFromDiagnosis(diagnosis ConnectionDiagnosis) EndpointChain {
+	chain := EndpointChain{URL: diagnosis.URL}
+	if parsed, err := url.Parse(diagnosis.URL); err == nil {
+		chain.ServerName = parsed.Hostname()
+	}
+	if diagnosis.TLS == nil || len(diagnosis.TLS.PeerCertificates) == 0 {
+		chain.Error = fmt.Sprintf("no certificate chain was presented, the connection failed at the %s stage", diagnosis.FailedStage)
+		return chain
+	}
+	chain.Certificates = diagnosis.TLS.CertificateChain
+	chain.Intercepted = diagnosis.TLS.Intercepted
+	for _, cert := range diagnosis.TLS.PeerCertificates {
+		chain.PEM = append(chain.PEM, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+	return chain
+}
+
+// X509 - parses the PEM encoded chain
+func (c EndpointChain) X509() []*x509.Certificate {
+	var certs []*x509.Certificate
+	for _, block := range c.PEM {
+		certs = append(certs, ParsePEMCertificates([]byte(block))...)
+	}
+	return certs
+}
+
+// ParsePEMCertificates - returns every certificate in PEM encoded data, skipping blocks that are not certificates
+func ParsePEMCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// CheckTrust - verifies each endpoint chain against the roots of a trust store and explains any rejection
+func CheckTrust(store string, source string, roots *x509.CertPool, chains []EndpointChain) []TrustCheck {
+	var checks []TrustCheck
+	for _, chain := range chains {
+		certs := chain.X509()
+		if len(certs) == 0 {
+			continue
+		}
+		check := TrustCheck{Store: store, Source: source, ServerName: chain.ServerName}
+		err := VerifyChain(certs, chain.ServerName, roots)
+		check.Trusted = err == nil
+		if err != nil {
+			check.Reason = rejectionReason(err, certs)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func rejectionReason(err error, certs []*x509.Certificate) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalid x509.CertificateInvalidError
+	var hostname x509.HostnameError
+	top := certs[len(certs)-1]
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Sprintf("the store does not contain the certificate authority %q that issued %q", top.Issuer.String(), top.Subject.String())
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		for _, cert := range certs {
+			if time.Now().After(cert.NotAfter) {
+				return fmt.Sprintf("the certificate %q expired on %s", cert.Subject.String(), cert.NotAfter.Format("2006-01-02"))
+			}
+		}
+		return invalid.Error()
+	case errors.As(err, &hostname):
+		return "the certificate is not valid for this host: " + hostname.Error()
+	}
+	return err.Error()
+}
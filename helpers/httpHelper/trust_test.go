@@ -0,0 +1,60 @@
+package httpHelper
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckTrust(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	chain := EndpointChain{
+		ServerName: "example.com",
+		PEM:        []string{string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))},
+	}
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+
+	tests := []struct {
+		name        string
+		roots       *x509.CertPool
+		serverName  string
+		wantTrusted bool
+		wantReason  string
+	}{
+		{name: "should trust a chain issued by a root in the store", roots: trusted, serverName: "example.com", wantTrusted: true},
+		{name: "should explain a missing certificate authority", roots: x509.NewCertPool(), serverName: "example.com", wantReason: "the store does not contain the certificate authority"},
+		{name: "should explain a host name mismatch", roots: trusted, serverName: "collector.newrelic.com", wantReason: "the certificate is not valid for this host"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain.ServerName = tt.serverName
+			got := CheckTrust("Test", "test bundle", tt.roots, []EndpointChain{chain})
+			if len(got) != 1 {
+				t.Fatalf("CheckTrust() returned %d checks, want 1", len(got))
+			}
+			if got[0].Trusted != tt.wantTrusted {
+				t.Errorf("CheckTrust() Trusted = %v, want %v", got[0].Trusted, tt.wantTrusted)
+			}
+			if !strings.Contains(got[0].Reason, tt.wantReason) {
+				t.Errorf("CheckTrust() Reason = %q, want it to contain %q", got[0].Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestParsePEMCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	bundle := "# comment\n" + string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	got := ParsePEMCertificates([]byte(bundle))
+	if len(got) != 1 || !got[0].Equal(server.Certificate()) {
+		t.Errorf("ParsePEMCertificates() = %v, want only the server certificate", got)
+	}
+}
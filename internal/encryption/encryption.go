@@ -0,0 +1,250 @@
+// Package encryption encrypts output artifacts to a recipient public key so only the holder of the
+// matching private key can open them.
+//
+// Files are encrypted with an ephemeral X25519 key agreement against the recipient key. The shared
+// secret is expanded with HKDF-SHA256 into an AES-256-GCM key, and the content is sealed in 64 KiB
+// chunks whose nonces carry the chunk counter and a final chunk flag, so files are streamed rather
+// than held in memory and truncation or reordering is detected.
+//
+//	magic "NRDIAGENC1" | recipient fingerprint (8) | ephemeral public key (32) | chunks...
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// FileExtension is appended to the name of encrypted files
+const FileExtension = ".enc"
+
+const (
+	magic           = "NRDIAGENC1"
+	fingerprintSize = 8
+	chunkSize       = 64 << 10
+	publicKeyType   = "NRDIAG X25519 PUBLIC KEY"
+	privateKeyType  = "NRDIAG X25519 PRIVATE KEY"
+	keyInfo         = "nrdiag-encryption v1 file key"
+)
+
+var (
+	// ErrNotEncrypted is returned when decrypting a file that was not written by Encrypt
+	ErrNotEncrypted = errors.New("not an nrdiag encrypted file")
+	// ErrWrongKey is returned when the file was encrypted to a different public key
+	ErrWrongKey = errors.New("the file was encrypted to a different public key")
+	// ErrCorrupt is returned when the file was truncated or modified
+	ErrCorrupt = errors.New("the encrypted file is truncated or has been modified")
+)
+
+// GenerateKeyPair returns a new PEM encoded public and private key pair
+func GenerateKeyPair() (publicKey []byte, privateKey []byte, err error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey = pem.EncodeToMemory(&pem.Block{Type: publicKeyType, Bytes: key.PublicKey().Bytes()})
+	privateKey = pem.EncodeToMemory(&pem.Block{Type: privateKeyType, Bytes: key.Bytes()})
+	return publicKey, privateKey, nil
+}
+
+// ReadPublicKey reads a PEM encoded public key file
+func ReadPublicKey(path string) (*ecdh.PublicKey, error) {
+	keyBytes, err := readKeyFile(path, publicKeyType)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPublicKey(keyBytes)
+}
+
+// ReadPrivateKey reads a PEM encoded private key file
+func ReadPrivateKey(path string) (*ecdh.PrivateKey, error) {
+	keyBytes, err := readKeyFile(path, privateKeyType)
+	if err != nil {
+		return nil, err
+	}
+	return ecdh.X25519().NewPrivateKey(keyBytes)
+}
+
+func readKeyFile(path string, keyType string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(content)
+	if block == nil || block.Type != keyType {
+		return nil, fmt.Errorf("%s does not contain an %s", path, strings.ToLower(keyType))
+	}
+	return block.Bytes, nil
+}
+
+// Encrypt writes src to dst encrypted to the recipient public key
+func Encrypt(dst io.Writer, src io.Reader, recipient *ecdh.PublicKey) error {
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(shared, ephemeral.PublicKey(), recipient)
+	if err != nil {
+		return err
+	}
+	header := append([]byte(magic), fingerprint(recipient)...)
+	header = append(header, ephemeral.PublicKey().Bytes()...)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	// read one chunk ahead so the last chunk, even an empty one, is sealed with the final flag
+	reader := bufio.NewReaderSize(src, chunkSize+1)
+	buf := make([]byte, chunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := n < chunkSize
+		if !last {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				last = true
+			}
+		}
+		if _, err := dst.Write(aead.Seal(nil, nonce(counter, last), buf[:n], nil)); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// Decrypt writes the decrypted content of src to dst. Content is only written once each chunk has been authenticated.
+func Decrypt(dst io.Writer, src io.Reader, identity *ecdh.PrivateKey) error {
+	header := make([]byte, len(magic)+fingerprintSize+32)
+	if _, err := io.ReadFull(src, header); err != nil || string(header[:len(magic)]) != magic {
+		return ErrNotEncrypted
+	}
+	if !bytes.Equal(header[len(magic):len(magic)+fingerprintSize], fingerprint(identity.PublicKey())) {
+		return ErrWrongKey
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(header[len(magic)+fingerprintSize:])
+	if err != nil {
+		return ErrCorrupt
+	}
+	shared, err := identity.ECDH(ephemeral)
+	if err != nil {
+		return ErrCorrupt
+	}
+	aead, err := newAEAD(shared, ephemeral, identity.PublicKey())
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(src, chunkSize+aead.Overhead()+1)
+	buf := make([]byte, chunkSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := n < len(buf)
+		if !last {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				last = true
+			}
+		}
+		plain, err := aead.Open(nil, nonce(counter, last), buf[:n], nil)
+		if err != nil {
+			return ErrCorrupt
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// EncryptFile encrypts the file at path to path+FileExtension and removes the plain file
+func EncryptFile(path string, recipient *ecdh.PublicKey) (string, error) {
+	encryptedPath := path + FileExtension
+	src, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+	if err := writeFile(encryptedPath, func(dst io.Writer) error { return Encrypt(dst, src, recipient) }); err != nil {
+		return "", err
+	}
+	src.Close()
+	return encryptedPath, os.Remove(path)
+}
+
+// DecryptFile decrypts the file at path to outPath, which is not left behind when decryption fails
+func DecryptFile(path string, outPath string, identity *ecdh.PrivateKey) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	return writeFile(outPath, func(dst io.Writer) error { return Decrypt(dst, src, identity) })
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	dst, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	err = write(dst)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
+
+// newAEAD derives the file key from the shared secret, salted with both public keys
+func newAEAD(shared []byte, ephemeral *ecdh.PublicKey, recipient *ecdh.PublicKey) (cipher.AEAD, error) {
+	salt := append(append([]byte{}, ephemeral.Bytes()...), recipient.Bytes()...)
+	key, err := hkdf.Key(sha256.New, shared, salt, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// nonce is an 11 byte big endian chunk counter followed by 1 on the last chunk
+func nonce(counter uint64, last bool) []byte {
+	n := make([]byte, 12)
+	binary.BigEndian.PutUint64(n[3:11], counter)
+	if last {
+		n[11] = 1
+	}
+	return n
+}
+
+func fingerprint(key *ecdh.PublicKey) []byte {
+	sum := sha256.Sum256(key.Bytes())
+	return sum[:fingerprintSize]
+}
@@ -0,0 +1,141 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeKeyPair(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	publicKey, privateKey, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("GenerateKeyPair() error = %v", err)
+	}
+	publicPath := filepath.Join(dir, "nrdiag.pub")
+	privatePath := filepath.Join(dir, "nrdiag.key")
+	os.WriteFile(publicPath, publicKey, 0600)
+	os.WriteFile(privatePath, privateKey, 0600)
+	return publicPath, privatePath
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	publicPath, privatePath := writeKeyPair(t, t.TempDir())
+	recipient, err := ReadPublicKey(publicPath)
+	if err != nil {
+		t.Fatalf("ReadPublicKey() error = %v", err)
+	}
+	identity, err := ReadPrivateKey(privatePath)
+	if err != nil {
+		t.Fatalf("ReadPrivateKey() error = %v", err)
+	}
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 17} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+		encrypted := new(bytes.Buffer)
+		if err := Encrypt(encrypted, bytes.NewReader(plain), recipient); err != nil {
+			t.Fatalf("Encrypt() size %d error = %v", size, err)
+		}
+		if size > 16 && bytes.Contains(encrypted.Bytes(), plain[:16]) {
+			t.Errorf("Encrypt() size %d output contains the plain text", size)
+		}
+		decrypted := new(bytes.Buffer)
+		if err := Decrypt(decrypted, bytes.NewReader(encrypted.Bytes()), identity); err != nil {
+			t.Fatalf("Decrypt() size %d error = %v", size, err)
+		}
+		if !bytes.Equal(decrypted.Bytes(), plain) {
+			t.Errorf("Decrypt() size %d did not return the original content", size)
+		}
+	}
+}
+
+func TestDecryptErrors(t *testing.T) {
+	dir := t.TempDir()
+	publicPath, privatePath := writeKeyPair(t, dir)
+	_, otherPrivatePath := writeKeyPair(t, t.TempDir())
+	recipient, _ := ReadPublicKey(publicPath)
+	identity, _ := ReadPrivateKey(privatePath)
+	other, _ := ReadPrivateKey(otherPrivatePath)
+
+	encrypted := new(bytes.Buffer)
+	Encrypt(encrypted, bytes.NewReader(make([]byte, 2*chunkSize+5)), recipient)
+	headerSize := len(magic) + fingerprintSize + 32
+	tampered := append([]byte{}, encrypted.Bytes()...)
+	tampered[headerSize+10] ^= 1
+
+	tests := []struct {
+		name     string
+		content  []byte
+		wrongKey bool
+		want     error
+	}{
+		{name: "plain file", content: []byte("PK\x03\x04 not encrypted at all, just a zip"), want: ErrNotEncrypted},
+		{name: "different key", content: encrypted.Bytes(), wrongKey: true, want: ErrWrongKey},
+		{name: "modified chunk", content: tampered, want: ErrCorrupt},
+		{name: "truncated at a chunk boundary", content: encrypted.Bytes()[:headerSize+chunkSize+16], want: ErrCorrupt},
+		{name: "truncated mid chunk", content: encrypted.Bytes()[:encrypted.Len()-3], want: ErrCorrupt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := identity
+			if tt.wrongKey {
+				key = other
+			}
+			err := Decrypt(new(bytes.Buffer), bytes.NewReader(tt.content), key)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncryptFileDecryptFile(t *testing.T) {
+	dir := t.TempDir()
+	publicPath, privatePath := writeKeyPair(t, dir)
+	recipient, _ := ReadPublicKey(publicPath)
+	identity, _ := ReadPrivateKey(privatePath)
+	zip := filepath.Join(dir, "nrdiag-output.zip")
+	os.WriteFile(zip, []byte("zip content"), 0600)
+
+	encryptedPath, err := EncryptFile(zip, recipient)
+	if err != nil || encryptedPath != zip+FileExtension {
+		t.Fatalf("EncryptFile() = %q, %v", encryptedPath, err)
+	}
+	if _, err := os.Stat(zip); !os.IsNotExist(err) {
+		t.Errorf("EncryptFile() left the plain file behind")
+	}
+	if err := DecryptFile(encryptedPath, zip, identity); err != nil {
+		t.Fatalf("DecryptFile() error = %v", err)
+	}
+	if content, _ := os.ReadFile(zip); string(content) != "zip content" {
+		t.Errorf("DecryptFile() wrote %q", content)
+	}
+
+	_, otherPrivatePath := writeKeyPair(t, t.TempDir())
+	other, _ := ReadPrivateKey(otherPrivatePath)
+	out := filepath.Join(dir, "other.zip")
+	if err := DecryptFile(encryptedPath, out, other); err == nil {
+		t.Error("DecryptFile() expected an error for the wrong key")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("DecryptFile() left a partial file behind")
+	}
+}
+
+func TestReadKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	publicPath, privatePath := writeKeyPair(t, dir)
+	if _, err := ReadPublicKey(privatePath); err == nil {
+		t.Error("ReadPublicKey() expected an error for a private key file")
+	}
+	if _, err := ReadPrivateKey(publicPath); err == nil {
+		t.Error("ReadPrivateKey() expected an error for a public key file")
+	}
+	if _, err := ReadPublicKey(filepath.Join(dir, "missing")); err == nil {
+		t.Error("ReadPublicKey() expected an error for a missing file")
+	}
+}
@@ -0,0 +1,57 @@
+package haberdasher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrRateLimited is matched by errors.Is when Haberdasher responds with 429 Too Many Requests
+	ErrRateLimited = errors.New("haberdasher rate limited the request")
+	// ErrUnauthorized is matched by errors.Is when Haberdasher responds with 401 or 403
+	ErrUnauthorized = errors.New("haberdasher rejected the request credentials")
+	// ErrServerError is matched by errors.Is when Haberdasher responds with a 5xx status
+	ErrServerError = errors.New("haberdasher server error")
+)
+
+// APIError is returned by Client.Do when Haberdasher responds with a status code of 300 or above
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("expected StatusCode < 300 got %d: %v", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the sentinel error for the status code class so callers can use errors.Is
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode >= 500:
+		return ErrServerError
+	}
+	return nil
+}
+
+type idempotentKey struct{}
+
+// MarkIdempotent flags a request as safe to retry. GET, HEAD, OPTIONS, PUT and DELETE requests
+// are always retried, POST requests only when they are read-only lookups marked with this function
+func MarkIdempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey{}, true))
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
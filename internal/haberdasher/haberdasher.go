@@ -2,19 +2,27 @@ package haberdasher
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 const (
-	defaultBaseURL   = "http://localhost:3000"
-	defaultUserAgent = "haberdasher-go/1.0"
-	contentType      = "application/json"
+	defaultBaseURL      = "http://localhost:3000"
+	defaultUserAgent    = "haberdasher-go/1.0"
+	contentType         = "application/json"
+	defaultTimeout      = 30 * time.Second
+	defaultMaxRetries   = 3
+	defaultRetryWaitMin = 500 * time.Millisecond
+	defaultRetryWaitMax = 8 * time.Second
 )
 
 // DefaultClient - Singleton instance of Haberdasher API client
@@ -39,6 +47,21 @@ type Client struct {
 	// InsertKey is a value seeded by the RunID, and is required as a header for many haberdasher endpoints
 	InsertKey string
 
+	// ProxyURL is the proxy used for every request. When nil the proxy is read from the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, as httpHelper does
+	ProxyURL *url.URL
+
+	// MaxRetries is how many times an idempotent request is retried after a
+	// network error, a 429 or a 5xx response
+	MaxRetries int
+
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff between retries
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// sleep waits between retries, replaced in tests
+	sleep func(time.Duration)
+
 	common service // Reuse a single struct instead of allocating one for each service on the heap.
 
 	// Services used for talking to different parts of the Haberdasher API
@@ -68,9 +91,16 @@ func newClientWithDefaults() *Client {
 	baseURL, _ := url.Parse(defaultBaseURL)
 
 	newClient := &Client{
-		httpClient: http.DefaultClient,
-		BaseURL:    baseURL,
-		UserAgent:  defaultUserAgent,
+		BaseURL:      baseURL,
+		UserAgent:    defaultUserAgent,
+		MaxRetries:   defaultMaxRetries,
+		RetryWaitMin: defaultRetryWaitMin,
+		RetryWaitMax: defaultRetryWaitMax,
+		sleep:        time.Sleep,
+	}
+	newClient.httpClient = &http.Client{
+		Transport: newClient.newTransport(),
+		Timeout:   defaultTimeout,
 	}
 
 	// Create client after figuring out defaults
@@ -122,25 +152,54 @@ func (c *Client) NewRequest(method, url string, body interface{}) (*http.Request
 }
 
 // Do sends an API request and returns the API response. The response is JSON
-// decoded.
+// decoded. Idempotent requests are retried with exponential backoff and jitter
+// after network errors, 429 and 5xx responses.
 func (c *Client) Do(req *http.Request, respStruct interface{}) (*Response, error) {
+	// a body that cannot be re-read would be sent empty on the next attempt
+	maxRetries := 0
+	if isIdempotent(req) && (req.Body == nil || req.GetBody != nil) {
+		maxRetries = c.MaxRetries
+	}
+
+	var resp *Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retryAfter time.Duration
+		resp, retryAfter, err = c.do(req, respStruct)
+		if err == nil || attempt >= maxRetries || !isRetryable(req.Context(), err) {
+			return resp, err
+		}
+		c.sleep(c.backoff(attempt, retryAfter))
+	}
+}
+
+// do makes a single attempt at the request
+func (c *Client) do(req *http.Request, respStruct interface{}) (*Response, time.Duration, error) {
+	attempt := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, 0, err
+		}
+		attempt.Body = body
+	}
 
 	// Make HTTP request to API
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.httpClient.Do(attempt)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	defer resp.Body.Close() // nolint: errcheck
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %v", err)
+		return nil, 0, fmt.Errorf("error reading response: %v", err)
 	}
 
 	if resp.StatusCode >= 300 {
-		bodyString := string(bodyBytes)
-		return nil, fmt.Errorf("expected StatusCode < 300 got %d: %v", resp.StatusCode, bodyString)
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), apiErr
 	}
 
 	response := newResponse(resp)
@@ -160,7 +219,50 @@ func (c *Client) Do(req *http.Request, respStruct interface{}) (*Response, error
 
 	}
 
-	return response, err
+	return response, 0, err
+}
+
+// backoff returns the wait before the next attempt: the server's Retry-After when given,
+// otherwise an exponential delay with jitter, never more than RetryWaitMax
+func (c *Client) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if retryAfter > c.RetryWaitMax {
+			return c.RetryWaitMax
+		}
+		return retryAfter
+	}
+	wait := c.RetryWaitMin << attempt
+	if wait <= 0 || wait > c.RetryWaitMax {
+		wait = c.RetryWaitMax
+	}
+	// full jitter on the upper half keeps concurrent runs from retrying in lockstep
+	half := wait / 2
+	if half <= 0 {
+		return wait
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	// the http.Client wraps every transport failure (DNS, refused connection, timeout) in a url.Error
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	var seconds int
+	if _, err := fmt.Sscanf(value, "%d", &seconds); err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
 
 // newResponse creates a new Response for the provided http.Response
@@ -187,6 +289,39 @@ func (c *Client) SetUserAgent(userAgent string) {
 	c.UserAgent = userAgent
 }
 
+// SetTimeout sets the timeout of each attempt at a request
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// SetProxy sets the proxy used for every request, overriding the proxy environment variables
+func (c *Client) SetProxy(proxy string) error {
+	parsed, err := url.Parse(proxy)
+	if err != nil {
+		return err
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("proxy url %q must include a scheme and a host", parsed.Redacted())
+	}
+	c.ProxyURL = parsed
+	return nil
+}
+
+// newTransport returns a transport with the http.DefaultTransport settings whose proxy is resolved on every request
+func (c *Client) newTransport() *http.Transport {
+	transport := &http.Transport{}
+	if defaultTransport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport = defaultTransport.Clone()
+	}
+	transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		if c.ProxyURL != nil {
+			return c.ProxyURL, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+	return transport
+}
+
 // generateInsertKey takes a string, and returns a unique deterministic hash
 // it generates a SHA512 digest based on every other char of the input, reversed
 func generateInsertKey(runID string) string {
@@ -1,6 +1,7 @@
 package haberdasher
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -187,3 +189,95 @@ func testRequestURL(t *testing.T, r *http.Request, want string) {
 		t.Errorf("Request URL: %v, want %v", got, want)
 	}
 }
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		statusCode   int
+		retryAfter   time.Duration
+		method       string
+		idempotent   bool
+		wantErr      error
+		wantRequests int
+		wantWaits    []time.Duration
+	}{
+		{name: "retries a transient 502", failures: 1, statusCode: 502, method: "POST", idempotent: true, wantRequests: 2},
+		{name: "honors Retry-After on 429", failures: 2, statusCode: 429, retryAfter: 2 * time.Second, method: "POST", idempotent: true, wantRequests: 3, wantWaits: []time.Duration{2 * time.Second, 2 * time.Second}},
+		{name: "gives up after MaxRetries", failures: 5, statusCode: 503, method: "POST", idempotent: true, wantErr: ErrServerError, wantRequests: 4},
+		{name: "does not retry unauthorized", failures: 1, statusCode: 401, method: "POST", idempotent: true, wantErr: ErrUnauthorized, wantRequests: 1},
+		{name: "does not retry requests that are not idempotent", failures: 1, statusCode: 502, method: "POST", wantErr: ErrServerError, wantRequests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := NewStubServer()
+			defer stub.Close()
+			stub.FailNext(tt.failures, tt.statusCode, tt.retryAfter)
+			client := stub.Client()
+			var waits []time.Duration
+			client.sleep = func(d time.Duration) { waits = append(waits, d) }
+
+			req, err := client.NewRequest(tt.method, "/tasks/license-key", LicenseKeyRequest{LicenseKeys: []string{"key"}})
+			if err != nil {
+				t.Fatalf("Failed to setup http.NewRequest: %v", err)
+			}
+			if tt.idempotent {
+				req = MarkIdempotent(req)
+			}
+			_, err = client.Do(req, &LicenseKeyResponse{})
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("Did not expect error, but received: %s", err.Error())
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := stub.Requests(); got != tt.wantRequests {
+				t.Errorf("Do() made %d requests, want %d", got, tt.wantRequests)
+			}
+			if tt.wantWaits != nil && !reflect.DeepEqual(waits, tt.wantWaits) {
+				t.Errorf("Do() waited %v, want %v", waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	client := newClientWithDefaults()
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := client.backoff(attempt, 0)
+		ceiling := client.RetryWaitMin << attempt
+		if ceiling > client.RetryWaitMax || ceiling <= 0 {
+			ceiling = client.RetryWaitMax
+		}
+		if wait < ceiling/2 || wait > ceiling {
+			t.Errorf("backoff(%d) = %v, want between %v and %v", attempt, wait, ceiling/2, ceiling)
+		}
+	}
+	if got := client.backoff(0, time.Minute); got != client.RetryWaitMax {
+		t.Errorf("backoff() with a long Retry-After = %v, want %v", got, client.RetryWaitMax)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	proxied := false
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = true
+		fmt.Fprint(w, `{"success":true,"data":[]}`)
+	}))
+	defer proxy.Close()
+
+	client := newClientWithDefaults()
+	client.SetBaseURL("http://haberdasher.invalid")
+	if err := client.SetProxy(proxy.URL); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+	if _, _, err := client.Tasks.ValidateLicenseKeys([]string{"key"}); err != nil {
+		t.Errorf("Did not expect error, but received: %s", err.Error())
+	}
+	if !proxied {
+		t.Error("Expected the request to go through the proxy")
+	}
+	if err := client.SetProxy("proxy.example.com:8080"); err == nil {
+		t.Error("Expected an error for a proxy without a scheme")
+	}
+}
@@ -28,6 +28,8 @@ func (s *TasksService) CheckHSM(licenseKeys []string) ([]HSMresult, *Response, e
 	if err != nil {
 		return nil, nil, err
 	}
+	// the lookup does not change any state, so it is safe to retry
+	req = MarkIdempotent(req)
 
 	HSMresponse := &HSMresponse{}
 	resp, err := s.client.Do(req, HSMresponse)
@@ -28,6 +28,8 @@ func (s *TasksService) ValidateLicenseKeys(licenseKeys []string) ([]LicenseKeyRe
 	if err != nil {
 		return nil, nil, err
 	}
+	// the lookup does not change any state, so it is safe to retry
+	req = MarkIdempotent(req)
 
 	licenseKeyResponse := &LicenseKeyResponse{}
 	resp, err := s.client.Do(req, licenseKeyResponse)
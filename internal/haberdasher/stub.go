@@ -0,0 +1,161 @@
+package haberdasher
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StubServer is an in-memory Haberdasher API for tests and local development. It serves
+// the /tasks/license-key and /tasks/hsm endpoints and can be told to fail upcoming requests.
+//
+// For local development run it on the default base URL:
+//
+//	stub := haberdasher.NewStub()
+//	stub.ValidLicenseKeys["<license key>"] = true
+//	http.ListenAndServe("localhost:3000", stub)
+type StubServer struct {
+	// ValidLicenseKeys are the license keys /tasks/license-key reports as valid
+	ValidLicenseKeys map[string]bool
+	// HSMEnabled are the license keys whose account /tasks/hsm reports as High Security Mode enabled
+	HSMEnabled map[string]bool
+
+	mu       sync.Mutex
+	failures []stubFailure
+	requests int
+	server   *httptest.Server
+	mux      *http.ServeMux
+}
+
+type stubFailure struct {
+	statusCode int
+	retryAfter time.Duration
+}
+
+// NewStub returns a StubServer handler that is not listening yet
+func NewStub() *StubServer {
+	s := &StubServer{
+		ValidLicenseKeys: map[string]bool{},
+		HSMEnabled:       map[string]bool{},
+		mux:              http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/tasks/license-key", s.licenseKeys)
+	s.mux.HandleFunc("/tasks/hsm", s.hsm)
+	return s
+}
+
+// NewStubServer starts a StubServer on a local port. Call Close when done.
+func NewStubServer() *StubServer {
+	s := NewStub()
+	s.server = httptest.NewServer(s)
+	return s
+}
+
+// URL returns the base URL of a started StubServer
+func (s *StubServer) URL() string {
+	if s.server == nil {
+		return ""
+	}
+	return s.server.URL
+}
+
+// Close shuts down a started StubServer
+func (s *StubServer) Close() {
+	if s.server != nil {
+		s.server.Close()
+	}
+}
+
+// Client returns a Client for the started StubServer that has a run ID set and retries without waiting
+func (s *StubServer) Client() *Client {
+	client := newClientWithDefaults()
+	client.SetBaseURL(s.URL())
+	client.SetRunID("00000000-0000-0000-0000-000000000000")
+	client.sleep = func(time.Duration) {}
+	return client
+}
+
+// FailNext makes the next count requests respond with statusCode. A retryAfter above zero is sent as a Retry-After header.
+func (s *StubServer) FailNext(count int, statusCode int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures = append(s.failures, stubFailure{statusCode: statusCode, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns the number of requests the StubServer has received
+func (s *StubServer) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ServeHTTP records the request and responds with a queued failure or the endpoint handler
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	var failure *stubFailure
+	if len(s.failures) > 0 {
+		failure = &s.failures[0]
+		s.failures = s.failures[1:]
+	}
+	s.mu.Unlock()
+
+	if failure != nil {
+		if failure.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(failure.retryAfter.Seconds())))
+		}
+		http.Error(w, http.StatusText(failure.statusCode), failure.statusCode)
+		return
+	}
+	if r.Header.Get("Run-Id") == "" || r.Header.Get("Insert-Key") != generateInsertKey(r.Header.Get("Run-Id")) {
+		http.Error(w, "missing or invalid Run-Id and Insert-Key headers", http.StatusUnauthorized)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *StubServer) licenseKeys(w http.ResponseWriter, r *http.Request) {
+	var request LicenseKeyRequest
+	if !decodeStubRequest(w, r, &request) {
+		return
+	}
+	response := LicenseKeyResponse{Success: true, Data: []LicenseKeyResult{}}
+	for _, licenseKey := range request.LicenseKeys {
+		response.Data = append(response.Data, LicenseKeyResult{LicenseKey: licenseKey, IsValid: s.ValidLicenseKeys[licenseKey]})
+	}
+	writeStubResponse(w, response)
+}
+
+func (s *StubServer) hsm(w http.ResponseWriter, r *http.Request) {
+	var request HSMrequest
+	if !decodeStubRequest(w, r, &request) {
+		return
+	}
+	response := HSMresponse{Success: true, Data: []HSMresult{}}
+	for _, licenseKey := range request.LicenseKeys {
+		response.Data = append(response.Data, HSMresult{LicenseKey: licenseKey, IsEnabled: s.HSMEnabled[licenseKey]})
+	}
+	writeStubResponse(w, response)
+}
+
+func decodeStubRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeStubResponse(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	json.NewEncoder(w).Encode(response) // nolint: errcheck
+}
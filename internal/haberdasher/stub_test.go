@@ -0,0 +1,36 @@
+package haberdasher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStubServer(t *testing.T) {
+	stub := NewStubServer()
+	defer stub.Close()
+	stub.ValidLicenseKeys["good_key"] = true
+	stub.HSMEnabled["good_key"] = true
+	client := stub.Client()
+
+	licenseKeyResults, _, err := client.Tasks.ValidateLicenseKeys([]string{"good_key", "bad_key"})
+	if err != nil {
+		t.Fatalf("Did not expect error, but received: %s", err.Error())
+	}
+	wantLicenseKeyResults := []LicenseKeyResult{{LicenseKey: "good_key", IsValid: true}, {LicenseKey: "bad_key", IsValid: false}}
+	if !reflect.DeepEqual(licenseKeyResults, wantLicenseKeyResults) {
+		t.Errorf("ValidateLicenseKeys() = %#v, want %#v", licenseKeyResults, wantLicenseKeyResults)
+	}
+
+	hsmResults, _, err := client.Tasks.CheckHSM([]string{"good_key"})
+	if err != nil {
+		t.Fatalf("Did not expect error, but received: %s", err.Error())
+	}
+	if len(hsmResults) != 1 || !hsmResults[0].IsEnabled {
+		t.Errorf("CheckHSM() = %#v, want HSM enabled for good_key", hsmResults)
+	}
+
+	client.SetRunID("")
+	if _, _, err := client.Tasks.ValidateLicenseKeys([]string{"good_key"}); err == nil {
+		t.Error("Expected an error for a request without a run ID")
+	}
+}
@@ -1,7 +1,7 @@
 package mocks
 
 import (
-	"bytes"
+	"io"
 	"net/http"
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
@@ -23,12 +23,12 @@ func (m *MAttachDeps) GetFileSize(file string) int64 {
 	return r0
 }
 
-func (m *MAttachDeps) GetReader(file string) (*bytes.Reader, error) {
+func (m *MAttachDeps) GetReader(file string) (io.ReadSeeker, error) {
 	ret := m.Called(file)
 
-	var r0 *bytes.Reader
+	var r0 io.ReadSeeker
 	if ret.Get(0) != nil {
-		r0 = ret.Get(0).(*bytes.Reader)
+		r0 = ret.Get(0).(io.ReadSeeker)
 	}
 
 	var r1 error
@@ -39,7 +39,7 @@ func (m *MAttachDeps) GetReader(file string) (*bytes.Reader, error) {
 	return r0, r1
 }
 
-func (m *MAttachDeps) GetWrapper(endpoint string, file *bytes.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper {
+func (m *MAttachDeps) GetWrapper(endpoint string, file io.Reader, fileSize int64, filename string, attachmentKey string) httpHelper.RequestWrapper {
 	ret := m.Called(file, fileSize, filename, attachmentKey)
 
 	var r0 httpHelper.RequestWrapper
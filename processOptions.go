@@ -223,6 +223,7 @@ func printTasks() {
 // PrintOptions will output all the command line options
 func printOptions() {
 	flag.PrintDefaults()
+	log.Infof("\nCommands:\n\t%[1]s update\tUpdate to the latest release\n\t%[1]s decrypt\tDecrypt output files written with -encrypt-to\n", os.Args[0])
 }
 
 func processOverrides() (tasks.Options, []override) {
@@ -258,6 +259,12 @@ func processOverrides() (tasks.Options, []override) {
 		options.Options["ACAgentsNamespace"] = config.Flags.ACAgentsNamespace
 	}
 
+	// Passed as an option rather than an override as URLs contain the characters overrides are split on
+	if config.Flags.BrowserURL != "" {
+		log.Debug("Manually setting BrowserURL to ", config.Flags.BrowserURL)
+		options.Options["BrowserURL"] = config.Flags.BrowserURL
+	}
+
 	// Pass in Proxy file override value
 	if config.Flags.Proxy != "" {
 		log.Debug("Manually setting Proxy to ", config.Flags.Proxy)
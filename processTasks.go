@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -119,7 +120,15 @@ func processTasks(options tasks.Options, overrides []override, wg *sync.WaitGrou
 		}
 
 		if !overrideEnabled {
-			result = task.Execute(namedTaskOptions, dependentResults)
+			if config.Flags.Offline && tasks.RequiresNetwork(task) {
+				log.Debug("Skipping", task.Identifier(), "in offline mode")
+				result = tasks.Result{
+					Status:  tasks.None,
+					Summary: tasks.OfflineModeSummary,
+				}
+			} else {
+				result = task.Execute(namedTaskOptions, dependentResults)
+			}
 		}
 
 		taskResult := registration.TaskResult{
@@ -204,6 +213,21 @@ func processFlagsSuites(flagValue string, args []string) ([]suites.Suite, error)
 func processUploads() {
 	log.Debug("processing uploads")
 
+	if config.Flags.UploadTo != "" {
+		var paths []string
+		for _, filename := range attach.OutputFilenames() {
+			paths = append(paths, filepath.Join(config.Flags.OutputPath, filename))
+		}
+		processUploadTo(paths)
+	}
+
+	if config.Flags.Offline {
+		if config.Flags.APIKey != "" || config.Flags.AutoAttach {
+			log.Info("Offline mode: skipping upload. nrdiag-output.zip can be uploaded manually from a host with network access.")
+		}
+		return
+	}
+
 	//get timestamp to use attachment
 	timestamp := time.Now().UTC().Format(time.RFC3339)
 
@@ -213,7 +237,7 @@ func processUploads() {
 	}
 
 	if config.Flags.APIKey != "" || config.Flags.AutoAttach {
-		question := "We've created nrdiag-output.zip and nrdiag-output.json\n" +
+		question := "We've created " + strings.Join(attach.OutputFilenames(), " and ") + "\n" +
 			"Do you want to upload these to your New Relic account?"
 		if promptUser(question) {
 			checkAttachmentFlags(timestamp)
@@ -221,6 +245,52 @@ func processUploads() {
 	}
 }
 
+// processUploadOnly - uploads the output of an earlier run given with -upload-only instead of running diagnostics
+func processUploadOnly() {
+	if config.Flags.APIKey == "" && config.Flags.UploadTo == "" {
+		log.Fatalf("'-upload-only' requires '-api-key' or '-upload-to' since license keys are only validated while running diagnostics")
+	}
+	if config.Flags.UploadTo != "" {
+		paths := []string{config.Flags.UploadOnly}
+		jsonPath := attach.SiblingJSON(config.Flags.UploadOnly)
+		if _, err := os.Stat(jsonPath); err == nil {
+			paths = append(paths, jsonPath)
+		}
+		processUploadTo(paths)
+	}
+	if config.Flags.APIKey == "" {
+		return
+	}
+	if config.Flags.Offline {
+		log.Fatalf("Unable to upload %s in offline mode", config.Flags.UploadOnly)
+	}
+	timestamp := time.Now().UTC().Format(time.RFC3339)
+	attach.UploadExisting(config.Flags.UploadOnly, "upload_api", config.Flags.APIKey, timestamp, new(attach.AttachDeps))
+}
+
+// processUploadTo - uploads the output files to the -upload-to destination
+func processUploadTo(paths []string) {
+	headers, err := attach.ParseHeaders(config.Flags.UploadHeaders)
+	if err != nil {
+		log.Infof("Unable to upload to %s: %s\n", config.Flags.UploadTo, err.Error())
+		return
+	}
+	dest, err := attach.NewDestination(config.Flags.UploadTo, config.Flags.UploadMethod, headers)
+	if err != nil {
+		log.Infof("Unable to upload to %s: %s\n", config.Flags.UploadTo, err.Error())
+		return
+	}
+	if config.Flags.Offline && dest.RequiresNetwork() {
+		log.Infof("Offline mode: skipping upload to %s\n", config.Flags.UploadTo)
+		return
+	}
+	// a timestamp without colons is valid in file names and object keys
+	timestamp := time.Now().UTC().Format("20060102T150405Z")
+	if err := attach.UploadToDestination(dest, paths, timestamp); err != nil {
+		log.Infof("Error uploading to %s: %s\n", config.Flags.UploadTo, err.Error())
+	}
+}
+
 func checkAttachmentFlags(timestamp string) {
 	var ValidLicenseKeys []string
 	attachDeps := new(attach.AttachDeps)
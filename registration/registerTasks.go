@@ -22,6 +22,7 @@ import (
 	dotnetCoreCustInst "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/custominstrumentation"
 	dotnetCoreEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/env"
 	dotnetCoreLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/log"
+	dotnetCoreProfiler "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/profiler"
 	dotnetCoreRequirements "github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/requirements"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/example/template"
 	goAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/go/agent"
@@ -35,6 +36,7 @@ import (
 	infraConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/config"
 	infraEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/env"
 	infraLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/log"
+	infraLogs "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/logs"
 	javaAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/agent"
 	javaAppserver "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/appserver"
 	javaConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/config"
@@ -51,6 +53,9 @@ import (
 	nodeEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
 	nodeLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/log"
 	nodeRequirements "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/requirements"
+	otelCollector "github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/collector"
+	otelOTLP "github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/otlp"
+	otelSDK "github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/sdk"
 	phpAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/agent"
 	phpConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/config"
 	phpDaemon "github.com/newrelic/newrelic-diagnostics-cli/tasks/php/daemon"
@@ -66,6 +71,7 @@ import (
 	rubyEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/env"
 	rubyLog "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/log"
 	rubyRequirements "github.com/newrelic/newrelic-diagnostics-cli/tasks/ruby/requirements"
+	syntheticsJobManager "github.com/newrelic/newrelic-diagnostics-cli/tasks/synthetics/jobmanager"
 	syntheticsMinion "github.com/newrelic/newrelic-diagnostics-cli/tasks/synthetics/minion"
 )
 
@@ -77,6 +83,7 @@ func init() {
 	containers.RegisterWith(Register)
 	javaJvm.RegisterWith(Register)
 	phpDaemon.RegisterWith(Register)
+	syntheticsJobManager.RegisterWith(Register)
 	syntheticsMinion.RegisterWith(Register)
 	javaConfig.RegisterWith(Register)
 	javaAgent.RegisterWith(Register)
@@ -101,6 +108,7 @@ func init() {
 	infraConfig.RegisterWith(Register)
 	infraAgent.RegisterWith(Register)
 	infraLog.RegisterWith(Register)
+	infraLogs.RegisterWith(Register)
 	infraEnv.RegisterWith(Register)
 	androidConfig.RegisterWith(Register)
 	androidAgent.RegisterWith(Register)
@@ -123,6 +131,7 @@ func init() {
 	dotnetCoreRequirements.RegisterWith(Register)
 	javaEnv.RegisterWith(Register)
 	dotnetCoreEnv.RegisterWith(Register)
+	dotnetCoreProfiler.RegisterWith(Register)
 	baseAgent.RegisterWith(Register)
 	nodeRequirements.RegisterWith(Register)
 	rubyRequirements.RegisterWith(Register)
@@ -133,6 +142,9 @@ func init() {
 	K8sHelm.RegisterWith(Register)
 	agentControlAgent.RegisterWith(Register)
 	agentControlConfig.RegisterWith(Register)
+	otelCollector.RegisterWith(Register)
+	otelSDK.RegisterWith(Register)
+	otelOTLP.RegisterWith(Register)
 
 	//example stuff, doesn't need to "ship" because binary gets name after directory with `go build` cmd
 	if strings.Contains(os.Args[0], "newrelic-diagnostics-cli") {
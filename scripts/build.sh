@@ -26,6 +26,11 @@ if [ -z "$BUILD_NUMBER" ]; then
   JP_ATTACHMENT_ENDPOINT="${STAGING_ATTACHMENT_ENDPOINT}"
   JP_HABERDASHER_URL="${STAGING_HABERDASHER_URL}"
 else
+  # Release builds must embed the key nrdiag update authenticates releases with
+  if [ -z "$UPDATE_SIGNING_PUBLIC_KEY" ]; then
+    echo "UPDATE_SIGNING_PUBLIC_KEY is required for a release build"
+    exit 1
+  fi
   # Build number is present, send usage data to production endpoint
   echo "send usage data to Haberdasher production"
   US_USAGE_ENDPOINT="${PROD_USAGE_ENDPOINT}"
@@ -42,7 +47,7 @@ fi
 VERSION=$(cat releaseVersion.txt | awk -F'majorMinor=' '{printf$2}')
 
 BUILD_TIMESTAMP=$(date -u '+%Y-%m-%d_%I:%M:%S%p')
-LDFLAGS="-s -w -X ${CONFIG_PATH}.Version=${VERSION}.${VERSION_NUMBER} -X ${CONFIG_PATH}.BuildTimestamp=${BUILD_TIMESTAMP} -X ${CONFIG_PATH}.USUsageEndpoint=${US_USAGE_ENDPOINT} -X ${CONFIG_PATH}.USAttachmentEndpoint=${US_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.USHaberdasherURL=${US_HABERDASHER_URL} -X ${CONFIG_PATH}.EUUsageEndpoint=${EU_USAGE_ENDPOINT} -X ${CONFIG_PATH}.EUAttachmentEndpoint=${EU_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.EUHaberdasherURL=${EU_HABERDASHER_URL} -X ${CONFIG_PATH}.JPUsageEndpoint=${JP_USAGE_ENDPOINT} -X ${CONFIG_PATH}.JPAttachmentEndpoint=${JP_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.JPHaberdasherURL=${JP_HABERDASHER_URL}"
+LDFLAGS="-s -w -X ${CONFIG_PATH}.Version=${VERSION}.${VERSION_NUMBER} -X ${CONFIG_PATH}.BuildTimestamp=${BUILD_TIMESTAMP} -X ${CONFIG_PATH}.USUsageEndpoint=${US_USAGE_ENDPOINT} -X ${CONFIG_PATH}.USAttachmentEndpoint=${US_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.USHaberdasherURL=${US_HABERDASHER_URL} -X ${CONFIG_PATH}.EUUsageEndpoint=${EU_USAGE_ENDPOINT} -X ${CONFIG_PATH}.EUAttachmentEndpoint=${EU_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.EUHaberdasherURL=${EU_HABERDASHER_URL} -X ${CONFIG_PATH}.JPUsageEndpoint=${JP_USAGE_ENDPOINT} -X ${CONFIG_PATH}.JPAttachmentEndpoint=${JP_ATTACHMENT_ENDPOINT} -X ${CONFIG_PATH}.JPHaberdasherURL=${JP_HABERDASHER_URL} -X ${CONFIG_PATH}.UpdateSigningKey=${UPDATE_SIGNING_PUBLIC_KEY}"
 
 # Set version based on version.txt file and auto version number
 echo "Build version is $VERSION.$VERSION_NUMBER"
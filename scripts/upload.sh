@@ -1,7 +1,7 @@
 #!/usr/bin/env bash
 
 # This script runs with the following env values passed in and mounts the current working directory into the container:
-#    $ docker run --rm -e S3_BUCKET -e AWS_ACCESS_KEY_ID -e AWS_SECRET_ACCESS_KEY -e BUILD_NUMBER \
+#    $ docker run --rm -e S3_BUCKET -e AWS_ACCESS_KEY_ID -e AWS_SECRET_ACCESS_KEY -e BUILD_NUMBER -e UPDATE_SIGNING_KEY_FILE -e UPDATE_SIGNING_PUBLIC_KEY \
 #      -v $PWD/production:/root/go/src/github.com/newrelic/newrelic-diagnostics-cli/sharedfolder nrdiag-build ./scripts/upload.sh
 # To check files from command line:
 #    $ AWS_ACCESS_KEY_ID=abc AWS_SECRET_ACCESS_KEY=123 aws s3 ls s3://${S3_BUCKET}/nrdiag/
@@ -25,6 +25,18 @@ checkBuildNumber() {
   fi
 }
 
+# nrdiag update refuses releases it cannot authenticate, so every release must be signed
+checkSigningKeys() {
+  if [[ -z "${UPDATE_SIGNING_PUBLIC_KEY}" || -z "${UPDATE_SIGNING_KEY_FILE}" ]]; then
+    echo "UPDATE_SIGNING_PUBLIC_KEY and UPDATE_SIGNING_KEY_FILE are required to sign the release. The release process did not run."
+    exit 1
+  fi
+  if [[ ! -f "${UPDATE_SIGNING_KEY_FILE}" ]]; then
+    echo "UPDATE_SIGNING_KEY_FILE ${UPDATE_SIGNING_KEY_FILE} does not exist. The release process did not run."
+    exit 1
+  fi
+}
+
 build() {
   echo "Running build script"
   sh ./scripts/build.sh
@@ -55,6 +67,17 @@ createZip() {
   cd ..
 }
 
+# nrdiag update verifies downloads against these checksums and their signature with the embedded
+# UPDATE_SIGNING_PUBLIC_KEY. UPDATE_SIGNING_KEY_FILE is the matching Ed25519 private key.
+createChecksums() {
+  local checksums="nrdiag_${VERSION}_checksums.txt"
+  echo "Creating ${checksums}"
+  cd ./${SCRATCH_DIR}/${BASE_DIR}
+  sha256sum nrdiag_${VERSION}.zip nrdiag_${VERSION}_*.zip nrdiag_${VERSION}_*.tar.gz >${checksums}
+  openssl pkeyutl -sign -rawin -inkey "${UPDATE_SIGNING_KEY_FILE}" -in ${checksums} -out ${checksums}.sig
+  cd ../..
+}
+
 cleanUpBeforeUpload() {
   rm ${SCRATCH_DIR}/${BASE_DIR}/LICENSE*
   rm ${SCRATCH_DIR}/${BASE_DIR}/README.txt
@@ -104,7 +127,7 @@ uploadToAws() {
 
 removeOldestFromAws() {
   echo "Finding all files for oldest release on download.newrelic.com"
-  local aws_sort_query='sort_by(Contents[?Key && (contains(Key, `.zip`) == `true` || contains(Key, `.tar.gz`) == `true`) && contains(Key, `nrdiag`) == `true` && contains(Key, `latest`) == `false` && contains(Key, `'"${VERSION}"'`) == `false` && contains(Key, `'"${PREV_VERSION}"'`) == `false`], &LastModified)[].[Key]'
+  local aws_sort_query='sort_by(Contents[?Key && (contains(Key, `.zip`) == `true` || contains(Key, `.tar.gz`) == `true` || contains(Key, `_checksums.txt`) == `true`) && contains(Key, `nrdiag`) == `true` && contains(Key, `latest`) == `false` && contains(Key, `'"${VERSION}"'`) == `false` && contains(Key, `'"${PREV_VERSION}"'`) == `false`], &LastModified)[].[Key]'
   IFS=$'\n' read -r -d '' -a old_releases < <(aws s3api list-objects-v2 --bucket "${S3_BUCKET}" --prefix "${BASE_DIR}"/ --query "${aws_sort_query}" --output text && printf '\0')
   for rel in "${old_releases[@]}"; do
     aws s3 rm s3://${S3_BUCKET}/${rel}
@@ -114,6 +137,7 @@ removeOldestFromAws() {
 # ------------------------- MAIN
 
 checkBuildNumber
+checkSigningKeys
 prepDirs
 build
 createVersionText
@@ -124,6 +148,7 @@ createPlatformSpecificArchive linux arm64
 createPlatformSpecificArchive windows x86
 createPlatformSpecificArchive windows x64
 createPlatformSpecificArchive windows arm64
+createChecksums
 cleanUpBeforeUpload
 uploadToAws
 removeOldestFromAws
@@ -111,6 +111,24 @@ var suiteDefinitions = []Suite{
 			"Synthetics/*",
 		},
 	},
+	{
+		Identifier:  "job-manager",
+		DisplayName: "Synthetics Job Manager",
+		Description: "Gather information about Synthetics job managers on Docker, Podman or Kubernetes",
+		Tasks: []string{
+			"Base/Env/HostInfo",
+			"Synthetics/JobManager/*",
+		},
+	},
+	{
+		Identifier:  "otel",
+		DisplayName: "OpenTelemetry",
+		Description: "OpenTelemetry collector and SDK config and OTLP connectivity to New Relic",
+		Tasks: []string{
+			"Base/Env/HostInfo",
+			"OTel/*",
+		},
+	},
 	{
 		Identifier:  "browser",
 		DisplayName: "Browser Agent",
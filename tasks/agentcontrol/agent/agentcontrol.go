@@ -19,8 +19,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		configReader: os.ReadFile,
 	}, true)
 	registrationFunc(AgentControlAgentConnect{
-		httpGetter:   httpHelper.MakeHTTPRequest,
-		configReader: os.ReadFile,
+		httpGetter:         httpHelper.MakeHTTPRequest,
+		configReader:       os.ReadFile,
+		diagnoseConnection: httpHelper.DiagnoseConnection,
 	}, true)
 	registrationFunc(AgentControlLogCollect{
 		cmdExec: tasks.CmdExecutor,
@@ -18,13 +18,22 @@ import (
 )
 
 const (
-	defaultOpAMPEndpoint = "https://opamp.service.newrelic.com/v1/opamp"
-	defaultJWKSEndpoint  = "https://publickeys.newrelic.com/r/blob-management/global/agentconfiguration/jwks.json"
-	defaultOAuthEndpoint = "https://system-identity-oauth.service.newrelic.com/oauth2/token"
-	defaultStatusHost    = "127.0.0.1"
-	defaultStatusPort    = 51200
+	defaultStatusHost = "127.0.0.1"
+	defaultStatusPort = 51200
 )
 
+// Agent Control defaults to the US region endpoints unless local_config.yaml overrides them
+var (
+	defaultOpAMPEndpoint = defaultEndpointURL(tasks.ProductOpAMP)
+	defaultJWKSEndpoint  = defaultEndpointURL(tasks.ProductFleetPublicKey)
+	defaultOAuthEndpoint = defaultEndpointURL(tasks.ProductSystemIdentity)
+)
+
+func defaultEndpointURL(product tasks.EndpointProduct) string {
+	endpoint, _ := tasks.GetEndpoint(tasks.RegionUS, product)
+	return endpoint.URL()
+}
+
 var acLocalConfigPath = func() string {
 	if runtime.GOOS == "windows" {
 		return `C:\Program Files\New Relic\newrelic-agent-control\local-data\agent-control\local_config.yaml`
@@ -72,6 +81,7 @@ type ConnectResult struct {
 	Reachable  bool
 	StatusCode int
 	Note       string
+	Diagnosis  *httpHelper.ConnectionDiagnosis
 }
 
 // KeyValidationResult holds the outcome of the local private key file check.
@@ -90,8 +100,9 @@ type AgentControlConnectPayload struct {
 // AgentControlAgentConnect checks network connectivity to agent-control service endpoints
 // and validates the local identity private key.
 type AgentControlAgentConnect struct {
-	httpGetter   requestFunc
-	configReader func(string) ([]byte, error)
+	httpGetter         requestFunc
+	configReader       func(string) ([]byte, error)
+	diagnoseConnection httpHelper.DiagnoseFunc
 }
 
 func (p AgentControlAgentConnect) Identifier() tasks.Identifier {
@@ -106,6 +117,11 @@ func (p AgentControlAgentConnect) Dependencies() []string {
 	return []string{"AgentControl/Config/Agent"}
 }
 
+// RequiresNetwork - this task connects to the Agent Control endpoints, so it is skipped in offline mode
+func (p AgentControlAgentConnect) RequiresNetwork() bool {
+	return true
+}
+
 func (p AgentControlAgentConnect) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	if upstream["AgentControl/Config/Agent"].Status != tasks.Success {
 		return tasks.Result{
@@ -277,7 +293,7 @@ func (p AgentControlAgentConnect) checkConnectivity(name, url string) ConnectRes
 	response, err := p.httpGetter(wrapper)
 	if err != nil {
 		log.Debug(fmt.Sprintf("%s endpoint unreachable: %s", name, err.Error()))
-		return ConnectResult{URL: url, Reachable: false, Note: err.Error()}
+		return p.unreachable(url, err)
 	}
 	_ = response.Body.Close()
 
@@ -285,6 +301,17 @@ func (p AgentControlAgentConnect) checkConnectivity(name, url string) ConnectRes
 	return ConnectResult{URL: url, Reachable: true, StatusCode: response.StatusCode}
 }
 
+// unreachable builds the result for a connection-level error, diagnosing which network stage failed
+func (p AgentControlAgentConnect) unreachable(url string, err error) ConnectResult {
+	result := ConnectResult{URL: url, Reachable: false, Note: err.Error()}
+	if p.diagnoseConnection != nil {
+		diagnosis := p.diagnoseConnection(url)
+		result.Diagnosis = &diagnosis
+		result.Note += "\n" + diagnosis.Summary()
+	}
+	return result
+}
+
 type jwksPayload struct {
 	Keys []json.RawMessage `json:"keys"`
 }
@@ -300,7 +327,7 @@ func (p AgentControlAgentConnect) checkJWKS(url string) ConnectResult {
 
 	response, err := p.httpGetter(wrapper)
 	if err != nil {
-		return ConnectResult{URL: url, Reachable: false, Note: err.Error()}
+		return p.unreachable(url, err)
 	}
 	defer func() { _ = response.Body.Close() }()
 
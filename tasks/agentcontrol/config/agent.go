@@ -56,10 +56,7 @@ func (p AgentControlConfigAgent) Execute(_ tasks.Options, _ map[string]tasks.Res
 
 	log.Debug("Identified AgentControl from binary: " + binaryFilename)
 
-	roots := acCollectLinux
-	if runtime.GOOS == "windows" {
-		roots = acCollectWindows
-	}
+	roots := defaultRoots()
 
 	filesToCopy := collectYAMLFiles(roots)
 
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"runtime"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -11,4 +13,15 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 
 	registrationFunc(AgentControlConfigAgent{binaryChecker: checkForBinary}, true)
 	registrationFunc(AgentControlDirTree{}, true)
+	registrationFunc(AgentControlConfigSubAgents{roots: defaultRoots()}, true)
+	registrationFunc(AgentControlConfigPermissions{roots: defaultRoots(), goos: runtime.GOOS, owner: tasks.FileOwner}, true)
+	registrationFunc(AgentControlConfigRemoteDiff{roots: defaultRoots()}, true)
+}
+
+// defaultRoots returns the agent-control data directories of this OS
+func defaultRoots() []collectRoot {
+	if runtime.GOOS == "windows" {
+		return acCollectWindows
+	}
+	return acCollectLinux
 }
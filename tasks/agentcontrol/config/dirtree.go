@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
-	"runtime"
 	"strings"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
@@ -34,10 +33,7 @@ func (p AgentControlDirTree) Execute(_ tasks.Options, upstream map[string]tasks.
 		}
 	}
 
-	roots := acCollectLinux
-	if runtime.GOOS == "windows" {
-		roots = acCollectWindows
-	}
+	roots := defaultRoots()
 	var dirs []string
 	for _, r := range roots {
 		dirs = append(dirs, r.base)
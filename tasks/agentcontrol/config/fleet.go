@@ -0,0 +1,198 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// agentControlID is the directory of Agent Control's own config in the local and fleet data directories
+const agentControlID = "agent-control"
+
+const (
+	localConfigFile  = "local_config.yaml"
+	remoteConfigFile = "remote_config.yaml"
+)
+
+// agentsConfig mirrors the agents Agent Control runs, declared in its local_config.yaml or delivered by Fleet Control
+type agentsConfig struct {
+	Agents map[string]struct {
+		AgentType string `yaml:"agent_type"`
+	} `yaml:"agents"`
+}
+
+// configFile is a parsed local or remote config, Values is nil when the file does not exist
+type configFile struct {
+	Path   string
+	Values map[string]interface{}
+	Err    error
+}
+
+// fleetConfig is the config of Agent Control and its sub-agents found in the local and fleet data directories
+type fleetConfig struct {
+	localDir  string
+	remoteDir string
+	// AgentControl holds local_config.yaml and the remote config of Agent Control
+	AgentControl subAgentConfig
+	// SubAgents maps every agent id with a declaration or a config directory to its config
+	SubAgents map[string]subAgentConfig
+}
+
+type subAgentConfig struct {
+	ID        string
+	AgentType string
+	// Declared is where the agent is declared: local, remote or empty for a config directory left behind
+	Declared string
+	Local    configFile
+	Remote   configFile
+}
+
+// dataDirs returns the local and fleet data directories of a set of collect roots
+func dataDirs(roots []collectRoot) (string, string) {
+	var localDir, remoteDir string
+	for _, root := range roots {
+		switch root.subDir {
+		case "local-data":
+			localDir = filepath.Join(root.base, root.subDir)
+		case "fleet-data":
+			remoteDir = filepath.Join(root.base, root.subDir)
+		}
+	}
+	return localDir, remoteDir
+}
+
+// loadFleetConfig reads Agent Control's config and the config of every sub-agent it declares or has a directory for.
+// The remote agents list replaces the local one, as Agent Control does when Fleet Control delivers a config.
+func loadFleetConfig(roots []collectRoot) (fleetConfig, error) {
+	localDir, remoteDir := dataDirs(roots)
+	fleet := fleetConfig{
+		localDir:  localDir,
+		remoteDir: remoteDir,
+		SubAgents: make(map[string]subAgentConfig),
+	}
+	fleet.AgentControl = fleet.readAgent(agentControlID)
+	if fleet.AgentControl.Local.Values == nil {
+		if fleet.AgentControl.Local.Err != nil {
+			return fleet, fleet.AgentControl.Local.Err
+		}
+		return fleet, fmt.Errorf("%s not found", fleet.AgentControl.Local.Path)
+	}
+
+	declared, source := fleet.AgentControl.Local, "local"
+	if fleet.AgentControl.Remote.Values != nil {
+		declared, source = fleet.AgentControl.Remote, "remote"
+	}
+	var agents agentsConfig
+	if raw, err := yaml.Marshal(declared.Values); err == nil {
+		_ = yaml.Unmarshal(raw, &agents)
+	}
+	for id, agent := range agents.Agents {
+		subAgent := fleet.readAgent(id)
+		subAgent.AgentType = agent.AgentType
+		subAgent.Declared = source
+		fleet.SubAgents[id] = subAgent
+	}
+
+	for _, dir := range []string{localDir, remoteDir} {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if _, ok := fleet.SubAgents[entry.Name()]; ok || !entry.IsDir() || entry.Name() == agentControlID {
+				continue
+			}
+			fleet.SubAgents[entry.Name()] = fleet.readAgent(entry.Name())
+		}
+	}
+	return fleet, nil
+}
+
+func (f fleetConfig) readAgent(id string) subAgentConfig {
+	return subAgentConfig{
+		ID:     id,
+		Local:  readConfigFile(filepath.Join(f.localDir, id, localConfigFile)),
+		Remote: readConfigFile(filepath.Join(f.remoteDir, id, remoteConfigFile)),
+	}
+}
+
+// ids returns the sub-agent ids in order
+func (f fleetConfig) ids() []string {
+	var ids []string
+	for id := range f.SubAgents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func readConfigFile(path string) configFile {
+	file := configFile{Path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return file
+	}
+	if err != nil {
+		file.Err = err
+		return file
+	}
+	values := make(map[string]interface{})
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		file.Err = fmt.Errorf("%s is not valid YAML: %w", path, err)
+		return file
+	}
+	file.Values = values
+	return file
+}
+
+// configDiff lists the settings of a remote config that differ from the local config, by key path
+type configDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// diffConfig compares two configs by key path. Values are not reported as they can hold license keys.
+func diffConfig(local map[string]interface{}, remote map[string]interface{}) configDiff {
+	localValues := flatten("", local, map[string]interface{}{})
+	remoteValues := flatten("", remote, map[string]interface{}{})
+	var diff configDiff
+	for key, value := range remoteValues {
+		localValue, ok := localValues[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case !reflect.DeepEqual(localValue, value):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range localValues {
+		if _, ok := remoteValues[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Changed)
+	return diff
+}
+
+// flatten maps the key path of each leaf value, lists are compared as a whole
+func flatten(prefix string, values map[string]interface{}, flat map[string]interface{}) map[string]interface{} {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flatten(path, nested, flat)
+			continue
+		}
+		flat[path] = value
+	}
+	return flat
+}
@@ -0,0 +1,252 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	agentcontrol "github.com/newrelic/newrelic-diagnostics-cli/tasks/agentcontrol/agent"
+)
+
+const localACConfig = `
+fleet_control:
+  endpoint: https://opamp.service.newrelic.com/v1/opamp
+agents:
+  nr-infra:
+    agent_type: "newrelic/com.newrelic.infrastructure:0.1.0"
+  nrdot:
+    agent_type: "newrelic/io.opentelemetry.collector:0.1.0"
+`
+
+// fleetRoots lays out the local and fleet data directories the way agent-control does under one base
+func fleetRoots(base string) []collectRoot {
+	return []collectRoot{
+		{base: base, subDir: "fleet-data"},
+		{base: base, subDir: "local-data"},
+	}
+}
+
+var agentDetected = map[string]tasks.Result{
+	"AgentControl/Config/Agent": {Status: tasks.Success},
+}
+
+func withStatus(body string) map[string]tasks.Result {
+	return map[string]tasks.Result{
+		"AgentControl/Config/Agent":       {Status: tasks.Success},
+		"AgentControl/Agent/StatusServer": {Status: tasks.Success, Payload: agentcontrol.RequestResult{StatusCode: 200, Body: body}},
+	}
+}
+
+func TestLoadFleetConfig_remoteAgentsReplaceLocal(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", localACConfig)
+	mustWrite(t, tmp, "fleet-data/agent-control/remote_config.yaml", "agents:\n  nr-infra:\n    agent_type: newrelic/com.newrelic.infrastructure:0.1.0\n")
+	mustWrite(t, tmp, "local-data/nr-infra/local_config.yaml", "config_agent:\n  log:\n    level: info\n")
+	mustWrite(t, tmp, "local-data/old-agent/local_config.yaml", "config_agent: {}\n")
+
+	fleet, err := loadFleetConfig(fleetRoots(tmp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fleet.ids(), []string{"nr-infra", "old-agent"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ids = %v, want %v", got, want)
+	}
+	if got := fleet.SubAgents["nr-infra"].Declared; got != "remote" {
+		t.Errorf("nr-infra declared = %q, want remote", got)
+	}
+	if got := fleet.SubAgents["old-agent"].Declared; got != "" {
+		t.Errorf("old-agent declared = %q, want empty", got)
+	}
+	if fleet.SubAgents["nr-infra"].Local.Values == nil {
+		t.Error("nr-infra local config not read")
+	}
+}
+
+func TestLoadFleetConfig_missingLocalConfig(t *testing.T) {
+	if _, err := loadFleetConfig(fleetRoots(t.TempDir())); err == nil {
+		t.Error("expected an error without local_config.yaml")
+	}
+}
+
+func TestDiffConfig(t *testing.T) {
+	local := map[string]interface{}{
+		"config_agent": map[string]interface{}{"log": map[string]interface{}{"level": "info"}, "license_key": "abc"},
+		"backoff":      "5s",
+	}
+	remote := map[string]interface{}{
+		"config_agent": map[string]interface{}{"log": map[string]interface{}{"level": "debug"}, "license_key": "abc"},
+		"health":       map[string]interface{}{"interval": "30s"},
+	}
+	got := diffConfig(local, remote)
+	want := configDiff{
+		Added:   []string{"health.interval"},
+		Removed: []string{"backoff"},
+		Changed: []string{"config_agent.log.level"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffConfig() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSubAgents_validConfigRunning(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", localACConfig)
+	mustWrite(t, tmp, "local-data/nr-infra/local_config.yaml", "config_agent: {}\n")
+	mustWrite(t, tmp, "local-data/nrdot/local_config.yaml", "config: {}\n")
+
+	p := AgentControlConfigSubAgents{roots: fleetRoots(tmp)}
+	result := p.Execute(tasks.Options{}, withStatus(`{"sub_agents":{"nr-infra":{"healthy":true},"nrdot":{"healthy":true}}}`))
+
+	if result.Status != tasks.Success {
+		t.Fatalf("Status = %v, want Success: %s", result.Status, result.Summary)
+	}
+	subAgents := result.Payload.([]SubAgent)
+	if len(subAgents) != 2 || !subAgents[0].Running || !subAgents[0].Healthy {
+		t.Errorf("unexpected payload %+v", subAgents)
+	}
+}
+
+func TestSubAgents_problems(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", `
+agents:
+  nr-infra:
+    agent_type: "newrelic/com.newrelic.infrastructure:0.1.0"
+  Java_App:
+    agent_type: "newrelic/com.newrelic.apm_java:0.1.0"
+  nrdot:
+    agent_type: "newrelic/io.opentelemetry.collector"
+`)
+	mustWrite(t, tmp, "local-data/nr-infra/local_config.yaml", "config_agent: [unclosed\n")
+	mustWrite(t, tmp, "local-data/nrdot/local_config.yaml", "config: {}\n")
+	mustWrite(t, tmp, "fleet-data/removed/remote_config.yaml", "config: {}\n")
+
+	p := AgentControlConfigSubAgents{roots: fleetRoots(tmp)}
+	result := p.Execute(tasks.Options{}, withStatus(`{"sub_agents":{"nr-infra":{"healthy":false,"last_error":"exit status 1"},"stray":{"healthy":true}}}`))
+
+	if result.Status != tasks.Failure {
+		t.Errorf("Status = %v, want Failure", result.Status)
+	}
+	for _, expected := range []string{
+		"Java_App: The agent id must be up to 32 lowercase letters",
+		"Java_App: The agent type newrelic/com.newrelic.apm_java only runs on Kubernetes.",
+		"Java_App: The agent is declared in the local config but agent-control is not running it.",
+		"nr-infra: " + filepath.Join(tmp, "local-data", "nr-infra", "local_config.yaml") + " is not valid YAML",
+		"nr-infra: The agent is not healthy: exit status 1",
+		`nrdot: The agent_type "newrelic/io.opentelemetry.collector" is not in the namespace/name:version format.`,
+		"removed: agent-control does not declare this agent, its config directory is left over and not used.",
+		"stray: agent-control is running this agent but it is not in the config",
+	} {
+		if !strings.Contains(result.Summary, expected) {
+			t.Errorf("Summary does not contain %q:\n%s", expected, result.Summary)
+		}
+	}
+}
+
+func TestSubAgents_statusServerUnavailable(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", localACConfig)
+	mustWrite(t, tmp, "local-data/nr-infra/local_config.yaml", "config_agent: {}\n")
+	mustWrite(t, tmp, "local-data/nrdot/local_config.yaml", "config: {}\n")
+
+	p := AgentControlConfigSubAgents{roots: fleetRoots(tmp)}
+	upstream := map[string]tasks.Result{
+		"AgentControl/Config/Agent":       {Status: tasks.Success},
+		"AgentControl/Agent/StatusServer": {Status: tasks.Warning},
+	}
+	result := p.Execute(tasks.Options{}, upstream)
+
+	if result.Status != tasks.Success {
+		t.Errorf("Status = %v, want Success", result.Status)
+	}
+	if !strings.Contains(result.Summary, "The running sub-agents were not checked: the status server did not respond") {
+		t.Errorf("unexpected summary %q", result.Summary)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not enforced on Windows")
+	}
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", "fleet_control:\n  auth_config:\n    private_key_path: "+filepath.Join(tmp, "key.pem")+"\n")
+	mustWrite(t, tmp, "key.pem", "key")
+	mustWrite(t, tmp, "fleet-data/nr-infra/remote_config.yaml", "config: {}\n")
+	if err := os.Chmod(filepath.Join(tmp, "fleet-data", "nr-infra", "remote_config.yaml"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+
+	p := AgentControlConfigPermissions{
+		roots: fleetRoots(tmp),
+		goos:  "linux",
+		owner: func(os.FileInfo) (uint32, uint32, bool) { return 0, 0, true },
+	}
+	result := p.Execute(tasks.Options{}, agentDetected)
+
+	if result.Status != tasks.Failure {
+		t.Errorf("Status = %v, want Failure", result.Status)
+	}
+	for _, expected := range []string{
+		filepath.Join(tmp, "fleet-data", "nr-infra", "remote_config.yaml") + " is writable by any user (-rw-rw-rw-).",
+		"The fleet_control private key " + filepath.Join(tmp, "key.pem") + " can be accessed by other users (-rw-r--r--)",
+	} {
+		if !strings.Contains(result.Summary, expected) {
+			t.Errorf("Summary does not contain %q:\n%s", expected, result.Summary)
+		}
+	}
+}
+
+func TestPermissions_ownedByRoot(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", "agents: {}\n")
+
+	p := AgentControlConfigPermissions{
+		roots: fleetRoots(tmp),
+		goos:  "linux",
+		owner: func(os.FileInfo) (uint32, uint32, bool) { return 1000, 1000, true },
+	}
+	result := p.Execute(tasks.Options{}, agentDetected)
+
+	if result.Status != tasks.Warning || !strings.Contains(result.Summary, "is owned by uid 1000 instead of root.") {
+		t.Errorf("Status = %v, Summary = %q", result.Status, result.Summary)
+	}
+}
+
+func TestRemoteDiff(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", localACConfig)
+	mustWrite(t, tmp, "local-data/nr-infra/local_config.yaml", "config_agent:\n  log:\n    level: info\n")
+	mustWrite(t, tmp, "fleet-data/nr-infra/remote_config.yaml", "config_agent:\n  log:\n    level: debug\n")
+
+	result := AgentControlConfigRemoteDiff{roots: fleetRoots(tmp)}.Execute(tasks.Options{}, agentDetected)
+
+	if result.Status != tasks.Info {
+		t.Fatalf("Status = %v, want Info", result.Status)
+	}
+	want := "nr-infra: the Fleet Control config replaces the local config, adding 0, removing 0 and changing 1 setting(s)."
+	if result.Summary != want {
+		t.Errorf("Summary = %q, want %q", result.Summary, want)
+	}
+	var file strings.Builder
+	for line := range result.FilesToCopy[0].Stream {
+		file.WriteString(line)
+	}
+	if !strings.Contains(file.String(), "  changed: config_agent.log.level\n") {
+		t.Errorf("unexpected diff file %q", file.String())
+	}
+}
+
+func TestRemoteDiff_noRemoteConfig(t *testing.T) {
+	tmp := t.TempDir()
+	mustWrite(t, tmp, "local-data/agent-control/local_config.yaml", localACConfig)
+
+	result := AgentControlConfigRemoteDiff{roots: fleetRoots(tmp)}.Execute(tasks.Options{}, agentDetected)
+
+	if result.Status != tasks.None {
+		t.Errorf("Status = %v, want None", result.Status)
+	}
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// AgentControlConfigPermissions checks the ownership and permissions of the agent-control config.
+// agent-control runs as root, so anyone able to change its config can choose what it runs.
+type AgentControlConfigPermissions struct {
+	roots []collectRoot
+	goos  string
+	owner tasks.FileOwnerFunc
+}
+
+func (p AgentControlConfigPermissions) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("AgentControl/Config/Permissions")
+}
+
+func (p AgentControlConfigPermissions) Explain() string {
+	return "Check ownership and permissions of agent-control config files"
+}
+
+func (p AgentControlConfigPermissions) Dependencies() []string {
+	return []string{"AgentControl/Config/Agent"}
+}
+
+func (p AgentControlConfigPermissions) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["AgentControl/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Agent Control not detected on system.",
+		}
+	}
+	if p.goos == "windows" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "agent-control file permissions are not checked on Windows.",
+		}
+	}
+
+	status := tasks.Success
+	var problems []string
+	problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+		status = maxStatus(status, problemStatus)
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	localDir, remoteDir := dataDirs(p.roots)
+	for _, dir := range []string{localDir, remoteDir} {
+		if !tasks.FileExists(dir) {
+			continue
+		}
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				log.Debug("could not check permissions of " + path + ": " + err.Error())
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if info.Mode().Perm()&0o002 != 0 {
+				problem(tasks.Failure, "%s is writable by any user (%s).", path, info.Mode().Perm())
+			}
+			if uid, _, ok := p.owner(info); ok && uid != 0 {
+				problem(tasks.Warning, "%s is owned by uid %d instead of root.", path, uid)
+			}
+			return nil
+		})
+	}
+
+	if keyPath := privateKeyPath(readConfigFile(filepath.Join(localDir, agentControlID, localConfigFile))); keyPath != "" {
+		info, err := os.Stat(keyPath)
+		switch {
+		case err != nil:
+			problem(tasks.Failure, "The fleet_control private key %s could not be read: %s", keyPath, err)
+		case info.Mode().Perm()&0o077 != 0:
+			problem(tasks.Failure, "The fleet_control private key %s can be accessed by other users (%s), it should only be readable by root.", keyPath, info.Mode().Perm())
+		}
+	}
+
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "agent-control config files are owned by root and not writable by other users.",
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(problems, "\n"),
+		Payload: problems,
+	}
+}
+
+// privateKeyPath returns fleet_control.auth_config.private_key_path from local_config.yaml
+func privateKeyPath(localConfig configFile) string {
+	fleetControl, _ := localConfig.Values["fleet_control"].(map[string]interface{})
+	authConfig, _ := fleetControl["auth_config"].(map[string]interface{})
+	path, _ := authConfig["private_key_path"].(string)
+	return path
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// AgentControlConfigRemoteDiff compares the config Fleet Control delivered with the local config it replaces
+type AgentControlConfigRemoteDiff struct {
+	roots []collectRoot
+}
+
+// RemoteConfigDiff lists the settings a remote config changes for one agent
+type RemoteConfigDiff struct {
+	ID         string
+	RemotePath string
+	LocalPath  string
+	Added      []string
+	Removed    []string
+	Changed    []string
+}
+
+func (p AgentControlConfigRemoteDiff) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("AgentControl/Config/RemoteDiff")
+}
+
+func (p AgentControlConfigRemoteDiff) Explain() string {
+	return "Compare agent-control local config with the config delivered by Fleet Control"
+}
+
+func (p AgentControlConfigRemoteDiff) Dependencies() []string {
+	return []string{"AgentControl/Config/Agent"}
+}
+
+func (p AgentControlConfigRemoteDiff) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["AgentControl/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Agent Control not detected on system.",
+		}
+	}
+
+	fleet, err := loadFleetConfig(p.roots)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Could not read agent-control config: " + err.Error(),
+		}
+	}
+
+	var diffs []RemoteConfigDiff
+	for _, config := range append([]subAgentConfig{fleet.AgentControl}, subAgentConfigs(fleet)...) {
+		if config.Remote.Values == nil {
+			continue
+		}
+		diff := diffConfig(config.Local.Values, config.Remote.Values)
+		diffs = append(diffs, RemoteConfigDiff{
+			ID:         config.ID,
+			RemotePath: config.Remote.Path,
+			LocalPath:  config.Local.Path,
+			Added:      diff.Added,
+			Removed:    diff.Removed,
+			Changed:    diff.Changed,
+		})
+	}
+	if len(diffs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No config delivered by Fleet Control was found, agent-control uses its local config.",
+		}
+	}
+
+	var sb strings.Builder
+	var summary []string
+	for _, diff := range diffs {
+		sb.WriteString(fmt.Sprintf("%s: %s replaces %s\n", diff.ID, diff.RemotePath, diff.LocalPath))
+		for _, section := range []struct {
+			name string
+			keys []string
+		}{{"added", diff.Added}, {"removed", diff.Removed}, {"changed", diff.Changed}} {
+			for _, key := range section.keys {
+				sb.WriteString(fmt.Sprintf("  %s: %s\n", section.name, key))
+			}
+		}
+		if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+			summary = append(summary, fmt.Sprintf("%s: the Fleet Control config matches the local config.", diff.ID))
+			continue
+		}
+		summary = append(summary, fmt.Sprintf("%s: the Fleet Control config replaces the local config, adding %d, removing %d and changing %d setting(s).", diff.ID, len(diff.Added), len(diff.Removed), len(diff.Changed)))
+	}
+
+	stream := make(chan string)
+	go tasks.StreamBlob(sb.String(), stream)
+
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: strings.Join(summary, "\n"),
+		Payload: diffs,
+		FilesToCopy: []tasks.FileCopyEnvelope{{
+			Path:       "agent-control-remote-config-diff.txt",
+			Stream:     stream,
+			Identifier: "AgentControl/Config/RemoteDiff",
+		}},
+	}
+}
+
+func subAgentConfigs(fleet fleetConfig) []subAgentConfig {
+	var configs []subAgentConfig
+	for _, id := range fleet.ids() {
+		configs = append(configs, fleet.SubAgents[id])
+	}
+	return configs
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	agentcontrol "github.com/newrelic/newrelic-diagnostics-cli/tasks/agentcontrol/agent"
+)
+
+const agentTypesDocURL = "https://docs.newrelic.com/docs/new-relic-control/agent-control/configuration"
+
+// onHostAgentTypes are the agent types Agent Control runs on hosts, with the agent type versions it supports
+var onHostAgentTypes = map[string][]string{
+	"newrelic/com.newrelic.infrastructure": {"0.1+"},
+	"newrelic/io.opentelemetry.collector":  {"0.1+"},
+}
+
+var (
+	agentIDRgx   = regexp.MustCompile(`^[a-z]([a-z0-9-]{0,30}[a-z0-9])?$`)
+	agentTypeRgx = regexp.MustCompile(`^([a-z0-9._-]+/[a-z0-9._-]+):([0-9]+\.[0-9]+\.[0-9]+)$`)
+)
+
+// AgentControlConfigSubAgents validates the sub-agents Agent Control is configured to run
+type AgentControlConfigSubAgents struct {
+	roots []collectRoot
+}
+
+// SubAgent is the validation result of one sub-agent
+type SubAgent struct {
+	ID        string
+	AgentType string
+	Declared  string
+	Running   bool
+	Healthy   bool
+	LastError string
+	Status    tasks.Status
+	Problems  []string
+}
+
+// statusResponse mirrors the sub-agents section of the status server response
+type statusResponse struct {
+	SubAgents map[string]struct {
+		AgentType string `json:"agent_type"`
+		Healthy   bool   `json:"healthy"`
+		LastError string `json:"last_error"`
+	} `json:"sub_agents"`
+}
+
+func (p AgentControlConfigSubAgents) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("AgentControl/Config/SubAgents")
+}
+
+func (p AgentControlConfigSubAgents) Explain() string {
+	return "Validate the sub-agents declared in agent-control config against the running sub-agents"
+}
+
+func (p AgentControlConfigSubAgents) Dependencies() []string {
+	return []string{"AgentControl/Config/Agent", "AgentControl/Agent/StatusServer"}
+}
+
+func (p AgentControlConfigSubAgents) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["AgentControl/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Agent Control not detected on system.",
+		}
+	}
+
+	fleet, err := loadFleetConfig(p.roots)
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "Could not read agent-control config: " + err.Error(),
+			URL:     agentTypesDocURL,
+		}
+	}
+	if len(fleet.SubAgents) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "No sub-agents are declared in agent-control config, agent-control is not running any agent.",
+			URL:     agentTypesDocURL,
+		}
+	}
+
+	running, statusErr := runningSubAgents(upstream["AgentControl/Agent/StatusServer"])
+	var subAgents []SubAgent
+	status := tasks.Success
+	var summary []string
+	for _, id := range fleet.ids() {
+		subAgent := checkSubAgent(fleet.SubAgents[id], running, statusErr == nil)
+		subAgents = append(subAgents, subAgent)
+		status = maxStatus(status, subAgent.Status)
+		for _, problem := range subAgent.Problems {
+			summary = append(summary, id+": "+problem)
+		}
+	}
+	for _, id := range sortedStatusIDs(running) {
+		if _, ok := fleet.SubAgents[id]; !ok {
+			status = maxStatus(status, tasks.Warning)
+			summary = append(summary, id+": agent-control is running this agent but it is not in the config, the config may have changed since agent-control started.")
+		}
+	}
+	if statusErr != nil {
+		log.Debug("Sub-agents not compared to the status server:", statusErr)
+		summary = append(summary, "The running sub-agents were not checked: "+statusErr.Error())
+	}
+
+	if status == tasks.Success {
+		summary = append([]string{fmt.Sprintf("%d sub-agent(s) are configured correctly.", len(subAgents))}, summary...)
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: strings.Join(summary, "\n"),
+			Payload: subAgents,
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		URL:     agentTypesDocURL,
+		Payload: subAgents,
+	}
+}
+
+// runningSubAgents reads the sub-agents from the status server response
+func runningSubAgents(statusResult tasks.Result) (statusResponse, error) {
+	var response statusResponse
+	if statusResult.Status != tasks.Success {
+		return response, errors.New("the status server did not respond")
+	}
+	payload, ok := statusResult.Payload.(agentcontrol.RequestResult)
+	if !ok {
+		return response, errors.New(tasks.AssertionErrorSummary)
+	}
+	if err := json.Unmarshal([]byte(payload.Body), &response); err != nil {
+		return response, fmt.Errorf("the status server response could not be parsed: %w", err)
+	}
+	return response, nil
+}
+
+func checkSubAgent(config subAgentConfig, running statusResponse, statusKnown bool) SubAgent {
+	subAgent := SubAgent{
+		ID:        config.ID,
+		AgentType: config.AgentType,
+		Declared:  config.Declared,
+		Status:    tasks.Success,
+	}
+	problem := func(status tasks.Status, format string, args ...interface{}) {
+		if status > subAgent.Status {
+			subAgent.Status = status
+		}
+		subAgent.Problems = append(subAgent.Problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, file := range []configFile{config.Local, config.Remote} {
+		if file.Err != nil {
+			problem(tasks.Failure, "%s", file.Err)
+		}
+	}
+
+	if config.Declared == "" {
+		problem(tasks.Warning, "agent-control does not declare this agent, its config directory is left over and not used.")
+		return subAgent
+	}
+
+	if config.ID == agentControlID || !agentIDRgx.MatchString(config.ID) {
+		problem(tasks.Failure, "The agent id must be up to 32 lowercase letters, digits and dashes, start with a letter and not be %q.", agentControlID)
+	}
+	subAgent.checkAgentType(problem)
+	if config.Local.Values == nil && config.Remote.Values == nil && config.Local.Err == nil && config.Remote.Err == nil {
+		problem(tasks.Warning, "No %s or %s found for this agent, it runs with the agent type defaults.", localConfigFile, remoteConfigFile)
+	}
+
+	if !statusKnown {
+		return subAgent
+	}
+	status, ok := running.SubAgents[config.ID]
+	switch {
+	case !ok:
+		problem(tasks.Warning, "The agent is declared in the %s config but agent-control is not running it.", config.Declared)
+	case !status.Healthy && status.LastError != "":
+		subAgent.Running = true
+		subAgent.LastError = status.LastError
+		problem(tasks.Warning, "The agent is not healthy: %s", status.LastError)
+	case !status.Healthy:
+		subAgent.Running = true
+		problem(tasks.Warning, "The agent is not healthy.")
+	default:
+		subAgent.Running = true
+		subAgent.Healthy = true
+	}
+	return subAgent
+}
+
+func (s SubAgent) checkAgentType(problem func(tasks.Status, string, ...interface{})) {
+	match := agentTypeRgx.FindStringSubmatch(s.AgentType)
+	if match == nil {
+		problem(tasks.Failure, "The agent_type %q is not in the namespace/name:version format.", s.AgentType)
+		return
+	}
+	name, version := match[1], match[2]
+	requirements, ok := onHostAgentTypes[name]
+	if !ok {
+		if strings.Contains(name, "k8s") || strings.HasPrefix(name, "newrelic/com.newrelic.apm_") {
+			problem(tasks.Failure, "The agent type %s only runs on Kubernetes.", name)
+			return
+		}
+		problem(tasks.Failure, "The agent type %s is not supported by agent-control on hosts.", name)
+		return
+	}
+	if compatible, err := tasks.VersionIsCompatible(version, requirements); err != nil || !compatible {
+		problem(tasks.Failure, "Version %s of the agent type %s is not supported, supported versions are %s.", version, name, strings.Join(requirements, ", "))
+	}
+}
+
+func sortedStatusIDs(running statusResponse) []string {
+	var ids []string
+	for id := range running.SubAgents {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func maxStatus(a, b tasks.Status) tasks.Status {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,384 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const (
+	newRelicGroup  = "com.newrelic.agent.android"
+	newRelicPlugin = newRelicGroup + ":agent-gradle-plugin"
+	newRelicAgent  = newRelicGroup + ":android-agent"
+	androidGradle  = "com.android.tools.build:gradle"
+	gradleDocsURL  = "https://docs.newrelic.com/docs/mobile-monitoring/new-relic-mobile-android/install-configure/install-android-apps-gradle-android-studio/"
+)
+
+var (
+	// coordinateRegex matches group:name:version coordinates in a classpath, dependency or version catalog library
+	coordinateRegex = regexp.MustCompile(`["']([\w.\-]+:[\w.\-]+):([^"'\s]+)["']`)
+	// pluginIDRegex matches a plugin applied with its version in the plugins block of Groovy and Kotlin DSL
+	pluginIDRegex = regexp.MustCompile(`id\s*\(?\s*["']([\w.\-]+)["']\s*\)?\s*version\s*\(?\s*["']([^"']+)["']`)
+	// propertyRegex matches ext properties, Kotlin vals and gradle.properties entries used as versions
+	propertyRegex    = regexp.MustCompile(`^\s*(?:ext\.|val\s+|def\s+|extra\[")?([\w.\-]+)"?\]?\s*=\s*["']?([\w.\-]+)["']?\s*$`)
+	variableRegex    = regexp.MustCompile(`^\$\{?([\w.]+)\}?$`)
+	catalogRefRegex  = regexp.MustCompile(`version\.ref\s*=\s*["']([\w.\-]+)["']`)
+	catalogIDRegex   = regexp.MustCompile(`(?:id|module)\s*=\s*["']([\w.\-:]+)["']`)
+	catalogVerRegex  = regexp.MustCompile(`version\s*=\s*["']([^"']+)["']`)
+	catalogGroupRgx  = regexp.MustCompile(`group\s*=\s*["']([^"']+)["']`)
+	catalogNameRgx   = regexp.MustCompile(`name\s*=\s*["']([^"']+)["']`)
+	minifyRegex      = regexp.MustCompile(`(?:minifyEnabled|isMinifyEnabled)\s*=?\s*true`)
+	noUploadRegex    = regexp.MustCompile(`uploadMappingFile\s*=?\s*false`)
+	distributionRgx  = regexp.MustCompile(`distributionUrl=.*gradle-([\d.]+(?:-[\w]+)?)-(?:bin|all)\.zip`)
+	appTokenRegex    = regexp.MustCompile(`(?m)^\s*com\.newrelic\.application_token\s*=\s*\S+`)
+	keepNewRelicRule = regexp.MustCompile(`-keep\s+class\s+com\.newrelic\.\*\*`)
+)
+
+// minimumGradle - the minimum Gradle version of each Android Gradle plugin release
+// https://developer.android.com/build/releases/gradle-plugin#updating-gradle
+var minimumGradle = map[string]string{
+	"4.0": "6.1.1",
+	"4.1": "6.5",
+	"4.2": "6.7.1",
+	"7.0": "7.0",
+	"7.1": "7.2",
+	"7.2": "7.3.3",
+	"7.3": "7.4",
+	"7.4": "7.5",
+	"8.0": "8.0",
+	"8.1": "8.0",
+	"8.2": "8.2",
+	"8.3": "8.4",
+	"8.4": "8.6",
+	"8.5": "8.7",
+	"8.6": "8.7",
+	"8.7": "8.9",
+}
+
+// AndroidConfigGradle - reads the Gradle build of an Android app for the New Relic plugin and agent
+type AndroidConfigGradle struct {
+	readFile func(string) ([]byte, error)
+}
+
+// AndroidBuild - the New Relic setup found in the Gradle build
+type AndroidBuild struct {
+	BuildFiles    []string
+	PluginVersion string
+	AgentVersion  string
+	AGPVersion    string
+	GradleVersion string
+	MinifyEnabled bool
+	Problems      []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p AndroidConfigGradle) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Android/Config/Gradle")
+}
+
+// Explain - Returns the help text for each individual task
+func (p AndroidConfigGradle) Explain() string {
+	return "Check the New Relic Android Gradle plugin and agent versions and mapping file upload settings"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p AndroidConfigGradle) Dependencies() []string {
+	return []string{
+		"Base/Config/Collect",
+	}
+}
+
+// Execute - Parses the Gradle build files, version catalogs and properties collected by Base/Config/Collect
+func (p AndroidConfigGradle) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	configs, _ := upstream["Base/Config/Collect"].Payload.([]config.ConfigElement)
+
+	var buildFiles, catalogs, properties, wrappers, proguardFiles, nrProperties []string
+	for _, element := range configs {
+		path := filepath.Join(element.FilePath, element.FileName)
+		switch name := element.FileName; {
+		case strings.HasPrefix(name, "build.gradle"), strings.HasPrefix(name, "settings.gradle"):
+			buildFiles = append(buildFiles, path)
+		case strings.HasSuffix(name, ".versions.toml"):
+			catalogs = append(catalogs, path)
+		case name == "gradle.properties":
+			properties = append(properties, path)
+		case name == "gradle-wrapper.properties":
+			wrappers = append(wrappers, path)
+		case name == "proguard-rules.pro":
+			proguardFiles = append(proguardFiles, path)
+		case name == "newrelic.properties":
+			nrProperties = append(nrProperties, path)
+		}
+	}
+	if len(buildFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Gradle build files found. Task not executed.",
+		}
+	}
+	sort.Strings(buildFiles)
+
+	build := AndroidBuild{BuildFiles: buildFiles}
+	vars := map[string]string{}
+	for _, path := range append(append([]string{}, properties...), buildFiles...) {
+		p.readProperties(path, vars)
+	}
+	catalog := map[string]string{}
+	for _, path := range catalogs {
+		p.readCatalog(path, catalog)
+	}
+	resolve := func(version string) string {
+		if m := variableRegex.FindStringSubmatch(version); m != nil {
+			// rootProject.ext.nrVersion and project.nrVersion resolve to nrVersion
+			name := m[1][strings.LastIndex(m[1], ".")+1:]
+			if value, ok := vars[name]; ok {
+				return value
+			}
+			log.Debug("unresolved Gradle variable", version)
+		}
+		return version
+	}
+
+	for _, path := range buildFiles {
+		content, err := p.readFile(path)
+		if err != nil {
+			log.Debug("could not read " + path + ": " + err.Error())
+			continue
+		}
+		for _, m := range coordinateRegex.FindAllStringSubmatch(string(content), -1) {
+			switch m[1] {
+			case newRelicPlugin:
+				build.PluginVersion = resolve(m[2])
+			case newRelicAgent:
+				build.AgentVersion = resolve(m[2])
+			case androidGradle:
+				build.AGPVersion = resolve(m[2])
+			}
+		}
+		for _, m := range pluginIDRegex.FindAllStringSubmatch(string(content), -1) {
+			switch m[1] {
+			case "newrelic", newRelicGroup:
+				build.PluginVersion = resolve(m[2])
+			case "com.android.application", "com.android.library":
+				build.AGPVersion = resolve(m[2])
+			}
+		}
+		if minifyRegex.Match(content) {
+			build.MinifyEnabled = true
+		}
+		if noUploadRegex.Match(content) {
+			build.Problems = append(build.Problems, fmt.Sprintf("%s sets uploadMappingFile false, crashes of that variant are not deobfuscated.", path))
+		}
+	}
+	// version catalogs are used through aliases, so their entries apply to the whole build
+	fromCatalog := func(version *string, ids ...string) {
+		for _, id := range ids {
+			if catalogVersion, ok := catalog[id]; ok && *version == "" {
+				*version = catalogVersion
+			}
+		}
+	}
+	fromCatalog(&build.PluginVersion, newRelicPlugin, "newrelic", newRelicGroup)
+	fromCatalog(&build.AgentVersion, newRelicAgent)
+	fromCatalog(&build.AGPVersion, androidGradle, "com.android.application", "com.android.library")
+	for _, path := range wrappers {
+		if content, err := p.readFile(path); err == nil {
+			if m := distributionRgx.FindSubmatch(content); m != nil {
+				build.GradleVersion = string(m[1])
+			}
+		}
+	}
+
+	if build.PluginVersion == "" && build.AgentVersion == "" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The Gradle build does not use the New Relic Android agent.",
+			Payload: build,
+		}
+	}
+
+	status := tasks.Success
+	failure := func(problem string) {
+		status = tasks.Failure
+		build.Problems = append(build.Problems, problem)
+	}
+	warning := func(problem string) {
+		if status < tasks.Warning {
+			status = tasks.Warning
+		}
+		build.Problems = append(build.Problems, problem)
+	}
+	if len(build.Problems) > 0 {
+		status = tasks.Warning
+	}
+
+	switch {
+	case build.PluginVersion == "":
+		failure(fmt.Sprintf("The build depends on the agent %s but does not apply the New Relic Gradle plugin, the app is not instrumented.", build.AgentVersion))
+	case build.AgentVersion == "":
+		failure(fmt.Sprintf("The build applies the New Relic Gradle plugin %s but does not depend on %s.", build.PluginVersion, newRelicAgent))
+	case build.PluginVersion != build.AgentVersion:
+		failure(fmt.Sprintf("The New Relic Gradle plugin %s and agent %s versions must be the same.", build.PluginVersion, build.AgentVersion))
+	}
+	for _, version := range []string{build.PluginVersion, build.AgentVersion, build.AGPVersion} {
+		if strings.HasPrefix(version, "$") {
+			warning(fmt.Sprintf("The version %s could not be resolved from gradle.properties or the build files.", version))
+		}
+	}
+
+	if build.AGPVersion != "" && build.GradleVersion != "" {
+		if minimum, ok := minimumGradle[majorMinor(build.AGPVersion)]; ok && !versionAtLeast(build.GradleVersion, minimum) {
+			failure(fmt.Sprintf("The Android Gradle plugin %s requires Gradle %s or later, the wrapper uses Gradle %s.", build.AGPVersion, minimum, build.GradleVersion))
+		}
+	}
+	if build.AGPVersion != "" && build.PluginVersion != "" && !strings.HasPrefix(build.PluginVersion, "$") {
+		switch {
+		case versionAtLeast(build.AGPVersion, "8.0") && !versionAtLeast(build.PluginVersion, "7.0"):
+			failure(fmt.Sprintf("The New Relic Gradle plugin %s does not support the Android Gradle plugin %s, upgrade the agent to 7.0 or later.", build.PluginVersion, build.AGPVersion))
+		case !versionAtLeast(build.AGPVersion, "7.0") && versionAtLeast(build.PluginVersion, "7.0"):
+			warning(fmt.Sprintf("The New Relic Gradle plugin %s requires the Android Gradle plugin 7.0 or later, the build uses %s.", build.PluginVersion, build.AGPVersion))
+		}
+	}
+
+	if build.MinifyEnabled {
+		hasToken := false
+		for _, path := range nrProperties {
+			if content, err := p.readFile(path); err == nil && appTokenRegex.Match(content) {
+				hasToken = true
+			}
+		}
+		if !hasToken {
+			warning("ProGuard/R8 is enabled but no newrelic.properties with com.newrelic.application_token was found, mapping files are not uploaded and crashes are not deobfuscated.")
+		}
+		hasKeep := false
+		for _, path := range proguardFiles {
+			if content, err := p.readFile(path); err == nil && keepNewRelicRule.Match(content) {
+				hasKeep = true
+			}
+		}
+		if !hasKeep {
+			warning("ProGuard/R8 is enabled but no proguard-rules.pro keeps com.newrelic.** classes, add the New Relic rules to avoid stripping the agent.")
+		}
+	}
+
+	summary := fmt.Sprintf("New Relic Gradle plugin %s, agent %s", orUnknown(build.PluginVersion), orUnknown(build.AgentVersion))
+	if build.AGPVersion != "" || build.GradleVersion != "" {
+		summary += fmt.Sprintf(", Android Gradle plugin %s, Gradle %s", orUnknown(build.AGPVersion), orUnknown(build.GradleVersion))
+	}
+	summary += "."
+	if len(build.Problems) > 0 {
+		summary += "\n" + strings.Join(build.Problems, "\n")
+	}
+	result := tasks.Result{
+		Status:  status,
+		Summary: summary,
+		Payload: build,
+	}
+	if status != tasks.Success {
+		result.URL = gradleDocsURL
+	}
+	return result
+}
+
+// readProperties - collects the name = value assignments of gradle.properties and the build files, used to resolve $versions
+func (p AndroidConfigGradle) readProperties(path string, vars map[string]string) {
+	content, err := p.readFile(path)
+	if err != nil {
+		log.Debug("could not read " + path + ": " + err.Error())
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if m := propertyRegex.FindStringSubmatch(scanner.Text()); m != nil {
+			vars[m[1]] = m[2]
+		}
+	}
+}
+
+// readCatalog - reads a version catalog into library module or plugin id to version, resolving version.ref against [versions]
+func (p AndroidConfigGradle) readCatalog(path string, catalog map[string]string) {
+	content, err := p.readFile(path)
+	if err != nil {
+		log.Debug("could not read " + path + ": " + err.Error())
+		return
+	}
+	versions := map[string]string{}
+	var entries [][2]string
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		if !found || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch section {
+		case "versions":
+			versions[key] = strings.Trim(value, `"'`)
+		case "libraries", "plugins":
+			entries = append(entries, [2]string{key, value})
+		}
+	}
+
+	for _, entry := range entries {
+		key, value := entry[0], entry[1]
+		if m := coordinateRegex.FindStringSubmatch(value); m != nil && !strings.HasPrefix(value, "{") {
+			catalog[m[1]] = m[2]
+			continue
+		}
+		var id string
+		if m := catalogIDRegex.FindStringSubmatch(value); m != nil {
+			id = m[1]
+		} else if group, name := catalogGroupRgx.FindStringSubmatch(value), catalogNameRgx.FindStringSubmatch(value); group != nil && name != nil {
+			id = group[1] + ":" + name[1]
+		}
+		if id == "" {
+			continue
+		}
+		if m := catalogRefRegex.FindStringSubmatch(value); m != nil {
+			catalog[id] = versions[m[1]]
+		} else if m := catalogVerRegex.FindStringSubmatch(value); m != nil {
+			catalog[id] = m[1]
+		}
+		log.Debug("version catalog entry", key, id, catalog[id])
+	}
+}
+
+func orUnknown(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
+
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// versionAtLeast - compares release versions, ignoring qualifiers like -rc01
+func versionAtLeast(version string, minimum string) bool {
+	v, err := tasks.ParseVersion(strings.SplitN(version, "-", 2)[0])
+	if err != nil {
+		return true
+	}
+	m, _ := tasks.ParseVersion(minimum)
+	return v.IsGreaterThanEq(m)
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+func mockProject(files map[string]string) (AndroidConfigGradle, map[string]tasks.Result) {
+	var elements []config.ConfigElement
+	contents := map[string]string{}
+	for path, content := range files {
+		dir, name := filepath.Split(filepath.FromSlash(path))
+		elements = append(elements, config.ConfigElement{FileName: name, FilePath: dir})
+		contents[filepath.Join(dir, name)] = content
+	}
+	p := AndroidConfigGradle{
+		readFile: func(path string) ([]byte, error) {
+			content, ok := contents[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return []byte(content), nil
+		},
+	}
+	return p, map[string]tasks.Result{
+		"Base/Config/Collect": {Status: tasks.Success, Payload: elements},
+	}
+}
+
+const gradleWrapper = "distributionUrl=https\\://services.gradle.org/distributions/gradle-8.2-bin.zip\n"
+
+func TestAndroidConfigGradle_Identifier(t *testing.T) {
+	want := tasks.Identifier{Category: "Android", Subcategory: "Config", Name: "Gradle"}
+	if got := (AndroidConfigGradle{}).Identifier(); !reflect.DeepEqual(got, want) {
+		t.Errorf("AndroidConfigGradle.Identifier() = %v, want %v", got, want)
+	}
+}
+
+func TestAndroidConfigGradle_Execute(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        map[string]string
+		wantStatus   tasks.Status
+		wantBuild    AndroidBuild
+		wantProblems []string
+	}{
+		{
+			name:       "It should return None without Gradle files",
+			files:      map[string]string{"app/newrelic.properties": ""},
+			wantStatus: tasks.None,
+		},
+		{
+			name: "It should read Groovy build files and resolve ext versions",
+			files: map[string]string{
+				"build.gradle": `buildscript {
+    ext.nrVersion = '7.3.0'
+    dependencies {
+        classpath "com.android.tools.build:gradle:8.1.0"
+        classpath "com.newrelic.agent.android:agent-gradle-plugin:$nrVersion"
+    }
+}`,
+				"app/build.gradle": `apply plugin: 'newrelic'
+dependencies {
+    implementation "com.newrelic.agent.android:android-agent:${rootProject.ext.nrVersion}"
+}`,
+				"gradle/wrapper/gradle-wrapper.properties": gradleWrapper,
+			},
+			wantStatus: tasks.Success,
+			wantBuild:  AndroidBuild{PluginVersion: "7.3.0", AgentVersion: "7.3.0", AGPVersion: "8.1.0", GradleVersion: "8.2"},
+		},
+		{
+			name: "It should read Kotlin DSL build files with a version catalog",
+			files: map[string]string{
+				"build.gradle.kts": `plugins {
+    alias(libs.plugins.android.application) apply false
+    alias(libs.plugins.newrelic) apply false
+}`,
+				"app/build.gradle.kts": `android {
+    buildTypes {
+        release {
+            isMinifyEnabled = true
+        }
+    }
+}
+dependencies {
+    implementation(libs.newrelic.agent)
+}`,
+				"gradle/libs.versions.toml": `[versions]
+agp = "8.2.0"
+newrelic = "7.2.0"
+
+[libraries]
+newrelic-agent = { group = "com.newrelic.agent.android", name = "android-agent", version.ref = "newrelic" }
+
+[plugins]
+android-application = { id = "com.android.application", version.ref = "agp" }
+newrelic = { id = "newrelic", version = "7.3.0" }
+`,
+				"app/newrelic.properties":                  "com.newrelic.application_token=AA0123456789abcdef0123456789abcdef01234567-NRMA\n",
+				"app/proguard-rules.pro":                   "-keep class com.newrelic.** { *; }\n-dontwarn com.newrelic.**\n",
+				"gradle/wrapper/gradle-wrapper.properties": gradleWrapper,
+			},
+			wantStatus:   tasks.Failure,
+			wantBuild:    AndroidBuild{PluginVersion: "7.3.0", AgentVersion: "7.2.0", AGPVersion: "8.2.0", GradleVersion: "8.2", MinifyEnabled: true},
+			wantProblems: []string{"The New Relic Gradle plugin 7.3.0 and agent 7.2.0 versions must be the same."},
+		},
+		{
+			name: "It should check the Android Gradle plugin against Gradle and the New Relic plugin",
+			files: map[string]string{
+				"settings.gradle": `plugins {
+    id 'com.android.application' version '8.4.0' apply false
+    id "newrelic" version "6.11.1" apply false
+}`,
+				"app/build.gradle": `dependencies {
+    implementation 'com.newrelic.agent.android:android-agent:6.11.1'
+}`,
+				"gradle/wrapper/gradle-wrapper.properties": gradleWrapper,
+			},
+			wantStatus: tasks.Failure,
+			wantBuild:  AndroidBuild{PluginVersion: "6.11.1", AgentVersion: "6.11.1", AGPVersion: "8.4.0", GradleVersion: "8.2"},
+			wantProblems: []string{
+				"The Android Gradle plugin 8.4.0 requires Gradle 8.6 or later, the wrapper uses Gradle 8.2.",
+				"The New Relic Gradle plugin 6.11.1 does not support the Android Gradle plugin 8.4.0, upgrade the agent to 7.0 or later.",
+			},
+		},
+		{
+			name: "It should warn when ProGuard/R8 mapping files are not uploaded",
+			files: map[string]string{
+				"app/build.gradle": `plugins {
+    id 'newrelic' version '7.3.0'
+}
+android {
+    buildTypes {
+        release {
+            minifyEnabled true
+        }
+    }
+}
+newrelic {
+    variantConfigurations {
+        release {
+            uploadMappingFile = false
+        }
+    }
+}
+dependencies {
+    implementation 'com.newrelic.agent.android:android-agent:7.3.0'
+}`,
+			},
+			wantStatus: tasks.Warning,
+			wantBuild:  AndroidBuild{PluginVersion: "7.3.0", AgentVersion: "7.3.0", MinifyEnabled: true},
+			wantProblems: []string{
+				filepath.FromSlash("app/build.gradle") + " sets uploadMappingFile false, crashes of that variant are not deobfuscated.",
+				"ProGuard/R8 is enabled but no newrelic.properties with com.newrelic.application_token was found, mapping files are not uploaded and crashes are not deobfuscated.",
+				"ProGuard/R8 is enabled but no proguard-rules.pro keeps com.newrelic.** classes, add the New Relic rules to avoid stripping the agent.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, upstream := mockProject(tt.files)
+			result := p.Execute(tasks.Options{}, upstream)
+			if result.Status != tt.wantStatus {
+				t.Fatalf("AndroidConfigGradle.Execute() status = %v, want %v: %s", result.Status, tt.wantStatus, result.Summary)
+			}
+			if tt.wantStatus == tasks.None {
+				return
+			}
+			build := result.Payload.(AndroidBuild)
+			if !reflect.DeepEqual(build.Problems, tt.wantProblems) {
+				t.Errorf("AndroidConfigGradle.Execute() problems = \n%q, want \n%q", build.Problems, tt.wantProblems)
+			}
+			build.BuildFiles, build.Problems = nil, nil
+			if !reflect.DeepEqual(build, tt.wantBuild) {
+				t.Errorf("AndroidConfigGradle.Execute() build = %+v, want %+v", build, tt.wantBuild)
+			}
+			if strings.Contains(result.Summary, "NRMA") {
+				t.Errorf("AndroidConfigGradle.Execute() summary contains the application token: %s", result.Summary)
+			}
+		})
+	}
+}
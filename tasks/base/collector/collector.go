@@ -1,6 +1,9 @@
 package collector
 
 import (
+	"crypto/x509"
+	"os"
+
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -11,9 +14,21 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Base/Collector/*")
 
 	registrationFunc(BaseCollectorConnectUS{
-		httpGetter: httpHelper.MakeHTTPRequest,
+		httpGetter:         httpHelper.MakeHTTPRequest,
+		diagnoseConnection: httpHelper.DiagnoseConnection,
 	}, true)
 	registrationFunc(BaseCollectorConnectEU{
-		httpGetter: httpHelper.MakeHTTPRequest,
+		httpGetter:         httpHelper.MakeHTTPRequest,
+		diagnoseConnection: httpHelper.DiagnoseConnection,
+	}, true)
+	registrationFunc(BaseCollectorConnect{
+		httpGetter:         httpHelper.MakeHTTPRequest,
+		diagnoseConnection: httpHelper.DiagnoseConnection,
+	}, true)
+	registrationFunc(BaseCollectorTrustStore{
+		getEndpointChain: httpHelper.GetEndpointChain,
+		systemCertPool:   x509.SystemCertPool,
+		readFile:         os.ReadFile,
+		readDir:          os.ReadDir,
 	}, true)
 }
@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// configProducts maps the config files found by Base/Config/Collect to the endpoints their agent connects to
+var configProducts = map[string][]tasks.EndpointProduct{
+	"newrelic.yml":                   {tasks.ProductAPMCollector},
+	"newrelic.xml":                   {tasks.ProductAPMCollector},
+	"newrelic.config":                {tasks.ProductAPMCollector},
+	"newrelic.js":                    {tasks.ProductAPMCollector},
+	"newrelic.cfg":                   {tasks.ProductAPMCollector},
+	"newrelic.ini":                   {tasks.ProductAPMCollector},
+	"newrelic-infra.yml":             {tasks.ProductInfra, tasks.ProductInfraIdentity, tasks.ProductInfraCommand, tasks.ProductLogs, tasks.ProductMetricAPI},
+	"private-location-settings.json": {tasks.ProductSynthetics},
+}
+
+// BaseCollectorConnect - This task connects to every New Relic endpoint used by the detected agents in the detected regions
+type BaseCollectorConnect struct {
+	httpGetter         requestFunc
+	diagnoseConnection httpHelper.DiagnoseFunc
+}
+
+// EndpointConnectResult - the outcome of connecting to a single endpoint
+type EndpointConnectResult struct {
+	Endpoint   tasks.Endpoint
+	StatusCode int
+	Error      string
+	Diagnosis  *httpHelper.ConnectionDiagnosis
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/Connect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorConnect) Explain() string {
+	return "Check network connection to every New Relic endpoint used by the detected agents and region"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseCollectorConnect) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect", //we are not using the payload of this task, but we want to make sure that it was already detected and set before running any HTTP request
+		"Base/Config/RegionDetect",
+		"Base/Config/Collect",
+	}
+}
+
+// RequiresNetwork - this task connects to the New Relic endpoints of the detected products, so it is skipped in offline mode
+func (p BaseCollectorConnect) RequiresNetwork() bool {
+	return true
+}
+
+// Execute - Attempts to connect to each endpoint in the endpoint catalog relevant to this environment
+func (p BaseCollectorConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	regions := getRegions(upstream)
+	products := getProducts(upstream)
+
+	var endpoints []tasks.Endpoint
+	for _, region := range regions {
+		endpoints = append(endpoints, tasks.GetEndpoints(region, products...)...)
+	}
+
+	if len(endpoints) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: fmt.Sprintf("No New Relic endpoints are known for the region(s) %s.", strings.Join(regions, ", ")),
+		}
+	}
+
+	var connectResults []EndpointConnectResult
+	var failures []string
+	var successes []string
+	for _, endpoint := range endpoints {
+		connectResult := p.connect(endpoint)
+		connectResults = append(connectResults, connectResult)
+		description := fmt.Sprintf("%s (%s, %s)", endpoint.Host, endpoint.Product, endpoint.Region)
+		if connectResult.Error != "" {
+			failure := description + ": " + connectResult.Error
+			if connectResult.Diagnosis != nil {
+				failure += "\n" + connectResult.Diagnosis.Summary()
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		successes = append(successes, description)
+	}
+
+	if len(failures) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "There was an error connecting to the following New Relic endpoints:\n\t" + strings.Join(failures, "\n\t") + "\nPlease check network and proxy settings and try again or see -help for more options.",
+			URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/",
+			Payload: connectResults,
+		}
+	}
+
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "Successfully connected to:\n\t" + strings.Join(successes, "\n\t"),
+		Payload: connectResults,
+	}
+}
+
+// connect treats any HTTP response as a successful connection since endpoints answer unauthenticated requests with different status codes
+func (p BaseCollectorConnect) connect(endpoint tasks.Endpoint) EndpointConnectResult {
+	connectResult := EndpointConnectResult{Endpoint: endpoint}
+
+	wrapper := httpHelper.RequestWrapper{
+		Method:         "GET",
+		URL:            endpoint.URL(),
+		TimeoutSeconds: 30,
+	}
+	resp, err := p.httpGetter(wrapper)
+	if err != nil {
+		log.Debug("Error connecting to", endpoint.URL(), err)
+		connectResult.Error = err.Error()
+		if p.diagnoseConnection != nil {
+			diagnosis := p.diagnoseConnection(endpoint.URL())
+			connectResult.Diagnosis = &diagnosis
+		}
+		return connectResult
+	}
+	defer resp.Body.Close()
+
+	connectResult.StatusCode = resp.StatusCode
+	return connectResult
+}
+
+// getRegions returns the region given with -region or NEW_RELIC_REGION, otherwise the regions detected from license keys, defaulting to US
+func getRegions(upstream map[string]tasks.Result) []string {
+	if region := tasks.RequestedRegion(); region != "" {
+		return []string{region}
+	}
+	regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string)
+	if !ok || len(regions) == 0 {
+		return []string{tasks.RegionUS}
+	}
+	return regions
+}
+
+// getProducts returns the products for the agents whose config files were found, defaulting to the APM collector
+func getProducts(upstream map[string]tasks.Result) []tasks.EndpointProduct {
+	var products []tasks.EndpointProduct
+	configs, _ := upstream["Base/Config/Collect"].Payload.([]baseConfig.ConfigElement)
+	for _, configFile := range configs {
+		for _, product := range configProducts[strings.ToLower(configFile.FileName)] {
+			if !containsProduct(products, product) {
+				products = append(products, product)
+			}
+		}
+	}
+	if len(products) == 0 {
+		return []tasks.EndpointProduct{tasks.ProductAPMCollector}
+	}
+	return products
+}
+
+func containsProduct(products []tasks.EndpointProduct, product tasks.EndpointProduct) bool {
+	for _, p := range products {
+		if p == product {
+			return true
+		}
+	}
+	return false
+}
@@ -14,8 +14,9 @@ import (
 
 // BaseCollectorConnectEU - This task connects to collector.newrelic.com and reports the status
 type BaseCollectorConnectEU struct {
-	upstream   map[string]tasks.Result
-	httpGetter requestFunc
+	upstream           map[string]tasks.Result
+	httpGetter         requestFunc
+	diagnoseConnection httpHelper.DiagnoseFunc
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -36,11 +37,17 @@ func (p BaseCollectorConnectEU) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task connects to the EU collector, so it is skipped in offline mode
+func (p BaseCollectorConnectEU) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - Attempts to connect to the EU collector endpoint
 func (p BaseCollectorConnectEU) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	p.upstream = upstream
 
-	url := "https://collector.eu.newrelic.com/status/mongrel"
+	endpoint, _ := tasks.GetEndpoint(tasks.RegionEU, tasks.ProductAPMCollector)
+	url := endpoint.URL()
 
 	// Was the task not explicitly provided on -t ?
 	if !config.Flags.IsForcedTask(p.Identifier().String()) {
@@ -61,7 +68,13 @@ func (p BaseCollectorConnectEU) Execute(op tasks.Options, upstream map[string]ta
 
 	if err != nil {
 		// HTTP error
-		return p.prepareCollectorErrorResult(err)
+		result := p.prepareCollectorErrorResult(err)
+		if p.diagnoseConnection != nil {
+			diagnosis := p.diagnoseConnection(url)
+			result.Summary += "\n" + diagnosis.Summary()
+			result.Payload = diagnosis
+		}
+		return result
 	}
 
 	defer resp.Body.Close()
@@ -84,7 +97,7 @@ func (p BaseCollectorConnectEU) prepareEarlyResult() tasks.Result {
 	if ok {
 		// If this region was not in the non-empty list of detected region, return early.
 		// If no regions were detected, we run all collector connect checks.
-		if !tasks.StringInSlice("eu01", regions) && len(regions) > 0 {
+		if !tasks.StringInSlice(tasks.RegionEU, regions) && len(regions) > 0 {
 			result.Status = tasks.None
 			result.Summary = "EU Region not detected, skipping EU collector connect check"
 			return result
@@ -14,8 +14,9 @@ import (
 
 // BaseCollectorConnectUS - This task connects to collector.newrelic.com and reports the status
 type BaseCollectorConnectUS struct {
-	upstream   map[string]tasks.Result
-	httpGetter requestFunc
+	upstream           map[string]tasks.Result
+	httpGetter         requestFunc
+	diagnoseConnection httpHelper.DiagnoseFunc
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -36,11 +37,17 @@ func (p BaseCollectorConnectUS) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task connects to the US collector, so it is skipped in offline mode
+func (p BaseCollectorConnectUS) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - Attempts to connect to the US collector endpoint
 func (p BaseCollectorConnectUS) Execute(op tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	p.upstream = upstream
 
-	url := "https://collector.newrelic.com/status/mongrel"
+	endpoint, _ := tasks.GetEndpoint(tasks.RegionUS, tasks.ProductAPMCollector)
+	url := endpoint.URL()
 
 	// Was the task not explicitly provided on -t ?
 	if !config.Flags.IsForcedTask(p.Identifier().String()) {
@@ -61,7 +68,13 @@ func (p BaseCollectorConnectUS) Execute(op tasks.Options, upstream map[string]ta
 
 	if err != nil {
 		// HTTP error
-		return p.prepareCollectorErrorResult(err)
+		result := p.prepareCollectorErrorResult(err)
+		if p.diagnoseConnection != nil {
+			diagnosis := p.diagnoseConnection(url)
+			result.Summary += "\n" + diagnosis.Summary()
+			result.Payload = diagnosis
+		}
+		return result
 	}
 
 	defer resp.Body.Close()
@@ -84,7 +97,7 @@ func (p BaseCollectorConnectUS) prepareEarlyResult() tasks.Result {
 	if ok {
 		// If this region was not in the non-empty list of detected region, return early.
 		// If no regions were detected, we run all collector connect checks.
-		if !tasks.StringInSlice("us01", regions) && len(regions) > 0 {
+		if !tasks.StringInSlice(tasks.RegionUS, regions) && len(regions) > 0 {
 			result.Status = tasks.None
 			result.Summary = "US Region not detected, skipping US collector connect check"
 			return result
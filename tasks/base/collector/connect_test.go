@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+func TestBaseCollectorConnect_Execute(t *testing.T) {
+	infraConfig := tasks.Result{Payload: []baseConfig.ConfigElement{{FileName: "newrelic-infra.yml", FilePath: "/etc/"}}}
+
+	tests := []struct {
+		name       string
+		region     config.Region
+		upstream   map[string]tasks.Result
+		httpGetter requestFunc
+		wantStatus tasks.Status
+		wantURLs   []string
+	}{
+		{
+			name:       "should check the US collector when nothing was detected",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequest400,
+			wantStatus: tasks.Success,
+			wantURLs:   []string{"https://collector.newrelic.com/status/mongrel"},
+		},
+		{
+			name: "should check the infrastructure endpoints of each detected region",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"eu01"}},
+				"Base/Config/Collect":      infraConfig,
+			},
+			httpGetter: mockSuccessfulRequest200,
+			wantStatus: tasks.Success,
+			wantURLs: []string{
+				"https://infra-api.eu.newrelic.com",
+				"https://identity-api.eu.newrelic.com",
+				"https://infrastructure-command-api.eu.newrelic.com",
+				"https://log-api.eu.newrelic.com",
+				"https://metric-api.eu.newrelic.com",
+			},
+		},
+		{
+			name:   "should use the requested region over the detected region",
+			region: "FedRAMP",
+			upstream: map[string]tasks.Result{
+				"Base/Config/RegionDetect": {Payload: []string{"us01"}},
+				"Base/Config/Collect":      {Payload: []baseConfig.ConfigElement{{FileName: "newrelic.yml"}, {FileName: "newrelic.ini"}}},
+			},
+			httpGetter: mockSuccessfulRequest200,
+			wantStatus: tasks.Success,
+			wantURLs:   []string{"https://gov-collector.newrelic.com/status/mongrel"},
+		},
+		{
+			name:       "should fail when an endpoint cannot be reached",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockUnsuccessfulRequestError,
+			wantStatus: tasks.Failure,
+			wantURLs:   []string{"https://collector.newrelic.com/status/mongrel"},
+		},
+		{
+			name:       "should error for an unknown region",
+			region:     "mars",
+			upstream:   map[string]tasks.Result{},
+			httpGetter: mockSuccessfulRequest200,
+			wantStatus: tasks.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.region != "" {
+				config.RequestedRegion = tt.region
+				defer func() { config.RequestedRegion = config.NoRegion }()
+			}
+			var requested []string
+			p := BaseCollectorConnect{
+				httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+					requested = append(requested, wrapper.URL)
+					return tt.httpGetter(wrapper)
+				},
+			}
+			got := p.Execute(tasks.Options{}, tt.upstream)
+			if got.Status != tt.wantStatus {
+				t.Errorf("BaseCollectorConnect.Execute() status = %v, want %v: %s", got.Status, tt.wantStatus, got.Summary)
+			}
+			if !reflect.DeepEqual(requested, tt.wantURLs) {
+				t.Errorf("BaseCollectorConnect.Execute() requested %v, want %v", requested, tt.wantURLs)
+			}
+		})
+	}
+}
+
+func TestBaseCollectorConnect_ExecuteDiagnosesFailures(t *testing.T) {
+	p := BaseCollectorConnect{
+		httpGetter: mockUnsuccessfulRequestError,
+		diagnoseConnection: func(url string) httpHelper.ConnectionDiagnosis {
+			return httpHelper.ConnectionDiagnosis{
+				URL:         url,
+				FailedStage: httpHelper.StageDNS,
+				Stages:      []httpHelper.StageResult{{Stage: httpHelper.StageDNS, Target: "collector.newrelic.com", Error: "no such host"}},
+			}
+		},
+	}
+	got := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+	if !strings.Contains(got.Summary, "failed at the DNS stage") {
+		t.Errorf("BaseCollectorConnect.Execute() summary = %q, want the failing network stage", got.Summary)
+	}
+	results := got.Payload.([]EndpointConnectResult)
+	if results[0].Diagnosis == nil || results[0].Diagnosis.FailedStage != httpHelper.StageDNS {
+		t.Errorf("BaseCollectorConnect.Execute() payload = %+v, want the diagnosis", results)
+	}
+}
@@ -0,0 +1,258 @@
+package collector
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+// caBundleFileSettings are the agent settings that point at a PEM bundle replacing the runtime's default trust store
+var caBundleFileSettings = []string{
+	"ca_bundle_path",                // Java, Python, Ruby
+	"newrelic.daemon.ssl_ca_bundle", // PHP
+	"ca_bundle_file",                // Infrastructure
+}
+
+// caBundleDirSettings are the agent settings that point at a directory of PEM files
+var caBundleDirSettings = []string{
+	"newrelic.daemon.ssl_ca_path", // PHP
+	"ca_bundle_dir",               // Infrastructure
+}
+
+// BaseCollectorTrustStore - compares the certificate chains presented by New Relic endpoints against the trust stores agents use
+type BaseCollectorTrustStore struct {
+	getEndpointChain func(string) httpHelper.EndpointChain
+	systemCertPool   func() (*x509.CertPool, error)
+	readFile         func(string) ([]byte, error)
+	readDir          func(string) ([]os.DirEntry, error)
+}
+
+// TrustStorePayload - the chains presented by each endpoint and how every trust store judged them
+type TrustStorePayload struct {
+	Chains []httpHelper.EndpointChain
+	Checks []httpHelper.TrustCheck
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseCollectorTrustStore) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Collector/TrustStore")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseCollectorTrustStore) Explain() string {
+	return "Check that the system trust store, CA bundle environment variables and agent CA bundle settings trust the New Relic endpoint certificates"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseCollectorTrustStore) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+		"Base/Config/RegionDetect",
+		"Base/Config/Collect",
+		"Base/Config/Validate",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// RequiresNetwork - this task retrieves certificate chains from New Relic endpoints, so it is skipped in offline mode
+func (p BaseCollectorTrustStore) RequiresNetwork() bool {
+	return true
+}
+
+// Execute - The core work within each task
+func (p BaseCollectorTrustStore) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var payload TrustStorePayload
+	var chainErrors []string
+	seen := make(map[string]bool)
+
+	for _, region := range getRegions(upstream) {
+		for _, endpoint := range tasks.GetEndpoints(region, getProducts(upstream)...) {
+			if seen[endpoint.Host] {
+				continue
+			}
+			seen[endpoint.Host] = true
+			chain := p.getEndpointChain(endpoint.URL())
+			payload.Chains = append(payload.Chains, chain)
+			if chain.Error != "" {
+				chainErrors = append(chainErrors, endpoint.Host+": "+chain.Error)
+			}
+		}
+	}
+
+	if len(chainErrors) == len(payload.Chains) {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to retrieve a certificate chain from any New Relic endpoint:\n\t" + strings.Join(chainErrors, "\n\t"),
+			Payload: payload,
+		}
+	}
+
+	if systemPool, err := p.systemCertPool(); err != nil {
+		log.Debug("Unable to load the system trust store:", err)
+	} else {
+		payload.Checks = append(payload.Checks, httpHelper.CheckTrust("System", "operating system trust store", systemPool, payload.Chains)...)
+	}
+
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	payload.Checks = append(payload.Checks, p.checkEnvVarStores(envVars, payload.Chains)...)
+
+	validations, _ := upstream["Base/Config/Validate"].Payload.([]baseConfig.ValidateElement)
+	payload.Checks = append(payload.Checks, p.checkAgentSettings(validations, payload.Chains)...)
+
+	return summarizeTrustChecks(payload, chainErrors)
+}
+
+func summarizeTrustChecks(payload TrustStorePayload, chainErrors []string) tasks.Result {
+	var rejections []string
+	var trusted []string
+	for _, check := range payload.Checks {
+		if check.Trusted {
+			trusted = append(trusted, fmt.Sprintf("%s (%s) trusts %s", check.Store, check.Source, check.ServerName))
+			continue
+		}
+		rejections = append(rejections, fmt.Sprintf("%s (%s) would reject %s: %s", check.Store, check.Source, check.ServerName, check.Reason))
+	}
+
+	var intercepted []string
+	for _, chain := range payload.Chains {
+		if chain.Intercepted {
+			intercepted = append(intercepted, chain.ServerName)
+		}
+	}
+
+	var summary []string
+	status := tasks.Success
+	if len(rejections) > 0 {
+		status = tasks.Failure
+		summary = append(summary, "The following trust stores would reject the New Relic endpoint certificates:\n\t"+strings.Join(rejections, "\n\t"))
+	}
+	if len(intercepted) > 0 {
+		if status == tasks.Success {
+			status = tasks.Warning
+		}
+		summary = append(summary, "The certificates presented for "+strings.Join(intercepted, ", ")+" are not issued by a public certificate authority. A TLS inspecting proxy may be re-signing New Relic traffic, and every runtime sending data must trust its certificate authority.")
+	}
+	if len(chainErrors) > 0 {
+		summary = append(summary, "Unable to check some endpoints:\n\t"+strings.Join(chainErrors, "\n\t"))
+	}
+	if len(trusted) > 0 {
+		summary = append(summary, "\t"+strings.Join(trusted, "\n\t"))
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: payload,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/"
+	}
+	return result
+}
+
+// checkEnvVarStores checks the CA bundles runtimes load from environment variables
+func (p BaseCollectorTrustStore) checkEnvVarStores(envVars map[string]string, chains []httpHelper.EndpointChain) []httpHelper.TrustCheck {
+	var checks []httpHelper.TrustCheck
+
+	if path := envVars["SSL_CERT_FILE"]; path != "" {
+		checks = append(checks, p.checkBundle("OpenSSL based runtimes (Python, Ruby, PHP)", "SSL_CERT_FILE="+path, path, nil, chains)...)
+	}
+	if dir := envVars["SSL_CERT_DIR"]; dir != "" {
+		checks = append(checks, p.checkBundleDir("OpenSSL based runtimes (Python, Ruby, PHP)", "SSL_CERT_DIR="+dir, dir, chains)...)
+	}
+	if path := envVars["REQUESTS_CA_BUNDLE"]; path != "" {
+		checks = append(checks, p.checkBundle("Python requests", "REQUESTS_CA_BUNDLE="+path, path, nil, chains)...)
+	}
+	if path := envVars["NODE_EXTRA_CA_CERTS"]; path != "" {
+		// Node adds these certificates to its bundled root store, which the system store approximates
+		base, err := p.systemCertPool()
+		if err != nil {
+			base = x509.NewCertPool()
+		}
+		checks = append(checks, p.checkBundle("Node", "NODE_EXTRA_CA_CERTS="+path, path, base, chains)...)
+	}
+	return checks
+}
+
+// checkAgentSettings checks the CA bundles configured in New Relic agent config files
+func (p BaseCollectorTrustStore) checkAgentSettings(validations []baseConfig.ValidateElement, chains []httpHelper.EndpointChain) []httpHelper.TrustCheck {
+	var checks []httpHelper.TrustCheck
+	for _, validation := range validations {
+		configFile := filepath.Join(validation.Config.FilePath, validation.Config.FileName)
+		for _, setting := range caBundleFileSettings {
+			for _, key := range validation.ParsedResult.FindKey(setting) {
+				if path := strings.Trim(key.Value(), `"'`); path != "" {
+					checks = append(checks, p.checkBundle("Agent "+setting, configFile+": "+path, path, nil, chains)...)
+				}
+			}
+		}
+		for _, setting := range caBundleDirSettings {
+			for _, key := range validation.ParsedResult.FindKey(setting) {
+				if dir := strings.Trim(key.Value(), `"'`); dir != "" {
+					checks = append(checks, p.checkBundleDir("Agent "+setting, configFile+": "+dir, dir, chains)...)
+				}
+			}
+		}
+	}
+	return checks
+}
+
+// checkBundle loads the PEM bundle into base, or into an empty pool when base is nil, and checks every chain against it
+func (p BaseCollectorTrustStore) checkBundle(store string, source string, path string, base *x509.CertPool, chains []httpHelper.EndpointChain) []httpHelper.TrustCheck {
+	content, err := p.readFile(path)
+	if err != nil {
+		return unusableStore(store, source, chains, "unable to read the CA bundle: "+err.Error())
+	}
+	certs := httpHelper.ParsePEMCertificates(content)
+	if len(certs) == 0 {
+		return unusableStore(store, source, chains, "the CA bundle does not contain any PEM encoded certificates")
+	}
+	pool := x509.NewCertPool()
+	if base != nil {
+		pool = base.Clone()
+	}
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	return httpHelper.CheckTrust(store, source, pool, chains)
+}
+
+func (p BaseCollectorTrustStore) checkBundleDir(store string, source string, dir string, chains []httpHelper.EndpointChain) []httpHelper.TrustCheck {
+	entries, err := p.readDir(dir)
+	if err != nil {
+		return unusableStore(store, source, chains, "unable to read the CA directory: "+err.Error())
+	}
+	pool := x509.NewCertPool()
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		content, err := p.readFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, cert := range httpHelper.ParsePEMCertificates(content) {
+			pool.AddCert(cert)
+		}
+	}
+	return httpHelper.CheckTrust(store, source, pool, chains)
+}
+
+// unusableStore reports every endpoint as rejected when the store itself cannot be loaded
+func unusableStore(store string, source string, chains []httpHelper.EndpointChain, reason string) []httpHelper.TrustCheck {
+	var checks []httpHelper.TrustCheck
+	for _, chain := range chains {
+		if len(chain.PEM) == 0 {
+			continue
+		}
+		checks = append(checks, httpHelper.TrustCheck{Store: store, Source: source, ServerName: chain.ServerName, Reason: reason})
+	}
+	return checks
+}
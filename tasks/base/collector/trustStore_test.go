@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func TestBaseCollectorTrustStore_Execute(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	serverPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	trustedPool := x509.NewCertPool()
+	trustedPool.AddCert(server.Certificate())
+
+	chainFor := func(intercepted bool) func(string) httpHelper.EndpointChain {
+		return func(url string) httpHelper.EndpointChain {
+			return httpHelper.EndpointChain{URL: url, ServerName: "example.com", PEM: []string{serverPEM}, Intercepted: intercepted}
+		}
+	}
+	files := map[string]string{
+		"/etc/ssl/custom.pem": serverPEM,
+		"/etc/ssl/empty.pem":  "",
+	}
+	readFile := func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+		return []byte(content), nil
+	}
+	readDir := func(string) ([]os.DirEntry, error) { return nil, errors.New("not a directory") }
+
+	tests := []struct {
+		name             string
+		getEndpointChain func(string) httpHelper.EndpointChain
+		systemPool       *x509.CertPool
+		envVars          map[string]string
+		wantStatus       tasks.Status
+		wantSummary      string
+	}{
+		{
+			name:             "should succeed when every store trusts the chain",
+			getEndpointChain: chainFor(false),
+			systemPool:       trustedPool,
+			envVars:          map[string]string{"SSL_CERT_FILE": "/etc/ssl/custom.pem"},
+			wantStatus:       tasks.Success,
+			wantSummary:      "OpenSSL based runtimes (Python, Ruby, PHP) (SSL_CERT_FILE=/etc/ssl/custom.pem) trusts example.com",
+		},
+		{
+			name:             "should fail when a CA bundle from the environment rejects the chain",
+			getEndpointChain: chainFor(false),
+			systemPool:       trustedPool,
+			envVars:          map[string]string{"REQUESTS_CA_BUNDLE": "/etc/ssl/empty.pem"},
+			wantStatus:       tasks.Failure,
+			wantSummary:      "Python requests (REQUESTS_CA_BUNDLE=/etc/ssl/empty.pem) would reject example.com: the CA bundle does not contain any PEM encoded certificates",
+		},
+		{
+			name:             "should fail when the system store does not contain the root",
+			getEndpointChain: chainFor(true),
+			systemPool:       x509.NewCertPool(),
+			envVars:          map[string]string{"NODE_EXTRA_CA_CERTS": "/etc/ssl/custom.pem"},
+			wantStatus:       tasks.Failure,
+			wantSummary:      "System (operating system trust store) would reject example.com: the store does not contain the certificate authority",
+		},
+		{
+			name:             "should warn when the chain looks intercepted but is trusted",
+			getEndpointChain: chainFor(true),
+			systemPool:       trustedPool,
+			wantStatus:       tasks.Warning,
+			wantSummary:      "A TLS inspecting proxy may be re-signing New Relic traffic",
+		},
+		{
+			name: "should error when no chain could be retrieved",
+			getEndpointChain: func(url string) httpHelper.EndpointChain {
+				return httpHelper.EndpointChain{URL: url, Error: "no certificate chain was presented, the connection failed at the DNS stage"}
+			},
+			systemPool:  trustedPool,
+			wantStatus:  tasks.Error,
+			wantSummary: "Unable to retrieve a certificate chain from any New Relic endpoint",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := BaseCollectorTrustStore{
+				getEndpointChain: tt.getEndpointChain,
+				systemCertPool:   func() (*x509.CertPool, error) { return tt.systemPool, nil },
+				readFile:         readFile,
+				readDir:          readDir,
+			}
+			upstream := map[string]tasks.Result{
+				"Base/Env/CollectEnvVars": {Payload: tt.envVars},
+			}
+			got := p.Execute(tasks.Options{}, upstream)
+			if got.Status != tt.wantStatus {
+				t.Errorf("BaseCollectorTrustStore.Execute() status = %v, want %v: %s", got.Status, tt.wantStatus, got.Summary)
+			}
+			if !strings.Contains(got.Summary, tt.wantSummary) {
+				t.Errorf("BaseCollectorTrustStore.Execute() summary = %q, want it to contain %q", got.Summary, tt.wantSummary)
+			}
+		})
+	}
+}
@@ -37,6 +37,9 @@ var patterns = []string{
 	"newrelic[.]ini",
 	"Podfile",
 	// "Podfile[.]lock",
+	"Package[.]resolved",
+	"Cartfile[.]resolved",
+	"libs[.]versions[.]toml",
 	"proguard-rules[.]pro",
 	"proguard[.]multidex[.]config",
 	"dexguard-release[.]pro",
@@ -55,6 +58,7 @@ var secureFilePatterns = []string{
 	"AndroidManifest[.]xml",
 	"gradle[.]properties",
 	"build[.]gradle",
+	"settings[.]gradle",
 	"project[.]pbxproj",
 	"^(?i)(web|app)[.]config$",
 	"(?i).+[.]exe[.]config$", //  app.config files are almost always app-me.exe.config. filter NewRelicStatusMonitor.exe.config later
@@ -21,6 +21,10 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	}, true)
 	registrationFunc(BaseConfigAppName{}, true)
 	registrationFunc(BaseConfigRegionDetect{}, true)
+	registrationFunc(BaseConfigProxyValidate{
+		proxyRequest: makeProxyRequest,
+		nrdiagProxy:  nrdiagProxy,
+	}, true)
 	registrationFunc(BaseConfigValidateHSM{
 		createHSMLocalValidation: createHSMLocalValidation,
 		getHSMConfiguration:      getHSMConfiguration,
@@ -0,0 +1,317 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// agentProxySchemes are the proxy schemes New Relic agents can connect through
+var agentProxySchemes = []string{"http", "https"}
+
+// BaseConfigProxyValidate - makes a request through every proxy configured for a New Relic agent
+type BaseConfigProxyValidate struct {
+	proxyRequest func(proxyURL *url.URL, target string) (int, error)
+	nrdiagProxy  func() string
+}
+
+// ProxyValidation - the outcome of connecting through one detected proxy
+type ProxyValidation struct {
+	Proxy             string
+	Sources           []string
+	Endpoint          string
+	StatusCode        int
+	Status            tasks.Status
+	Issues            []string
+	DiffersFromNrdiag bool
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseConfigProxyValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Config/ProxyValidate")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseConfigProxyValidate) Explain() string {
+	return "Validate that each proxy configured for a New Relic agent can reach New Relic"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseConfigProxyValidate) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect",
+		"Base/Config/Validate",
+		"Base/Config/RegionDetect",
+		"Base/Env/CollectEnvVars",
+		"Base/Env/CollectSysProps",
+	}
+}
+
+// RequiresNetwork - this task connects to New Relic through each detected proxy, so it is skipped in offline mode
+func (p BaseConfigProxyValidate) RequiresNetwork() bool {
+	return true
+}
+
+// Execute - The core work within each task
+func (p BaseConfigProxyValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	configElements, _ := upstream["Base/Config/Validate"].Payload.([]ValidateElement)
+	proxyConfigs := collectProxyConfigs(configElements, options, upstream)
+	if len(proxyConfigs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No proxy server settings found for New Relic agents",
+		}
+	}
+
+	region := tasks.RegionUS
+	if regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string); ok && len(regions) > 0 {
+		region = regions[0]
+	}
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	noProxy := envVars["NO_PROXY"]
+	if noProxy == "" {
+		noProxy = envVars["no_proxy"]
+	}
+	nrdiagProxy := p.nrdiagProxy()
+
+	// agents configured with the same proxy and endpoint only need one request
+	var validations []ProxyValidation
+	validationIndex := make(map[string]int)
+	for _, proxyConfig := range proxyConfigs {
+		proxyURL := setProxyURL(proxyConfig)
+		endpoint := proxyEndpoint(proxyConfig.proxySource, region)
+		key := proxyURL + " " + endpoint.URL()
+		if i, found := validationIndex[key]; found {
+			validations[i].Sources = append(validations[i].Sources, proxyConfig.proxySource)
+			continue
+		}
+		validationIndex[key] = len(validations)
+		validations = append(validations, p.validateProxy(proxyURL, proxyConfig.proxySource, endpoint, noProxy, nrdiagProxy))
+	}
+
+	return summarizeProxyValidations(validations)
+}
+
+func summarizeProxyValidations(validations []ProxyValidation) tasks.Result {
+	status := tasks.Success
+	var summary []string
+	for _, validation := range validations {
+		if validation.Status > status {
+			status = validation.Status
+		}
+		line := fmt.Sprintf("Proxy %s (%s) to %s: ", validation.Proxy, strings.Join(validation.Sources, ", "), validation.Endpoint)
+		if len(validation.Issues) == 0 {
+			line += fmt.Sprintf("connected, HTTP %d", validation.StatusCode)
+		} else {
+			line += "\n\t" + strings.Join(validation.Issues, "\n\t")
+		}
+		summary = append(summary, line)
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: validations,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/#proxies"
+	}
+	return result
+}
+
+func (p BaseConfigProxyValidate) validateProxy(rawProxyURL string, source string, endpoint tasks.Endpoint, noProxy string, nrdiagProxy string) ProxyValidation {
+	validation := ProxyValidation{
+		Proxy:    rawProxyURL,
+		Sources:  []string{source},
+		Endpoint: endpoint.URL(),
+		Status:   tasks.Success,
+	}
+
+	proxyURL, err := url.Parse(rawProxyURL)
+	if err != nil || proxyURL.Host == "" {
+		validation.Status = tasks.Failure
+		validation.Issues = append(validation.Issues, fmt.Sprintf("the proxy setting %q is not a valid URL", rawProxyURL))
+		return validation
+	}
+	validation.Proxy = proxyURL.Redacted()
+
+	if !tasks.ContainsString(agentProxySchemes, proxyURL.Scheme) {
+		validation.Status = tasks.Failure
+		validation.Issues = append(validation.Issues, fmt.Sprintf("the proxy scheme %q is not supported by New Relic agents, use http or https", proxyURL.Scheme))
+		return validation
+	}
+
+	if entry := matchNoProxy(noProxy, endpoint.Host); entry != "" {
+		validation.Status = tasks.Warning
+		validation.Issues = append(validation.Issues, fmt.Sprintf("NO_PROXY entry %q excludes %s, so agents that honor NO_PROXY will bypass this proxy", entry, endpoint.Host))
+	}
+
+	if nrdiagProxy != "" && !sameProxy(proxyURL, nrdiagProxy) {
+		validation.DiffersFromNrdiag = true
+		if validation.Status < tasks.Warning {
+			validation.Status = tasks.Warning
+		}
+		validation.Issues = append(validation.Issues, "this proxy differs from the proxy "+tasks.ThisProgramFullName+" used for its own requests, so other connectivity results may not reflect this agent")
+	}
+
+	statusCode, err := p.proxyRequest(proxyURL, endpoint.URL())
+	validation.StatusCode = statusCode
+	switch {
+	case statusCode == http.StatusProxyAuthRequired || (err != nil && strings.Contains(err.Error(), http.StatusText(http.StatusProxyAuthRequired))):
+		validation.Status = tasks.Failure
+		validation.Issues = append(validation.Issues, "the proxy rejected the credentials (407 Proxy Authentication Required), check the proxy user and password")
+	case err != nil:
+		validation.Status = tasks.Failure
+		validation.Issues = append(validation.Issues, "unable to connect through the proxy: "+err.Error())
+	}
+	return validation
+}
+
+// proxyEndpoint returns the endpoint the agent owning the proxy setting connects to
+func proxyEndpoint(source string, region string) tasks.Endpoint {
+	product := tasks.ProductAPMCollector
+	switch filepath.Base(source) {
+	case "newrelic-infra.yml":
+		product = tasks.ProductInfra
+	case "private-location-settings.json":
+		product = tasks.ProductSynthetics
+	}
+	endpoint, ok := tasks.GetEndpoint(region, product)
+	if !ok {
+		endpoint, _ = tasks.GetEndpoint(tasks.RegionUS, product)
+	}
+	return endpoint
+}
+
+// matchNoProxy returns the NO_PROXY entry that excludes the host, using the suffix matching most HTTP clients implement
+func matchNoProxy(noProxy string, host string) string {
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return entry
+		}
+		if h, _, found := strings.Cut(entry, ":"); found {
+			entry = h
+		}
+		domain := strings.TrimPrefix(entry, "*")
+		domain = strings.TrimPrefix(domain, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return entry
+		}
+	}
+	return ""
+}
+
+func sameProxy(proxyURL *url.URL, other string) bool {
+	otherURL, err := url.Parse(other)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(proxyURL.Hostname(), otherURL.Hostname()) && proxyPort(proxyURL) == proxyPort(otherURL)
+}
+
+func proxyPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// collectProxyConfigs returns every proxy configured for an agent, from env vars, each JVM's system properties and each config file
+func collectProxyConfigs(configElements []ValidateElement, options tasks.Options, upstream map[string]tasks.Result) []ProxyConfig {
+	var proxyConfigs []ProxyConfig
+	if proxyConfig := findProxyValuesFromEnvVars(upstream); hasProxy(proxyConfig) {
+		proxyConfigs = append(proxyConfigs, proxyConfig)
+	}
+	proxyConfigs = append(proxyConfigs, findAllProxyValuesFromSysProps(upstream)...)
+
+	for _, validation := range configElements {
+		source := validation.Config.FilePath + validation.Config.FileName
+		if filepath.Ext(validation.Config.FileName) == ".yml" && validation.Config.FileName != "newrelic-infra.yml" {
+			proxyConfig := findProxyValuesFromYmlFile(validation, options)
+			if hasProxy(proxyConfig) {
+				proxyConfig.proxySource = source
+				proxyConfigs = append(proxyConfigs, proxyConfig)
+			}
+			continue
+		}
+		for _, proxyConfigKeys := range nrProxyConfigs {
+			proxyConfig, _ := findProxyValuesFromConfigFile(proxyConfigKeys, validation)
+			if hasProxy(proxyConfig) {
+				proxyConfig.proxySource = source
+				proxyConfigs = append(proxyConfigs, proxyConfig)
+				break
+			}
+		}
+	}
+	return proxyConfigs
+}
+
+func findAllProxyValuesFromSysProps(upstream map[string]tasks.Result) []ProxyConfig {
+	var proxyConfigs []ProxyConfig
+	processes, _ := upstream["Base/Env/CollectSysProps"].Payload.([]tasks.ProcIDSysProps)
+	for _, process := range processes {
+		proxyConfig := findProxyValuesFromSysProps(map[string]tasks.Result{
+			"Base/Env/CollectSysProps": {Status: tasks.Info, Payload: []tasks.ProcIDSysProps{process}},
+		})
+		if hasProxy(proxyConfig) {
+			proxyConfig.proxySource = fmt.Sprintf("system properties of process %d", process.ProcID)
+			proxyConfigs = append(proxyConfigs, proxyConfig)
+		}
+	}
+	return proxyConfigs
+}
+
+func hasProxy(proxyConfig ProxyConfig) bool {
+	return proxyConfig.proxyHost != "" || proxyConfig.proxyURL != ""
+}
+
+// makeProxyRequest sends a GET to the target through only the given proxy, ignoring nrdiag's own proxy settings
+func makeProxyRequest(proxyURL *url.URL, target string) (int, error) {
+	client := &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyURL(proxyURL),
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		Timeout: 30 * time.Second,
+	}
+	resp, err := client.Get(target)
+	if err != nil {
+		log.Debug("Request through proxy", proxyURL.Redacted(), "failed:", err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// nrdiagProxy returns the proxy given with -proxy, otherwise the one nrdiag's requests use from the environment,
+// which includes the HTTP_PROXY Base/Config/ProxyDetect sets from the agent config
+func nrdiagProxy() string {
+	if config.Flags.Proxy != "" {
+		return config.Flags.Proxy
+	}
+	return firstEnv("HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy")
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -52,6 +52,11 @@ func (t BaseConfigValidateHSM) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task looks up High Security Mode through the New Relic account service, so it is skipped in offline mode
+func (t BaseConfigValidateHSM) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - The core work within each task
 func (t BaseConfigValidateHSM) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/internal/haberdasher"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/obfuscate"
@@ -90,7 +91,15 @@ func (p BaseConfigValidateLicenseKey) Execute(options tasks.Options, upstream ma
 
 	//Only if we have collected a license key with a valid format, then we can move into checking that the customer's account agrees that this is a valid key
 	var resultsPayload map[string][]string
-	if len(validFormatLKToSources) > 0 {
+	if len(validFormatLKToSources) > 0 && config.Flags.Offline {
+		//offline mode skips the account check, the format checks still give downstream tasks such as RegionDetect their license keys
+		for lk, sources := range validFormatLKToSources {
+			obfuscatedKey := obfuscate.ObfuscateSensitiveValue(lk)
+			obfuscatedKey = strings.ReplaceAll(obfuscatedKey, "%", "%%")
+			successSummary += fmt.Sprintf("The license key found in %s has a valid New Relic format: %s\nIt was not validated against your account because the Diagnostics CLI is running in offline mode.\n", strings.Join(sources, ",\n "), obfuscatedKey)
+		}
+		resultsPayload = validFormatLKToSources
+	} else if len(validFormatLKToSources) > 0 {
 		validAccountLKToSources, invalidAccountLKToSources, err := p.validateAgainstAccount(validFormatLKToSources)
 
 		if err != nil {
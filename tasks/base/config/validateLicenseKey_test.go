@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -411,5 +412,38 @@ var _ = Describe("BaseConfigValidateLicenseKey", func() {
 				Expect(result.Summary).To(Equal("We validated 1 license key(s):\n" + "The license key found in NEW_RELIC_LICENSE_KEY does not have a valid format: x692c6*****************************************. \nThe NR license key is 40 alphanumeric characters. \nReview this documentation to make sure that you have the proper format of a New Relic Personal API key: \nhttps://docs.newrelic.com/docs/apis/get-started/intro-apis/types-new-relic-api-keys" + "\n\n"))
 			})
 		})
+
+		Context("when 1 valid license key is found and nrdiag is running in offline mode", func() {
+			BeforeEach(func() {
+				config.Flags.Offline = true
+				DeferCleanup(func() { config.Flags.Offline = false })
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Base/Config/LicenseKey": {
+						Status: tasks.Success,
+						Payload: []LicenseKey{
+							{
+								Value:  `eu01xx66c637a29c3982469a3fe8d1982d00NRAL`,
+								Source: "NEW_RELIC_LICENSE_KEY",
+							},
+						},
+					},
+				}
+				p.validateAgainstAccount = func(map[string][]string) (map[string][]string, map[string][]string, error) {
+					Fail("the account validation should not run in offline mode")
+					return nil, nil, nil
+				}
+			})
+
+			It("Should only check the format and still give RegionDetect its payload", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(ContainSubstring("It was not validated against your account because the Diagnostics CLI is running in offline mode."))
+				Expect(result.Payload).To(Equal(map[string][]string{"eu01xx66c637a29c3982469a3fe8d1982d00NRAL": {"NEW_RELIC_LICENSE_KEY"}}))
+
+				regions := BaseConfigRegionDetect{}.Execute(options, map[string]tasks.Result{"Base/Config/ValidateLicenseKey": result})
+				Expect(regions.Status).To(Equal(tasks.Info))
+				Expect(regions.Payload).To(Equal([]string{"eu01"}))
+			})
+		})
 	})
 })
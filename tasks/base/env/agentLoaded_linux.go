@@ -0,0 +1,145 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ProcessAgentEvidence - the New Relic agent files a running process has loaded
+type ProcessAgentEvidence struct {
+	Pid       int32
+	Name      string
+	Agents    []string
+	Artifacts []tasks.AgentArtifact
+	// NoEvidence is set for a node or python process without native agent files. Those agents load source files
+	// that are read and closed, so the process cannot show whether it loaded one.
+	NoEvidence bool
+}
+
+// BaseEnvAgentLoaded - This task confirms which New Relic agents running processes have actually loaded
+type BaseEnvAgentLoaded struct {
+	getPids        func() ([]int32, error)
+	getProcessName func(int32) (string, error)
+	findArtifacts  func(int32) ([]tasks.AgentArtifact, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseEnvAgentLoaded) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Env/AgentLoaded")
+}
+
+// Explain - Returns the help text for this task
+func (p BaseEnvAgentLoaded) Explain() string {
+	return "Confirm which running processes have loaded a New Relic agent"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseEnvAgentLoaded) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p BaseEnvAgentLoaded) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	pids, err := p.getPids()
+	if err != nil {
+		log.Debug("Unable to list processes", err)
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to list running processes: " + err.Error(),
+		}
+	}
+
+	var evidence []ProcessAgentEvidence
+	unreadable := 0
+	for _, pid := range pids {
+		artifacts, err := p.findArtifacts(pid)
+		if err != nil {
+			unreadable++
+			continue
+		}
+		name, err := p.getProcessName(pid)
+		if err != nil {
+			log.Debug("Unable to get process name", pid, err)
+		}
+		if len(artifacts) == 0 {
+			if interpreterAgent(name) != "" {
+				evidence = append(evidence, ProcessAgentEvidence{Pid: pid, Name: name, NoEvidence: true})
+			}
+			continue
+		}
+		evidence = append(evidence, ProcessAgentEvidence{
+			Pid:       pid,
+			Name:      name,
+			Agents:    agentsOf(artifacts),
+			Artifacts: artifacts,
+		})
+	}
+
+	var unreadableSummary string
+	if unreadable > 0 {
+		unreadableSummary = fmt.Sprintf("\n%d processes could not be inspected. Run as root to inspect processes owned by other users.", unreadable)
+	}
+	if len(evidence) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running process has loaded a New Relic agent." + unreadableSummary,
+		}
+	}
+
+	var lines []string
+	loaded := 0
+	for _, process := range evidence {
+		if process.NoEvidence {
+			lines = append(lines, fmt.Sprintf("PID %d (%s): no evidence available. The %s agent is loaded from source files that are closed once read, check its log to confirm it started.", process.Pid, process.Name, interpreterAgent(process.Name)))
+			continue
+		}
+		loaded++
+		lines = append(lines, fmt.Sprintf("PID %d (%s) loaded the %s agent:", process.Pid, process.Name, strings.Join(process.Agents, ", ")))
+		for _, artifact := range process.Artifacts {
+			lines = append(lines, fmt.Sprintf("  %s (%s)", artifact.Path, artifact.Source))
+		}
+	}
+	header := fmt.Sprintf("%d processes have loaded a New Relic agent:\n", loaded)
+	if loaded == 0 {
+		header = "No running process could be confirmed to have loaded a New Relic agent:\n"
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: header + strings.Join(lines, "\n") + unreadableSummary,
+		Payload: evidence,
+	}
+}
+
+// interpreterAgent returns the agent of a node or python process name, whose loading leaves no native files when the
+// agent runs without its optional extensions
+func interpreterAgent(name string) string {
+	switch {
+	case name == "node" || name == "nodejs":
+		return "Node"
+	case strings.HasPrefix(name, "python") || name == "gunicorn" || name == "uwsgi":
+		return "Python"
+	}
+	return ""
+}
+
+func agentsOf(artifacts []tasks.AgentArtifact) []string {
+	var agents []string
+	seen := make(map[string]bool)
+	for _, artifact := range artifacts {
+		if !seen[artifact.Agent] {
+			seen[artifact.Agent] = true
+			agents = append(agents, artifact.Agent)
+		}
+	}
+	sort.Strings(agents)
+	return agents
+}
+
+func processName(pid int32) (string, error) {
+	return (&process.Process{Pid: pid}).Name()
+}
@@ -0,0 +1,115 @@
+package env
+
+import (
+	"errors"
+	"os"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Env/AgentLoaded", func() {
+	var p BaseEnvAgentLoaded
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			expectedIdentifier := tasks.Identifier{
+				Category:    "Base",
+				Subcategory: "Env",
+				Name:        "AgentLoaded",
+			}
+
+			Expect(p.Identifier()).To(Equal(expectedIdentifier))
+		})
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should return correct slice", func() {
+			Expect(p.Dependencies()).To(Equal([]string{}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			result    tasks.Result
+			artifacts map[int32][]tasks.AgentArtifact
+		)
+
+		BeforeEach(func() {
+			artifacts = map[int32][]tasks.AgentArtifact{}
+			p = BaseEnvAgentLoaded{
+				getPids: func() ([]int32, error) { return []int32{1, 100, 200, 300}, nil },
+				getProcessName: func(pid int32) (string, error) {
+					return map[int32]string{100: "java", 200: "php-fpm", 300: "bash"}[pid], nil
+				},
+				findArtifacts: func(pid int32) ([]tasks.AgentArtifact, error) {
+					if pid == 1 {
+						return nil, os.ErrPermission
+					}
+					return artifacts[pid], nil
+				},
+			}
+		})
+
+		JustBeforeEach(func() {
+			result = p.Execute(tasks.Options{}, map[string]tasks.Result{})
+		})
+
+		Context("When processes have loaded agents", func() {
+			BeforeEach(func() {
+				artifacts[100] = []tasks.AgentArtifact{{Agent: "Java", Path: "/opt/newrelic/newrelic.jar", Source: tasks.ArtifactOpen}}
+				artifacts[200] = []tasks.AgentArtifact{{Agent: "PHP", Path: "/usr/lib/php/newrelic.so", Source: tasks.ArtifactMapped}}
+			})
+
+			It("Should return an Info status with the evidence per process", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(ContainSubstring("PID 100 (java) loaded the Java agent:\n  /opt/newrelic/newrelic.jar (open)"))
+				Expect(result.Summary).To(ContainSubstring("PID 200 (php-fpm) loaded the PHP agent"))
+				Expect(result.Summary).To(ContainSubstring("1 processes could not be inspected"))
+				Expect(result.Payload).To(Equal([]ProcessAgentEvidence{
+					{Pid: 100, Name: "java", Agents: []string{"Java"}, Artifacts: artifacts[100]},
+					{Pid: 200, Name: "php-fpm", Agents: []string{"PHP"}, Artifacts: artifacts[200]},
+				}))
+			})
+		})
+
+		Context("When node and python processes have no native agent files", func() {
+			BeforeEach(func() {
+				p.getPids = func() ([]int32, error) { return []int32{100, 400, 500, 600}, nil }
+				p.getProcessName = func(pid int32) (string, error) {
+					return map[int32]string{100: "java", 400: "node", 500: "python3.11", 600: "python3"}[pid], nil
+				}
+				artifacts[100] = []tasks.AgentArtifact{{Agent: "Java", Path: "/opt/newrelic/newrelic.jar", Source: tasks.ArtifactOpen}}
+				artifacts[600] = []tasks.AgentArtifact{{Agent: "Python", Path: "/app/site-packages/newrelic/core/_thread_utilization.cpython-311-x86_64-linux-gnu.so", Source: tasks.ArtifactMapped}}
+			})
+
+			It("Should report no evidence for them instead of not loaded", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				Expect(result.Summary).To(HavePrefix("2 processes have loaded a New Relic agent:\n"))
+				Expect(result.Summary).To(ContainSubstring("PID 400 (node): no evidence available. The Node agent is loaded from source files"))
+				Expect(result.Summary).To(ContainSubstring("PID 500 (python3.11): no evidence available. The Python agent"))
+				Expect(result.Summary).To(ContainSubstring("PID 600 (python3) loaded the Python agent"))
+				Expect(result.Payload).To(ContainElement(ProcessAgentEvidence{Pid: 400, Name: "node", NoEvidence: true}))
+			})
+		})
+
+		Context("When no process has loaded an agent", func() {
+			It("Should return a None status", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Summary).To(HavePrefix("No running process has loaded a New Relic agent."))
+			})
+		})
+
+		Context("When processes cannot be listed", func() {
+			BeforeEach(func() {
+				p.getPids = func() ([]int32, error) { return nil, errors.New("no /proc") }
+			})
+
+			It("Should return an Error status", func() {
+				Expect(result.Status).To(Equal(tasks.Error))
+				Expect(result.Summary).To(ContainSubstring("no /proc"))
+			})
+		})
+	})
+})
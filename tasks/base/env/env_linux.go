@@ -3,6 +3,7 @@ package env
 import (
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/shirou/gopsutil/v3/process"
 )
 
 // RegisterLinuxWith - will register any plugins in this package
@@ -15,6 +16,11 @@ func RegisterLinuxWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseEnvRootUser{
 		isUserRoot: tasks.IsUserRoot,
 	}, true)
+	registrationFunc(BaseEnvAgentLoaded{
+		getPids:        process.Pids,
+		getProcessName: processName,
+		findArtifacts:  tasks.FindLoadedAgentArtifacts,
+	}, true)
 }
 
 
@@ -0,0 +1,115 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// BaseLogAnalyze - Scans collected agent logs for known-issue signatures from the rule catalog
+type BaseLogAnalyze struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p BaseLogAnalyze) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Base/Log/Analyze")
+}
+
+// Explain - Returns the help text for each individual task
+func (p BaseLogAnalyze) Explain() string {
+	explain := "Scan New Relic agent logs for known issues (has overrides)"
+	if config.Flags.ShowOverrideHelp {
+		explain += fmt.Sprintf("\n%37s %s", " ", "Override: rules => path to a JSON rule catalog that adds to or replaces the built-in rules")
+	}
+	return explain
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p BaseLogAnalyze) Dependencies() []string {
+	return []string{"Base/Log/Copy"}
+}
+
+// Execute - The core work within each task
+func (p BaseLogAnalyze) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	logElements, ok := upstream["Base/Log/Copy"].Payload.([]LogElement)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Logs not found",
+		}
+	}
+
+	var logFiles []string
+	for _, logElement := range logElements {
+		//IsSecureLocation represents non-new relic log files such as docker syslog
+		if !logElement.CanCollect || len(logElement.FileName) == 0 || len(logElement.FilePath) == 0 || logElement.IsSecureLocation {
+			continue
+		}
+		logFiles = append(logFiles, logElement.FilePath+logElement.FileName)
+	}
+
+	if len(logFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "New Relic logs not found",
+		}
+	}
+
+	rules, err := loadLogRules(options.Options["rules"])
+	if err != nil {
+		log.Debug("Unable to load log rules:", err)
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to load the log analysis rule catalog: " + err.Error(),
+		}
+	}
+
+	matches := analyzeLogFiles(rules, logFiles)
+	if len(matches) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: fmt.Sprintf("No known issues were found in %d New Relic log file(s).", len(logFiles)),
+			Payload: matches,
+		}
+	}
+
+	status := tasks.Info
+	summary := fmt.Sprintf("Found %d known issue(s) in the New Relic logs:", len(matches))
+	url := ""
+	for _, match := range matches {
+		if statusSeverity(match.Status) > statusSeverity(status) {
+			status = match.Status
+			url = match.URL
+		}
+		summary += fmt.Sprintf("\n\t%s: %d occurrence(s)", match.Rule, match.Count)
+		if match.FirstOccurrence != "" {
+			summary += fmt.Sprintf(" between %s and %s", match.FirstOccurrence, match.LastOccurrence)
+		}
+		summary += "\n\t\t" + match.Summary
+		if match.URL != "" {
+			summary += "\n\t\t" + match.URL
+		}
+	}
+
+	return tasks.Result{
+		Status:  status,
+		Summary: summary,
+		URL:     url,
+		Payload: matches,
+	}
+}
+
+func statusSeverity(status tasks.Status) int {
+	switch status {
+	case tasks.Failure:
+		return 3
+	case tasks.Warning:
+		return 2
+	case tasks.Info:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,164 @@
+package log
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Base/Log/Analyze", func() {
+	var p BaseLogAnalyze
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			options  tasks.Options
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When upstream payload is not a list of logs", func() {
+			BeforeEach(func() {
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Base/Log/Copy": {
+						Status: tasks.None,
+					},
+				}
+			})
+
+			It("should return an expected none result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Summary).To(Equal("Logs not found"))
+			})
+		})
+
+		Context("When the log contains known issue signatures", func() {
+			BeforeEach(func() {
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Base/Log/Copy": {
+						Status: tasks.Success,
+						Payload: []LogElement{
+							{
+								FileName:   "analyze_java.log",
+								FilePath:   "./fixtures/",
+								CanCollect: true,
+							},
+						},
+					},
+				}
+			})
+
+			It("should return the most severe status among the matched rules", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+			})
+
+			It("should return one match per rule in catalog order", func() {
+				matches, ok := result.Payload.([]LogRuleMatch)
+				Expect(ok).To(BeTrue())
+				var names []string
+				for _, match := range matches {
+					names = append(names, match.Rule)
+				}
+				Expect(names).To(Equal([]string{"ForceRestart", "SSLHandshake", "InstrumentationLoadFailure"}))
+			})
+
+			It("should record counts, occurrence timestamps and samples", func() {
+				matches := result.Payload.([]LogRuleMatch)
+				Expect(matches[0].Count).To(Equal(2))
+				Expect(matches[0].FirstOccurrence).To(Equal("Apr 2, 2020 04:10:01 +0000"))
+				Expect(matches[0].LastOccurrence).To(Equal("Apr 2, 2020 05:10:01 +0000"))
+				Expect(matches[0].SampleLines).To(HaveLen(2))
+				Expect(matches[0].Logfiles).To(Equal([]string{"./fixtures/analyze_java.log"}))
+			})
+		})
+
+		Context("When the log does not contain any known issue signature", func() {
+			BeforeEach(func() {
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Base/Log/Copy": {
+						Status: tasks.Success,
+						Payload: []LogElement{
+							{
+								FileName:   "analyze_clean.log",
+								FilePath:   "./fixtures/",
+								CanCollect: true,
+							},
+						},
+					},
+				}
+			})
+
+			It("should return an expected success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(Equal("No known issues were found in 1 New Relic log file(s)."))
+			})
+		})
+
+		Context("When a custom rule catalog is provided", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"rules": "./fixtures/analyze_rules.json"}}
+				upstream = map[string]tasks.Result{
+					"Base/Log/Copy": {
+						Status: tasks.Success,
+						Payload: []LogElement{
+							{
+								FileName:   "analyze_clean.log",
+								FilePath:   "./fixtures/",
+								CanCollect: true,
+							},
+						},
+					},
+				}
+			})
+
+			It("should apply the custom rules in addition to the built-in ones", func() {
+				Expect(result.Status).To(Equal(tasks.Info))
+				matches := result.Payload.([]LogRuleMatch)
+				Expect(matches).To(HaveLen(1))
+				Expect(matches[0].Rule).To(Equal("CustomSignature"))
+				Expect(matches[0].FirstOccurrence).To(Equal("2020-04-02 03:51:25,512"))
+			})
+		})
+
+		Context("When the custom rule catalog cannot be read", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"rules": "./fixtures/missing.json"}}
+				upstream = map[string]tasks.Result{
+					"Base/Log/Copy": {
+						Status: tasks.Success,
+						Payload: []LogElement{
+							{
+								FileName:   "analyze_clean.log",
+								FilePath:   "./fixtures/",
+								CanCollect: true,
+							},
+						},
+					},
+				}
+			})
+
+			It("should return an expected error result", func() {
+				Expect(result.Status).To(Equal(tasks.Error))
+			})
+		})
+	})
+
+	Describe("parseLogRules()", func() {
+		It("should parse the built-in catalog", func() {
+			rules, err := parseLogRules(defaultLogRules)
+			Expect(err).To(BeNil())
+			Expect(rules).ToNot(BeEmpty())
+		})
+
+		It("should reject rules with invalid patterns", func() {
+			_, err := parseLogRules([]byte(`[{"name": "Bad", "patterns": ["("]}]`))
+			Expect(err).ToNot(BeNil())
+		})
+	})
+})
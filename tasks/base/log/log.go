@@ -12,4 +12,5 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(BaseLogCollect{}, false)
 	registrationFunc(BaseLogCopy{}, true)
 	registrationFunc(BaseLogReportingTo{}, true)
+	registrationFunc(BaseLogAnalyze{}, true)
 }
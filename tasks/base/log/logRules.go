@@ -0,0 +1,241 @@
+package log
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// defaultLogRules is the built-in catalog of known-issue signatures. New signatures
+// should be added to logRules.json rather than in code.
+//
+//go:embed logRules.json
+var defaultLogRules []byte
+
+const (
+	maxSampleLines    = 3
+	maxLogLineLength  = 1024 * 1024
+	maxSampleLineSize = 500
+)
+
+// LogRule - a single known-issue signature from the rule catalog
+type LogRule struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Patterns    []string `json:"patterns"`
+	Files       []string `json:"files,omitempty"` // log filename regexes the rule applies to; empty means every log
+	Severity    string   `json:"severity"`        // info, warning or failure
+	URL         string   `json:"url"`
+
+	patterns []*regexp.Regexp
+	files    []*regexp.Regexp
+}
+
+// LogRuleMatch - the aggregated occurrences of one rule across all analyzed logs
+type LogRuleMatch struct {
+	Rule            string
+	Status          tasks.Status
+	Summary         string
+	URL             string
+	Count           int
+	Logfiles        []string
+	FirstOccurrence string
+	LastOccurrence  string
+	SampleLines     []string
+}
+
+// timestampPatterns cover the line prefixes written by the APM and infrastructure agents
+var timestampPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|\s?[+-]\d{2}:?\d{2})?`), // ISO-8601 style: Python, PHP, Ruby, .NET, Node and Infra
+	regexp.MustCompile(`[A-Z][a-z]{2} \d{1,2}, \d{4} \d{1,2}:\d{2}:\d{2}(?: [AP]M)?(?: [+-]\d{4})?`),   // Java: Jan 30, 2019 10:20:42 -0800
+}
+
+func parseLogRules(data []byte) ([]LogRule, error) {
+	var rules []LogRule
+	err := json.Unmarshal(data, &rules)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		if rules[i].Name == "" || len(rules[i].Patterns) == 0 {
+			return nil, fmt.Errorf("rule %d must have a name and at least one pattern", i)
+		}
+		for _, pattern := range rules[i].Patterns {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s has an invalid pattern: %s", rules[i].Name, err.Error())
+			}
+			rules[i].patterns = append(rules[i].patterns, regex)
+		}
+		for _, pattern := range rules[i].Files {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s has an invalid files pattern: %s", rules[i].Name, err.Error())
+			}
+			rules[i].files = append(rules[i].files, regex)
+		}
+	}
+	return rules, nil
+}
+
+// loadLogRules returns the built-in catalog, merged with the rules found in rulesFile if one is given.
+// Rules from the file replace built-in rules of the same name.
+func loadLogRules(rulesFile string) ([]LogRule, error) {
+	rules, err := parseLogRules(defaultLogRules)
+	if err != nil {
+		return nil, err
+	}
+	if rulesFile == "" {
+		return rules, nil
+	}
+
+	data, err := os.ReadFile(rulesFile)
+	if err != nil {
+		return nil, err
+	}
+	customRules, err := parseLogRules(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, custom := range customRules {
+		replaced := false
+		for i, rule := range rules {
+			if rule.Name == custom.Name {
+				rules[i] = custom
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			rules = append(rules, custom)
+		}
+	}
+	return rules, nil
+}
+
+func (r LogRule) appliesTo(filename string) bool {
+	if len(r.files) == 0 {
+		return true
+	}
+	for _, regex := range r.files {
+		if regex.MatchString(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r LogRule) matches(line string) bool {
+	for _, regex := range r.patterns {
+		if regex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r LogRule) status() tasks.Status {
+	switch strings.ToLower(r.Severity) {
+	case "failure":
+		return tasks.Failure
+	case "info":
+		return tasks.Info
+	default:
+		return tasks.Warning
+	}
+}
+
+func findLogTimestamp(line string) string {
+	for _, regex := range timestampPatterns {
+		if timestamp := regex.FindString(line); timestamp != "" {
+			return timestamp
+		}
+	}
+	return ""
+}
+
+func truncateLogLine(line string) string {
+	if len(line) > maxSampleLineSize {
+		return line[:maxSampleLineSize] + "..."
+	}
+	return line
+}
+
+// analyzeLogFiles runs every rule against every line of the given files and returns one match per rule that was found, in catalog order
+func analyzeLogFiles(rules []LogRule, logFiles []string) []LogRuleMatch {
+	matchesByRule := make(map[string]*LogRuleMatch)
+
+	for _, logFile := range logFiles {
+		var applicable []LogRule
+		for _, rule := range rules {
+			if rule.appliesTo(filepath.Base(logFile)) {
+				applicable = append(applicable, rule)
+			}
+		}
+		if len(applicable) == 0 {
+			continue
+		}
+
+		file, err := os.Open(logFile)
+		if err != nil {
+			log.Debug("Unable to open log file for analysis", logFile, err)
+			continue
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 64*1024), maxLogLineLength)
+		for scanner.Scan() {
+			line := scanner.Text()
+			for _, rule := range applicable {
+				if !rule.matches(line) {
+					continue
+				}
+				match, ok := matchesByRule[rule.Name]
+				if !ok {
+					match = &LogRuleMatch{
+						Rule:    rule.Name,
+						Status:  rule.status(),
+						Summary: rule.Description,
+						URL:     rule.URL,
+					}
+					matchesByRule[rule.Name] = match
+				}
+				match.Count++
+				if !tasks.ContainsString(match.Logfiles, logFile) {
+					match.Logfiles = append(match.Logfiles, logFile)
+				}
+				if timestamp := findLogTimestamp(line); timestamp != "" {
+					if match.FirstOccurrence == "" {
+						match.FirstOccurrence = timestamp
+					}
+					match.LastOccurrence = timestamp
+				}
+				if len(match.SampleLines) < maxSampleLines {
+					match.SampleLines = append(match.SampleLines, truncateLogLine(line))
+				}
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Debug("Error while scanning log file", logFile, err)
+		}
+		file.Close()
+	}
+
+	var matches []LogRuleMatch
+	for _, rule := range rules {
+		if match, ok := matchesByRule[rule.Name]; ok {
+			matches = append(matches, *match)
+		}
+	}
+	return matches
+}
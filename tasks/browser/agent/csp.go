@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// agentHost serves the agent code the loader fetches after it starts
+const agentHost = "js-agent.newrelic.com"
+
+// defaultBeacon is used when a loader does not set one
+const defaultBeacon = "bam.nr-data.net"
+
+var (
+	metaCSPRgx    = regexp.MustCompile(`(?is)<meta\b[^>]*http-equiv\s*=\s*["']?content-security-policy["'\s/>][^>]*>`)
+	contentAttRgx = regexp.MustCompile(`(?is)\bcontent\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	nonceAttRgx   = regexp.MustCompile(`(?i)\bnonce\s*=\s*["']?([^"'\s>]+)`)
+)
+
+// metaContentSecurityPolicies returns the policies set by meta tags, these are enforced like the header
+func metaContentSecurityPolicies(data string) []string {
+	var policies []string
+	for _, tag := range metaCSPRgx.FindAllString(data, -1) {
+		if match := contentAttRgx.FindStringSubmatch(tag); match != nil {
+			policies = append(policies, match[1]+match[2])
+		}
+	}
+	return policies
+}
+
+// cspPolicy maps each directive of a Content-Security-Policy to its source list
+type cspPolicy map[string][]string
+
+func parseCSP(policy string) cspPolicy {
+	parsed := make(cspPolicy)
+	for _, directive := range strings.Split(policy, ";") {
+		fields := strings.Fields(directive)
+		if len(fields) == 0 {
+			continue
+		}
+		name := strings.ToLower(fields[0])
+		// the first occurrence of a directive wins
+		if _, ok := parsed[name]; !ok {
+			parsed[name] = fields[1:]
+		}
+	}
+	return parsed
+}
+
+// sources returns the source list of the first directive present, following the CSP fallback order
+func (p cspPolicy) sources(directives ...string) (string, []string, bool) {
+	for _, directive := range directives {
+		if sources, ok := p[directive]; ok {
+			return directive, sources, true
+		}
+	}
+	return "", nil, false
+}
+
+// allowsHost checks an https URL on host is allowed by a source list. Paths in host sources are not compared.
+func allowsHost(sources []string, host string, page *url.URL) bool {
+	for _, source := range sources {
+		source = strings.ToLower(source)
+		switch {
+		case source == "'none'":
+			return false
+		case source == "*", source == "https:":
+			return true
+		case source == "'self'":
+			if page != nil && page.Scheme == "https" && strings.EqualFold(page.Hostname(), host) {
+				return true
+			}
+			continue
+		case strings.HasPrefix(source, "'"):
+			continue
+		}
+		if i := strings.Index(source, "://"); i >= 0 {
+			if scheme := source[:i]; scheme != "https" && scheme != "wss" {
+				continue
+			}
+			source = source[i+3:]
+		}
+		source = strings.SplitN(source, "/", 2)[0]
+		if hostPort := strings.SplitN(source, ":", 2); len(hostPort) == 2 {
+			if hostPort[1] != "443" && hostPort[1] != "*" {
+				continue
+			}
+			source = hostPort[0]
+		}
+		if source == host || (strings.HasPrefix(source, "*.") && strings.HasSuffix(host, source[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasSource checks a source list holds a keyword source such as 'strict-dynamic'
+func hasSource(sources []string, keyword string) bool {
+	for _, source := range sources {
+		if strings.EqualFold(source, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsInline checks an inline script is allowed to run. 'unsafe-inline' is ignored when the list has a nonce or hash.
+func allowsInline(sources []string, tag string, body string) bool {
+	nonce := ""
+	if match := nonceAttRgx.FindStringSubmatch(tag); match != nil {
+		nonce = match[1]
+	}
+	sha256Sum := sha256.Sum256([]byte(body))
+	sha384Sum := sha512.Sum384([]byte(body))
+	sha512Sum := sha512.Sum512([]byte(body))
+	hashes := map[string]bool{
+		"'sha256-" + base64.StdEncoding.EncodeToString(sha256Sum[:]) + "'": true,
+		"'sha384-" + base64.StdEncoding.EncodeToString(sha384Sum[:]) + "'": true,
+		"'sha512-" + base64.StdEncoding.EncodeToString(sha512Sum[:]) + "'": true,
+	}
+
+	unsafeInline, strict := false, false
+	for _, source := range sources {
+		lower := strings.ToLower(source)
+		switch {
+		case lower == "'unsafe-inline'":
+			unsafeInline = true
+		case strings.HasPrefix(lower, "'nonce-"):
+			strict = true
+			if nonce != "" && source == "'nonce-"+nonce+"'" {
+				return true
+			}
+		case strings.HasPrefix(lower, "'sha256-"), strings.HasPrefix(lower, "'sha384-"), strings.HasPrefix(lower, "'sha512-"):
+			strict = true
+			if hashes[source] {
+				return true
+			}
+		case lower == "'strict-dynamic'":
+			strict = true
+		}
+	}
+	return unsafeInline && !strict
+}
+
+// cspProblems returns how the enforced policies of a page would stop the agent loading or sending data
+func cspProblems(page BrowserAgentSourcePayload, beacon string) []string {
+	if beacon == "" {
+		beacon = defaultBeacon
+	}
+	pageURL, _ := url.Parse(page.FinalURL)
+	inlineLoader := page.LoaderTag != "" && !nrSrcRgx.MatchString(page.LoaderTag)
+	loaderBody := ""
+	if inlineLoader {
+		for _, loader := range append(append([]string{}, page.Loader...), page.LoaderOutsideHead...) {
+			if strings.HasPrefix(loader, page.LoaderTag) {
+				loaderBody = strings.TrimPrefix(loader, page.LoaderTag)
+				break
+			}
+		}
+	}
+
+	var problems []string
+	for _, policy := range page.ContentSecurityPolicies {
+		parsed := parseCSP(policy)
+		if directive, sources, ok := parsed.sources("script-src-elem", "script-src", "default-src"); ok {
+			// CSP3 browsers ignore host sources under 'strict-dynamic', the agent loads only when the script loading it
+			// is trusted with a nonce or hash, which the inline check below covers
+			if hasSource(sources, "'strict-dynamic'") {
+				if !inlineLoader {
+					problems = append(problems, fmt.Sprintf("Content-Security-Policy %s uses 'strict-dynamic', which ignores allowed hosts, so whether the agent can load cannot be determined. The Browser script element needs a nonce or hash the policy allows.", directive))
+				}
+			} else if !allowsHost(sources, agentHost, pageURL) {
+				problems = append(problems, fmt.Sprintf("Content-Security-Policy %s does not allow https://%s, the agent cannot load.", directive, agentHost))
+			}
+			if inlineLoader && !allowsInline(sources, page.LoaderTag, loaderBody) {
+				problems = append(problems, fmt.Sprintf("Content-Security-Policy %s blocks the inline Browser script. Add a nonce to the script element or the script's hash to the policy.", directive))
+			}
+		}
+		if directive, sources, ok := parsed.sources("connect-src", "default-src"); ok && !allowsHost(sources, beacon, pageURL) {
+			problems = append(problems, fmt.Sprintf("Content-Security-Policy %s does not allow https://%s, the agent cannot send data.", directive, beacon))
+		}
+	}
+	return problems
+}
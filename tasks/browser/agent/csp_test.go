@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Content-Security-Policy", func() {
+	const loaderBody = `window.NREUM||(NREUM={});NREUM.init={};`
+	sum := sha256.Sum256([]byte(loaderBody))
+	loaderHash := "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+
+	page := func(tag string, policies ...string) BrowserAgentSourcePayload {
+		return BrowserAgentSourcePayload{
+			FinalURL:                "https://example.com/",
+			Loader:                  []string{tag + loaderBody},
+			LoaderTag:               tag,
+			ContentSecurityPolicies: policies,
+		}
+	}
+
+	DescribeTable("cspProblems()",
+		func(loader BrowserAgentSourcePayload, beacon string, expected []string) {
+			Expect(cspProblems(loader, beacon)).To(Equal(expected))
+		},
+		Entry("no policy", page("<script>"), "", nil),
+		Entry("policy allowing the agent", page("<script>", "script-src 'self' 'unsafe-inline' *.newrelic.com; connect-src https://*.nr-data.net"), "bam.nr-data.net", nil),
+		Entry("default-src applies to scripts and connections", page("<script>", "default-src 'self'"), "", []string{
+			"Content-Security-Policy default-src does not allow https://js-agent.newrelic.com, the agent cannot load.",
+			"Content-Security-Policy default-src blocks the inline Browser script. Add a nonce to the script element or the script's hash to the policy.",
+			"Content-Security-Policy default-src does not allow https://bam.nr-data.net, the agent cannot send data.",
+		}),
+		Entry("EU beacon not allowed", page("<script>", "script-src 'unsafe-inline' https://js-agent.newrelic.com; connect-src bam.nr-data.net"), "bam.eu01.nr-data.net", []string{
+			"Content-Security-Policy connect-src does not allow https://bam.eu01.nr-data.net, the agent cannot send data.",
+		}),
+		Entry("nonce on the loader", page(`<script nonce="r4nd0m">`, "script-src 'nonce-r4nd0m' js-agent.newrelic.com"), "", nil),
+		Entry("nonce missing from the loader", page(`<script>`, "script-src 'nonce-r4nd0m' 'unsafe-inline' js-agent.newrelic.com"), "", []string{
+			"Content-Security-Policy script-src blocks the inline Browser script. Add a nonce to the script element or the script's hash to the policy.",
+		}),
+		Entry("hash of the loader", page(`<script>`, "script-src "+loaderHash+" js-agent.newrelic.com"), "", nil),
+		Entry("strict-dynamic allows the scripts the loader adds", page(`<script nonce="r4nd0m">`, "script-src 'nonce-r4nd0m' 'strict-dynamic'"), "", nil),
+		Entry("strict-dynamic needs a trusted inline loader", page(`<script>`, "script-src 'strict-dynamic' 'unsafe-inline' https:"), "", []string{
+			"Content-Security-Policy script-src blocks the inline Browser script. Add a nonce to the script element or the script's hash to the policy.",
+		}),
+		Entry("strict-dynamic ignores the hosts allowed for an external loader", page(`<script src="https://js-agent.newrelic.com/nr-loader-spa-1.260.0.min.js">`, "script-src 'strict-dynamic' https://js-agent.newrelic.com; connect-src *"), "", []string{
+			"Content-Security-Policy script-src uses 'strict-dynamic', which ignores allowed hosts, so whether the agent can load cannot be determined. The Browser script element needs a nonce or hash the policy allows.",
+		}),
+		Entry("strict-dynamic does not allow connections", page(`<script nonce="r4nd0m">`, "default-src 'nonce-r4nd0m' 'strict-dynamic'"), "", []string{
+			"Content-Security-Policy default-src does not allow https://bam.nr-data.net, the agent cannot send data.",
+		}),
+		Entry("script-src-elem takes precedence", page("<script>", "script-src 'none'; script-src-elem 'unsafe-inline' https:"), "", nil),
+		Entry("meta and header policies are both enforced", page("<script>", "script-src 'unsafe-inline' https:", "script-src 'unsafe-inline' 'self'"), "", []string{
+			"Content-Security-Policy script-src does not allow https://js-agent.newrelic.com, the agent cannot load.",
+		}),
+	)
+
+	Describe("metaContentSecurityPolicies()", func() {
+		It("should return enforced meta policies", func() {
+			html := `<head><meta http-equiv="Content-Security-Policy" content="script-src 'self'">` +
+				`<meta content='default-src *' http-equiv='content-security-policy' />` +
+				`<meta http-equiv="Content-Security-Policy-Report-Only" content="script-src 'none'"></head>`
+			Expect(metaContentSecurityPolicies(html)).To(Equal([]string{"script-src 'self'", "default-src *"}))
+		})
+	})
+})
@@ -1,7 +1,9 @@
 package agent
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/output/obfuscate"
@@ -14,12 +16,16 @@ type BrowserAgentDetect struct {
 
 // BrowserAgentPayload - formatted data for json output
 type BrowserAgentPayload struct {
+	URL               string
 	AppReporting      string
 	AgentVersion      string
 	BrowserLicenseKey string
 	BrowserLoader     string
 	AgentType         string
 	TransactionName   string
+	Beacon            string
+	LoaderCount       int
+	CSPProblems       []string
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -43,14 +49,14 @@ func (t BrowserAgentDetect) Dependencies() []string {
 // Execute - The core work within each task
 func (t BrowserAgentDetect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
-	if upstream["Browser/Agent/GetSource"].Status == tasks.Failure || upstream["Browser/Agent/GetSource"].Status == tasks.Error || upstream["Browser/Agent/GetSource"].Status == tasks.None {
+	if upstream["Browser/Agent/GetSource"].Status == tasks.Error || upstream["Browser/Agent/GetSource"].Status == tasks.None {
 		return tasks.Result{
 			Status:  tasks.None,
 			Summary: "This task did not run because the previous task 'Browser/Agent/GetSource' either did not run or was not successful.",
 		}
 	}
 
-	pageSourcePayload, ok := upstream["Browser/Agent/GetSource"].Payload.(BrowserAgentSourcePayload)
+	pages, ok := upstream["Browser/Agent/GetSource"].Payload.([]BrowserAgentSourcePayload)
 
 	if !ok {
 		return tasks.Result{
@@ -59,35 +65,140 @@ func (t BrowserAgentDetect) Execute(options tasks.Options, upstream map[string]t
 		}
 	}
 
-	if len(pageSourcePayload.Loader) > 1 {
-		log.Debug("More than 1 browser agent detected")
+	var payloads []BrowserAgentPayload
+	for _, page := range pages {
+		if len(page.Loader)+len(page.LoaderOutsideHead) == 0 {
+			continue
+		}
+		payloads = append(payloads, detectPage(page))
+	}
+	if len(payloads) == 0 {
 		return tasks.Result{
-			Status:  tasks.Warning,
-			Summary: "More than one browser agent detected, please check to ensure only one browser agent is configured per page",
+			Status:  tasks.None,
+			Summary: "This task did not run because the previous task 'Browser/Agent/GetSource' did not find a Browser agent on any page.",
 		}
 	}
+	log.Debug("payload is ", payloads)
 
-	var payload BrowserAgentPayload
-	payload.AgentVersion = getAgentVersion(pageSourcePayload.Loader)
-	payload.AppReporting = getAppReporting(pageSourcePayload.Loader)
-	payload.BrowserLicenseKey = getBrowserKey(pageSourcePayload.Loader)
-	payload.BrowserLoader = getBrowserLoader(pageSourcePayload.Loader)
-	payload.AgentType, payload.TransactionName = getAgentType(pageSourcePayload.Loader)
-	log.Debug("payload is ", payload)
+	status := tasks.Success
+	var problems []string
+	for _, payload := range payloads {
+		if payload.LoaderCount > 1 {
+			log.Debug("More than 1 browser agent detected")
+			status = maxStatus(status, tasks.Warning)
+			problems = append(problems, fmt.Sprintf("%s: More than one browser agent detected, please check to ensure only one browser agent is configured per page", payload.URL))
+		}
+		for _, problem := range payload.CSPProblems {
+			status = maxStatus(status, tasks.Failure)
+			problems = append(problems, payload.URL+": "+problem)
+		}
+	}
+	if inconsistencies := compareAcrossPages(payloads); len(inconsistencies) > 0 {
+		status = maxStatus(status, tasks.Warning)
+		problems = append(problems, inconsistencies...)
+	}
 
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "Found values for browser agent",
+			Payload: payloads,
+		}
+	}
 	return tasks.Result{
-		Status:  tasks.Success,
-		Summary: "Found values for browser agent",
-		Payload: payload,
+		Status:  status,
+		Summary: strings.Join(problems, "\n"),
+		URL:     installDocURL,
+		Payload: payloads,
 	}
 }
 
-func getAppReporting(scripts []string) string {
-	regex := regexp.MustCompile(`applicationID[":\s]*"([0-9,]*)",`)
+// detectPage reads the agent settings from every New Relic script on a page, so values from the NREUM.info
+// configuration injected at the end of the body are found as well as those in the loader
+func detectPage(page BrowserAgentSourcePayload) BrowserAgentPayload {
+	scripts := page.NREUMScripts
+	if len(scripts) == 0 {
+		scripts = append(append([]string{}, page.Loader...), page.LoaderOutsideHead...)
+	}
+	payload := BrowserAgentPayload{
+		URL:               page.URL,
+		AgentVersion:      getAgentVersion(scripts),
+		AppReporting:      getAppReporting(scripts),
+		BrowserLicenseKey: getBrowserKey(scripts),
+		BrowserLoader:     getBrowserLoader(scripts),
+		Beacon:            getBeacon(scripts),
+		LoaderCount:       len(page.Loader) + len(page.LoaderOutsideHead),
+	}
+	payload.AgentType, payload.TransactionName = getAgentType(scripts)
+	payload.CSPProblems = cspProblems(page, payload.Beacon)
+	return payload
+}
+
+// compareAcrossPages reports settings that differ between the pages of a site, which splits its data across applications or accounts
+func compareAcrossPages(payloads []BrowserAgentPayload) []string {
+	fields := []struct {
+		name      string
+		value     func(BrowserAgentPayload) string
+		sensitive bool
+	}{
+		{"applicationID", func(p BrowserAgentPayload) string { return p.AppReporting }, false},
+		{"licenseKey", func(p BrowserAgentPayload) string { return p.BrowserLicenseKey }, true},
+		{"beacon", func(p BrowserAgentPayload) string { return p.Beacon }, false},
+		{"loader type", func(p BrowserAgentPayload) string { return p.BrowserLoader }, false},
+	}
+	var inconsistencies []string
+	for _, field := range fields {
+		var values []string
+		pagesByValue := make(map[string][]string)
+		for _, payload := range payloads {
+			value := field.value(payload)
+			if value == "" {
+				continue
+			}
+			if _, ok := pagesByValue[value]; !ok {
+				values = append(values, value)
+			}
+			pagesByValue[value] = append(pagesByValue[value], payload.URL)
+		}
+		if len(values) < 2 {
+			continue
+		}
+		var details []string
+		for _, value := range values {
+			display := value
+			if field.sensitive {
+				display = obfuscate.ObfuscateSensitiveValue(value)
+			}
+			details = append(details, fmt.Sprintf("%s on %s", display, strings.Join(pagesByValue[value], ", ")))
+		}
+		inconsistencies = append(inconsistencies, fmt.Sprintf("The Browser agent %s differs between pages: %s", field.name, strings.Join(details, "; ")))
+	}
+	return inconsistencies
+}
+
+func maxStatus(a, b tasks.Status) tasks.Status {
+	if b > a {
+		return b
+	}
+	return a
+}
 
+// settingRgx matches a setting in both the JSON NREUM.info and the unquoted object literals of newer loaders
+func settingRgx(name string, value string) *regexp.Regexp {
+	return regexp.MustCompile(`\b` + name + `["']?\s*:\s*["'](` + value + `)["']`)
+}
+
+var (
+	appIDRgx      = settingRgx("applicationID", `[0-9][0-9,]*`)
+	licenseKeyRgx = settingRgx("licenseKey", `[A-Za-z0-9-]+`)
+	beaconRgx     = settingRgx("beacon", `[^"']+`)
+	// agent file names are nr-<version>, nr-spa-<version> or nr-loader-<type>-<version>, with older versions numbered 1216 and newer ones 1.262.0
+	agentFileRgx = regexp.MustCompile(`js-agent\.newrelic\.com/(nr-(?:loader-)?(?:(spa|full|rum)-)?([0-9][0-9.]*[0-9]))(?:\.min)?\.js`)
+)
+
+func getAppReporting(scripts []string) string {
 	for _, loader := range scripts {
-		//log.Debug("loader is ", loader)
-		for _, value := range regex.FindAllStringSubmatch(loader, -1) {
+		for _, value := range appIDRgx.FindAllStringSubmatch(loader, -1) {
 			log.Debug("App reporting ID found: ", value[1])
 			return value[1]
 		}
@@ -97,23 +208,19 @@ func getAppReporting(scripts []string) string {
 }
 
 func getAgentVersion(scripts []string) string {
-	regex := regexp.MustCompile(`agent:"js-agent\.newrelic\.com.*-([0-9]*)\.min\.js"`)
-
 	for _, loader := range scripts {
-		for _, value := range regex.FindAllStringSubmatch(loader, -1) {
-			log.Debug("agent version found: ", value[1])
-			return value[1]
+		for _, value := range agentFileRgx.FindAllStringSubmatch(loader, -1) {
+			log.Debug("agent version found: ", value[3])
+			return value[3]
 		}
 	}
-	log.Debug("browser key not found, returning empty value")
+	log.Debug("agent version not found, returning empty value")
 	return ""
 }
 
 func getBrowserKey(scripts []string) string {
-	regex, _ := regexp.Compile(`licenseKey[":\s]*"([a-z0-9]*)"`)
-
 	for _, loader := range scripts {
-		for _, value := range regex.FindAllStringSubmatch(loader, -1) {
+		for _, value := range licenseKeyRgx.FindAllStringSubmatch(loader, -1) {
 			log.Debug("browser license key found", obfuscate.ObfuscateSensitiveValue(value[1]))
 			return value[1]
 		}
@@ -123,22 +230,42 @@ func getBrowserKey(scripts []string) string {
 	return ""
 }
 
-// detects loader in use
+func getBeacon(scripts []string) string {
+	for _, loader := range scripts {
+		for _, value := range beaconRgx.FindAllStringSubmatch(loader, -1) {
+			log.Debug("beacon found: ", value[1])
+			return value[1]
+		}
+	}
+	log.Debug("beacon not found, returning empty value")
+	return ""
+}
+
+// detects loader in use. The agent file named in the script is checked first on every page,
+// then the features only the Pro loader wraps, so that the same loader is reported the same way on every page.
 func getBrowserLoader(scripts []string) string {
-	spa := regexp.MustCompile(`agent:"js-agent\.newrelic\.com/nr-spa-[0-9]*\.min\.js"`)
 	pro := regexp.MustCompile(`\(NREUM={}\)\).loader_config|window.onerror|UncaughtException`)
 
 	for _, loader := range scripts {
-		// First check for SPA agent
-		if spa.MatchString(loader) {
-			log.Debug("Found SPA agent")
-			return "SPA"
-		} else if pro.MatchString(loader) { //Now check for Pro vs Lite since SPA was not found
-
+		for _, value := range agentFileRgx.FindAllStringSubmatch(loader, -1) {
+			switch value[2] {
+			case "spa":
+				log.Debug("Found SPA agent")
+				return "SPA"
+			case "full":
+				log.Debug("Found Pro loader")
+				return "Pro"
+			case "rum":
+				log.Debug("Found Lite loader")
+				return "Lite"
+			}
+		}
+	}
+	for _, loader := range scripts {
+		if pro.MatchString(loader) {
 			log.Debug("Found Pro loader")
 			return "Pro"
 		}
-
 	}
 	log.Debug("Pro loader not found, returning Lite")
 	return "Lite"
@@ -147,7 +274,7 @@ func getBrowserLoader(scripts []string) string {
 
 // detects copy/paste vs injected, returns type and Transaction name (if injected)
 func getAgentType(scripts []string) (string, string) {
-	regex, _ := regexp.Compile(`transactionName"[:\s]"*([0-9a-zA-Z=]*)"`)
+	regex := regexp.MustCompile(`transactionName["']?\s*:\s*["']([0-9a-zA-Z=+/_-]*)["']`)
 
 	for _, loader := range scripts {
 		for _, value := range regex.FindAllStringSubmatch(loader, -1) {
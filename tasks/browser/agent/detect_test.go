@@ -14,6 +14,14 @@ func TestBrowserAgentDetect(t *testing.T) {
 	RunSpecs(t, "Browser/Agent/* test suite")
 }
 
+// modernLoader is a copy/paste snippet in the format the Browser UI generates, with unquoted keys
+func modernLoader(applicationID string, licenseKey string, beacon string) string {
+	return `<script type="text/javascript">;window.NREUM||(NREUM={});NREUM.init={privacy:{cookies_enabled:true}};` +
+		`;NREUM.loader_config={accountID:"1",trustKey:"1",agentID:"` + applicationID + `",licenseKey:"` + licenseKey + `",applicationID:"` + applicationID + `"};` +
+		`;NREUM.info={beacon:"` + beacon + `",errorBeacon:"` + beacon + `",licenseKey:"` + licenseKey + `",applicationID:"` + applicationID + `",sa:1};` +
+		`(()=>{var e={p:"https://js-agent.newrelic.com/nr-loader-spa-1.262.0.min.js"}})();</script>`
+}
+
 var _ = Describe("Browser/Agent/Detect", func() {
 	var p BrowserAgentDetect
 
@@ -63,11 +71,11 @@ var _ = Describe("Browser/Agent/Detect", func() {
 				upstream = map[string]tasks.Result{
 					"Browser/Agent/GetSource": {
 						Status: tasks.Success,
-						Payload: BrowserAgentSourcePayload{
+						Payload: []BrowserAgentSourcePayload{{
 							URL:    "https://www.testingmctestface.com",
 							Source: BrowserFixtures.HTMLWithGoodLoader,
 							Loader: []string{BrowserFixtures.AgentScript},
-						},
+						}},
 					},
 				}
 			})
@@ -80,6 +88,124 @@ var _ = Describe("Browser/Agent/Detect", func() {
 				Expect(result.Summary).To(Equal("Found values for browser agent"))
 			})
 		})
+
+		Context("when pages report to different applications", func() {
+
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Browser/Agent/GetSource": {
+						Status: tasks.Warning,
+						Payload: []BrowserAgentSourcePayload{
+							{URL: "https://example.com/", Loader: []string{modernLoader("1234", "NRJS-aaaaaaaaaaaaaaaa", "bam.nr-data.net")}},
+							{URL: "https://example.com/cart", Loader: []string{modernLoader("5678", "NRJS-aaaaaaaaaaaaaaaa", "bam.eu01.nr-data.net")}},
+							{URL: "https://example.com/missing"},
+						},
+					},
+				}
+			})
+
+			It("should return a warning naming the settings that differ", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("The Browser agent applicationID differs between pages: 1234 on https://example.com/; 5678 on https://example.com/cart"))
+				Expect(result.Summary).To(ContainSubstring("The Browser agent beacon differs between pages"))
+				Expect(result.Summary).NotTo(ContainSubstring("licenseKey"))
+				Expect(result.Payload).To(HaveLen(2))
+			})
+		})
+
+		Context("when a page has more than one loader", func() {
+
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Browser/Agent/GetSource": {
+						Status: tasks.Success,
+						Payload: []BrowserAgentSourcePayload{{
+							URL:    "https://example.com/",
+							Loader: []string{BrowserFixtures.AgentScript, BrowserFixtures.AgentScript},
+						}},
+					},
+				}
+			})
+
+			It("should return a warning", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("More than one browser agent detected"))
+			})
+		})
+
+		Context("when the Content-Security-Policy blocks the beacon", func() {
+
+			BeforeEach(func() {
+				loader := modernLoader("1234", "NRJS-aaaaaaaaaaaaaaaa", "bam.nr-data.net")
+				upstream = map[string]tasks.Result{
+					"Browser/Agent/GetSource": {
+						Status: tasks.Success,
+						Payload: []BrowserAgentSourcePayload{{
+							URL:                     "https://example.com/",
+							FinalURL:                "https://example.com/",
+							Loader:                  []string{loader},
+							LoaderTag:               `<script nonce="abc">`,
+							ContentSecurityPolicies: []string{"script-src 'nonce-abc' https://js-agent.newrelic.com; connect-src 'self'"},
+						}},
+					},
+				}
+			})
+
+			It("should return a failure", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(Equal("https://example.com/: Content-Security-Policy connect-src does not allow https://bam.nr-data.net, the agent cannot send data."))
+			})
+		})
+
+		Context("when GetSource did not find a loader", func() {
+
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Browser/Agent/GetSource": {
+						Status:  tasks.Failure,
+						Payload: []BrowserAgentSourcePayload{{URL: "https://example.com/"}},
+					},
+				}
+			})
+
+			It("should return a none status", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+	})
+
+	Describe("getBrowserLoader()", func() {
+		It("should detect the loader type from the agent file name", func() {
+			Expect(getBrowserLoader([]string{`NREUM.info={"agent":"js-agent.newrelic.com/nr-spa-1216.min.js"}`})).To(Equal("SPA"))
+			Expect(getBrowserLoader([]string{`e.p="https://js-agent.newrelic.com/nr-loader-full-1.262.0.min.js"`})).To(Equal("Pro"))
+			Expect(getBrowserLoader([]string{`e.p="https://js-agent.newrelic.com/nr-loader-rum-1.262.0.min.js"`})).To(Equal("Lite"))
+			Expect(getBrowserLoader([]string{BrowserFixtures.AgentScript})).To(Equal("SPA"))
+		})
+
+		It("should fall back to the features the Pro loader wraps", func() {
+			Expect(getBrowserLoader([]string{`(window.NREUM||(NREUM={})).loader_config={xpid:"VQIGUV5TDBADVFdWAQUFV1E="}`})).To(Equal("Pro"))
+			Expect(getBrowserLoader([]string{`window.NREUM||(NREUM={});NREUM.info={}`})).To(Equal("Lite"))
+		})
+	})
+
+	Describe("detectPage()", func() {
+		It("should read settings from the loader and the injected NREUM.info", func() {
+			payload := detectPage(BrowserAgentSourcePayload{
+				URL:    "https://example.com/",
+				Loader: []string{modernLoader("1234", "NRJS-aaaaaaaaaaaaaaaa", "bam.nr-data.net")},
+				NREUMScripts: []string{
+					modernLoader("1234", "NRJS-aaaaaaaaaaaaaaaa", "bam.nr-data.net"),
+					`<script>window.NREUM||(NREUM={});NREUM.info={"beacon":"bam.nr-data.net","transactionName":"ZVdWZ0JXW0MCUkA="}</script>`,
+				},
+			})
+			Expect(payload.AppReporting).To(Equal("1234"))
+			Expect(payload.BrowserLicenseKey).To(Equal("NRJS-aaaaaaaaaaaaaaaa"))
+			Expect(payload.Beacon).To(Equal("bam.nr-data.net"))
+			Expect(payload.AgentVersion).To(Equal("1.262.0"))
+			Expect(payload.BrowserLoader).To(Equal("SPA"))
+			Expect(payload.AgentType).To(Equal("injected"))
+			Expect(payload.TransactionName).To(Equal("ZVdWZ0JXW0MCUkA="))
+		})
 	})
 
 })
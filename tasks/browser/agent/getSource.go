@@ -6,30 +6,63 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/cookiejar"
 	"regexp"
+	"strings"
+	"time"
 
-	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
 
+const (
+	installDocURL  = "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent"
+	requestTimeout = 30 * time.Second
+	// maxRedirects matches the limit of the default http.Client
+	maxRedirects = 10
+)
+
 // BrowserAgentGetSource - This struct defined the sample plugin which can be used as a starting point
 type BrowserAgentGetSource struct {
+	// transport is nil outside of tests so requests use http.DefaultTransport and its proxy settings
+	transport http.RoundTripper
 }
 
+// BrowserAgentSourcePayload - the source of one page and the New Relic scripts found in it
 type BrowserAgentSourcePayload struct {
 	Source string
 	URL    string
-	Loader []string
+	// FinalURL is the page the redirects from URL ended on
+	FinalURL  string
+	Redirects []string
+	// Loader holds the loader scripts found inside the head tag and LoaderOutsideHead those found after it
+	Loader            []string
+	LoaderOutsideHead []string
+	// NREUMScripts holds every New Relic script on the page, including the NREUM.info configuration APM agents inject at the end of the body
+	NREUMScripts []string
+	// ScriptsBeforeLoader lists the src, or "inline", of the scripts that run before the first loader
+	ScriptsBeforeLoader []string
+	// LoaderTag is the opening tag of the first loader script
+	LoaderTag string
+	// ContentSecurityPolicies holds the enforced policies from response headers and meta tags
+	ContentSecurityPolicies []string
+	Status                  tasks.Status
+	Summary                 string
 }
 
 func (payload BrowserAgentSourcePayload) MarshalJSON() ([]byte, error) {
 	//note: this technique can be used to return anything you want, including modified values or nothing at all.
 	//anything that gets returned here ends up in the output json file
 	return json.Marshal(&struct {
-		URL string
+		URL       string
+		FinalURL  string
+		Redirects []string
 	}{
-		URL: payload.URL,
+		URL:       payload.URL,
+		FinalURL:  payload.FinalURL,
+		Redirects: payload.Redirects,
 	})
 }
 
@@ -49,91 +82,161 @@ func (t BrowserAgentGetSource) Dependencies() []string {
 	return []string{}
 }
 
+// RequiresNetwork - this task fetches the page given with -browser-url, so it is skipped in offline mode
+func (t BrowserAgentGetSource) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - The core work within each task
 func (t BrowserAgentGetSource) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	log.Debug(options)
-	url := options.Options["url"]
+	// the url override takes a single URL, the -browser-url flag a comma separated list
+	urls := splitURLs(options.Options["url"])
+	if len(urls) == 0 {
+		urls = splitURLs(options.Options["BrowserURL"])
+	}
 
-	if url == "" {
+	if len(urls) == 0 {
 		return tasks.Result{
 			Status:  tasks.None,
 			Summary: "This health check requires the usage of the command option '-browser-url'. Please re-run " + tasks.ThisProgramFullName + " using the flag in this manner: ./nrdiag -browser-url http://YOUR-WEBSITE-URL -suites browser",
 		}
 	}
 
-	request := httpHelper.RequestWrapper{
-		Method: "GET",
-		URL:    url,
+	// pages share a cookie jar so consent and session cookies set by one page or redirect apply to the next
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{
+		Transport: t.transport,
+		Jar:       jar,
+		Timeout:   requestTimeout,
 	}
-	resp, err := httpHelper.MakeHTTPRequest(request)
-	if err != nil {
-		return tasks.Result{
-			Status:  tasks.Failure,
-			Summary: fmt.Sprintf("Failed to connect to %s. Please make sure to add a protocol to the URL or verify connectivity. Encountered error: %s", url, err.Error()),
+
+	urls = expandSitemaps(client, urls)
+
+	var pages []BrowserAgentSourcePayload
+	var filesToCopy []tasks.FileCopyEnvelope
+	for i, url := range urls {
+		page, body := fetchPage(client, url)
+		pages = append(pages, page)
+		if body == nil {
+			continue
+		}
+		fileName := "nrdiag-output/source.html"
+		if len(urls) > 1 {
+			fileName = fmt.Sprintf("nrdiag-output/source-%d.html", i+1)
 		}
+		stream := make(chan string)
+		go streamSource(body, stream)
+		filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{
+			Path:       fileName,
+			Stream:     stream,
+			Identifier: t.Identifier().String(),
+		})
 	}
 
-	stream := make(chan string)
-	responseBody, errReadingBody := io.ReadAll(resp.Body)
-	go streamSource(responseBody, stream)
-	if errReadingBody != nil {
-		return tasks.Result{
-			Status:  tasks.Failure,
-			Summary: fmt.Sprintf("Failed to connect to %s. Please check your URL and verify connectivity: %s", url, errReadingBody.Error()),
+	result := tasks.Result{
+		Status:      tasks.Success,
+		FilesToCopy: filesToCopy,
+		Payload:     pages,
+	}
+	var summaries []string
+	for _, page := range pages {
+		if page.Status > result.Status {
+			result.Status = page.Status
 		}
+		if len(pages) == 1 {
+			summaries = append(summaries, page.Summary)
+		} else {
+			summaries = append(summaries, page.URL+": "+page.Summary)
+		}
+	}
+	result.Summary = strings.Join(summaries, "\n")
+	if result.Status != tasks.Success {
+		result.URL = installDocURL
 	}
-	resultFilesToCopy := []tasks.FileCopyEnvelope{{Path: "nrdiag-output/source.html",
-		Stream:     stream,
-		Identifier: t.Identifier().String(),
-	}}
-	//goodScripts means that we 1 or more loader scripts inside the head tag and badScripts means that we found instance of loader scripts outside of the head tag
-	goodScripts, badScripts := getLoaderScript(string(responseBody))
+	return result
+}
 
-	loaderFoundInheadTag := len(goodScripts) > 0
-	loaderFoundOutsideHead := len(badScripts) > 0
+// splitURLs splits a comma or whitespace separated list of URLs
+func splitURLs(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t'
+	})
+}
 
-	if !loaderFoundInheadTag && !loaderFoundOutsideHead {
-		return tasks.Result{
-			Status:      tasks.Failure,
-			Summary:     fmt.Sprintf("We were unable to find the Browser agent script in the HTML source code for %s. If this has not been added to the application yet, follow one of the deployment options suggested in our documentation. However, if the browser script is being loaded via an external file, keep in mind that this can cause issues in collecting the data for New Relic.", url),
-			URL:         "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent",
-			FilesToCopy: resultFilesToCopy,
-		}
+// fetchPage requests a page and locates the New Relic scripts in it, the body is nil if the page could not be read
+func fetchPage(client *http.Client, url string) (BrowserAgentSourcePayload, []byte) {
+	page := BrowserAgentSourcePayload{URL: url}
+	resp, err := get(client, url)
+	if err != nil {
+		page.Status = tasks.Failure
+		page.Summary = fmt.Sprintf("Failed to connect to %s. Please make sure to add a protocol to the URL or verify connectivity. Encountered error: %s", url, err.Error())
+		return page, nil
 	}
+	defer resp.Body.Close()
+	page.FinalURL = resp.Request.URL.String()
+	page.Redirects = redirectChain(resp)
 
-	if loaderFoundInheadTag && loaderFoundOutsideHead {
-		return tasks.Result{
-			Status:      tasks.Warning,
-			Summary:     "We found at least one instance of the New Relic Browser script element outside of the </head> tag. This script should only be present in the </head> tag or it can cause monitoring issues.",
-			URL:         "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent",
-			FilesToCopy: resultFilesToCopy,
-			Payload: BrowserAgentSourcePayload{
-				URL:    url,
-				Source: string(responseBody),
-				Loader: goodScripts,
-			},
-		}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		page.Status = tasks.Failure
+		page.Summary = fmt.Sprintf("Failed to connect to %s. Please check your URL and verify connectivity: %s", url, err.Error())
+		return page, nil
+	}
+	page.Source = string(body)
+	page.ContentSecurityPolicies = append(resp.Header.Values("Content-Security-Policy"), metaContentSecurityPolicies(page.Source)...)
+
+	scripts := parseScripts(page.Source)
+	page.Loader, page.LoaderOutsideHead = loaderScripts(scripts)
+	page.NREUMScripts = nreumScripts(scripts)
+	page.ScriptsBeforeLoader, page.LoaderTag = scriptsBeforeLoader(scripts)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		page.Status = tasks.Failure
+		page.Summary = fmt.Sprintf("%s returned %s. Check the URL is publicly reachable or that the page does not require a login.", url, resp.Status)
+		return page, body
+	}
+	page.Status, page.Summary = placementSummary(page)
+	return page, body
+}
+
+func get(client *http.Client, url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Nrdiag_/"+config.Version)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	return client.Do(req)
+}
+
+// redirectChain returns the URLs that redirected to the final response, in the order they were requested
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for previous := resp.Request.Response; previous != nil; previous = previous.Request.Response {
+		chain = append([]string{previous.Request.URL.String()}, chain...)
 	}
+	return chain
+}
+
+// placementSummary checks the loader is inline in the head and runs before any other script
+func placementSummary(page BrowserAgentSourcePayload) (tasks.Status, string) {
+	loaderFoundInheadTag := len(page.Loader) > 0
+	loaderFoundOutsideHead := len(page.LoaderOutsideHead) > 0
 
+	if !loaderFoundInheadTag && !loaderFoundOutsideHead {
+		return tasks.Failure, fmt.Sprintf("We were unable to find the Browser agent script in the HTML source code for %s. If this has not been added to the application yet, follow one of the deployment options suggested in our documentation. However, if the browser script is being loaded via an external file, keep in mind that this can cause issues in collecting the data for New Relic.", page.URL)
+	}
+	if loaderFoundInheadTag && loaderFoundOutsideHead {
+		return tasks.Warning, "We found at least one instance of the New Relic Browser script element outside of the </head> tag. This script should only be present in the </head> tag or it can cause monitoring issues."
+	}
 	if loaderFoundOutsideHead {
-		return tasks.Result{
-			Status:      tasks.Failure,
-			Summary:     "We found the browser agent in the source code but it is not inline in the <head>. This can cause issues in collecting the data. The best practices for installation are to copy the snippet of code and paste it as close to the top of the HEAD as possible, but after any position-sensitive META tags (X-UA-Compatible and charset). The script needs to load very early in order to wrap the browser's built-in APIs",
-			URL:         "https://docs.newrelic.com/docs/browser/browser-monitoring/installation/install-browser-monitoring-agent",
-			FilesToCopy: resultFilesToCopy,
-		}
+		return tasks.Failure, "We found the browser agent in the source code but it is not inline in the <head>. This can cause issues in collecting the data. The best practices for installation are to copy the snippet of code and paste it as close to the top of the HEAD as possible, but after any position-sensitive META tags (X-UA-Compatible and charset). The script needs to load very early in order to wrap the browser's built-in APIs"
 	}
-	//Looks like we only have good scripts
-	return tasks.Result{
-		Status:      tasks.Success,
-		Summary:     "We successfully found the New Relic Browser script element in the following page's source: " + url + ". The body of the page has been included in the nrdiag-zip file",
-		FilesToCopy: resultFilesToCopy,
-		Payload: BrowserAgentSourcePayload{
-			URL:    url,
-			Source: string(responseBody),
-			Loader: goodScripts,
-		},
+	if len(page.ScriptsBeforeLoader) > 0 {
+		return tasks.Warning, fmt.Sprintf("The New Relic Browser script element is in the <head> but %d other scripts run before it, starting with %s. Move the Browser script above them so it can wrap the browser's built-in APIs before they are used.", len(page.ScriptsBeforeLoader), page.ScriptsBeforeLoader[0])
 	}
+	return tasks.Success, "We successfully found the New Relic Browser script element in the following page's source: " + page.URL + ". The body of the page has been included in the nrdiag-zip file"
 }
 
 func streamSource(responseBody []byte, ch chan string) {
@@ -148,31 +251,122 @@ func streamSource(responseBody []byte, ch chan string) {
 
 }
 
+// script is a script element of a page
+type script struct {
+	Tag    string
+	Attrs  string
+	Body   string
+	InHead bool
+}
+
+var (
+	scriptRgx    = regexp.MustCompile(`(?is)(<script\b([^>]*)>)(.*?)</script\s*>`)
+	headEndRgx   = regexp.MustCompile(`(?i)</head\s*>`)
+	bodyStartRgx = regexp.MustCompile(`(?i)<body\b`)
+	typeAttrRgx  = regexp.MustCompile(`(?i)\btype\s*=\s*["']?([^"'\s>]+)`)
+	srcAttrRgx   = regexp.MustCompile(`(?i)\bsrc\s*=\s*["']?([^"'\s>]+)`)
+	nrScriptRgx  = regexp.MustCompile(`window\.NREUM|NREUM\.(info|init|loader_config)\b`)
+	nrSrcRgx     = regexp.MustCompile(`js-agent\.newrelic\.com/nr-`)
+	// the NREUM.info configuration APM agents inject at the end of the body is short and carries no loader code
+	nrInfoOnlyRgx = regexp.MustCompile(`NREUM\.(info|setToken)\s*[=(]`)
+)
+
+// maxInfoOnlyLength is longer than any injected NREUM.info configuration and shorter than any loader
+const maxInfoOnlyLength = 2048
+
+// parseScripts returns the script elements of a page in document order. Pages without a closing head tag end it at the body tag.
+func parseScripts(data string) []script {
+	headEnd := len(data)
+	if loc := headEndRgx.FindStringIndex(data); loc != nil {
+		headEnd = loc[0]
+	} else if loc := bodyStartRgx.FindStringIndex(data); loc != nil {
+		headEnd = loc[0]
+	}
+
+	var scripts []script
+	for _, match := range scriptRgx.FindAllStringSubmatchIndex(data, -1) {
+		scripts = append(scripts, script{
+			Tag:    data[match[2]:match[3]],
+			Attrs:  data[match[4]:match[5]],
+			Body:   data[match[6]:match[7]],
+			InHead: match[0] < headEnd,
+		})
+	}
+	return scripts
+}
+
+// isExecutable excludes data blocks such as application/ld+json and templates, which the browser does not run
+func (s script) isExecutable() bool {
+	match := typeAttrRgx.FindStringSubmatch(s.Attrs)
+	if match == nil {
+		return true
+	}
+	switch strings.ToLower(match[1]) {
+	case "text/javascript", "application/javascript", "module":
+		return true
+	}
+	return false
+}
+
+func (s script) isNREUM() bool {
+	return s.isExecutable() && (nrScriptRgx.MatchString(s.Body) || nrSrcRgx.MatchString(s.Attrs))
+}
+
+// isLoader is true for the scripts that load the agent, as opposed to the NREUM.info configuration injected at the end of the body
+func (s script) isLoader() bool {
+	if !s.isNREUM() {
+		return false
+	}
+	return nrSrcRgx.MatchString(s.Attrs) || !(nrInfoOnlyRgx.MatchString(s.Body) && len(s.Body) < maxInfoOnlyLength)
+}
+
+func (s script) String() string {
+	if match := srcAttrRgx.FindStringSubmatch(s.Attrs); match != nil {
+		return match[1]
+	}
+	return "an inline script"
+}
+
+// getLoaderScript returns the loader scripts found inside the head tag and those found after it
 func getLoaderScript(data string) ([]string, []string) {
+	return loaderScripts(parseScripts(data))
+}
+
+func loaderScripts(scripts []script) ([]string, []string) {
 	var goodScripts, badScripts []string
-	nrScriptRgx := regexp.MustCompile("window.NREUM")
-	// check for Browser agent in head tag
-	scriptInHeadRgx := regexp.MustCompile(`(?m:<script\b[^>]*>([\s\S]*?)<\/script>[\s\S]*<\/head>)`)
-	scriptInHeadMatches := scriptInHeadRgx.FindAllString(data, -1)
-
-	if len(scriptInHeadMatches) > 0 {
-		for _, match := range scriptInHeadMatches {
-			if nrScriptRgx.MatchString(match) {
-				// it is considered a good script because the loader was found inside the Head tag
-				goodScripts = append(goodScripts, match)
-			}
+	for _, s := range scripts {
+		if !s.isLoader() {
+			continue
 		}
-	}
-	//This would match any script: (?m:<script\b[^>]*>([\s\S]*?)<\/script>)
-	scriptAfterHeadRgx := regexp.MustCompile(`(<\/head>[\s\S]*<script\b[^>]*>([\s\S]*?)<\/script>)`)
-	scriptAfterheadMatches := scriptAfterHeadRgx.FindAllString(data, -1)
-	// Now loop through scriptAfterheadMatches to find the browser agent loader outside of head tag
-	for _, match := range scriptAfterheadMatches {
-		if nrScriptRgx.MatchString(match) {
-			badScripts = append(badScripts, match)
+		if s.InHead {
+			goodScripts = append(goodScripts, s.Tag+s.Body)
+		} else {
+			badScripts = append(badScripts, s.Tag+s.Body)
 		}
 	}
-
 	return goodScripts, badScripts
+}
 
+func nreumScripts(scripts []script) []string {
+	var nreum []string
+	for _, s := range scripts {
+		if s.isNREUM() {
+			nreum = append(nreum, s.Tag+s.Body)
+		}
+	}
+	return nreum
+}
+
+// scriptsBeforeLoader returns the executable scripts that run before the first loader, and the loader's opening tag
+func scriptsBeforeLoader(scripts []script) ([]string, string) {
+	var before []string
+	for _, s := range scripts {
+		if s.isLoader() {
+			return before, s.Tag
+		}
+		if s.isExecutable() && !s.isNREUM() {
+			before = append(before, s.String())
+		}
+	}
+	return nil, ""
 }
@@ -1,6 +1,11 @@
 package agent
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	BrowserFixtures "github.com/newrelic/newrelic-diagnostics-cli/tasks/fixtures/Browser"
 	. "github.com/onsi/ginkgo/v2"
@@ -57,6 +62,155 @@ var _ = Describe("Browser/Agent/GetSource", func() {
 				Expect(len(badScripts)).To(Equal(1))
 			})
 		})
+
+		Context("when an APM agent injected the loader in the head and NREUM.info at the end of the body", func() {
+
+			BeforeEach(func() {
+				data = `<html><head><script type="text/javascript">` + strings.Repeat("/* loader */", 300) + `window.NREUM||(NREUM={});NREUM.init={};</script></head>` +
+					`<body><script type="text/javascript">window.NREUM||(NREUM={});NREUM.info={"beacon":"bam.nr-data.net","transactionName":"ZVdWZ0JXW0MCUkA="}</script></body></html>`
+			})
+
+			It("should not report the NREUM.info script as a loader outside the head", func() {
+				Expect(len(goodScripts)).To(Equal(1))
+				Expect(len(badScripts)).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("scriptsBeforeLoader()", func() {
+		It("should list the executable scripts before the loader", func() {
+			before, tag := scriptsBeforeLoader(parseScripts(BrowserFixtures.HTMLWithGoodLoader))
+			Expect(before).To(Equal([]string{"an inline script", "//cdn.www.testingmctestface.com/js/jquery1.9.1.min.js"}))
+			Expect(tag).To(Equal(`<script type="text/javascript">`))
+		})
+
+		It("should ignore data blocks", func() {
+			before, _ := scriptsBeforeLoader(parseScripts(`<head><script type="application/ld+json">{}</script>` + BrowserFixtures.AgentScript + `</head>`))
+			Expect(before).To(BeEmpty())
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			result  tasks.Result
+			options tasks.Options
+			server  *httptest.Server
+		)
+
+		BeforeEach(func() {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/start", func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+				http.Redirect(w, r, "/home", http.StatusFound)
+			})
+			mux.HandleFunc("/home", func(w http.ResponseWriter, r *http.Request) {
+				if _, err := r.Cookie("session"); err != nil {
+					http.Error(w, "login required", http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Content-Security-Policy", "default-src 'self'")
+				fmt.Fprint(w, `<html><head><script src="/app.js"></script>`+BrowserFixtures.AgentScript+`</head><body></body></html>`)
+			})
+			mux.HandleFunc("/about", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `<html><head><meta charset="utf-8">`+BrowserFixtures.AgentScript+`<script src="/app.js"></script></head><body></body></html>`)
+			})
+			mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>%[1]s/start</loc></url><url><loc>%[1]s/about</loc></url></urlset>`, "http://"+r.Host)
+			})
+			server = httptest.NewServer(mux)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, map[string]tasks.Result{})
+			for _, file := range result.FilesToCopy {
+				for range file.Stream {
+				}
+			}
+		})
+
+		Context("when no URL is given", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{}}
+			})
+
+			It("should return a none status", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("when a list of URLs is given", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"BrowserURL": server.URL + "/start," + server.URL + "/about"}}
+			})
+
+			It("should follow redirects with cookies and check each page", func() {
+				pages := result.Payload.([]BrowserAgentSourcePayload)
+				Expect(pages).To(HaveLen(2))
+				Expect(pages[0].FinalURL).To(Equal(server.URL + "/home"))
+				Expect(pages[0].Redirects).To(Equal([]string{server.URL + "/start"}))
+				Expect(pages[0].ScriptsBeforeLoader).To(Equal([]string{"/app.js"}))
+				Expect(pages[0].ContentSecurityPolicies).To(Equal([]string{"default-src 'self'"}))
+				Expect(pages[0].Status).To(Equal(tasks.Warning))
+				Expect(pages[1].Status).To(Equal(tasks.Success))
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring(server.URL + "/start: The New Relic Browser script element is in the <head> but 1 other scripts run before it, starting with /app.js."))
+				Expect(result.FilesToCopy).To(HaveLen(2))
+				Expect(result.FilesToCopy[1].Path).To(Equal("nrdiag-output/source-2.html"))
+			})
+		})
+
+		Context("when a sitemap is given", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"BrowserURL": server.URL + "/sitemap.xml"}}
+			})
+
+			It("should check the pages it lists", func() {
+				pages := result.Payload.([]BrowserAgentSourcePayload)
+				Expect(pages).To(HaveLen(2))
+				Expect(pages[0].URL).To(Equal(server.URL + "/start"))
+				Expect(pages[1].URL).To(Equal(server.URL + "/about"))
+			})
+		})
+
+		Context("when the url override is given", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"url": server.URL + "/about"}}
+			})
+
+			It("should check the single page", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.FilesToCopy[0].Path).To(Equal("nrdiag-output/source.html"))
+			})
+		})
+
+		Context("when a page cannot be reached", func() {
+			BeforeEach(func() {
+				options = tasks.Options{Options: map[string]string{"BrowserURL": server.URL + "/home"}}
+			})
+
+			It("should return a failure", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("returned 403 Forbidden"))
+			})
+		})
+	})
+
+	Describe("parseSitemap()", func() {
+		It("should return the child sitemaps of a sitemap index", func() {
+			pages, children, ok := parseSitemap([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc> https://example.com/pages.xml </loc></sitemap></sitemapindex>`))
+			Expect(ok).To(BeTrue())
+			Expect(pages).To(BeEmpty())
+			Expect(children).To(Equal([]string{"https://example.com/pages.xml"}))
+		})
+
+		It("should not accept other documents", func() {
+			_, _, ok := parseSitemap([]byte(`<?xml version="1.0"?><rss></rss>`))
+			Expect(ok).To(BeFalse())
+		})
 	})
 
 })
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// maxSitemapPages limits how many pages of a sitemap are checked
+const maxSitemapPages = 10
+
+type sitemap struct {
+	XMLName  xml.Name
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// parseSitemap returns the page and child sitemap URLs of a sitemap, ok is false for any other document
+func parseSitemap(body []byte) (pages []string, children []string, ok bool) {
+	trimmed := bytes.TrimSpace(body)
+	if !bytes.HasPrefix(trimmed, []byte("<?xml")) && !bytes.HasPrefix(trimmed, []byte("<urlset")) && !bytes.HasPrefix(trimmed, []byte("<sitemapindex")) {
+		return nil, nil, false
+	}
+	var s sitemap
+	if err := xml.Unmarshal(trimmed, &s); err != nil {
+		log.Debug("Unable to parse sitemap", err)
+		return nil, nil, false
+	}
+	if s.XMLName.Local != "urlset" && s.XMLName.Local != "sitemapindex" {
+		return nil, nil, false
+	}
+	for _, url := range s.URLs {
+		pages = append(pages, strings.TrimSpace(url))
+	}
+	for _, url := range s.Sitemaps {
+		children = append(children, strings.TrimSpace(url))
+	}
+	return pages, children, true
+}
+
+// expandSitemaps replaces any sitemap in urls by the first maxSitemapPages pages it lists, following one level of sitemap index
+func expandSitemaps(client *http.Client, urls []string) []string {
+	var expanded []string
+	for _, url := range urls {
+		if !strings.HasSuffix(strings.ToLower(strings.SplitN(url, "?", 2)[0]), ".xml") {
+			expanded = append(expanded, url)
+			continue
+		}
+		pages, children, ok := fetchSitemap(client, url)
+		if !ok {
+			// let the page checks report the problem with this URL
+			expanded = append(expanded, url)
+			continue
+		}
+		for _, child := range children {
+			if len(pages) >= maxSitemapPages {
+				break
+			}
+			childPages, _, _ := fetchSitemap(client, child)
+			pages = append(pages, childPages...)
+		}
+		if len(pages) > maxSitemapPages {
+			log.Debugf("Checking the first %d of %d pages in %s\n", maxSitemapPages, len(pages), url)
+			pages = pages[:maxSitemapPages]
+		}
+		expanded = append(expanded, pages...)
+	}
+	return expanded
+}
+
+func fetchSitemap(client *http.Client, url string) ([]string, []string, bool) {
+	resp, err := get(client, url)
+	if err != nil {
+		log.Debug("Unable to fetch sitemap", url, err)
+		return nil, nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Debug("Unable to fetch sitemap", url, resp.Status)
+		return nil, nil, false
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, false
+	}
+	return parseSitemap(body)
+}
@@ -117,3 +117,28 @@ var DotnetCoreSupportedVersions = map[string][]string{
 }
 
 //https://docs.newrelic.com/docs/agents/net-agent/getting-started/net-agent-compatibility-requirements-net-core#net-version
+
+// https://docs.newrelic.com/docs/apm/agents/ruby-agent/getting-started/ruby-agent-requirements-supported-frameworks
+// Instrumented gems as keys and the gem versions the current Ruby agent supports as values
+var RubyInstrumentedGemSupportability = map[string][]string{
+	"rails":         {"4.2-8.*"},
+	"activerecord":  {"4.2-8.*"},
+	"sinatra":       {"2.0-4.*"},
+	"rack":          {"1.6-3.*"},
+	"puma":          {"3.9-6.*"},
+	"unicorn":       {"4.0-6.*"},
+	"passenger":     {"5.1.3+"},
+	"sidekiq":       {"5.0-8.*"},
+	"resque":        {"1.23-2.*"},
+	"delayed_job":   {"4.1+"},
+	"grpc":          {"1.34+"},
+	"redis":         {"3.2-5.*"},
+	"mongo":         {"2.4.1+"},
+	"elasticsearch": {"7.0-8.*"},
+	"bunny":         {"2.7+"},
+	"grape":         {"0.19.2+"},
+	"httpclient":    {"2.2+"},
+	"typhoeus":      {"1.3+"},
+	"excon":         {"0.56+"},
+	"dalli":         {"3.2.1+"},
+}
@@ -0,0 +1,273 @@
+package profiler
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/env"
+)
+
+// newRelicProfilerGUID is the COM class ID of the New Relic .NET profiler
+const newRelicProfilerGUID = "{36032161-FFC0-4B61-B559-F6C5D41BAE5A}"
+
+const linuxInstallDocURL = "https://docs.newrelic.com/docs/apm/agents/net-agent/install-guides/install-net-agent-linux/"
+
+// agentHomeLayout are the files and directories the profiler expects to find in CORECLR_NEWRELIC_HOME
+var agentHomeLayout = []string{"NewRelic.Agent.Core.dll", "newrelic.config", "extensions"}
+
+// DotNetCoreProfilerAttach - This task checks each running dotnet process is configured to load the New Relic profiler and has loaded it
+type DotNetCoreProfilerAttach struct {
+	goos          string
+	readFile      func(string) ([]byte, error)
+	stat          func(string) (os.FileInfo, error)
+	inspectBinary func(string) (binaryInfo, error)
+}
+
+// ProfilerCheck - the outcome of one check against a process
+type ProfilerCheck struct {
+	Name   string
+	Status tasks.Status
+	Detail string
+}
+
+// ProcessProfilerResult - the checks run against one dotnet process
+type ProcessProfilerResult struct {
+	Pid    int32
+	Status tasks.Status
+	Checks []ProfilerCheck
+}
+
+// Identifier - This returns the Category, Subcategory and Name of the task
+func (t DotNetCoreProfilerAttach) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("DotNetCore/Profiler/Attach")
+}
+
+// Explain - Returns the help text of the task
+func (t DotNetCoreProfilerAttach) Explain() string {
+	return "Check running dotnet processes are configured to load the New Relic profiler and have loaded it"
+}
+
+// Dependencies - Returns the dependencies of the task
+func (t DotNetCoreProfilerAttach) Dependencies() []string {
+	return []string{
+		"DotNetCore/Env/Process",
+	}
+}
+
+// Execute - The core work within each task
+func (t DotNetCoreProfilerAttach) Execute(options tasks.Options, upstream map[string]tasks.Result) (result tasks.Result) {
+	if t.goos != "linux" {
+		result.Status = tasks.None
+		result.Summary = "Profiler attach verification is only available on Linux, skipping this task."
+		return
+	}
+	if upstream["DotNetCore/Env/Process"].Status != tasks.Success {
+		result.Status = tasks.None
+		result.Summary = "No dotnet processes were detected, skipping this task."
+		return
+	}
+	processes, ok := upstream["DotNetCore/Env/Process"].Payload.([]env.ProcessArgs)
+	if !ok {
+		result.Status = tasks.Error
+		result.Summary = tasks.AssertionErrorSummary
+		return
+	}
+
+	var results []ProcessProfilerResult
+	var failures []string
+	result.Status = tasks.Success
+	for _, process := range processes {
+		processResult := t.checkProcess(process)
+		results = append(results, processResult)
+		if processResult.Status > result.Status {
+			result.Status = processResult.Status
+		}
+		for _, check := range processResult.Checks {
+			if check.Status != tasks.Success {
+				failures = append(failures, fmt.Sprintf("PID %d: %s", processResult.Pid, check.Detail))
+			}
+		}
+	}
+	result.Payload = results
+
+	switch result.Status {
+	case tasks.Success:
+		result.Summary = fmt.Sprintf("The New Relic profiler is configured and loaded in all %d dotnet processes.", len(results))
+	case tasks.Warning:
+		result.Summary = "Some profiler checks could not be completed:\n" + strings.Join(failures, "\n")
+	default:
+		result.Summary = "The New Relic profiler is not attached to every dotnet process:\n" + strings.Join(failures, "\n")
+		result.URL = linuxInstallDocURL
+	}
+	return
+}
+
+func (t DotNetCoreProfilerAttach) checkProcess(process env.ProcessArgs) ProcessProfilerResult {
+	result := ProcessProfilerResult{Pid: process.Pid}
+	add := func(name string, status tasks.Status, detail string) {
+		result.Checks = append(result.Checks, ProfilerCheck{Name: name, Status: status, Detail: detail})
+		if status > result.Status {
+			result.Status = status
+		}
+	}
+	result.Status = tasks.Success
+
+	if value := process.EnvVars["CORECLR_ENABLE_PROFILING"]; value == "1" {
+		add("CORECLR_ENABLE_PROFILING", tasks.Success, "CORECLR_ENABLE_PROFILING is 1")
+	} else {
+		add("CORECLR_ENABLE_PROFILING", tasks.Failure, fmt.Sprintf("CORECLR_ENABLE_PROFILING is %q, it must be 1", value))
+	}
+
+	if value := process.EnvVars["CORECLR_PROFILER"]; strings.EqualFold(value, newRelicProfilerGUID) {
+		add("CORECLR_PROFILER", tasks.Success, "CORECLR_PROFILER is the New Relic profiler")
+	} else {
+		add("CORECLR_PROFILER", tasks.Failure, fmt.Sprintf("CORECLR_PROFILER is %q, it must be %s", value, newRelicProfilerGUID))
+	}
+
+	// the executable tells which of CORECLR_PROFILER_PATH_64 and _32 the runtime reads
+	executable, executableErr := t.inspectBinary(procPath(process.Pid, "exe"))
+	if executableErr != nil {
+		log.Debug("DotNetCoreProfilerAttach - unable to inspect the process executable", executableErr)
+	}
+	pathVar, profilerPath := profilerPathVariable(process.EnvVars, executable, executableErr)
+	profilerExists := false
+	if profilerPath == "" {
+		add("CORECLR_PROFILER_PATH", tasks.Failure, "CORECLR_PROFILER_PATH is not set")
+	} else if _, err := t.stat(rootPath(process.Pid, profilerPath)); os.IsNotExist(err) {
+		add("CORECLR_PROFILER_PATH", tasks.Failure, fmt.Sprintf("%s %s does not exist", pathVar, profilerPath))
+	} else if err != nil {
+		log.Debug("DotNetCoreProfilerAttach - unable to stat the profiler", err)
+		add("CORECLR_PROFILER_PATH", tasks.Warning, fmt.Sprintf("Unable to check %s %s exists. Run as root or as the process owner.", pathVar, profilerPath))
+	} else {
+		profilerExists = true
+		add("CORECLR_PROFILER_PATH", tasks.Success, pathVar+" "+profilerPath+" exists")
+	}
+
+	if profilerExists {
+		t.checkArchitecture(process.Pid, profilerPath, executable, executableErr, add)
+	}
+	t.checkAgentHome(process.Pid, process.EnvVars, add)
+	t.checkLoaded(process.Pid, profilerPath, add)
+	return result
+}
+
+// profilerPathVariable returns the variable the runtime loads the profiler from, CORECLR_PROFILER_PATH_64 or _32
+// for the bitness of the process take precedence over CORECLR_PROFILER_PATH. The process is assumed 64-bit when
+// its executable cannot be read.
+func profilerPathVariable(envVars map[string]string, executable binaryInfo, executableErr error) (string, string) {
+	bitnessVar := "CORECLR_PROFILER_PATH_64"
+	if executableErr == nil && (executable.Machine == elf.EM_386 || executable.Machine == elf.EM_ARM) {
+		bitnessVar = "CORECLR_PROFILER_PATH_32"
+	}
+	if value := envVars[bitnessVar]; value != "" {
+		return bitnessVar, value
+	}
+	return "CORECLR_PROFILER_PATH", envVars["CORECLR_PROFILER_PATH"]
+}
+
+// checkArchitecture compares the profiler library with the process executable, a library built for another
+// architecture or C library is silently not loaded by the runtime
+func (t DotNetCoreProfilerAttach) checkArchitecture(pid int32, profilerPath string, process binaryInfo, processErr error, add func(string, tasks.Status, string)) {
+	const name = "Profiler architecture"
+	profiler, err := t.inspectBinary(rootPath(pid, profilerPath))
+	if err != nil {
+		add(name, tasks.Failure, fmt.Sprintf("%s is not a Linux shared library: %s", profilerPath, err.Error()))
+		return
+	}
+	if processErr != nil {
+		add(name, tasks.Warning, "Unable to read the process executable to compare architectures. Run as root or as the process owner.")
+		return
+	}
+	if profiler.Machine != process.Machine {
+		add(name, tasks.Failure, fmt.Sprintf("%s is built for %s but the process runs on %s", profilerPath, machineName(profiler.Machine), machineName(process.Machine)))
+		return
+	}
+	if profiler.Musl != process.Musl {
+		add(name, tasks.Failure, fmt.Sprintf("%s is built for %s but the process uses %s, use the %s build of the profiler", profilerPath, libcName(profiler.Musl), libcName(process.Musl), libcName(process.Musl)))
+		return
+	}
+	add(name, tasks.Success, fmt.Sprintf("The profiler matches the process architecture, %s with %s", machineName(process.Machine), libcName(process.Musl)))
+}
+
+func (t DotNetCoreProfilerAttach) checkAgentHome(pid int32, envVars map[string]string, add func(string, tasks.Status, string)) {
+	const name = "CORECLR_NEWRELIC_HOME"
+	home := envVars["CORECLR_NEWRELIC_HOME"]
+	if home == "" {
+		home = envVars["CORECLR_NEW_RELIC_HOME"]
+	}
+	if home == "" {
+		add(name, tasks.Failure, "CORECLR_NEWRELIC_HOME is not set")
+		return
+	}
+	var missing []string
+	for _, entry := range agentHomeLayout {
+		_, err := t.stat(rootPath(pid, filepath.Join(home, entry)))
+		if os.IsNotExist(err) {
+			missing = append(missing, entry)
+		} else if err != nil {
+			log.Debug("DotNetCoreProfilerAttach - unable to stat the agent home", err)
+			add(name, tasks.Warning, fmt.Sprintf("Unable to check CORECLR_NEWRELIC_HOME %s contains the agent. Run as root or as the process owner.", home))
+			return
+		}
+	}
+	if len(missing) > 0 {
+		add(name, tasks.Failure, fmt.Sprintf("CORECLR_NEWRELIC_HOME %s is missing %s", home, strings.Join(missing, ", ")))
+		return
+	}
+	add(name, tasks.Success, "CORECLR_NEWRELIC_HOME "+home+" contains the agent")
+}
+
+// checkLoaded looks for the profiler library in the memory mappings of the process
+func (t DotNetCoreProfilerAttach) checkLoaded(pid int32, profilerPath string, add func(string, tasks.Status, string)) {
+	const name = "Profiler loaded"
+	maps, err := t.readFile(procPath(pid, "maps"))
+	if err != nil {
+		log.Debug("DotNetCoreProfilerAttach - unable to read maps", err)
+		add(name, tasks.Warning, "Unable to read the process memory mappings to confirm the profiler loaded. Run as root or as the process owner.")
+		return
+	}
+	libraries := tasks.MappedFiles(string(maps))
+	for _, library := range libraries {
+		if (profilerPath != "" && library == filepath.Clean(profilerPath)) || filepath.Base(library) == "libNewRelicProfiler.so" {
+			add(name, tasks.Success, "The profiler is loaded from "+library)
+			return
+		}
+	}
+	add(name, tasks.Failure, "The profiler is not loaded in the process. Restart the application after fixing its environment.")
+}
+
+func procPath(pid int32, name string) string {
+	return filepath.Join("/proc", strconv.Itoa(int(pid)), name)
+}
+
+// rootPath resolves a path the process sees under its root directory, so paths inside containers are found from the host
+func rootPath(pid int32, path string) string {
+	return filepath.Join(procPath(pid, "root"), path)
+}
+
+func containsMusl(name string) bool {
+	return strings.Contains(name, "musl")
+}
+
+func machineName(machine elf.Machine) string {
+	switch machine {
+	case elf.EM_X86_64:
+		return "x64"
+	case elf.EM_AARCH64:
+		return "arm64"
+	}
+	return strings.TrimPrefix(machine.String(), "EM_")
+}
+
+func libcName(musl bool) string {
+	if musl {
+		return "musl (Alpine)"
+	}
+	return "glibc"
+}
@@ -0,0 +1,272 @@
+package profiler
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/dotnetcore/env"
+)
+
+const testProfilerPath = "/usr/local/newrelic-dotnet-agent/libNewRelicProfiler.so"
+
+var testMaps = `55d0c2a00000-55d0c2a0b000 r--p 00000000 08:01 1311 /usr/share/dotnet/dotnet
+7f3a1c000000-7f3a1c021000 rw-p 00000000 00:00 0
+7f3a1d200000-7f3a1d4f2000 r-xp 00000000 08:01 2040 /usr/local/newrelic-dotnet-agent/libNewRelicProfiler.so
+7f3a1e000000-7f3a1e1b7000 r-xp 00000000 08:01 1042 /usr/lib/x86_64-linux-gnu/libc.so.6
+7ffd5a1f0000-7ffd5a211000 rw-p 00000000 00:00 0 [stack]
+`
+
+func goodEnvVars() map[string]string {
+	return map[string]string{
+		"CORECLR_ENABLE_PROFILING": "1",
+		"CORECLR_PROFILER":         "{36032161-ffc0-4b61-b559-f6c5d41bae5a}",
+		"CORECLR_PROFILER_PATH":    testProfilerPath,
+		"CORECLR_NEWRELIC_HOME":    "/usr/local/newrelic-dotnet-agent",
+	}
+}
+
+func withEnv(key, value string) map[string]string {
+	envVars := goodEnvVars()
+	if value == "" {
+		delete(envVars, key)
+	} else {
+		envVars[key] = value
+	}
+	return envVars
+}
+
+// statExisting fakes the files found under the root of process 42
+func statExisting(paths ...string) func(string) (os.FileInfo, error) {
+	return func(path string) (os.FileInfo, error) {
+		for _, existing := range paths {
+			if path == "/proc/42/root"+existing {
+				return nil, nil
+			}
+		}
+		return nil, os.ErrNotExist
+	}
+}
+
+var agentInstalled = statExisting(
+	testProfilerPath,
+	"/usr/local/newrelic-dotnet-agent/NewRelic.Agent.Core.dll",
+	"/usr/local/newrelic-dotnet-agent/newrelic.config",
+	"/usr/local/newrelic-dotnet-agent/extensions",
+)
+
+func binaries(profiler, process binaryInfo) func(string) (binaryInfo, error) {
+	return func(path string) (binaryInfo, error) {
+		if path == "/proc/42/root"+testProfilerPath {
+			return profiler, nil
+		}
+		return process, nil
+	}
+}
+
+var glibcX64 = binaryInfo{Machine: elf.EM_X86_64}
+
+func TestDotNetCoreProfilerAttach_Execute(t *testing.T) {
+	tests := []struct {
+		name          string
+		envVars       map[string]string
+		stat          func(string) (os.FileInfo, error)
+		inspectBinary func(string) (binaryInfo, error)
+		readFile      func(string) ([]byte, error)
+		wantStatus    tasks.Status
+		wantSummary   string
+	}{
+		{
+			name:        "profiler configured and loaded",
+			envVars:     goodEnvVars(),
+			wantStatus:  tasks.Success,
+			wantSummary: "configured and loaded in all 1 dotnet processes",
+		},
+		{
+			name:        "profiling disabled",
+			envVars:     withEnv("CORECLR_ENABLE_PROFILING", "0"),
+			wantStatus:  tasks.Failure,
+			wantSummary: `PID 42: CORECLR_ENABLE_PROFILING is "0", it must be 1`,
+		},
+		{
+			name:        "another profiler",
+			envVars:     withEnv("CORECLR_PROFILER", "{846F5F1C-F9AE-4B07-969E-05C26BC060D8}"),
+			wantStatus:  tasks.Failure,
+			wantSummary: "CORECLR_PROFILER is",
+		},
+		{
+			name:        "profiler path missing",
+			envVars:     withEnv("CORECLR_PROFILER_PATH", "/opt/newrelic/libNewRelicProfiler.so"),
+			wantStatus:  tasks.Failure,
+			wantSummary: "CORECLR_PROFILER_PATH /opt/newrelic/libNewRelicProfiler.so does not exist",
+		},
+		{
+			name: "profiler path of the process bitness takes precedence",
+			envVars: map[string]string{
+				"CORECLR_ENABLE_PROFILING": "1",
+				"CORECLR_PROFILER":         newRelicProfilerGUID,
+				"CORECLR_PROFILER_PATH":    "/opt/newrelic/x86/libNewRelicProfiler.so",
+				"CORECLR_PROFILER_PATH_64": testProfilerPath,
+				"CORECLR_NEWRELIC_HOME":    "/usr/local/newrelic-dotnet-agent",
+			},
+			wantStatus:  tasks.Success,
+			wantSummary: "configured and loaded in all 1 dotnet processes",
+		},
+		{
+			name: "32-bit profiler path missing",
+			envVars: map[string]string{
+				"CORECLR_ENABLE_PROFILING": "1",
+				"CORECLR_PROFILER":         newRelicProfilerGUID,
+				"CORECLR_PROFILER_PATH_32": "/opt/newrelic/x86/libNewRelicProfiler.so",
+				"CORECLR_PROFILER_PATH_64": testProfilerPath,
+				"CORECLR_NEWRELIC_HOME":    "/usr/local/newrelic-dotnet-agent",
+			},
+			inspectBinary: binaries(glibcX64, binaryInfo{Machine: elf.EM_386}),
+			wantStatus:    tasks.Failure,
+			wantSummary:   "CORECLR_PROFILER_PATH_32 /opt/newrelic/x86/libNewRelicProfiler.so does not exist",
+		},
+		{
+			name:    "profiler only exists on the host",
+			envVars: goodEnvVars(),
+			stat: func(path string) (os.FileInfo, error) {
+				return agentInstalled(strings.TrimPrefix(path, "/proc/42/root"))
+			},
+			wantStatus:  tasks.Failure,
+			wantSummary: "CORECLR_PROFILER_PATH " + testProfilerPath + " does not exist",
+		},
+		{
+			name:        "process root not readable",
+			envVars:     goodEnvVars(),
+			stat:        func(string) (os.FileInfo, error) { return nil, os.ErrPermission },
+			wantStatus:  tasks.Warning,
+			wantSummary: "Unable to check CORECLR_PROFILER_PATH " + testProfilerPath + " exists",
+		},
+		{
+			name:          "profiler built for another architecture",
+			envVars:       goodEnvVars(),
+			inspectBinary: binaries(binaryInfo{Machine: elf.EM_AARCH64}, glibcX64),
+			wantStatus:    tasks.Failure,
+			wantSummary:   "is built for arm64 but the process runs on x64",
+		},
+		{
+			name:          "glibc profiler in an Alpine process",
+			envVars:       goodEnvVars(),
+			inspectBinary: binaries(glibcX64, binaryInfo{Machine: elf.EM_X86_64, Musl: true}),
+			wantStatus:    tasks.Failure,
+			wantSummary:   "use the musl (Alpine) build of the profiler",
+		},
+		{
+			name:        "agent home missing files",
+			envVars:     goodEnvVars(),
+			stat:        statExisting(testProfilerPath, "/usr/local/newrelic-dotnet-agent/newrelic.config"),
+			wantStatus:  tasks.Failure,
+			wantSummary: "is missing NewRelic.Agent.Core.dll, extensions",
+		},
+		{
+			name:        "agent home not set",
+			envVars:     withEnv("CORECLR_NEWRELIC_HOME", ""),
+			wantStatus:  tasks.Failure,
+			wantSummary: "CORECLR_NEWRELIC_HOME is not set",
+		},
+		{
+			name:        "profiler not loaded",
+			envVars:     goodEnvVars(),
+			readFile:    func(string) ([]byte, error) { return []byte(strings.Split(testMaps, "\n")[0]), nil },
+			wantStatus:  tasks.Failure,
+			wantSummary: "The profiler is not loaded in the process",
+		},
+		{
+			name:        "maps not readable",
+			envVars:     goodEnvVars(),
+			readFile:    func(string) ([]byte, error) { return nil, os.ErrPermission },
+			wantStatus:  tasks.Warning,
+			wantSummary: "Unable to read the process memory mappings",
+		},
+		{
+			name:    "process executable not readable",
+			envVars: goodEnvVars(),
+			inspectBinary: func(path string) (binaryInfo, error) {
+				if path == "/proc/42/exe" {
+					return binaryInfo{}, os.ErrPermission
+				}
+				return glibcX64, nil
+			},
+			wantStatus:  tasks.Warning,
+			wantSummary: "Unable to read the process executable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := DotNetCoreProfilerAttach{
+				goos:          "linux",
+				readFile:      func(string) ([]byte, error) { return []byte(testMaps), nil },
+				stat:          agentInstalled,
+				inspectBinary: binaries(glibcX64, glibcX64),
+			}
+			if tt.stat != nil {
+				task.stat = tt.stat
+			}
+			if tt.inspectBinary != nil {
+				task.inspectBinary = tt.inspectBinary
+			}
+			if tt.readFile != nil {
+				task.readFile = tt.readFile
+			}
+			upstream := map[string]tasks.Result{
+				"DotNetCore/Env/Process": {
+					Status:  tasks.Success,
+					Payload: []env.ProcessArgs{{Pid: 42, EnvVars: tt.envVars}},
+				},
+			}
+
+			result := task.Execute(tasks.Options{}, upstream)
+			if result.Status != tt.wantStatus {
+				t.Errorf("Execute() status = %v, want %v: %s", result.Status, tt.wantStatus, result.Summary)
+			}
+			if !strings.Contains(result.Summary, tt.wantSummary) {
+				t.Errorf("Execute() summary = %q, want it to contain %q", result.Summary, tt.wantSummary)
+			}
+			payload := result.Payload.([]ProcessProfilerResult)
+			if len(payload) != 1 || payload[0].Pid != 42 || payload[0].Status != tt.wantStatus {
+				t.Errorf("Execute() payload = %+v", payload)
+			}
+		})
+	}
+}
+
+func TestDotNetCoreProfilerAttach_ExecuteSkips(t *testing.T) {
+	task := DotNetCoreProfilerAttach{goos: "windows"}
+	if result := task.Execute(tasks.Options{}, nil); result.Status != tasks.None {
+		t.Errorf("Execute() on windows status = %v, want None", result.Status)
+	}
+	task.goos = "linux"
+	upstream := map[string]tasks.Result{"DotNetCore/Env/Process": {Status: tasks.None}}
+	if result := task.Execute(tasks.Options{}, upstream); result.Status != tasks.None {
+		t.Errorf("Execute() without processes status = %v, want None", result.Status)
+	}
+}
+
+func Test_inspectBinary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ELF binaries are only built on Linux")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := inspectBinary(executable)
+	if err != nil {
+		t.Fatalf("inspectBinary() error = %v", err)
+	}
+	want := map[string]elf.Machine{"amd64": elf.EM_X86_64, "arm64": elf.EM_AARCH64, "386": elf.EM_386}[runtime.GOARCH]
+	if want != 0 && info.Machine != want {
+		t.Errorf("inspectBinary() machine = %v, want %v", info.Machine, want)
+	}
+	if _, err := inspectBinary("/proc/self/maps"); err == nil || errors.Is(err, os.ErrNotExist) {
+		t.Errorf("inspectBinary() of a text file error = %v", err)
+	}
+}
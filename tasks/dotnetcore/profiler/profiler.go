@@ -0,0 +1,55 @@
+package profiler
+
+import (
+	"debug/elf"
+	"os"
+	"runtime"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering DotNetCore/Profiler/*")
+
+	registrationFunc(DotNetCoreProfilerAttach{
+		goos:          runtime.GOOS,
+		readFile:      os.ReadFile,
+		stat:          os.Stat,
+		inspectBinary: inspectBinary,
+	}, true)
+}
+
+// binaryInfo is the architecture and C library an ELF binary was built for
+type binaryInfo struct {
+	Machine elf.Machine
+	Musl    bool
+}
+
+// inspectBinary reads the ELF headers of a binary. Musl builds load ld-musl as their interpreter or link against libc.musl.
+func inspectBinary(path string) (binaryInfo, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return binaryInfo{}, err
+	}
+	defer file.Close()
+
+	info := binaryInfo{Machine: file.Machine}
+	for _, prog := range file.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		interp := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(interp, 0); err == nil && containsMusl(string(interp)) {
+			info.Musl = true
+		}
+	}
+	libraries, _ := file.ImportedLibraries()
+	for _, library := range libraries {
+		if containsMusl(library) {
+			info.Musl = true
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+// +build !windows
+
+package tasks
+
+import (
+	"os"
+	"syscall"
+)
+
+// FileOwner - returns the uid and gid owning a file
+func FileOwner(info os.FileInfo) (uint32, uint32, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return stat.Uid, stat.Gid, true
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package tasks
+
+import "os"
+
+// FileOwner - is not available on Windows, where access is controlled by ACLs
+func FileOwner(info os.FileInfo) (uint32, uint32, bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,224 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const dsymDocsURL = "https://docs.newrelic.com/docs/mobile-monitoring/new-relic-mobile-ios/configuration/upload-dsyms-bitcode-apps/"
+
+var (
+	podfileLockRegex  = regexp.MustCompile(`(?m)^\s*-\s*NewRelicAgent(?:/[\w-]+)?\s+\(([^)]+)\)`)
+	cartfileRegex     = regexp.MustCompile(`(?mi)^(?:binary|github|git)\s+"([^"]*newrelic[^"]*)"\s+"v?([^"]+)"`)
+	symbolToolRegex   = regexp.MustCompile(`run-symbol-tool|newrelic_postbuild\.sh`)
+	appTokenRegex     = regexp.MustCompile(`((?:run-symbol-tool|newrelic_postbuild\.sh)"?\s+)"?[^"\s]+"?`)
+	dwarfWithDSYMRgx  = regexp.MustCompile(`DEBUG_INFORMATION_FORMAT\s*=\s*"?dwarf-with-dsym"?`)
+	newRelicSPMRegex  = regexp.MustCompile(`(?i)newrelic`)
+	versionPrefixRgx  = regexp.MustCompile(`^v`)
+	dependencyManager = map[string]string{
+		"Podfile.lock":      "CocoaPods",
+		"Package.resolved":  "Swift Package Manager",
+		"Cartfile.resolved": "Carthage",
+	}
+)
+
+// iOSConfigBuild - reads the dependency manager lock files and Xcode project of an iOS app for the New Relic agent
+type iOSConfigBuild struct {
+	readFile func(string) ([]byte, error)
+}
+
+// AgentSource - the NewRelicAgent version a dependency manager resolved, or the one in the agent header
+type AgentSource struct {
+	Source  string
+	File    string
+	Version string
+}
+
+// IOSBuild - the New Relic setup found in the iOS project
+type IOSBuild struct {
+	Sources         []AgentSource
+	ProjectFile     string
+	DSYMUploadPhase string
+	Problems        []string
+}
+
+type packageResolved struct {
+	Pins   []packagePin `json:"pins"`
+	Object struct {
+		Pins []packagePin `json:"pins"`
+	} `json:"object"`
+}
+
+type packagePin struct {
+	Location      string `json:"location"`
+	RepositoryURL string `json:"repositoryURL"`
+	State         struct {
+		Version  string `json:"version"`
+		Revision string `json:"revision"`
+	} `json:"state"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p iOSConfigBuild) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("iOS/Config/Build")
+}
+
+// Explain - Returns the help text for each individual task
+func (p iOSConfigBuild) Explain() string {
+	return "Check the New Relic iOS agent version resolved by CocoaPods, Swift Package Manager or Carthage and the dSYM upload build phase"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p iOSConfigBuild) Dependencies() []string {
+	return []string{
+		"Base/Config/Collect",
+		"iOS/Agent/Version",
+	}
+}
+
+// Execute - Parses Podfile.lock, Package.resolved, Cartfile.resolved and project.pbxproj collected by Base/Config/Collect
+func (p iOSConfigBuild) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	configs, _ := upstream["Base/Config/Collect"].Payload.([]config.ConfigElement)
+
+	var build IOSBuild
+	var projects []string
+	for _, element := range configs {
+		path := filepath.Join(element.FilePath, element.FileName)
+		switch element.FileName {
+		case "Podfile.lock", "Package.resolved", "Cartfile.resolved":
+			content, err := p.readFile(path)
+			if err != nil {
+				log.Debug("could not read " + path + ": " + err.Error())
+				continue
+			}
+			version, err := resolvedVersion(element.FileName, content)
+			if err != nil {
+				build.Problems = append(build.Problems, fmt.Sprintf("%s could not be parsed: %s", path, err))
+				continue
+			}
+			if version != "" {
+				build.Sources = append(build.Sources, AgentSource{Source: dependencyManager[element.FileName], File: path, Version: version})
+			}
+		case "project.pbxproj":
+			projects = append(projects, path)
+		}
+	}
+	if version, ok := upstream["iOS/Agent/Version"].Payload.(string); ok && version != "" {
+		build.Sources = append(build.Sources, AgentSource{Source: "NewRelic.h", Version: strings.TrimSpace(version)})
+	}
+
+	if len(build.Sources) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The New Relic iOS agent was not found in Podfile.lock, Package.resolved, Cartfile.resolved or NewRelic.h.",
+		}
+	}
+
+	status := tasks.Success
+	if len(build.Problems) > 0 {
+		status = tasks.Warning
+	}
+	versions := map[string]bool{}
+	var lines []string
+	for _, source := range build.Sources {
+		versions[source.Version] = true
+		lines = append(lines, fmt.Sprintf("%s: %s", source.Source, source.Version))
+	}
+	if len(versions) > 1 {
+		status = tasks.Warning
+		build.Problems = append(build.Problems, "The agent versions found do not agree, the app may embed a different agent than the one declared.")
+	}
+
+	dsymChecked := false
+	for _, project := range projects {
+		content, err := p.readFile(project)
+		if err != nil {
+			log.Debug("could not read " + project + ": " + err.Error())
+			continue
+		}
+		dsymChecked = true
+		if phase := dsymBuildPhase(content); phase != "" {
+			build.ProjectFile = project
+			build.DSYMUploadPhase = phase
+			if !dwarfWithDSYMRgx.Match(content) {
+				status = tasks.Warning
+				build.Problems = append(build.Problems, project+" never sets DEBUG_INFORMATION_FORMAT to dwarf-with-dsym, no dSYM is generated for the upload build phase.")
+			}
+		}
+	}
+	switch {
+	case !dsymChecked:
+		lines = append(lines, "project.pbxproj was not collected, the dSYM upload build phase was not checked.")
+	case build.DSYMUploadPhase == "":
+		status = tasks.Warning
+		build.Problems = append(build.Problems, "No build phase runs run-symbol-tool, dSYMs are not uploaded and crashes are not symbolicated.")
+	default:
+		lines = append(lines, "dSYM upload build phase: "+build.DSYMUploadPhase)
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: "New Relic iOS agent versions found:\n" + strings.Join(lines, "\n"),
+		Payload: build,
+	}
+	if len(build.Problems) > 0 {
+		result.Summary += "\n" + strings.Join(build.Problems, "\n")
+		result.URL = dsymDocsURL
+	}
+	return result
+}
+
+// resolvedVersion - the NewRelicAgent version in a dependency manager lock file, empty when the app does not depend on it
+func resolvedVersion(fileName string, content []byte) (string, error) {
+	switch fileName {
+	case "Podfile.lock":
+		if m := podfileLockRegex.FindSubmatch(content); m != nil {
+			return string(m[1]), nil
+		}
+	case "Cartfile.resolved":
+		if m := cartfileRegex.FindSubmatch(content); m != nil {
+			return string(m[2]), nil
+		}
+	case "Package.resolved":
+		var resolved packageResolved
+		if err := json.Unmarshal(content, &resolved); err != nil {
+			return "", err
+		}
+		// version 1 nests the pins in object, version 2 and later have them at the top level
+		for _, pin := range append(resolved.Pins, resolved.Object.Pins...) {
+			if !newRelicSPMRegex.MatchString(pin.Location + pin.RepositoryURL) {
+				continue
+			}
+			if pin.State.Version != "" {
+				return versionPrefixRgx.ReplaceAllString(pin.State.Version, ""), nil
+			}
+			return "revision " + pin.State.Revision, nil
+		}
+	}
+	return "", nil
+}
+
+// dsymBuildPhase - the shell script line of project.pbxproj running the New Relic symbol tool, with its app token redacted
+func dsymBuildPhase(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.Contains(line, "shellScript") || !symbolToolRegex.MatchString(line) {
+			continue
+		}
+		// the script is a quoted string with escaped quotes and newlines
+		for _, scriptLine := range strings.Split(line, `\n`) {
+			if symbolToolRegex.MatchString(scriptLine) {
+				scriptLine = strings.ReplaceAll(scriptLine, `\"`, `"`)
+				scriptLine = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(scriptLine), `shellScript = "`))
+				return appTokenRegex.ReplaceAllString(scriptLine, `$1"_REDACTED_"`)
+			}
+		}
+	}
+	return ""
+}
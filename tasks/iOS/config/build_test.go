@@ -0,0 +1,162 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const (
+	podfileLock = `PODS:
+  - NewRelicAgent (7.4.8)
+
+DEPENDENCIES:
+  - NewRelicAgent
+
+COCOAPODS: 1.12.1
+`
+	packageResolvedV2 = `{
+  "pins" : [
+    {
+      "identity" : "newrelic-ios-agent-spm",
+      "kind" : "remoteSourceControl",
+      "location" : "https://github.com/newrelic/newrelic-ios-agent-spm",
+      "state" : {
+        "revision" : "0a1b2c3d",
+        "version" : "7.4.9"
+      }
+    }
+  ],
+  "version" : 2
+}`
+	packageResolvedV1 = `{"object":{"pins":[{"package":"NewRelic","repositoryURL":"https://github.com/newrelic/newrelic-ios-agent-spm","state":{"branch":null,"revision":"0a1b2c3d","version":"7.4.8"}}]},"version":1}`
+	cartfileResolved  = `binary "https://download.newrelic.com/ios-v5/NewRelic.json" "7.4.8"` + "\n"
+	pbxproj           = `		7A1B2C3D /* ShellScript */ = {
+			isa = PBXShellScriptBuildPhase;
+			shellScript = "ARTIFACT_DIR=\"${BUILD_DIR%Build/*}\"\n\"${PODS_ROOT}/NewRelicAgent/NewRelic.xcframework/Resources/run-symbol-tool\" \"AA0123456789abcdef0123456789abcdef01234567-NRMA\"\n";
+		};
+				DEBUG_INFORMATION_FORMAT = "dwarf-with-dsym";
+`
+)
+
+func mockIOSProject(files map[string]string, headerVersion string) (iOSConfigBuild, map[string]tasks.Result) {
+	var elements []config.ConfigElement
+	contents := map[string]string{}
+	for path, content := range files {
+		dir, name := filepath.Split(filepath.FromSlash(path))
+		elements = append(elements, config.ConfigElement{FileName: name, FilePath: dir})
+		contents[filepath.Join(dir, name)] = content
+	}
+	p := iOSConfigBuild{
+		readFile: func(path string) ([]byte, error) {
+			content, ok := contents[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return []byte(content), nil
+		},
+	}
+	upstream := map[string]tasks.Result{
+		"Base/Config/Collect": {Status: tasks.Success, Payload: elements},
+	}
+	if headerVersion != "" {
+		upstream["iOS/Agent/Version"] = tasks.Result{Status: tasks.Info, Payload: headerVersion}
+	}
+	return p, upstream
+}
+
+func TestIOSConfigBuild_Identifier(t *testing.T) {
+	want := tasks.Identifier{Category: "iOS", Subcategory: "Config", Name: "Build"}
+	if got := (iOSConfigBuild{}).Identifier(); !reflect.DeepEqual(got, want) {
+		t.Errorf("iOSConfigBuild.Identifier() = %v, want %v", got, want)
+	}
+}
+
+func TestIOSConfigBuild_Execute(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         map[string]string
+		headerVersion string
+		wantStatus    tasks.Status
+		wantVersions  []string
+		wantPhase     string
+		wantProblems  []string
+	}{
+		{
+			name:       "It should return None without the agent",
+			files:      map[string]string{"Podfile.lock": "PODS:\n  - Alamofire (5.8.0)\n"},
+			wantStatus: tasks.None,
+		},
+		{
+			name: "It should report the CocoaPods version and the redacted dSYM upload phase",
+			files: map[string]string{
+				"Podfile.lock":                  podfileLock,
+				"App.xcodeproj/project.pbxproj": pbxproj,
+			},
+			headerVersion: "7.4.8",
+			wantStatus:    tasks.Success,
+			wantVersions:  []string{"7.4.8", "7.4.8"},
+			wantPhase:     `"${PODS_ROOT}/NewRelicAgent/NewRelic.xcframework/Resources/run-symbol-tool" "_REDACTED_"`,
+		},
+		{
+			name: "It should read Package.resolved v1 and Cartfile.resolved without the project file",
+			files: map[string]string{
+				"App.xcworkspace/xcshareddata/swiftpm/Package.resolved": packageResolvedV1,
+				"Cartfile.resolved": cartfileResolved,
+			},
+			wantStatus:   tasks.Success,
+			wantVersions: []string{"7.4.8", "7.4.8"},
+		},
+		{
+			name: "It should warn when versions disagree and no dSYM is uploaded",
+			files: map[string]string{
+				"Package.resolved":              packageResolvedV2,
+				"Podfile.lock":                  podfileLock,
+				"App.xcodeproj/project.pbxproj": "DEBUG_INFORMATION_FORMAT = dwarf;\n",
+			},
+			wantStatus:   tasks.Warning,
+			wantVersions: []string{"7.4.9", "7.4.8"},
+			wantProblems: []string{
+				"The agent versions found do not agree, the app may embed a different agent than the one declared.",
+				"No build phase runs run-symbol-tool, dSYMs are not uploaded and crashes are not symbolicated.",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, upstream := mockIOSProject(tt.files, tt.headerVersion)
+			result := p.Execute(tasks.Options{}, upstream)
+			if result.Status != tt.wantStatus {
+				t.Fatalf("iOSConfigBuild.Execute() status = %v, want %v: %s", result.Status, tt.wantStatus, result.Summary)
+			}
+			if tt.wantStatus == tasks.None {
+				return
+			}
+			build := result.Payload.(IOSBuild)
+			var versions []string
+			for _, source := range build.Sources {
+				versions = append(versions, source.Version)
+			}
+			sort.Strings(versions)
+			sort.Strings(tt.wantVersions)
+			if !reflect.DeepEqual(versions, tt.wantVersions) {
+				t.Errorf("iOSConfigBuild.Execute() versions = %v, want %v", versions, tt.wantVersions)
+			}
+			if build.DSYMUploadPhase != tt.wantPhase {
+				t.Errorf("iOSConfigBuild.Execute() dSYM phase = %q, want %q", build.DSYMUploadPhase, tt.wantPhase)
+			}
+			if !reflect.DeepEqual(build.Problems, tt.wantProblems) {
+				t.Errorf("iOSConfigBuild.Execute() problems = %q, want %q", build.Problems, tt.wantProblems)
+			}
+			if strings.Contains(result.Summary, "NRMA") {
+				t.Errorf("iOSConfigBuild.Execute() summary contains the application token: %s", result.Summary)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -8,5 +10,5 @@ import (
 // RegisterWith - will register any plugins in this package
 func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering iOS/Config/*")
-
+	registrationFunc(iOSConfigBuild{readFile: os.ReadFile}, true)
 }
@@ -1,10 +1,14 @@
 package agent
 
 import (
+	"io"
 	"net/http"
+	"os"
 	"runtime"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/process"
+
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
@@ -27,7 +31,21 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		cmdExecutor:          tasks.CmdExecutor,
 	}, false)
 	registrationFunc(InfraAgentConnect{
+		httpGetter:         httpHelper.MakeHTTPRequest,
+		diagnoseConnection: httpHelper.DiagnoseConnection,
+	}, true)
+	registrationFunc(InfraAgentStatus{
 		httpGetter: httpHelper.MakeHTTPRequest,
 	}, true)
+	registrationFunc(InfraAgentInventory{
+		stat: os.Stat,
+		now:  time.Now,
+	}, true)
+	registrationFunc(InfraAgentIntegrations{
+		processes: process.Processes,
+		ppid:      (*process.Process).Ppid,
+		cmdLine:   (*process.Process).CmdlineSlice,
+		open:      func(path string) (io.ReadCloser, error) { return os.Open(path) },
+	}, true)
 
 }
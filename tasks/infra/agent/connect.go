@@ -8,12 +8,21 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
-	"golang.org/x/exp/maps"
 )
 
 // InfraAgentConnect - This struct tests the connector to Infrastructure
 type InfraAgentConnect struct {
-	httpGetter requestFunc
+	httpGetter         requestFunc
+	diagnoseConnection httpHelper.DiagnoseFunc
+}
+
+// infraEndpointProducts are the endpoints the infrastructure agent connects to
+var infraEndpointProducts = []tasks.EndpointProduct{
+	tasks.ProductInfra,
+	tasks.ProductInfraIdentity,
+	tasks.ProductInfraCommand,
+	tasks.ProductLogs,
+	tasks.ProductMetricAPI,
 }
 
 // RequestResult - contains HTTP response and error status data, Id is to distinguish requests from many, in this case region
@@ -23,6 +32,13 @@ type RequestResult struct {
 	StatusCode int
 	Body       string
 	Err        error
+	Diagnosis  *httpHelper.ConnectionDiagnosis
+}
+
+// EndpointConnection - whether nrdiag could connect to one infrastructure endpoint, the payload of Infra/Agent/Connect
+type EndpointConnection struct {
+	URL       string
+	Connected bool
 }
 
 // Identifier - This returns the Category, Subcategory and Name of each task
@@ -44,20 +60,13 @@ func (p InfraAgentConnect) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task connects to the infrastructure agent endpoints, so it is skipped in offline mode
+func (p InfraAgentConnect) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - The core work within each task
 func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
-	domains := map[string]string{
-		"us01": ".newrelic.com",
-		"eu01": ".eu.newrelic.com",
-	}
-	endpoints := []string{
-		"infra-api",
-		"identity-api",
-		"infrastructure-command-api",
-		"log-api",
-		"metric-api",
-	}
-
 	var result tasks.Result
 	var requestResults map[string]RequestResult
 	var requestURLs []string
@@ -68,34 +77,57 @@ func (p InfraAgentConnect) Execute(options tasks.Options, upstream map[string]ta
 		return result
 	}
 
-	regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string)
-
-	if (!ok) || len(regions) == 0 {
-		requestURLs = buildRequestURLs(endpoints, maps.Values(domains)...)
-	} else {
-		for _, region := range regions {
-			requestURLs = append(requestURLs, buildRequestURLs(endpoints, domains[region])...)
-		}
+	for _, region := range getRegions(upstream) {
+		requestURLs = append(requestURLs, buildRequestURLs(tasks.GetEndpoints(region, infraEndpointProducts...))...)
 	}
 
 	requestResults = makeRequests(requestURLs, p.httpGetter)
+	if p.diagnoseConnection != nil {
+		for url, requestResult := range requestResults {
+			if requestResult.Err != nil && requestResult.StatusCode == 0 {
+				diagnosis := p.diagnoseConnection(url)
+				requestResult.Diagnosis = &diagnosis
+				requestResults[url] = requestResult
+			}
+		}
+	}
 	summary, status := validateResponses(requestResults)
 
+	connections := make([]EndpointConnection, 0, len(requestURLs))
+	for _, url := range requestURLs {
+		connections = append(connections, EndpointConnection{URL: url, Connected: requestResults[url].connected()})
+	}
+
 	return tasks.Result{
 		Status:  status,
 		Summary: summary,
 		URL:     "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks/#infrastructure",
-		Payload: requestURLs,
+		Payload: connections,
 	}
 }
 
-func buildRequestURLs(endpoints []string, domains ...string) []string {
+// connected - whether the request reached the endpoint, which answers 404 to the GET of an ingest URL
+func (r RequestResult) connected() bool {
+	return r.Err == nil && (r.StatusCode == 404 || r.StatusCode == 200)
+}
+
+// getRegions returns the region given with -region or NEW_RELIC_REGION, or the regions detected from license keys.
+// Without either, both the US and EU endpoints are checked.
+func getRegions(upstream map[string]tasks.Result) []string {
+	if region := tasks.RequestedRegion(); region != "" {
+		return []string{region}
+	}
+	regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string)
+	if !ok || len(regions) == 0 {
+		return []string{tasks.RegionUS, tasks.RegionEU}
+	}
+	return regions
+}
+
+func buildRequestURLs(endpoints []tasks.Endpoint) []string {
 	var urls []string
-	for _, domain := range domains {
-		for _, endpoint := range endpoints {
-			url := "https://" + endpoint + domain
-			urls = append(urls, url)
-		}
+	for _, endpoint := range endpoints {
+		urls = append(urls, endpoint.URL())
 	}
 	return urls
 }
@@ -147,8 +179,11 @@ func validateResponses(requestResults map[string]RequestResult) (string, tasks.S
 			summary += "\nThere was an error connecting to " + url
 			summary += "\nPlease check network and proxy settings and try again or see -help for more options."
 			summary += "\nError = " + requestResult.Err.Error()
+			if requestResult.Diagnosis != nil {
+				summary += "\n" + requestResult.Diagnosis.Summary()
+			}
 			return summary, tasks.Failure
-		} else if requestResult.StatusCode == 404 || requestResult.StatusCode == 200 {
+		} else if requestResult.connected() {
 			log.Debug("Successfully connected")
 			summary += fmt.Sprintf(" Successfully connected to %s.", url)
 		} else {
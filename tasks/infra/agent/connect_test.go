@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	. "github.com/onsi/ginkgo/v2"
@@ -180,6 +181,13 @@ var _ = Describe("Infra/Agent/Connect", func() {
 			It("Should have a summary with unexpected response status and code", func() {
 				Expect(result.Summary).To(ContainSubstring("Unexpected Response: 403 Forbidden"))
 			})
+			It("Should report every endpoint as not connected in the payload", func() {
+				payload := result.Payload.([]EndpointConnection)
+				Expect(payload).To(HaveLen(5))
+				for _, connection := range payload {
+					Expect(connection.Connected).To(BeFalse())
+				}
+			})
 		})
 		Context("If region detect provides 0 regions", func() {
 			BeforeEach(func() {
@@ -204,7 +212,7 @@ var _ = Describe("Infra/Agent/Connect", func() {
 				}
 			})
 			It("Should have checked all 10 endpoints", func() {
-				payload, _ := result.Payload.([]string)
+				payload, _ := result.Payload.([]EndpointConnection)
 				Expect(len(payload)).To(Equal(10))
 			})
 			It("Should return a successful status", func() {
@@ -252,7 +260,7 @@ var _ = Describe("Infra/Agent/Connect", func() {
 				Expect(result.Status).To(Equal(tasks.Success))
 			})
 			It("Should have checked all 10 endpoints", func() {
-				payload, _ := result.Payload.([]string)
+				payload, _ := result.Payload.([]EndpointConnection)
 				Expect(len(payload)).To(Equal(10))
 			})
 			It("Should have a summary with success message", func() {
@@ -299,7 +307,7 @@ var _ = Describe("Infra/Agent/Connect", func() {
 				Expect(result.Status).To(Equal(tasks.Success))
 			})
 			It("Should have checked 5 region endpoints", func() {
-				payload, _ := result.Payload.([]string)
+				payload, _ := result.Payload.([]EndpointConnection)
 				Expect(len(payload)).To(Equal(5))
 			})
 			It("Should have a summary with success message for 5 eu endpoints", func() {
@@ -312,6 +320,38 @@ var _ = Describe("Infra/Agent/Connect", func() {
 			})
 		})
 
+		Context("If -region eu was given and the license keys are US keys", func() {
+
+			BeforeEach(func() {
+				config.RequestedRegion = config.EURegion
+				DeferCleanup(func() { config.RequestedRegion = config.NoRegion })
+				options = tasks.Options{}
+				upstream = map[string]tasks.Result{
+					"Infra/Config/Agent": {
+						Status: tasks.Success,
+					},
+					"Base/Config/RegionDetect": {
+						Status:  tasks.Success,
+						Payload: []string{"us01"},
+					},
+				}
+				p.httpGetter = func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: 404,
+						Status:     "404 Not Found",
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte("test body"))),
+					}, nil
+				}
+			})
+			It("Should only check the EU endpoints", func() {
+				payload, _ := result.Payload.([]EndpointConnection)
+				Expect(payload).To(HaveLen(5))
+				for _, connection := range payload {
+					Expect(connection.URL).To(ContainSubstring(".eu.newrelic.com"))
+				}
+			})
+		})
+
 		Context("If expected status code", func() {
 
 			BeforeEach(func() {
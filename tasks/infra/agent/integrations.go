@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var (
+	integrationNameRegex  = regexp.MustCompile(`integration_name="?([^"\s]+)`)
+	integrationErrorRegex = regexp.MustCompile(`level=(?:error|warn)`)
+	// agentProcessNames - Fluent Bit is also a child of the agent, it is reported by Infra/Logs/FluentBit
+	agentProcessNames = []string{"newrelic-infra", "newrelic-infra.exe"}
+	skipChildren      = []string{"fluent-bit", "fluent-bit.exe", "td-agent-bit", "td-agent-bit.exe"}
+)
+
+// InfraAgentIntegrations - This struct defines the task reporting the integration instances the agent is running and their last error
+type InfraAgentIntegrations struct {
+	processes func() ([]*process.Process, error)
+	ppid      func(*process.Process) (int32, error)
+	cmdLine   func(*process.Process) ([]string, error)
+	open      func(string) (io.ReadCloser, error)
+}
+
+// IntegrationInstance - a running integration process, or an integration only seen in the agent log.
+// Args and LastError have their credentials redacted.
+type IntegrationInstance struct {
+	Name      string
+	Pid       int32
+	Args      []string
+	LastError string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraAgentIntegrations) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Agent/Integrations")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraAgentIntegrations) Explain() string {
+	return "Report the integrations run by the New Relic Infrastructure agent and their last logged error"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraAgentIntegrations) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+		"Infra/Log/Collect",
+	}
+}
+
+// Execute - Lists the child processes of the agent and the last error or warning the agent logged for each integration
+func (p InfraAgentIntegrations) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Infrastructure Agent config not present.",
+		}
+	}
+
+	instances, agentRunning := p.runningIntegrations()
+
+	lastErrors := map[string]string{}
+	agentLogs, _ := upstream["Infra/Log/Collect"].Payload.([]string)
+	for _, agentLog := range agentLogs {
+		p.readLastErrors(agentLog, lastErrors)
+	}
+
+	// the agent logs the command line of failing integrations, so their credentials are redacted from the log lines too
+	var secrets []string
+	for _, instance := range instances {
+		secrets = append(secrets, tasks.ArgSecrets(instance.Args)...)
+	}
+	for name, line := range lastErrors {
+		lastErrors[name] = tasks.RedactSecrets(line, append(tasks.ArgSecrets(strings.Fields(line)), secrets...))
+	}
+
+	seen := map[string]bool{}
+	for i := range instances {
+		seen[instances[i].Name] = true
+		instances[i].Args = redactArgs(instances[i].Args)
+		instances[i].LastError = lastErrors[instances[i].Name]
+	}
+	var logged []string
+	for name := range lastErrors {
+		if !seen[name] {
+			logged = append(logged, name)
+		}
+	}
+	sort.Strings(logged)
+	for _, name := range logged {
+		instances = append(instances, IntegrationInstance{Name: name, LastError: lastErrors[name]})
+	}
+
+	if !agentRunning && len(instances) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The Infrastructure agent is not running and did not log any integration error.",
+		}
+	}
+
+	status := tasks.Info
+	var summary []string
+	if !agentRunning {
+		summary = append(summary, "The Infrastructure agent is not running.")
+	}
+	for _, instance := range instances {
+		line := instance.Name
+		if instance.Pid != 0 {
+			line += fmt.Sprintf(" is running (pid %d)", instance.Pid)
+		} else {
+			line += " is not running"
+		}
+		if instance.LastError != "" {
+			status = tasks.Warning
+			line += ", last error: " + instance.LastError
+		}
+		summary = append(summary, line)
+	}
+	if len(instances) == 0 {
+		summary = append(summary, "The agent is not running any integration.")
+	}
+
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: instances,
+	}
+}
+
+// runningIntegrations - the child processes of the agent, and whether the agent is running
+func (p InfraAgentIntegrations) runningIntegrations() ([]IntegrationInstance, bool) {
+	procs, err := p.processes()
+	if err != nil {
+		log.Debug("could not list processes:", err)
+		return nil, false
+	}
+
+	agentPids := map[int32]bool{}
+	names := make(map[int32]string, len(procs))
+	for _, proc := range procs {
+		args, err := p.cmdLine(proc)
+		if err != nil || len(args) == 0 {
+			continue
+		}
+		names[proc.Pid] = filepath.Base(args[0])
+		if tasks.ContainsString(agentProcessNames, names[proc.Pid]) {
+			agentPids[proc.Pid] = true
+		}
+	}
+
+	var instances []IntegrationInstance
+	for _, proc := range procs {
+		name, ok := names[proc.Pid]
+		if !ok || agentPids[proc.Pid] || tasks.ContainsString(skipChildren, name) {
+			continue
+		}
+		ppid, err := p.ppid(proc)
+		if err != nil || !agentPids[ppid] {
+			continue
+		}
+		args, _ := p.cmdLine(proc)
+		instances = append(instances, IntegrationInstance{
+			Name: strings.TrimSuffix(name, ".exe"),
+			Pid:  proc.Pid,
+			Args: args[1:],
+		})
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Pid < instances[j].Pid })
+	return instances, len(agentPids) > 0
+}
+
+// readLastErrors - keeps the last error or warning line the agent logged for each integration_name
+func (p InfraAgentIntegrations) readLastErrors(path string, lastErrors map[string]string) {
+	file, err := p.open(path)
+	if err != nil {
+		log.Debug("could not read " + path + ": " + err.Error())
+		return
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !integrationErrorRegex.MatchString(line) {
+			continue
+		}
+		if m := integrationNameRegex.FindStringSubmatch(line); m != nil {
+			lastErrors[m[1]] = line
+		}
+	}
+}
+
+// redactArgs - the command line of an integration with its credentials redacted
+func redactArgs(args []string) []string {
+	secrets := tasks.ArgSecrets(args)
+	redacted := make([]string, 0, len(args))
+	for _, arg := range args {
+		redacted = append(redacted, tasks.RedactSecrets(arg, secrets))
+	}
+	return redacted
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const agentLog = `time="2024-05-01T10:00:00Z" level=info msg="Integration health check finished with success" integration_name=nri-mysql
+time="2024-05-01T10:01:00Z" level=warn msg="integration exited with error state" error="exit status 1" integration_name=nri-mysql
+time="2024-05-01T10:02:00Z" level=error msg="integration exited with error state" error="exit status 2" integration_name=nri-mysql
+time="2024-05-01T10:03:00Z" level=error msg="cannot load config" integration_name="nri-redis"
+`
+
+var _ = Describe("Infra/Agent/Integrations", func() {
+	var (
+		p        InfraAgentIntegrations
+		procs    []*process.Process
+		cmdLines map[int32][]string
+		parents  map[int32]int32
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		procs = []*process.Process{{Pid: 1}, {Pid: 10}, {Pid: 11}, {Pid: 12}, {Pid: 13}}
+		cmdLines = map[int32][]string{
+			1:  {"/sbin/init"},
+			10: {"/usr/bin/newrelic-infra", "-config", "/etc/newrelic-infra.yml"},
+			11: {"/var/db/newrelic-infra/newrelic-integrations/bin/nri-mysql", "-metrics"},
+			12: {"/opt/fluent-bit/bin/fluent-bit", "-c", "/tmp/nr_fb_config"},
+			13: {"/usr/bin/nri-flex"},
+		}
+		parents = map[int32]int32{10: 1, 11: 10, 12: 10, 13: 1}
+		upstream = map[string]tasks.Result{
+			"Infra/Config/Agent": {Status: tasks.Success},
+			"Infra/Log/Collect":  {Status: tasks.Success, Payload: []string{"/var/log/newrelic-infra/newrelic-infra.log"}},
+		}
+		p = InfraAgentIntegrations{
+			processes: func() ([]*process.Process, error) { return procs, nil },
+			ppid:      func(proc *process.Process) (int32, error) { return parents[proc.Pid], nil },
+			cmdLine:   func(proc *process.Process) ([]string, error) { return cmdLines[proc.Pid], nil },
+			open: func(path string) (io.ReadCloser, error) {
+				if path != "/var/log/newrelic-infra/newrelic-infra.log" {
+					return nil, errors.New("no such file")
+				}
+				return io.NopCloser(strings.NewReader(agentLog)), nil
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Agent", Name: "Integrations"}))
+		})
+	})
+
+	Context("when the agent runs integrations that logged errors", func() {
+		It("should return Warning with the running instances and their last error", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Payload).To(Equal([]IntegrationInstance{
+				{Name: "nri-mysql", Pid: 11, Args: []string{"-metrics"}, LastError: strings.Split(agentLog, "\n")[2]},
+				{Name: "nri-redis", LastError: strings.Split(agentLog, "\n")[3]},
+			}))
+			Expect(result.Summary).To(HavePrefix("nri-mysql is running (pid 11), last error: "))
+			Expect(result.Summary).To(ContainSubstring("nri-redis is not running, last error: "))
+		})
+	})
+
+	Context("when the integration command line and its logged errors hold credentials", func() {
+		BeforeEach(func() {
+			cmdLines[11] = []string{"nri-mysql", "-password=hunter2", "-api_key", "abc123", "-dsn", "mysql://root:s3cret@db:3306"}
+			p.open = func(string) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(`time="2024-05-01T10:02:00Z" level=error msg="exec nri-mysql -password=hunter2 -api_key abc123 --token=t0k3n" integration_name=nri-mysql` + "\n" +
+					`time="2024-05-01T10:03:00Z" level=error msg="dial postgres://admin:pgpass@db:5432 failed" integration_name=nri-postgresql` + "\n")), nil
+			}
+		})
+
+		It("should redact them from the payload and summary", func() {
+			instances := result.Payload.([]IntegrationInstance)
+			Expect(instances[0].Args).To(Equal([]string{"-password=_REDACTED_", "-api_key", "_REDACTED_", "-dsn", "mysql://_REDACTED_@db:3306"}))
+			for _, secret := range []string{"hunter2", "abc123", "t0k3n", "s3cret", "pgpass"} {
+				Expect(result.Summary).ToNot(ContainSubstring(secret))
+				Expect(instances[0].LastError + instances[1].LastError).ToNot(ContainSubstring(secret))
+			}
+		})
+	})
+
+	Context("when the agent runs integrations without errors", func() {
+		BeforeEach(func() {
+			upstream["Infra/Log/Collect"] = tasks.Result{Status: tasks.None}
+		})
+
+		It("should return Info", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Summary).To(Equal("nri-mysql is running (pid 11)"))
+		})
+	})
+
+	Context("when the agent is not running and logged nothing", func() {
+		BeforeEach(func() {
+			procs = []*process.Process{{Pid: 1}}
+			upstream["Infra/Log/Collect"] = tasks.Result{Status: tasks.None}
+		})
+
+		It("should return None", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+})
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// staleInventoryAge - the agent refreshes its inventory plugins at least daily, an older cache means they stopped running
+const staleInventoryAge = 24 * time.Hour
+
+// InfraAgentInventory - This struct defines the task reporting the inventory plugins found in the agent's inventory cache
+type InfraAgentInventory struct {
+	stat func(string) (os.FileInfo, error)
+	now  func() time.Time
+}
+
+// InventoryPlugin - an inventory plugin, named category/term like in the Inventory UI, and when it last wrote the cache
+type InventoryPlugin struct {
+	Name    string
+	File    string
+	LastRun time.Time
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraAgentInventory) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Agent/Inventory")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraAgentInventory) Explain() string {
+	return "Report which New Relic Infrastructure agent inventory plugins ran and when"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraAgentInventory) Dependencies() []string {
+	return []string{
+		"Infra/Config/DataDirectoryCollect",
+	}
+}
+
+// Execute - Reads the plugin files of the inventory cache collected by Infra/Config/DataDirectoryCollect
+func (p InfraAgentInventory) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	dataDir := upstream["Infra/Config/DataDirectoryCollect"]
+	if dataDir.Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic Infrastructure data directory found. Task not executed.",
+		}
+	}
+
+	plugins := map[string]InventoryPlugin{}
+	for _, file := range dataDir.FilesToCopy {
+		if filepath.Ext(file.Path) != ".json" {
+			continue
+		}
+		info, err := p.stat(file.Path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		// the cache is <entity>/<category>/<term>.json, the agent keeps one copy per entity and one in .delta_repo
+		term := strings.TrimSuffix(filepath.Base(file.Path), ".json")
+		category := filepath.Base(filepath.Dir(file.Path))
+		name := category + "/" + term
+		if plugin, ok := plugins[name]; ok && !info.ModTime().After(plugin.LastRun) {
+			continue
+		}
+		plugins[name] = InventoryPlugin{Name: name, File: file.Path, LastRun: info.ModTime()}
+	}
+
+	if len(plugins) == 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "The agent inventory cache is empty, no inventory plugin has run. Check the agent log for inventory errors.",
+		}
+	}
+
+	var inventory []InventoryPlugin
+	var latest time.Time
+	for _, plugin := range plugins {
+		inventory = append(inventory, plugin)
+		if plugin.LastRun.After(latest) {
+			latest = plugin.LastRun
+		}
+	}
+	sort.Slice(inventory, func(i, j int) bool { return inventory[i].Name < inventory[j].Name })
+
+	var lines []string
+	for _, plugin := range inventory {
+		lines = append(lines, fmt.Sprintf("%s: %s", plugin.Name, plugin.LastRun.Format(time.RFC3339)))
+	}
+
+	if age := p.now().Sub(latest); age > staleInventoryAge {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("No inventory plugin has run since %s (%s ago), inventory is stale. Check the agent is running and the agent log for inventory errors.\n%s", latest.Format(time.RFC3339), age.Round(time.Hour), strings.Join(lines, "\n")),
+			Payload: inventory,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Info,
+		Summary: fmt.Sprintf("%d inventory plugin(s) in the agent cache:\n%s", len(inventory), strings.Join(lines, "\n")),
+		Payload: inventory,
+	}
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type mockFileInfo struct {
+	os.FileInfo
+	modTime time.Time
+}
+
+func (m mockFileInfo) ModTime() time.Time { return m.modTime }
+func (m mockFileInfo) IsDir() bool        { return false }
+
+var _ = Describe("Infra/Agent/Inventory", func() {
+	var (
+		p        InfraAgentInventory
+		now      = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		modTimes map[string]time.Time
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	dataDirectory := func(paths ...string) tasks.Result {
+		var files []tasks.FileCopyEnvelope
+		for _, path := range paths {
+			files = append(files, tasks.FileCopyEnvelope{Path: path, Identifier: "Infra/Config/DataDirectoryCollect"})
+		}
+		return tasks.Result{Status: tasks.Success, FilesToCopy: files}
+	}
+
+	BeforeEach(func() {
+		modTimes = map[string]time.Time{
+			"/var/db/newrelic-infra/data/host-1/metadata/system.json":           now.Add(-time.Hour),
+			"/var/db/newrelic-infra/data/host-1/packages/dpkg.json":             now.Add(-2 * time.Hour),
+			"/var/db/newrelic-infra/data/.delta_repo/host-1/packages/dpkg.json": now.Add(-time.Minute),
+			"/var/db/newrelic-infra/data/.delta_repo/host-1/packages/dpkg.sent": now,
+			"/var/db/newrelic-infra/data/host-1/config/sshd.json":               now.Add(-3 * time.Hour),
+		}
+		var paths []string
+		for path := range modTimes {
+			paths = append(paths, path)
+		}
+		upstream = map[string]tasks.Result{"Infra/Config/DataDirectoryCollect": dataDirectory(paths...)}
+		p = InfraAgentInventory{
+			stat: func(path string) (os.FileInfo, error) {
+				modTime, ok := modTimes[path]
+				if !ok {
+					return nil, errors.New("no such file")
+				}
+				return mockFileInfo{modTime: modTime}, nil
+			},
+			now: func() time.Time { return now },
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Agent", Name: "Inventory"}))
+		})
+	})
+
+	Context("when the data directory was not collected", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{"Infra/Config/DataDirectoryCollect": {Status: tasks.Error}}
+		})
+
+		It("should return None", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when the plugins ran recently", func() {
+		It("should return Info with each plugin and its latest run", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal([]InventoryPlugin{
+				{Name: "config/sshd", File: "/var/db/newrelic-infra/data/host-1/config/sshd.json", LastRun: now.Add(-3 * time.Hour)},
+				{Name: "metadata/system", File: "/var/db/newrelic-infra/data/host-1/metadata/system.json", LastRun: now.Add(-time.Hour)},
+				{Name: "packages/dpkg", File: "/var/db/newrelic-infra/data/.delta_repo/host-1/packages/dpkg.json", LastRun: now.Add(-time.Minute)},
+			}))
+			Expect(result.Summary).To(HavePrefix("3 inventory plugin(s) in the agent cache:\nconfig/sshd: 2024-05-01T09:00:00Z"))
+		})
+	})
+
+	Context("when no plugin ran in the last day", func() {
+		BeforeEach(func() {
+			now = now.Add(72 * time.Hour)
+		})
+		AfterEach(func() {
+			now = now.Add(-72 * time.Hour)
+		})
+
+		It("should return Warning", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(HavePrefix("No inventory plugin has run since 2024-05-01T11:59:00Z (72h0m0s ago)"))
+		})
+	})
+
+	Context("when the cache is empty", func() {
+		BeforeEach(func() {
+			upstream = map[string]tasks.Result{"Infra/Config/DataDirectoryCollect": dataDirectory("/var/db/newrelic-infra/data/host-1/.sent")}
+		})
+
+		It("should return Warning", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(HavePrefix("The agent inventory cache is empty"))
+		})
+	})
+})
@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const defaultStatusServerPort = "18003"
+
+// InfraAgentStatus - This struct defines the task reading the running agent's own view of its health from its status server
+type InfraAgentStatus struct {
+	httpGetter requestFunc
+}
+
+// AgentStatus - the status server response, with what nrdiag observed for the same endpoints
+type AgentStatus struct {
+	URL       string
+	Entity    AgentEntity
+	Endpoints []EndpointStatus
+}
+
+// AgentEntity - the entity the agent registered as, empty until it connects
+type AgentEntity struct {
+	GUID string `json:"guid"`
+	Key  string `json:"key"`
+}
+
+// EndpointStatus - the agent's reachability check of one endpoint. NrdiagReachable is nil when nrdiag did not check its host.
+type EndpointStatus struct {
+	URL             string `json:"url"`
+	Reachable       bool   `json:"reachable"`
+	Error           string `json:"error"`
+	NrdiagReachable *bool  `json:"-"`
+}
+
+type statusResponse struct {
+	Checks struct {
+		Endpoints []EndpointStatus `json:"endpoints"`
+	} `json:"checks"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraAgentStatus) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Agent/Status")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraAgentStatus) Explain() string {
+	return "Query the running New Relic Infrastructure agent status server for its view of its connectivity"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraAgentStatus) Dependencies() []string {
+	return []string{
+		"Infra/Config/Agent",
+		"Base/Env/CollectEnvVars",
+		"Infra/Agent/Connect",
+	}
+}
+
+// Execute - Reads /v1/status and /v1/status/entity and compares the endpoint checks with Infra/Agent/Connect
+func (p InfraAgentStatus) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Infrastructure Agent config not present.",
+		}
+	}
+
+	configs, _ := upstream["Infra/Config/Agent"].Payload.([]baseConfig.ValidateElement)
+	envVars, _ := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string)
+	enabled, port := statusServerSettings(configs, envVars)
+
+	baseURL := "http://localhost:" + port + "/v1/status"
+	var status statusResponse
+	if err := p.getJSON(baseURL, &status); err != nil {
+		if !enabled {
+			return tasks.Result{
+				Status:  tasks.None,
+				Summary: "The agent status server is not enabled. Set status_server_enabled: true in newrelic-infra.yml to let nrdiag read the agent's view of its connectivity.",
+				URL:     "https://docs.newrelic.com/docs/infrastructure/infrastructure-agent/configuration/infrastructure-agent-configuration-settings/#status-server-enabled",
+			}
+		}
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: fmt.Sprintf("The agent status server is enabled but %s did not answer: %s. Check the agent is running.", baseURL, err),
+		}
+	}
+
+	agentStatus := AgentStatus{URL: baseURL, Endpoints: status.Checks.Endpoints}
+	if err := p.getJSON(baseURL+"/entity", &agentStatus.Entity); err != nil {
+		log.Debug("could not read the agent entity:", err)
+	}
+
+	observed := nrdiagReachability(upstream["Infra/Agent/Connect"])
+	resultStatus := tasks.Success
+	var summary []string
+	if agentStatus.Entity.GUID == "" {
+		resultStatus = tasks.Warning
+		summary = append(summary, "The agent has not registered an entity yet, it has not connected to New Relic since it started.")
+	} else {
+		summary = append(summary, fmt.Sprintf("The agent is registered as entity %s (%s).", agentStatus.Entity.GUID, agentStatus.Entity.Key))
+	}
+	for i, endpoint := range agentStatus.Endpoints {
+		if reachable, ok := observed[hostOf(endpoint.URL)]; ok {
+			agentStatus.Endpoints[i].NrdiagReachable = &reachable
+		}
+		nrdiagView := agentStatus.Endpoints[i].NrdiagReachable
+		switch {
+		case !endpoint.Reachable && nrdiagView != nil && *nrdiagView:
+			resultStatus = tasks.Failure
+			summary = append(summary, fmt.Sprintf("The agent cannot reach %s (%s) but nrdiag can, compare the proxy and ca_bundle settings of the agent with the ones nrdiag used.", endpoint.URL, endpoint.Error))
+		case !endpoint.Reachable:
+			resultStatus = tasks.Failure
+			summary = append(summary, fmt.Sprintf("The agent cannot reach %s: %s", endpoint.URL, endpoint.Error))
+		case nrdiagView != nil && !*nrdiagView:
+			if resultStatus < tasks.Warning {
+				resultStatus = tasks.Warning
+			}
+			summary = append(summary, fmt.Sprintf("The agent reaches %s but nrdiag could not, nrdiag may not be using the agent's proxy settings.", endpoint.URL))
+		}
+	}
+	if resultStatus == tasks.Success {
+		summary = append(summary, fmt.Sprintf("The agent reaches all %d endpoint(s) it checks.", len(agentStatus.Endpoints)))
+	}
+
+	return tasks.Result{
+		Status:  resultStatus,
+		Summary: strings.Join(summary, "\n"),
+		Payload: agentStatus,
+	}
+}
+
+func (p InfraAgentStatus) getJSON(url string, v interface{}) error {
+	response, err := p.httpGetter(httpHelper.RequestWrapper{
+		Method:         "GET",
+		URL:            url,
+		TimeoutSeconds: 15,
+		BypassProxy:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	// /v1/status answers 503 when an endpoint is unreachable, with the same body
+	if response.StatusCode != 200 && response.StatusCode != 503 {
+		return fmt.Errorf("unexpected response %d", response.StatusCode)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// statusServerSettings - reads status_server_enabled and status_server_port from newrelic-infra.yml, overridden by the NRIA_ environment variables
+func statusServerSettings(configs []baseConfig.ValidateElement, envVars map[string]string) (bool, string) {
+	enabled, port := "", ""
+	for _, configFile := range configs {
+		if configFile.Config.FileName != "newrelic-infra.yml" {
+			continue
+		}
+		enabled = configFile.ParsedResult.FindKeyByPath("/status_server_enabled").Value()
+		port = configFile.ParsedResult.FindKeyByPath("/status_server_port").Value()
+	}
+	if value, ok := envVars["NRIA_STATUS_SERVER_ENABLED"]; ok {
+		enabled = value
+	}
+	if value, ok := envVars["NRIA_STATUS_SERVER_PORT"]; ok {
+		port = value
+	}
+	if port == "" {
+		port = defaultStatusServerPort
+	}
+	return strings.EqualFold(enabled, "true"), port
+}
+
+// nrdiagReachability - the hosts Infra/Agent/Connect checked, by whether it could connect
+func nrdiagReachability(connect tasks.Result) map[string]bool {
+	observed := make(map[string]bool)
+	connections, _ := connect.Payload.([]EndpointConnection)
+	for _, connection := range connections {
+		host := hostOf(connection.URL)
+		// a host is reachable when nrdiag connected to any of its URLs
+		observed[host] = observed[host] || connection.Connected
+	}
+	return observed
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,142 @@
+package agent
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	baseConfig "github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Agent/Status", func() {
+	var (
+		p         InfraAgentStatus
+		responses map[string]string
+		requested []string
+		upstream  map[string]tasks.Result
+		result    tasks.Result
+	)
+
+	infraConfig := func(children ...tasks.ValidateBlob) tasks.Result {
+		return tasks.Result{
+			Status: tasks.Success,
+			Payload: []baseConfig.ValidateElement{{
+				Config:       baseConfig.ConfigElement{FileName: "newrelic-infra.yml", FilePath: "/etc/"},
+				ParsedResult: tasks.ValidateBlob{Children: children},
+			}},
+		}
+	}
+
+	BeforeEach(func() {
+		requested = nil
+		responses = map[string]string{
+			"http://localhost:18003/v1/status": `{"checks":{"endpoints":[` +
+				`{"url":"https://infra-api.newrelic.com","reachable":true},` +
+				`{"url":"https://identity-api.newrelic.com","reachable":true}]}}`,
+			"http://localhost:18003/v1/status/entity": `{"guid":"MTIzfElORlJBfE5BfDQ1Ng","key":"host-1"}`,
+		}
+		upstream = map[string]tasks.Result{
+			"Infra/Config/Agent":      infraConfig(tasks.ValidateBlob{Key: "status_server_enabled", RawValue: true}),
+			"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{}},
+			"Infra/Agent/Connect": {
+				Status: tasks.Success,
+				Payload: []EndpointConnection{
+					{URL: "https://infra-api.newrelic.com/infra/v2/metrics", Connected: true},
+					{URL: "https://identity-api.newrelic.com/identity/v1", Connected: true},
+				},
+			},
+		}
+		p = InfraAgentStatus{
+			httpGetter: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+				requested = append(requested, wrapper.URL)
+				Expect(wrapper.BypassProxy).To(BeTrue())
+				body, ok := responses[wrapper.URL]
+				if !ok {
+					return nil, errors.New("connection refused")
+				}
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Agent", Name: "Status"}))
+		})
+	})
+
+	Context("when the agent reaches every endpoint", func() {
+		It("should return Success with the entity and the endpoints", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("The agent is registered as entity MTIzfElORlJBfE5BfDQ1Ng (host-1).\nThe agent reaches all 2 endpoint(s) it checks."))
+			status := result.Payload.(AgentStatus)
+			Expect(status.Entity.Key).To(Equal("host-1"))
+			Expect(*status.Endpoints[0].NrdiagReachable).To(BeTrue())
+		})
+	})
+
+	Context("when the status server is disabled", func() {
+		BeforeEach(func() {
+			upstream["Infra/Config/Agent"] = infraConfig()
+			responses = map[string]string{}
+		})
+
+		It("should return None with how to enable it", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+			Expect(result.Summary).To(ContainSubstring("status_server_enabled: true"))
+		})
+	})
+
+	Context("when the status server is enabled on another port but does not answer", func() {
+		BeforeEach(func() {
+			upstream["Base/Env/CollectEnvVars"] = tasks.Result{Payload: map[string]string{"NRIA_STATUS_SERVER_PORT": "18100"}}
+		})
+
+		It("should use the port and return Warning", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(requested).To(Equal([]string{"http://localhost:18100/v1/status"}))
+		})
+	})
+
+	Context("when the agent cannot reach an endpoint nrdiag reached", func() {
+		BeforeEach(func() {
+			responses["http://localhost:18003/v1/status"] = `{"checks":{"endpoints":[` +
+				`{"url":"https://infra-api.newrelic.com","reachable":false,"error":"proxyconnect tcp: dial tcp 10.0.0.1:3128: i/o timeout"}]}}`
+		})
+
+		It("should return Failure pointing at the agent proxy settings", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("The agent cannot reach https://infra-api.newrelic.com (proxyconnect tcp: dial tcp 10.0.0.1:3128: i/o timeout) but nrdiag can"))
+		})
+	})
+
+	Context("when nrdiag could not reach an endpoint the agent reaches", func() {
+		BeforeEach(func() {
+			upstream["Infra/Agent/Connect"] = tasks.Result{
+				Status: tasks.Failure,
+				Payload: []EndpointConnection{
+					{URL: "https://infra-api.newrelic.com/infra/v2/metrics", Connected: false},
+					{URL: "https://identity-api.newrelic.com/identity/v1", Connected: true},
+				},
+			}
+			delete(responses, "http://localhost:18003/v1/status/entity")
+		})
+
+		It("should return Warning", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("The agent has not registered an entity yet"))
+			Expect(result.Summary).To(ContainSubstring("The agent reaches https://infra-api.newrelic.com but nrdiag could not"))
+			status := result.Payload.(AgentStatus)
+			Expect(*status.Endpoints[1].NrdiagReachable).To(BeTrue())
+		})
+	})
+})
@@ -56,6 +56,11 @@ func (p InfraAgentVersion) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task looks up infrastructure agent releases on GitHub, so it is skipped in offline mode
+func (p InfraAgentVersion) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - The core work within each task
 func (p InfraAgentVersion) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result { //By default this task is commented out. To see it run go to the tasks/registerTasks.go file and uncomment the w.Register for this task
 
@@ -20,6 +20,11 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		runtimeOS: runtime.GOOS,
 	}, true)
 	registrationFunc(InfraConfigIntegrationsValidateJson{}, true)
+	registrationFunc(InfraConfigIntegrationsDryRun{
+		runtimeOS:  runtime.GOOS,
+		fileExists: tasks.FileExists,
+		run:        runIntegration,
+	}, false)
 	registrationFunc(InfraConfigValidateJMX{
 		mCmdExecutor:             tasks.MultiCmdExecutor,
 		getJMXProcessCmdlineArgs: getJMXProcessCmdlineArgs,
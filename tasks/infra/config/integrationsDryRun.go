@@ -0,0 +1,433 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+)
+
+const (
+	dryRunTimeoutDefault = 30
+	dryRunTimeoutMax     = 300
+)
+
+var (
+	// placeholderRegex matches values the agent resolves itself: discovery, secrets and env variables
+	placeholderRegex   = regexp.MustCompile(`\$\{[^}]+\}|\{\{[^}]+\}\}`)
+	connectionErrRegex = regexp.MustCompile(`(?i)connection refused|no such host|i/o timeout|dial (tcp|udp|unix)|network is unreachable|connection reset|could not connect|unable to connect|can't connect`)
+	authErrRegex       = regexp.MustCompile(`(?i)access denied|authentication failed|auth failed|unauthorized|invalid password|wrong password|NOAUTH|WRONGPASS|login failed|permission denied`)
+	stderrErrRegex     = regexp.MustCompile(`(?i)\[ERR\]|\[FATAL\]|level=(error|fatal)|\berror\b`)
+)
+
+type integrationRunner func(timeout time.Duration, dir string, env []string, name string, args ...string) ([]byte, []byte, error)
+
+// InfraConfigIntegrationsDryRun - runs each configured on-host integration instance once, the way the agent would
+type InfraConfigIntegrationsDryRun struct {
+	runtimeOS  string
+	fileExists tasks.FileExistsFunc
+	run        integrationRunner
+}
+
+// IntegrationDryRun - the result of running one integration instance. Env and Command have their credentials redacted.
+type IntegrationDryRun struct {
+	Integration     string
+	Instance        string
+	ConfigFile      string
+	Command         []string
+	Env             map[string]string
+	ProtocolVersion string
+	Entities        int
+	Errors          []string
+	Problem         string
+	Skipped         string
+}
+
+// integrationInstance - an instance to run, before redaction
+type integrationInstance struct {
+	integration string
+	name        string
+	configFile  string
+	dir         string
+	command     []string
+	env         map[string]string
+	skipped     string
+	problem     string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p InfraConfigIntegrationsDryRun) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Config/IntegrationsDryRun")
+}
+
+// Explain - Returns the help text for each individual task
+func (p InfraConfigIntegrationsDryRun) Explain() string {
+	return "Run each configured New Relic Infrastructure on-host integration instance once and validate its output"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p InfraConfigIntegrationsDryRun) Dependencies() []string {
+	return []string{
+		"Infra/Config/IntegrationsMatch",
+	}
+}
+
+// Execute - The core work within each task
+func (p InfraConfigIntegrationsDryRun) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	matched, ok := upstream["Infra/Config/IntegrationsMatch"].Payload.(MatchedIntegrationFiles)
+	if !ok || len(matched.IntegrationFilePairs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No matched on-host integration configuration files found. Task not executed.",
+		}
+	}
+
+	timeout := time.Duration(dryRunTimeoutDefault) * time.Second
+	if timeoutInt, err := strconv.Atoi(options.Options["timeout"]); err == nil && timeoutInt > 0 && timeoutInt <= dryRunTimeoutMax {
+		timeout = time.Duration(timeoutInt) * time.Second
+	}
+
+	var names []string
+	for name := range matched.IntegrationFilePairs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var runs []IntegrationDryRun
+	status := tasks.Success
+	var summary []string
+	for _, name := range names {
+		for _, instance := range p.instances(name, matched.IntegrationFilePairs[name]) {
+			run := p.dryRun(instance, timeout)
+			runs = append(runs, run)
+
+			line := fmt.Sprintf("%s (%s): ", run.Integration, run.Instance)
+			switch {
+			case run.Skipped != "":
+				if status < tasks.Warning {
+					status = tasks.Warning
+				}
+				line += "not run, " + run.Skipped
+			case run.Problem != "":
+				status = tasks.Failure
+				line += run.Problem
+				if len(run.Errors) > 0 {
+					line += "\n\t" + strings.Join(run.Errors, "\n\t")
+				}
+			default:
+				line += fmt.Sprintf("reported %d entity(ies) with protocol version %s", run.Entities, run.ProtocolVersion)
+			}
+			summary = append(summary, line)
+		}
+	}
+
+	if len(runs) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No on-host integration instances are configured. Task not executed.",
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: fmt.Sprintf("Ran %d on-host integration instance(s):\n%s", len(runs), strings.Join(summary, "\n")),
+		URL:     "https://docs.newrelic.com/docs/infrastructure/host-integrations/troubleshooting/run-integrations-manually/",
+		Payload: runs,
+	}
+}
+
+// instances - the instances of a matched config file, from its integrations list (v4) or its instances list and definition commands (v3)
+func (p InfraConfigIntegrationsDryRun) instances(name string, pair *IntegrationFilePair) []integrationInstance {
+	configFile := pair.Configuration.Config.FilePath + pair.Configuration.Config.FileName
+	parsed := pair.Configuration.ParsedResult
+
+	if pair.Definition.Config == (config.ConfigElement{}) {
+		var instances []integrationInstance
+		for i, entry := range listItems(childByKey(parsed, "integrations")) {
+			instance := integrationInstance{
+				integration: childValue(entry, "name"),
+				name:        fmt.Sprintf("entry %d", i+1),
+				configFile:  configFile,
+				env:         childMap(entry, "env"),
+			}
+			if execKey := childByKey(entry, "exec"); execKey.Key != "" && execKey.IsLeaf() {
+				instance.command = strings.Fields(execKey.Value())
+			} else {
+				instance.command = listValues(execKey)
+			}
+			switch {
+			case len(instance.command) == 0 && instance.integration == "":
+				instance.integration = name
+				instance.problem = "The entry has neither a name nor an exec to run."
+			case len(instance.command) == 0:
+				binary, found := p.findBinary(instance.integration)
+				if !found {
+					instance.problem = fmt.Sprintf("The integration binary %s was not found in %s.", instance.integration, strings.Join(p.definitionDirs(), ", "))
+				}
+				instance.command = []string{binary}
+			case instance.integration == "":
+				instance.integration = filepath.Base(instance.command[0])
+			}
+			instance.command = append(instance.command, listValues(childByKey(entry, "cli_args"))...)
+			if childByKey(entry, "config").Key != "" || childByKey(entry, "config_template_path").Key != "" {
+				instance.skipped = "the agent renders its config or config_template_path into a temporary file when it runs it."
+			}
+			instances = append(instances, instance)
+		}
+		return instances
+	}
+
+	definition := pair.Definition.ParsedResult
+	integration := childValue(parsed, "integration_name")
+	var instances []integrationInstance
+	for i, entry := range listItems(childByKey(parsed, "instances")) {
+		instance := integrationInstance{
+			integration: integration,
+			name:        childValue(entry, "name"),
+			configFile:  configFile,
+			dir:         pair.Definition.Config.FilePath,
+			env:         map[string]string{},
+		}
+		if instance.name == "" {
+			instance.name = fmt.Sprintf("entry %d", i+1)
+		}
+		for key, value := range childMap(entry, "arguments") {
+			instance.env[strings.ToUpper(key)] = value
+		}
+		command := childValue(entry, "command")
+		instance.command = listValues(childByKey(childByKey(childByKey(definition, "commands"), command), "command"))
+		if len(instance.command) == 0 {
+			instance.problem = fmt.Sprintf("The command %q is not defined in %s%s.", command, pair.Definition.Config.FilePath, pair.Definition.Config.FileName)
+		} else if binary := filepath.Join(instance.dir, instance.command[0]); !p.fileExists(binary) {
+			instance.problem = fmt.Sprintf("The integration binary %s was not found.", binary)
+		}
+		instances = append(instances, instance)
+	}
+	return instances
+}
+
+// dryRun - runs an instance once and validates its protocol output, redacting its credentials from everything it returns
+func (p InfraConfigIntegrationsDryRun) dryRun(instance integrationInstance, timeout time.Duration) IntegrationDryRun {
+	secrets := tasks.ArgSecrets(instance.command)
+	for key, value := range instance.env {
+		if tasks.IsSecretKey(key) && value != "" {
+			secrets = append(secrets, value)
+		}
+		secrets = append(secrets, tasks.URLUserinfo(value)...)
+	}
+	redact := func(s string) string {
+		return tasks.RedactSecrets(s, secrets)
+	}
+
+	env := map[string]string{}
+	for key, value := range instance.env {
+		if tasks.IsSecretKey(key) && value != "" {
+			env[key] = tasks.Redacted
+			continue
+		}
+		env[key] = redact(value)
+	}
+
+	run := IntegrationDryRun{
+		Integration: instance.integration,
+		Instance:    instance.name,
+		ConfigFile:  instance.configFile,
+		Env:         env,
+		Problem:     instance.problem,
+		Skipped:     instance.skipped,
+	}
+	for _, arg := range instance.command {
+		run.Command = append(run.Command, redact(arg))
+	}
+	if run.Problem != "" || run.Skipped != "" {
+		return run
+	}
+	for key, value := range instance.env {
+		if placeholderRegex.MatchString(value) {
+			run.Skipped = fmt.Sprintf("%s uses a variable the agent resolves when it runs the integration.", key)
+			return run
+		}
+	}
+
+	var cmdEnv []string
+	for key, value := range instance.env {
+		cmdEnv = append(cmdEnv, key+"="+value)
+	}
+	if _, ok := instance.env["METRICS"]; !ok {
+		cmdEnv = append(cmdEnv, "METRICS=true")
+	}
+	if _, ok := instance.env["INVENTORY"]; !ok {
+		cmdEnv = append(cmdEnv, "INVENTORY=true")
+	}
+	sort.Strings(cmdEnv)
+
+	stdout, stderr, err := p.run(timeout, instance.dir, cmdEnv, instance.command[0], instance.command[1:]...)
+	for _, line := range strings.Split(string(stderr), "\n") {
+		if stderrErrRegex.MatchString(line) {
+			run.Errors = append(run.Errors, redact(strings.TrimSpace(line)))
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		run.Problem = fmt.Sprintf("The integration did not finish within %s.", timeout)
+	case authErrRegex.MatchString(string(stderr)):
+		run.Problem = "The integration could not authenticate, check the credentials in " + instance.configFile + "."
+	case connectionErrRegex.MatchString(string(stderr)):
+		run.Problem = "The integration could not connect to the monitored service, check the host and port in " + instance.configFile + "."
+	case err != nil:
+		run.Problem = "The integration exited with an error: " + redact(err.Error())
+	}
+	if run.Problem != "" {
+		return run
+	}
+
+	version, entities, err := validateProtocolOutput(stdout)
+	if err != nil {
+		run.Problem = err.Error()
+		return run
+	}
+	run.ProtocolVersion = version
+	run.Entities = entities
+	return run
+}
+
+// validateProtocolOutput - checks every line of output is a JSON payload of the integrations protocol, returns its version and the number of entities
+func validateProtocolOutput(stdout []byte) (string, int, error) {
+	var version string
+	var entities int
+	for _, line := range bytes.Split(stdout, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var payload struct {
+			Name            string            `json:"name"`
+			ProtocolVersion json.RawMessage   `json:"protocol_version"`
+			Data            []json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(line, &payload); err != nil {
+			return "", 0, fmt.Errorf("The integration output is not valid JSON: %s", err)
+		}
+		version = strings.Trim(string(payload.ProtocolVersion), `"`)
+		switch version {
+		case "1":
+		case "2", "3", "4":
+			if payload.Data == nil {
+				return "", 0, fmt.Errorf("The integration output has protocol version %s but no data", version)
+			}
+		case "":
+			return "", 0, errors.New("The integration output has no protocol_version")
+		default:
+			return "", 0, fmt.Errorf("The integration output has an unknown protocol version %s", version)
+		}
+		entities += len(payload.Data)
+	}
+	if version == "" {
+		return "", 0, errors.New("The integration produced no output")
+	}
+	return version, entities, nil
+}
+
+func (p InfraConfigIntegrationsDryRun) definitionDirs() []string {
+	if p.runtimeOS == "windows" {
+		return definitionFilepathsWindows
+	}
+	return definitionFilepathsLinux
+}
+
+// findBinary - looks for an integration by name the way the agent does, in the bin folder of the definition directories
+func (p InfraConfigIntegrationsDryRun) findBinary(name string) (string, bool) {
+	if p.runtimeOS == "windows" && filepath.Ext(name) != ".exe" {
+		name += ".exe"
+	}
+	var candidates []string
+	for _, dir := range p.definitionDirs() {
+		candidates = append(candidates, dir+"bin"+string(filepath.Separator)+name, dir+name)
+	}
+	for _, candidate := range candidates {
+		if p.fileExists(candidate) {
+			return candidate, true
+		}
+	}
+	return candidates[0], false
+}
+
+func runIntegration(timeout time.Duration, dir string, env []string, name string, args ...string) ([]byte, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	log.Debug("ran integration", name, "error:", err)
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+func childByKey(blob tasks.ValidateBlob, key string) tasks.ValidateBlob {
+	for _, child := range blob.Children {
+		if child.Key == key {
+			return child
+		}
+	}
+	return tasks.ValidateBlob{}
+}
+
+func childValue(blob tasks.ValidateBlob, key string) string {
+	child := childByKey(blob, key)
+	if child.Key == "" {
+		return ""
+	}
+	return child.Value()
+}
+
+// childMap - the leaf values of a mapping child, nested values are not passed to integrations
+func childMap(blob tasks.ValidateBlob, key string) map[string]string {
+	values := map[string]string{}
+	for _, child := range childByKey(blob, key).Children {
+		if child.IsLeaf() {
+			values[child.Key] = child.Value()
+		} else {
+			log.Debug("skipping nested value", child.PathAndKey())
+		}
+	}
+	return values
+}
+
+// listItems - the items of a YAML list, in order, since ValidateBlob keys them by index
+func listItems(blob tasks.ValidateBlob) []tasks.ValidateBlob {
+	items := append([]tasks.ValidateBlob{}, blob.Children...)
+	sort.SliceStable(items, func(i, j int) bool {
+		a, _ := strconv.Atoi(items[i].Key)
+		b, _ := strconv.Atoi(items[j].Key)
+		return a < b
+	})
+	return items
+}
+
+func listValues(blob tasks.ValidateBlob) []string {
+	var values []string
+	for _, item := range listItems(blob) {
+		values = append(values, item.Value())
+	}
+	return values
+}
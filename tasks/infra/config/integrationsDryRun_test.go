@@ -0,0 +1,253 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const mysqlOutput = `{"name":"com.newrelic.mysql","protocol_version":"3","integration_version":"1.10.0","data":[{"entity":{"name":"localhost:3306","type":"node"},"metrics":[],"inventory":{},"events":[]}]}`
+
+func parsedElement(path string, name string, content string) config.ValidateElement {
+	parsed, err := config.ParseYaml(strings.NewReader(content))
+	Expect(err).NotTo(HaveOccurred())
+	return config.ValidateElement{
+		Config:       config.ConfigElement{FileName: name, FilePath: path},
+		Status:       tasks.Success,
+		ParsedResult: parsed,
+	}
+}
+
+type integrationRun struct {
+	dir  string
+	env  []string
+	name string
+	args []string
+}
+
+var _ = Describe("Infra/Config/IntegrationsDryRun", func() {
+	var (
+		p        InfraConfigIntegrationsDryRun
+		pairs    map[string]*IntegrationFilePair
+		runs     []integrationRun
+		stdout   string
+		stderr   string
+		runErr   error
+		options  tasks.Options
+		result   tasks.Result
+		binaries = map[string]bool{
+			"/var/db/newrelic-infra/newrelic-integrations/bin/nri-mysql": true,
+			"/var/db/newrelic-infra/custom-integrations/bin/nri-redis":   true,
+		}
+	)
+
+	BeforeEach(func() {
+		runs = nil
+		stdout, stderr, runErr = mysqlOutput, "", nil
+		options = tasks.Options{}
+		pairs = map[string]*IntegrationFilePair{
+			"mysql": {
+				Configuration: parsedElement("/etc/newrelic-infra/integrations.d/", "mysql-config.yml", `
+integrations:
+  - name: nri-mysql
+    env:
+      HOSTNAME: localhost
+      PORT: 3306
+      USERNAME: newrelic
+      PASSWORD: s3cr3t-p4ss
+    interval: 30s
+`),
+			},
+		}
+		p = InfraConfigIntegrationsDryRun{
+			runtimeOS:  "linux",
+			fileExists: func(path string) bool { return binaries[path] },
+			run: func(timeout time.Duration, dir string, env []string, name string, args ...string) ([]byte, []byte, error) {
+				runs = append(runs, integrationRun{dir: dir, env: env, name: name, args: args})
+				return []byte(stdout), []byte(stderr), runErr
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(options, map[string]tasks.Result{
+			"Infra/Config/IntegrationsMatch": {Status: tasks.Success, Payload: MatchedIntegrationFiles{IntegrationFilePairs: pairs}},
+		})
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Config", Name: "IntegrationsDryRun"}))
+		})
+	})
+
+	Context("when no integrations were matched", func() {
+		BeforeEach(func() {
+			pairs = map[string]*IntegrationFilePair{}
+		})
+
+		It("should return None", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when a v4 integration reports valid output", func() {
+		It("should run the binary with its env and return Success", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(ContainSubstring("nri-mysql (entry 1): reported 1 entity(ies) with protocol version 3"))
+			Expect(runs).To(Equal([]integrationRun{{
+				name: "/var/db/newrelic-infra/newrelic-integrations/bin/nri-mysql",
+				args: []string{},
+				env:  []string{"HOSTNAME=localhost", "INVENTORY=true", "METRICS=true", "PASSWORD=s3cr3t-p4ss", "PORT=3306", "USERNAME=newrelic"},
+			}}))
+			run := result.Payload.([]IntegrationDryRun)[0]
+			Expect(run.Env["PASSWORD"]).To(Equal("_REDACTED_"))
+			Expect(run.Env["USERNAME"]).To(Equal("newrelic"))
+		})
+	})
+
+	Context("when credentials are in a connection string or a -flag=value argument", func() {
+		BeforeEach(func() {
+			stderr = "[ERR] could not open mysql://newrelic:dsn-p4ss@db:3306/app using --password=fl4g-p4ss\n"
+			pairs["mysql"].Configuration = parsedElement("/etc/newrelic-infra/integrations.d/", "mysql-config.yml", `
+integrations:
+  - name: nri-mysql
+    cli_args: [-password=fl4g-p4ss, -verbose]
+    env:
+      CONNECTION_STRING: mysql://newrelic:dsn-p4ss@db:3306/app
+      DSN: newrelic:dsn-p4ss@tcp(db:3306)/app
+      HOSTNAME: localhost
+`)
+		})
+
+		It("should pass them to the integration but redact them from the output", func() {
+			Expect(runs).To(HaveLen(1))
+			Expect(runs[0].args).To(Equal([]string{"-password=fl4g-p4ss", "-verbose"}))
+			Expect(runs[0].env).To(ContainElement("CONNECTION_STRING=mysql://newrelic:dsn-p4ss@db:3306/app"))
+			run := result.Payload.([]IntegrationDryRun)[0]
+			Expect(run.Env["CONNECTION_STRING"]).To(Equal("mysql://_REDACTED_@db:3306/app"))
+			Expect(run.Env["DSN"]).To(Equal("_REDACTED_@tcp(db:3306)/app"))
+			Expect(run.Env["HOSTNAME"]).To(Equal("localhost"))
+			Expect(run.Command).To(Equal([]string{"/var/db/newrelic-infra/newrelic-integrations/bin/nri-mysql", "-password=_REDACTED_", "-verbose"}))
+			Expect(run.Errors).To(Equal([]string{"[ERR] could not open mysql://_REDACTED_@db:3306/app using --password=_REDACTED_"}))
+			Expect(result.Summary).NotTo(ContainSubstring("p4ss"))
+		})
+	})
+
+	Context("when the integration cannot authenticate", func() {
+		BeforeEach(func() {
+			stdout = ""
+			stderr = "[ERR] Error 1045: Access denied for user 'newrelic'@'localhost' (using password: s3cr3t-p4ss)\n"
+			runErr = errors.New("exit status 1")
+		})
+
+		It("should return Failure without echoing the password", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("The integration could not authenticate, check the credentials in /etc/newrelic-infra/integrations.d/mysql-config.yml."))
+			Expect(result.Summary).To(ContainSubstring("(using password: _REDACTED_)"))
+			Expect(result.Summary).NotTo(ContainSubstring("s3cr3t-p4ss"))
+		})
+	})
+
+	Context("when the integration cannot connect", func() {
+		BeforeEach(func() {
+			stdout = ""
+			stderr = "[ERR] dial tcp 127.0.0.1:3306: connect: connection refused\n"
+			runErr = errors.New("exit status 1")
+		})
+
+		It("should return Failure", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("could not connect to the monitored service"))
+		})
+	})
+
+	Context("when the integration times out", func() {
+		BeforeEach(func() {
+			options = tasks.Options{Options: map[string]string{"timeout": "5"}}
+			stdout = ""
+			runErr = context.DeadlineExceeded
+		})
+
+		It("should return Failure with the timeout", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("The integration did not finish within 5s."))
+		})
+	})
+
+	Context("when the output is not protocol JSON", func() {
+		BeforeEach(func() {
+			stdout = "{\"name\":\"com.newrelic.mysql\"}\n"
+		})
+
+		It("should return Failure", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("The integration output has no protocol_version"))
+		})
+	})
+
+	Context("when a v3 integration is paired with its definition", func() {
+		BeforeEach(func() {
+			pairs = map[string]*IntegrationFilePair{
+				"redis": {
+					Configuration: parsedElement("/etc/newrelic-infra/integrations.d/", "redis-config.yml", `
+integration_name: com.newrelic.redis
+instances:
+  - name: redis-metrics
+    command: metrics
+    arguments:
+      hostname: localhost
+      password: r3d1s
+  - name: redis-unknown
+    command: events
+`),
+					Definition: parsedElement("/var/db/newrelic-infra/custom-integrations/", "redis-definition.yml", `
+name: com.newrelic.redis
+commands:
+  metrics:
+    command:
+      - ./bin/nri-redis
+      - --metrics
+`),
+				},
+			}
+		})
+
+		It("should run the definition command from the definition directory", func() {
+			Expect(runs).To(Equal([]integrationRun{{
+				dir:  "/var/db/newrelic-infra/custom-integrations/",
+				name: "./bin/nri-redis",
+				args: []string{"--metrics"},
+				env:  []string{"HOSTNAME=localhost", "INVENTORY=true", "METRICS=true", "PASSWORD=r3d1s"},
+			}}))
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("com.newrelic.redis (redis-metrics): reported 1 entity(ies)"))
+			Expect(result.Summary).To(ContainSubstring(`com.newrelic.redis (redis-unknown): The command "events" is not defined in /var/db/newrelic-infra/custom-integrations/redis-definition.yml.`))
+		})
+	})
+
+	Context("when an instance relies on values the agent resolves", func() {
+		BeforeEach(func() {
+			pairs["mysql"].Configuration = parsedElement("/etc/newrelic-infra/integrations.d/", "mysql-config.yml", `
+integrations:
+  - name: nri-mysql
+    env:
+      HOSTNAME: ${discovery.ip}
+  - name: nri-postgresql
+`)
+		})
+
+		It("should skip it and report the missing binary", func() {
+			Expect(runs).To(BeEmpty())
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("nri-mysql (entry 1): not run, HOSTNAME uses a variable the agent resolves"))
+			Expect(result.Summary).To(ContainSubstring("nri-postgresql (entry 2): The integration binary nri-postgresql was not found"))
+		})
+	})
+})
@@ -10,6 +10,7 @@ import (
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	infraAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/agent"
 )
 
 var (
@@ -43,6 +44,11 @@ func (p InfraEnvClockSkew) Dependencies() []string {
 	return []string{"Infra/Agent/Connect", "Base/Config/ProxyDetect"}
 }
 
+// RequiresNetwork - this task reads the time from the New Relic collector, so it is skipped in offline mode
+func (p InfraEnvClockSkew) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - Returns result containing the log_file value(s) parsed from any found newrelic-infra.yml files previously collected.
 func (p InfraEnvClockSkew) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 
@@ -52,7 +58,7 @@ func (p InfraEnvClockSkew) Execute(options tasks.Options, upstream map[string]ta
 			Summary: "Unable to retrieve urls from Infra/Agent/Connect. This task did not run",
 		}
 	}
-	collectorURLs, ok := upstream["Infra/Agent/Connect"].Payload.([]string)
+	connections, ok := upstream["Infra/Agent/Connect"].Payload.([]infraAgent.EndpointConnection)
 
 	if !ok {
 		return tasks.Result{
@@ -61,6 +67,10 @@ func (p InfraEnvClockSkew) Execute(options tasks.Options, upstream map[string]ta
 		}
 	}
 
+	var collectorURLs []string
+	for _, connection := range connections {
+		collectorURLs = append(collectorURLs, connection.URL)
+	}
 	apiEndpoint, err := p.getCollectorURL(collectorURLs)
 	if err != nil {
 		return tasks.Result{
@@ -9,6 +9,7 @@ import (
 
 	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	infraAgent "github.com/newrelic/newrelic-diagnostics-cli/tasks/infra/agent"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/format"
@@ -97,7 +98,7 @@ var _ = Describe("Infra/Env/ClockSkew", func() {
 
 					"Infra/Agent/Connect": {
 						Status:  tasks.Failure,
-						Payload: []string{},
+						Payload: []infraAgent.EndpointConnection{},
 					},
 				}
 				p.httpGetter = mockInvalidDateHeader
@@ -135,8 +136,8 @@ var _ = Describe("Infra/Env/ClockSkew", func() {
 
 					"Infra/Agent/Connect": {
 						Status: tasks.Success,
-						Payload: []string{
-							"https://infra-api.newrelic.com",
+						Payload: []infraAgent.EndpointConnection{
+							{URL: "https://infra-api.newrelic.com", Connected: true},
 						},
 					},
 				}
@@ -167,8 +168,8 @@ var _ = Describe("Infra/Env/ClockSkew", func() {
 
 					"Infra/Agent/Connect": {
 						Status: tasks.Success,
-						Payload: []string{
-							"https://infra-api.newrelic.com",
+						Payload: []infraAgent.EndpointConnection{
+							{URL: "https://infra-api.newrelic.com", Connected: true},
 						},
 					},
 				}
@@ -192,8 +193,8 @@ var _ = Describe("Infra/Env/ClockSkew", func() {
 				upstream = map[string]tasks.Result{
 					"Infra/Agent/Connect": {
 						Status: tasks.Success,
-						Payload: []string{
-							"https://metric-api.newrelic.com",
+						Payload: []infraAgent.EndpointConnection{
+							{URL: "https://metric-api.newrelic.com", Connected: true},
 						},
 					},
 				}
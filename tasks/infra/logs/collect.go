@@ -0,0 +1,103 @@
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var (
+	// fluentBitLineRgx matches the agent log lines about the log forwarder, the agent logs the Fluent Bit output with them
+	fluentBitLineRgx = regexp.MustCompile(`(?i)fluent-?bit`)
+	logFileRgx       = regexp.MustCompile(`(?im)^\s*log_file\s+(\S+)`)
+)
+
+// InfraLogsCollect collects the Fluent Bit output logged by the agent and the Fluent Bit log file, when one is configured
+type InfraLogsCollect struct {
+	open func(string) (io.ReadCloser, error)
+}
+
+func (p InfraLogsCollect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logs/Collect")
+}
+
+func (p InfraLogsCollect) Explain() string {
+	return "Collect Fluent Bit log forwarder logs of the New Relic Infrastructure agent"
+}
+
+func (p InfraLogsCollect) Dependencies() []string {
+	return []string{"Infra/Log/Collect", "Infra/Logs/FluentBit"}
+}
+
+func (p InfraLogsCollect) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	fluentBit, ok := upstream["Infra/Logs/FluentBit"].Payload.(FluentBit)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Fluent Bit not detected. Task not executed.",
+		}
+	}
+
+	var lines []string
+	agentLogs, _ := upstream["Infra/Log/Collect"].Payload.([]string)
+	for _, agentLog := range agentLogs {
+		lines = append(lines, p.grep(agentLog, fluentBitLineRgx)...)
+	}
+
+	var summary []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	if len(lines) > 0 {
+		stream := make(chan string)
+		go tasks.StreamBlob(strings.Join(lines, "\n")+"\n", stream)
+		filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{
+			Path:       "infra-fluent-bit.log",
+			Stream:     stream,
+			Identifier: p.Identifier().String(),
+		})
+		summary = append(summary, fmt.Sprintf("Collected %d Fluent Bit line(s) from the agent log.", len(lines)))
+	}
+	if fluentBit.ConfigFile != "" {
+		if m := p.grep(fluentBit.ConfigFile, logFileRgx); len(m) > 0 {
+			logFile := logFileRgx.FindStringSubmatch(m[0])[1]
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: logFile})
+			summary = append(summary, "Collected the Fluent Bit log file "+logFile)
+		}
+	}
+
+	if len(filesToCopy) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Fluent Bit logs found in the agent log.",
+		}
+	}
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     strings.Join(summary, "\n"),
+		FilesToCopy: filesToCopy,
+	}
+}
+
+// grep returns the lines of a file matching a pattern
+func (p InfraLogsCollect) grep(path string, pattern *regexp.Regexp) []string {
+	file, err := p.open(path)
+	if err != nil {
+		log.Debug("could not read " + path + ": " + err.Error())
+		return nil
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if pattern.MatchString(scanner.Text()) {
+			lines = append(lines, scanner.Text())
+		}
+	}
+	return lines
+}
@@ -0,0 +1,260 @@
+package logs
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// Source types a logging.d entry can forward from
+const (
+	SourceFile      = "file"
+	SourceSystemd   = "systemd"
+	SourceSyslog    = "syslog"
+	SourceTCP       = "tcp"
+	SourceWinlog    = "winlog"
+	SourceWinevtlog = "winevtlog"
+	SourceFluentBit = "fluentbit"
+)
+
+var sourceTypes = []string{SourceFile, SourceSystemd, SourceSyslog, SourceTCP, SourceWinlog, SourceWinevtlog, SourceFluentBit}
+
+var (
+	syslogSchemes = map[string]bool{"tcp": true, "udp": true, "unix_tcp": true, "unix_udp": true}
+	syslogParsers = map[string]bool{"rfc3164": true, "rfc3164-local": true, "rfc5424": true}
+	tcpFormats    = map[string]bool{"json": true, "none": true}
+)
+
+var windowsAbsRgx = regexp.MustCompile(`^([A-Za-z]:[\\/]|\\\\)`)
+
+// InfraLogsConfig validates the log forwarding sources configured in logging.d
+type InfraLogsConfig struct {
+	goos       string
+	loggingDir string
+}
+
+// LogSource is one entry of a logging.d file
+type LogSource struct {
+	ConfigFile string
+	Name       string
+	// Type is the source type, empty when the entry has none or several
+	Type string
+	// Target is the file, unit, URI, channel or Fluent Bit config the source reads
+	Target        string
+	FluentParsers string `json:",omitempty"`
+	Problems      []string
+}
+
+// loggingConfig mirrors a logging.d file, entries are kept as maps to report the keys they set
+type loggingConfig struct {
+	Logs []map[string]interface{} `yaml:"logs"`
+}
+
+func (p InfraLogsConfig) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logs/Config")
+}
+
+func (p InfraLogsConfig) Explain() string {
+	return "Validate New Relic Infrastructure log forwarding configuration in logging.d"
+}
+
+func (p InfraLogsConfig) Dependencies() []string {
+	return []string{"Infra/Config/Agent"}
+}
+
+func (p InfraLogsConfig) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Infra Agent detected. Task not executed.",
+		}
+	}
+
+	var configFiles []string
+	for _, pattern := range []string{"*.yml", "*.yaml"} {
+		matches, _ := filepath.Glob(filepath.Join(p.loggingDir, pattern))
+		configFiles = append(configFiles, matches...)
+	}
+	sort.Strings(configFiles)
+	if len(configFiles) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No log forwarding configuration found in " + p.loggingDir,
+		}
+	}
+
+	status := tasks.Success
+	var summary []string
+	var sources []LogSource
+	names := make(map[string]string)
+	for _, configFile := range configFiles {
+		data, err := os.ReadFile(configFile)
+		if err != nil {
+			status = maxStatus(status, tasks.Failure)
+			summary = append(summary, fmt.Sprintf("Could not read %s: %s", configFile, err))
+			continue
+		}
+		var config loggingConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			status = maxStatus(status, tasks.Failure)
+			summary = append(summary, fmt.Sprintf("%s is not valid YAML, the agent does not forward any of its logs: %s", configFile, err))
+			continue
+		}
+		if len(config.Logs) == 0 {
+			status = maxStatus(status, tasks.Warning)
+			summary = append(summary, configFile+" has no entries under logs.")
+			continue
+		}
+		for i, entry := range config.Logs {
+			source, sourceStatus := p.validateEntry(configFile, entry)
+			if previous, ok := names[source.Name]; ok && source.Name != "" {
+				source.Problems = append(source.Problems, "The name is also used in "+previous+", use a unique name to tell the sources apart.")
+				sourceStatus = maxStatus(sourceStatus, tasks.Warning)
+			}
+			names[source.Name] = configFile
+			status = maxStatus(status, sourceStatus)
+			for _, problem := range source.Problems {
+				summary = append(summary, fmt.Sprintf("%s: %s: %s", configFile, source.label(i), problem))
+			}
+			sources = append(sources, source)
+		}
+	}
+
+	if status == tasks.Success {
+		summary = append(summary, fmt.Sprintf("%d log source(s) configured in %d file(s) are valid.", len(sources), len(configFiles)))
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: sources,
+	}
+}
+
+// validateEntry checks an entry has a name and exactly one source with valid settings for this OS
+func (p InfraLogsConfig) validateEntry(configFile string, entry map[string]interface{}) (LogSource, tasks.Status) {
+	source := LogSource{ConfigFile: configFile}
+	status := tasks.Success
+	problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+		status = maxStatus(status, problemStatus)
+		source.Problems = append(source.Problems, fmt.Sprintf(format, args...))
+	}
+
+	source.Name, _ = entry["name"].(string)
+	if source.Name == "" {
+		problem(tasks.Failure, "The entry has no name, the agent requires one for every source.")
+	}
+
+	var types []string
+	for _, sourceType := range sourceTypes {
+		if _, ok := entry[sourceType]; ok {
+			types = append(types, sourceType)
+		}
+	}
+	switch len(types) {
+	case 0:
+		problem(tasks.Failure, "The entry has no source, set one of %s.", strings.Join(sourceTypes, ", "))
+		return source, status
+	case 1:
+		source.Type = types[0]
+	default:
+		problem(tasks.Failure, "The entry sets %s, each entry can only have one source.", strings.Join(types, " and "))
+		return source, status
+	}
+
+	value := entry[source.Type]
+	settings, _ := value.(map[string]interface{})
+	switch source.Type {
+	case SourceFile:
+		source.Target, _ = value.(string)
+		switch {
+		case source.Target == "":
+			problem(tasks.Failure, "file must be the path of the log file.")
+		case !p.isAbs(source.Target):
+			problem(tasks.Failure, "file %s is not an absolute path.", source.Target)
+		case strings.Contains(source.Target, "**"):
+			problem(tasks.Warning, "file %s uses **, Fluent Bit does not match files recursively and treats it as *.", source.Target)
+		}
+	case SourceSystemd:
+		source.Target, _ = value.(string)
+		if source.Target == "" {
+			problem(tasks.Failure, "systemd must be the name of the systemd unit.")
+		}
+		if p.goos == "windows" {
+			problem(tasks.Failure, "systemd sources are only supported on Linux.")
+		}
+	case SourceSyslog, SourceTCP:
+		uri, _ := settings["uri"].(string)
+		source.Target = uri
+		u, err := url.Parse(uri)
+		switch {
+		case uri == "":
+			problem(tasks.Failure, "%s.uri is not set.", source.Type)
+		case err != nil:
+			problem(tasks.Failure, "%s.uri %s is not a valid URI: %s", source.Type, uri, err)
+		case source.Type == SourceSyslog && !syslogSchemes[u.Scheme]:
+			problem(tasks.Failure, "syslog.uri %s must start with tcp://, udp://, unix_tcp:// or unix_udp://.", uri)
+		case source.Type == SourceTCP && u.Scheme != "tcp":
+			problem(tasks.Failure, "tcp.uri %s must start with tcp://.", uri)
+		case !strings.HasPrefix(u.Scheme, "unix_") && u.Port() == "":
+			problem(tasks.Failure, "%s.uri %s has no port to listen on.", source.Type, uri)
+		}
+		if source.Type == SourceSyslog {
+			if parser, ok := settings["parser"].(string); ok && !syslogParsers[parser] {
+				problem(tasks.Failure, "syslog.parser %s is not one of rfc3164, rfc3164-local or rfc5424.", parser)
+			}
+		} else if format, ok := settings["format"].(string); ok && !tcpFormats[format] {
+			problem(tasks.Failure, "tcp.format %s is not one of json or none.", format)
+		}
+	case SourceWinlog, SourceWinevtlog:
+		source.Target, _ = settings["channel"].(string)
+		if source.Target == "" {
+			problem(tasks.Failure, "%s.channel is not set.", source.Type)
+		}
+		if p.goos != "windows" {
+			problem(tasks.Failure, "%s sources are only supported on Windows.", source.Type)
+		}
+	case SourceFluentBit:
+		source.Target, _ = settings["config_file"].(string)
+		source.FluentParsers, _ = settings["parsers_file"].(string)
+		if source.Target == "" {
+			problem(tasks.Failure, "fluentbit.config_file is not set.")
+		}
+	}
+
+	if maxLine, ok := entry["max_line_kb"]; ok {
+		if kb, isInt := maxLine.(int); !isInt || kb <= 0 {
+			problem(tasks.Failure, "max_line_kb must be a positive number of kilobytes.")
+		}
+	}
+	return source, status
+}
+
+func (p InfraLogsConfig) isAbs(path string) bool {
+	if p.goos == "windows" {
+		return windowsAbsRgx.MatchString(path)
+	}
+	return strings.HasPrefix(path, "/")
+}
+
+// label names an entry in the summary, by name or by position when it has none
+func (s LogSource) label(index int) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return fmt.Sprintf("entry %d", index+1)
+}
+
+func maxStatus(a tasks.Status, b tasks.Status) tasks.Status {
+	if b > a {
+		return b
+	}
+	return a
+}
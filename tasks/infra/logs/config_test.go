@@ -0,0 +1,139 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestInfraLogs(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Infra/Logs/* test suite")
+}
+
+var infraDetected = map[string]tasks.Result{
+	"Infra/Config/Agent": {Status: tasks.Success},
+}
+
+func writeFile(dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+	Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	return path
+}
+
+var _ = Describe("Infra/Logs/Config", func() {
+	var (
+		p          InfraLogsConfig
+		loggingDir string
+		result     tasks.Result
+	)
+
+	BeforeEach(func() {
+		loggingDir = GinkgoT().TempDir()
+		p = InfraLogsConfig{goos: "linux", loggingDir: loggingDir}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, infraDetected)
+	})
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Infra", Subcategory: "Logs", Name: "Config"}))
+		})
+	})
+
+	Context("when the infra agent is not detected", func() {
+		It("should return None", func() {
+			Expect(p.Execute(tasks.Options{}, map[string]tasks.Result{}).Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when logging.d is empty", func() {
+		It("should return None", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("when every source is valid", func() {
+		BeforeEach(func() {
+			writeFile(loggingDir, "file.yml", `
+logs:
+  - name: nginx
+    file: /var/log/nginx/*.log
+    max_line_kb: 256
+  - name: cupsd
+    systemd: cupsd
+  - name: syslog
+    syslog:
+      uri: tcp://0.0.0.0:5140
+      parser: rfc5424
+`)
+			writeFile(loggingDir, "tcp.yaml", "logs:\n  - name: app\n    tcp:\n      uri: tcp://0.0.0.0:2222\n      format: json\n")
+		})
+
+		It("should return Success with every source", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(Equal("4 log source(s) configured in 2 file(s) are valid."))
+			sources := result.Payload.([]LogSource)
+			Expect(sources[0]).To(Equal(LogSource{ConfigFile: filepath.Join(loggingDir, "file.yml"), Name: "nginx", Type: SourceFile, Target: "/var/log/nginx/*.log"}))
+			Expect(sources[3].Type).To(Equal(SourceTCP))
+		})
+	})
+
+	Context("when sources are invalid", func() {
+		BeforeEach(func() {
+			writeFile(loggingDir, "bad.yml", `
+logs:
+  - file: var/log/app.log
+  - name: both
+    file: /var/log/a.log
+    systemd: a
+  - name: deep
+    file: /var/log/**/app.log
+  - name: events
+    winlog:
+      channel: Application
+  - name: syslog
+    syslog:
+      uri: http://0.0.0.0:5140
+      parser: rfc1234
+  - name: tcp
+    tcp:
+      uri: tcp://0.0.0.0
+      format: xml
+  - name: deep
+    systemd: sshd
+    max_line_kb: big
+  - name: none
+`)
+			writeFile(loggingDir, "broken.yml", "logs: [unclosed\n")
+		})
+
+		It("should return Failure listing each problem", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			for _, expected := range []string{
+				"entry 1: The entry has no name",
+				"entry 1: file var/log/app.log is not an absolute path.",
+				"both: The entry sets file and systemd, each entry can only have one source.",
+				"deep: file /var/log/**/app.log uses **",
+				"events: winlog sources are only supported on Windows.",
+				"syslog: syslog.uri http://0.0.0.0:5140 must start with tcp://, udp://, unix_tcp:// or unix_udp://.",
+				"syslog: syslog.parser rfc1234 is not one of rfc3164, rfc3164-local or rfc5424.",
+				"tcp: tcp.uri tcp://0.0.0.0 has no port to listen on.",
+				"tcp: tcp.format xml is not one of json or none.",
+				"deep: max_line_kb must be a positive number of kilobytes.",
+				"deep: The name is also used in " + filepath.Join(loggingDir, "bad.yml"),
+				"none: The entry has no source",
+				filepath.Join(loggingDir, "broken.yml") + " is not valid YAML",
+			} {
+				Expect(result.Summary).To(ContainSubstring(expected))
+			}
+		})
+	})
+})
@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// InfraLogsFiles checks the files forwarded by logging.d sources exist and can be read by the agent user
+type InfraLogsFiles struct {
+	goos       string
+	agentUser  func() (agentUser, bool)
+	fileAccess tasks.FileOwnerFunc
+}
+
+// agentUser is the user the newrelic-infra process runs as, root by default or nri-agent in privileged and unprivileged modes
+type agentUser struct {
+	Name string
+	UID  uint32
+	GIDs []uint32
+}
+
+// LogFile is the outcome of checking the files of one source
+type LogFile struct {
+	Source   string
+	Pattern  string
+	Matches  []string
+	Problems []string
+}
+
+func (p InfraLogsFiles) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logs/Files")
+}
+
+func (p InfraLogsFiles) Explain() string {
+	return "Check log files forwarded by the New Relic Infrastructure agent exist and are readable by the agent user"
+}
+
+func (p InfraLogsFiles) Dependencies() []string {
+	return []string{"Infra/Logs/Config"}
+}
+
+func (p InfraLogsFiles) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	sources, ok := upstream["Infra/Logs/Config"].Payload.([]LogSource)
+	if !ok || len(sources) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No log forwarding sources configured.",
+		}
+	}
+
+	user, userFound := p.agentUser()
+	checkAccess := userFound && p.goos != "windows" && user.UID != 0
+
+	status := tasks.None
+	var summary []string
+	var files []LogFile
+	for _, source := range sources {
+		var patterns []string
+		switch source.Type {
+		case SourceFile:
+			patterns = []string{source.Target}
+		case SourceFluentBit:
+			patterns = []string{source.Target, source.FluentParsers}
+		}
+		for _, pattern := range patterns {
+			if pattern == "" {
+				continue
+			}
+			file := LogFile{Source: source.Name, Pattern: pattern}
+			fileStatus := tasks.Success
+			problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+				fileStatus = maxStatus(fileStatus, problemStatus)
+				file.Problems = append(file.Problems, fmt.Sprintf(format, args...))
+			}
+
+			matches, err := filepath.Glob(pattern)
+			switch {
+			case err != nil:
+				problem(tasks.Failure, "%s is not a valid pattern: %s", pattern, err)
+			case len(matches) > 0:
+			case source.Type == SourceFluentBit:
+				problem(tasks.Failure, "%s does not exist, Fluent Bit does not start without it.", pattern)
+			case strings.ContainsAny(pattern, "*?["):
+				problem(tasks.Warning, "%s does not match any file yet, nothing is forwarded until a matching file is created.", pattern)
+			default:
+				problem(tasks.Warning, "%s does not exist, nothing is forwarded until it is created.", pattern)
+			}
+			for _, match := range matches {
+				info, err := os.Stat(match)
+				if err != nil {
+					problem(tasks.Failure, "%s could not be read: %s", match, err)
+					continue
+				}
+				if info.IsDir() {
+					problem(tasks.Warning, "%s is a directory, use a file name or a pattern such as %s.", match, filepath.Join(match, "*.log"))
+					continue
+				}
+				file.Matches = append(file.Matches, match)
+				if checkAccess && !canRead(info, user, p.fileAccess) {
+					problem(tasks.Failure, "%s is not readable by the agent user %s (%s), the agent cannot forward it.", match, user.Name, info.Mode().Perm())
+				}
+			}
+
+			status = maxStatus(status, fileStatus)
+			files = append(files, file)
+			for _, fileProblem := range file.Problems {
+				summary = append(summary, source.Name+": "+fileProblem)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No file log forwarding sources configured.",
+		}
+	}
+	if status == tasks.Success {
+		summary = append(summary, fmt.Sprintf("The files of %d log source(s) exist and can be read by the agent.", len(files)))
+	}
+	if !userFound && p.goos != "windows" {
+		summary = append(summary, "The newrelic-infra process is not running, file permissions were not checked against its user.")
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: files,
+	}
+}
+
+// canRead reports whether the permission bits of a file let the agent user read it
+func canRead(info os.FileInfo, user agentUser, fileAccess tasks.FileOwnerFunc) bool {
+	uid, gid, ok := fileAccess(info)
+	if !ok {
+		return true
+	}
+	perm := info.Mode().Perm()
+	if uid == user.UID {
+		return perm&0o400 != 0
+	}
+	for _, userGID := range user.GIDs {
+		if userGID == gid {
+			return perm&0o040 != 0
+		}
+	}
+	return perm&0o004 != 0
+}
+
+// findAgentUser returns the user and groups of the running newrelic-infra process
+func findAgentUser() (agentUser, bool) {
+	procs, err := tasks.FindProcessByName("newrelic-infra")
+	if err != nil || len(procs) == 0 {
+		return agentUser{}, false
+	}
+	proc := &procs[0]
+	uids, err := proc.Uids()
+	if err != nil || len(uids) < 2 {
+		log.Debug("could not read the uid of newrelic-infra:", err)
+		return agentUser{}, false
+	}
+	user := agentUser{UID: uint32(uids[1])}
+	user.Name, _ = proc.Username()
+	gids, _ := proc.Gids()
+	groups, _ := proc.Groups()
+	for _, gid := range append(gids, groups...) {
+		user.GIDs = append(user.GIDs, uint32(gid))
+	}
+	return user, true
+}
@@ -0,0 +1,136 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Infra/Logs/Files", func() {
+	var (
+		p        InfraLogsFiles
+		dir      string
+		sources  []LogSource
+		result   tasks.Result
+		nriAgent = agentUser{Name: "nri-agent", UID: 1000, GIDs: []uint32{1000, 4}}
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("file modes are not enforced on Windows")
+		}
+		dir = GinkgoT().TempDir()
+		p = InfraLogsFiles{
+			goos:      "linux",
+			agentUser: func() (agentUser, bool) { return nriAgent, true },
+			// files owned by root:adm (gid 4), except owned.log owned by the agent user
+			fileAccess: func(info os.FileInfo) (uint32, uint32, bool) {
+				if info.Name() == "owned.log" {
+					return 1000, 1000, true
+				}
+				return 0, 4, true
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Infra/Logs/Config": {Status: tasks.Success, Payload: sources},
+		})
+	})
+
+	Context("when the files exist and are readable", func() {
+		BeforeEach(func() {
+			writeFile(dir, "app/a.log", "a")
+			writeFile(dir, "app/b.log", "b")
+			writeFile(dir, "owned.log", "c")
+			Expect(os.Chmod(filepath.Join(dir, "owned.log"), 0o600)).To(Succeed())
+			sources = []LogSource{
+				{Name: "app", Type: SourceFile, Target: filepath.Join(dir, "app", "*.log")},
+				{Name: "owned", Type: SourceFile, Target: filepath.Join(dir, "owned.log")},
+				{Name: "cupsd", Type: SourceSystemd, Target: "cupsd"},
+			}
+		})
+
+		It("should return Success with the matched files", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			files := result.Payload.([]LogFile)
+			Expect(files).To(HaveLen(2))
+			Expect(files[0].Matches).To(Equal([]string{filepath.Join(dir, "app", "a.log"), filepath.Join(dir, "app", "b.log")}))
+		})
+	})
+
+	Context("when files are missing or not readable by the agent user", func() {
+		BeforeEach(func() {
+			writeFile(dir, "secure", "s")
+			Expect(os.Chmod(filepath.Join(dir, "secure"), 0o600)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(dir, "logs"), 0o755)).To(Succeed())
+			sources = []LogSource{
+				{Name: "secure", Type: SourceFile, Target: filepath.Join(dir, "secure")},
+				{Name: "missing", Type: SourceFile, Target: filepath.Join(dir, "missing.log")},
+				{Name: "later", Type: SourceFile, Target: filepath.Join(dir, "later", "*.log")},
+				{Name: "dir", Type: SourceFile, Target: filepath.Join(dir, "logs")},
+				{Name: "custom", Type: SourceFluentBit, Target: filepath.Join(dir, "fluent.conf")},
+			}
+		})
+
+		It("should return Failure listing each problem", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			for _, expected := range []string{
+				"secure: " + filepath.Join(dir, "secure") + " is not readable by the agent user nri-agent (-rw-------)",
+				"missing: " + filepath.Join(dir, "missing.log") + " does not exist, nothing is forwarded until it is created.",
+				"later: " + filepath.Join(dir, "later", "*.log") + " does not match any file yet",
+				"dir: " + filepath.Join(dir, "logs") + " is a directory",
+				"custom: " + filepath.Join(dir, "fluent.conf") + " does not exist, Fluent Bit does not start without it.",
+			} {
+				Expect(result.Summary).To(ContainSubstring(expected))
+			}
+		})
+	})
+
+	Context("when the agent runs as root", func() {
+		BeforeEach(func() {
+			writeFile(dir, "secure", "s")
+			Expect(os.Chmod(filepath.Join(dir, "secure"), 0o600)).To(Succeed())
+			p.agentUser = func() (agentUser, bool) { return agentUser{Name: "root"}, true }
+			sources = []LogSource{{Name: "secure", Type: SourceFile, Target: filepath.Join(dir, "secure")}}
+		})
+
+		It("should not check permissions", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+		})
+	})
+})
+
+var _ = Describe("canRead", func() {
+	user := agentUser{UID: 1000, GIDs: []uint32{4}}
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("file modes are not enforced on Windows")
+		}
+	})
+	info := func(name string, mode os.FileMode) os.FileInfo {
+		path := writeFile(GinkgoT().TempDir(), name, "")
+		Expect(os.Chmod(path, mode)).To(Succeed())
+		stat, err := os.Stat(path)
+		Expect(err).NotTo(HaveOccurred())
+		return stat
+	}
+	owner := func(uid, gid uint32) func(os.FileInfo) (uint32, uint32, bool) {
+		return func(os.FileInfo) (uint32, uint32, bool) { return uid, gid, true }
+	}
+
+	It("should use the group bits for a group the agent user is in", func() {
+		Expect(canRead(info("a", 0o640), user, owner(0, 4))).To(BeTrue())
+		Expect(canRead(info("b", 0o604), user, owner(0, 4))).To(BeFalse())
+	})
+
+	It("should use the other bits otherwise", func() {
+		Expect(canRead(info("c", 0o644), user, owner(0, 0))).To(BeTrue())
+		Expect(canRead(info("d", 0o640), user, owner(0, 0))).To(BeFalse())
+	})
+})
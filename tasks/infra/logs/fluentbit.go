@@ -0,0 +1,169 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+var (
+	fluentBitVersionRgx = regexp.MustCompile(`Fluent Bit v(\d+\.\d+\.\d+)`)
+	// fluentBitKeyRgx matches the license key the agent writes to the newrelic output of the generated config
+	fluentBitKeyRgx = regexp.MustCompile(`(?im)^(\s*(?:licenseKey|apiKey)\s+)\S+`)
+)
+
+// InfraLogsFluentBit locates the Fluent Bit binary the agent runs, its version and the config the agent generates for it
+type InfraLogsFluentBit struct {
+	goos              string
+	findProcessByName tasks.FindProcessByNameFunc
+	cmdLineArgs       func(*process.Process) ([]string, error)
+	cmdExec           tasks.CmdExecFunc
+	fileExists        tasks.FileExistsFunc
+	glob              func(string) ([]string, error)
+	readFile          func(string) ([]byte, error)
+}
+
+// FluentBit is the Fluent Bit install the agent forwards logs with, Pid is 0 when it is not running
+type FluentBit struct {
+	Binary      string
+	Version     string
+	Pid         int32
+	ConfigFile  string
+	ParsersFile string
+	PluginFile  string
+}
+
+func (p InfraLogsFluentBit) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Infra/Logs/FluentBit")
+}
+
+func (p InfraLogsFluentBit) Explain() string {
+	return "Detect the Fluent Bit log forwarder of the New Relic Infrastructure agent and its generated config"
+}
+
+func (p InfraLogsFluentBit) Dependencies() []string {
+	return []string{"Infra/Config/Agent", "Infra/Logs/Config"}
+}
+
+func (p InfraLogsFluentBit) Execute(_ tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Infra/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Infra Agent detected. Task not executed.",
+		}
+	}
+	sources, _ := upstream["Infra/Logs/Config"].Payload.([]LogSource)
+
+	fluentBit := p.findFluentBit()
+	if fluentBit.Binary == "" {
+		if len(sources) == 0 {
+			return tasks.Result{
+				Status:  tasks.None,
+				Summary: "Log forwarding is not configured and Fluent Bit is not installed.",
+			}
+		}
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: fmt.Sprintf("logging.d configures %d log source(s) but Fluent Bit is not installed, install the fluent-bit or td-agent-bit package the agent depends on.", len(sources)),
+			URL:     "https://docs.newrelic.com/docs/logs/forward-logs/forward-your-logs-using-infrastructure-agent/",
+		}
+	}
+	if out, err := p.cmdExec(fluentBit.Binary, "--version"); err != nil {
+		log.Debug("could not read the Fluent Bit version:", err)
+	} else if m := fluentBitVersionRgx.FindStringSubmatch(string(out)); len(m) > 1 {
+		fluentBit.Version = m[1]
+	}
+
+	var filesToCopy []tasks.FileCopyEnvelope
+	if fluentBit.ConfigFile != "" {
+		if data, err := p.readFile(fluentBit.ConfigFile); err == nil {
+			stream := make(chan string)
+			go tasks.StreamBlob(fluentBitKeyRgx.ReplaceAllString(string(data), "${1}_REDACTED_"), stream)
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{
+				Path:       "infra-fluent-bit.conf",
+				Stream:     stream,
+				Identifier: p.Identifier().String(),
+			})
+		}
+	}
+	if fluentBit.ParsersFile != "" {
+		filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{Path: fluentBit.ParsersFile})
+	}
+
+	description := fluentBit.Binary
+	if fluentBit.Version != "" {
+		description += " v" + fluentBit.Version
+	}
+	result := tasks.Result{
+		Payload:     fluentBit,
+		FilesToCopy: filesToCopy,
+	}
+	switch {
+	case fluentBit.Pid != 0:
+		result.Status = tasks.Info
+		result.Summary = fmt.Sprintf("Fluent Bit %s is running with pid %d, config: %s, parsers: %s", description, fluentBit.Pid, fluentBit.ConfigFile, fluentBit.ParsersFile)
+	case len(sources) > 0:
+		result.Status = tasks.Warning
+		result.Summary = fmt.Sprintf("Fluent Bit %s is installed but not running although logging.d configures %d log source(s). Check the agent log for errors starting it.", description, len(sources))
+	default:
+		result.Status = tasks.Info
+		result.Summary = fmt.Sprintf("Fluent Bit %s is installed, logging.d configures no log sources.", description)
+	}
+	return result
+}
+
+// findFluentBit reads the files Fluent Bit runs with from its command line, or looks for them where the agent puts them
+func (p InfraLogsFluentBit) findFluentBit() FluentBit {
+	var fluentBit FluentBit
+	for _, name := range fluentBitProcesses {
+		procs, err := p.findProcessByName(name)
+		if err != nil || len(procs) == 0 {
+			continue
+		}
+		args, err := p.cmdLineArgs(&procs[0])
+		if err != nil || len(args) == 0 {
+			log.Debug("could not read the Fluent Bit command line:", err)
+			continue
+		}
+		fluentBit.Pid = procs[0].Pid
+		fluentBit.Binary = args[0]
+		fluentBit.ConfigFile = flagValue(args, "-c", "--config")
+		fluentBit.ParsersFile = flagValue(args, "-R", "--parser")
+		fluentBit.PluginFile = flagValue(args, "-e", "--plugin")
+		return fluentBit
+	}
+
+	for _, binary := range fluentBitBinaries[p.goos] {
+		if p.fileExists(binary) {
+			fluentBit.Binary = binary
+			break
+		}
+	}
+	if configs, err := p.glob(generatedConfigGlobs[p.goos]); err == nil && len(configs) > 0 {
+		sort.Strings(configs)
+		fluentBit.ConfigFile = configs[len(configs)-1]
+	}
+	if parsers := parsersFiles[p.goos]; p.fileExists(parsers) {
+		fluentBit.ParsersFile = parsers
+	}
+	return fluentBit
+}
+
+// flagValue returns the value of a command line flag given as -f value, --flag value or --flag=value
+func flagValue(args []string, short string, long string) string {
+	for i, arg := range args {
+		if (arg == short || arg == long) && i+1 < len(args) {
+			return args[i+1]
+		}
+		if strings.HasPrefix(arg, long+"=") {
+			return strings.TrimPrefix(arg, long+"=")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const generatedConfig = `[SERVICE]
+    Flush        1
+    Log_Level    info
+    Log_File     /var/log/fluent-bit.log
+
+[OUTPUT]
+    Name         newrelic
+    Match        *
+    licenseKey   0123456789abcdef0123456789abcdef01NRAL
+`
+
+var _ = Describe("Infra/Logs/FluentBit", func() {
+	var (
+		p        InfraLogsFluentBit
+		running  bool
+		upstream map[string]tasks.Result
+		result   tasks.Result
+	)
+
+	BeforeEach(func() {
+		running = true
+		upstream = map[string]tasks.Result{
+			"Infra/Config/Agent": {Status: tasks.Success},
+			"Infra/Logs/Config":  {Status: tasks.Success, Payload: []LogSource{{Name: "app", Type: SourceFile}}},
+		}
+		p = InfraLogsFluentBit{
+			goos: "linux",
+			findProcessByName: func(name string) ([]process.Process, error) {
+				if running && name == "fluent-bit" {
+					return []process.Process{{Pid: 77}}, nil
+				}
+				return nil, nil
+			},
+			cmdLineArgs: func(*process.Process) ([]string, error) {
+				return []string{"/opt/fluent-bit/bin/fluent-bit", "-c", "/tmp/nr_fb_config123", "-e", "/var/db/newrelic-infra/newrelic-integrations/logging/out_newrelic.so", "-R", "/var/db/newrelic-infra/newrelic-integrations/logging/parsers.conf"}, nil
+			},
+			cmdExec: func(name string, arg ...string) ([]byte, error) {
+				return []byte("Fluent Bit v2.0.8\nGit commit: 9e5fa1b\n"), nil
+			},
+			fileExists: func(path string) bool { return path == "/opt/td-agent-bit/bin/td-agent-bit" },
+			glob:       func(string) ([]string, error) { return []string{"/tmp/nr_fb_config1", "/tmp/nr_fb_config2"}, nil },
+			readFile:   func(string) ([]byte, error) { return []byte(generatedConfig), nil },
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("when Fluent Bit is running", func() {
+		It("should read its files from the command line and redact the generated config", func() {
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.Payload).To(Equal(FluentBit{
+				Binary:      "/opt/fluent-bit/bin/fluent-bit",
+				Version:     "2.0.8",
+				Pid:         77,
+				ConfigFile:  "/tmp/nr_fb_config123",
+				ParsersFile: "/var/db/newrelic-infra/newrelic-integrations/logging/parsers.conf",
+				PluginFile:  "/var/db/newrelic-infra/newrelic-integrations/logging/out_newrelic.so",
+			}))
+			Expect(result.FilesToCopy).To(HaveLen(2))
+			var config strings.Builder
+			for line := range result.FilesToCopy[0].Stream {
+				config.WriteString(line)
+			}
+			Expect(config.String()).To(ContainSubstring("licenseKey   _REDACTED_"))
+			Expect(config.String()).NotTo(ContainSubstring("NRAL"))
+		})
+	})
+
+	Context("when Fluent Bit is installed but not running", func() {
+		BeforeEach(func() {
+			running = false
+		})
+
+		It("should warn and use the latest generated config", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(HavePrefix("Fluent Bit /opt/td-agent-bit/bin/td-agent-bit v2.0.8 is installed but not running"))
+			Expect(result.Payload.(FluentBit).ConfigFile).To(Equal("/tmp/nr_fb_config2"))
+		})
+	})
+
+	Context("when log sources are configured but Fluent Bit is not installed", func() {
+		BeforeEach(func() {
+			running = false
+			p.fileExists = func(string) bool { return false }
+		})
+
+		It("should return Failure", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("logging.d configures 1 log source(s) but Fluent Bit is not installed"))
+		})
+	})
+})
+
+var _ = Describe("Infra/Logs/Collect", func() {
+	files := map[string]string{
+		"/var/log/newrelic-infra/newrelic-infra.log": "level=info msg=\"starting\"\n" +
+			"level=info msg=\"[2024/01/01] [ info] [engine] started\" component=integrations.Supervisor supervisor=fluent-bit\n" +
+			"level=warn msg=\"Fluent-Bit exited\"\n",
+		"/tmp/nr_fb_config123": generatedConfig,
+	}
+	p := InfraLogsCollect{
+		open: func(path string) (io.ReadCloser, error) {
+			content, ok := files[path]
+			if !ok {
+				return nil, errors.New("no such file")
+			}
+			return io.NopCloser(strings.NewReader(content)), nil
+		},
+	}
+
+	It("should collect the Fluent Bit lines of the agent log and the Fluent Bit log file", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{
+			"Infra/Log/Collect":    {Status: tasks.Success, Payload: []string{"/var/log/newrelic-infra/newrelic-infra.log"}},
+			"Infra/Logs/FluentBit": {Status: tasks.Info, Payload: FluentBit{ConfigFile: "/tmp/nr_fb_config123"}},
+		})
+
+		Expect(result.Status).To(Equal(tasks.Info))
+		Expect(result.FilesToCopy).To(HaveLen(2))
+		var lines strings.Builder
+		for line := range result.FilesToCopy[0].Stream {
+			lines.WriteString(line)
+		}
+		Expect(strings.Count(lines.String(), "\n")).To(Equal(2))
+		Expect(result.FilesToCopy[1].Path).To(Equal("/var/log/fluent-bit.log"))
+	})
+
+	It("should return None without Fluent Bit", func() {
+		result := p.Execute(tasks.Options{}, map[string]tasks.Result{"Infra/Logs/FluentBit": {Status: tasks.None}})
+		Expect(result.Status).To(Equal(tasks.None))
+	})
+})
@@ -0,0 +1,66 @@
+package logs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// Paths of the Infrastructure agent log forwarder, by OS
+var (
+	loggingDirs = map[string]string{
+		"linux":   "/etc/newrelic-infra/logging.d",
+		"windows": `C:\Program Files\New Relic\newrelic-infra\logging.d`,
+	}
+	// fluentBitBinaries are the Fluent Bit builds the agent packages install, newest first
+	fluentBitBinaries = map[string][]string{
+		"linux":   {"/opt/fluent-bit/bin/fluent-bit", "/opt/td-agent-bit/bin/td-agent-bit"},
+		"windows": {`C:\Program Files\New Relic\newrelic-infra\newrelic-integrations\logging\fluent-bit.exe`},
+	}
+	// parsersFiles are the parsers the agent ships and passes to Fluent Bit with -R
+	parsersFiles = map[string]string{
+		"linux":   "/var/db/newrelic-infra/newrelic-integrations/logging/parsers.conf",
+		"windows": `C:\Program Files\New Relic\newrelic-infra\newrelic-integrations\logging\parsers.conf`,
+	}
+	// generatedConfigGlobs match the Fluent Bit config the agent generates from logging.d in the temp directory
+	generatedConfigGlobs = map[string]string{
+		"linux":   "/tmp/nr_fb_config*",
+		"windows": filepath.Join(os.TempDir(), "nr_fb_config*"),
+	}
+)
+
+// fluentBitProcesses are the process names of the Fluent Bit builds
+var fluentBitProcesses = []string{"fluent-bit", "td-agent-bit"}
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Infra/Logs/*")
+
+	registrationFunc(InfraLogsConfig{
+		goos:       runtime.GOOS,
+		loggingDir: loggingDirs[runtime.GOOS],
+	}, true)
+	registrationFunc(InfraLogsFiles{
+		goos:       runtime.GOOS,
+		agentUser:  findAgentUser,
+		fileAccess: tasks.FileOwner,
+	}, true)
+	registrationFunc(InfraLogsFluentBit{
+		goos:              runtime.GOOS,
+		findProcessByName: tasks.FindProcessByName,
+		cmdLineArgs:       (*process.Process).CmdlineSlice,
+		cmdExec:           tasks.CmdExecutor,
+		fileExists:        tasks.FileExists,
+		glob:              filepath.Glob,
+		readFile:          os.ReadFile,
+	}, true)
+	registrationFunc(InfraLogsCollect{
+		open: func(path string) (io.ReadCloser, error) { return os.Open(path) },
+	}, true)
+}
@@ -16,4 +16,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		cmdExec:      tasks.CmdExecutor,
 		findTheFiles: tasks.FindFiles,
 	}, true)
+	registrationFunc(JavaAgentJar{
+		wdGetter:     os.Getwd,
+		findTheFiles: tasks.FindFiles,
+		readDir:      os.ReadDir,
+	}, true)
 }
@@ -0,0 +1,425 @@
+package agent
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	javaEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+)
+
+// manifestKeys are the newrelic.jar manifest attributes reported in the payload
+var manifestKeys = []string{
+	"Implementation-Title",
+	"Implementation-Version",
+	"Implementation-Vendor",
+	"Premain-Class",
+	"Agent-Class",
+	"Built-Date",
+	"Build-Date",
+	"Build-Id",
+}
+
+const (
+	agentJarName          = "newrelic.jar"
+	apiJarName            = "newrelic-api.jar"
+	extensionsDirName     = "extensions"
+	extensionXMLNamespace = "https://newrelic.com/docs/java/xsd/v1.0"
+	javaAgentArgPrefix    = "-javaagent:"
+)
+
+// JavaAgentJar - Inspects newrelic.jar, newrelic-api.jar and the extensions directory next to them
+type JavaAgentJar struct {
+	wdGetter     workingDirectoryGetterFunc
+	findTheFiles func([]string, []string) []string
+	readDir      func(string) ([]os.DirEntry, error)
+}
+
+// JarInfo - the manifest and integrity information of a jar file
+type JarInfo struct {
+	Path           string
+	Version        string
+	Manifest       map[string]string
+	IsIntact       bool
+	IntegrityError string
+}
+
+// ExtensionFile - a file in the agent's extensions directory
+type ExtensionFile struct {
+	Path             string
+	Type             string
+	ValidationErrors []string
+}
+
+// AgentJarInfo - everything found about one agent installation
+type AgentJarInfo struct {
+	AgentJar   JarInfo
+	APIJar     *JarInfo
+	Extensions []ExtensionFile
+	PIDs       []int32
+}
+
+// JavaAgentJarPayload - payload for Java/Agent/Jar
+type JavaAgentJarPayload struct {
+	Agents             []AgentJarInfo
+	MultipleJavaAgents map[int32][]string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaAgentJar) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Agent/Jar")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaAgentJar) Explain() string {
+	return "Inspect the New Relic Java agent jar, its manifest and installed extensions"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaAgentJar) Dependencies() []string {
+	return []string{
+		"Java/Config/Agent",
+		"Java/Env/Process",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaAgentJar) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Java/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Java agent not detected. Task did not run.",
+		}
+	}
+
+	payload := JavaAgentJarPayload{MultipleJavaAgents: make(map[int32][]string)}
+	jarPIDs := make(map[string][]int32)
+	var jarPaths []string
+
+	if processes, ok := upstream["Java/Env/Process"].Payload.([]javaEnv.ProcIdAndArgs); ok {
+		for i := range processes {
+			process := &processes[i]
+			javaAgents := findJavaAgentArgs(process.CmdLineArgs)
+			if len(javaAgents) > 1 {
+				payload.MultipleJavaAgents[process.Proc.Pid] = javaAgents
+			}
+			jarPath := process.JarPath
+			if !filepath.IsAbs(jarPath) && process.Cwd != "" {
+				jarPath = filepath.Join(process.Cwd, jarPath)
+			}
+			if _, found := jarPIDs[jarPath]; !found {
+				jarPaths = append(jarPaths, jarPath)
+			}
+			jarPIDs[jarPath] = append(jarPIDs[jarPath], process.Proc.Pid)
+		}
+	}
+
+	if len(jarPaths) == 0 {
+		jarPaths = p.findLocalJars()
+	}
+
+	if len(jarPaths) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Unable to locate newrelic.jar from running JVMs or the working directory.",
+		}
+	}
+
+	for _, jarPath := range jarPaths {
+		agent := AgentJarInfo{
+			AgentJar: inspectJar(jarPath),
+			PIDs:     jarPIDs[jarPath],
+		}
+		apiJarPath := filepath.Join(filepath.Dir(jarPath), apiJarName)
+		if tasks.FileExists(apiJarPath) {
+			apiJar := inspectJar(apiJarPath)
+			agent.APIJar = &apiJar
+		}
+		agent.Extensions = p.inspectExtensions(filepath.Join(filepath.Dir(jarPath), extensionsDirName))
+		payload.Agents = append(payload.Agents, agent)
+	}
+
+	return evaluateAgentJars(payload)
+}
+
+func evaluateAgentJars(payload JavaAgentJarPayload) tasks.Result {
+	status := tasks.Success
+	var summaries []string
+	versions := make(map[string]bool)
+	fromJVMs := false
+
+	for _, agent := range payload.Agents {
+		if len(agent.PIDs) > 0 {
+			fromJVMs = true
+		}
+		jar := agent.AgentJar
+		if !jar.IsIntact {
+			status = tasks.Failure
+			summaries = append(summaries, fmt.Sprintf("%s is corrupt or incomplete: %s", jar.Path, jar.IntegrityError))
+			continue
+		}
+		versions[jar.Version] = true
+		summaries = append(summaries, fmt.Sprintf("%s version %s", jar.Path, jar.Version))
+
+		if agent.APIJar != nil {
+			if !agent.APIJar.IsIntact {
+				status = tasks.Failure
+				summaries = append(summaries, fmt.Sprintf("%s is corrupt or incomplete: %s", agent.APIJar.Path, agent.APIJar.IntegrityError))
+			} else if agent.APIJar.Version != "" && agent.APIJar.Version != jar.Version {
+				status = worseStatus(status, tasks.Warning)
+				summaries = append(summaries, fmt.Sprintf("%s version %s does not match the agent version %s", agent.APIJar.Path, agent.APIJar.Version, jar.Version))
+			}
+		}
+
+		for _, extension := range agent.Extensions {
+			if len(extension.ValidationErrors) == 0 {
+				summaries = append(summaries, "\tExtension: "+extension.Path)
+				continue
+			}
+			status = worseStatus(status, tasks.Warning)
+			summaries = append(summaries, fmt.Sprintf("\tInvalid extension %s:\n\t\t%s", extension.Path, strings.Join(extension.ValidationErrors, "\n\t\t")))
+		}
+	}
+
+	if len(versions) > 1 {
+		status = worseStatus(status, tasks.Warning)
+		var found []string
+		for version := range versions {
+			found = append(found, version)
+		}
+		sort.Strings(found)
+		if fromJVMs {
+			summaries = append(summaries, "Running JVMs use different newrelic.jar versions: "+strings.Join(found, ", "))
+		} else {
+			summaries = append(summaries, "The newrelic.jar files found in the working directory have different versions: "+strings.Join(found, ", "))
+		}
+	}
+
+	pids := make([]int, 0, len(payload.MultipleJavaAgents))
+	for pid := range payload.MultipleJavaAgents {
+		pids = append(pids, int(pid))
+	}
+	sort.Ints(pids)
+	for _, pid := range pids {
+		status = worseStatus(status, tasks.Warning)
+		summaries = append(summaries, fmt.Sprintf("JVM %d has multiple -javaagent arguments: %s. New Relic is not compatible with other Java agents.", pid, strings.Join(payload.MultipleJavaAgents[int32(pid)], " ")))
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: payload,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/agents/java-agent/installation/include-java-agent-jvm-argument"
+	}
+	return result
+}
+
+func worseStatus(current tasks.Status, candidate tasks.Status) tasks.Status {
+	if current == tasks.Failure {
+		return current
+	}
+	return candidate
+}
+
+func (p JavaAgentJar) findLocalJars() []string {
+	path, err := p.wdGetter()
+	if err != nil {
+		log.Debug("Error getting current working directory")
+		return nil
+	}
+	jars := p.findTheFiles([]string{agentJarName}, []string{path})
+	sort.Strings(jars)
+	return jars
+}
+
+func findJavaAgentArgs(cmdLineArgs []string) []string {
+	var javaAgents []string
+	for _, arg := range cmdLineArgs {
+		if strings.HasPrefix(arg, javaAgentArgPrefix) {
+			javaAgents = append(javaAgents, arg)
+		}
+	}
+	return javaAgents
+}
+
+// inspectJar reads the manifest and verifies the checksum of every entry in the jar
+func inspectJar(path string) JarInfo {
+	jar := JarInfo{Path: path, Manifest: make(map[string]string)}
+
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		jar.IntegrityError = err.Error()
+		return jar
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		err := readZipEntry(file, func(r io.Reader) error {
+			if file.Name == "META-INF/MANIFEST.MF" {
+				return parseManifest(r, jar.Manifest)
+			}
+			_, err := io.Copy(io.Discard, r)
+			return err
+		})
+		if err != nil {
+			jar.IntegrityError = fmt.Sprintf("%s: %s", file.Name, err.Error())
+			return jar
+		}
+	}
+
+	jar.Version = jar.Manifest["Implementation-Version"]
+	if strings.HasSuffix(path, agentJarName) && jar.Manifest["Premain-Class"] == "" {
+		jar.IntegrityError = "the manifest does not declare a Premain-Class, so the JVM cannot load it as an agent"
+		return jar
+	}
+	jar.IsIntact = true
+	return jar
+}
+
+// readZipEntry hands the entry's content to read; the zip reader returns zip.ErrChecksum once the content is fully read if its CRC-32 does not match
+func readZipEntry(file *zip.File, read func(io.Reader) error) error {
+	rc, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return read(rc)
+}
+
+func parseManifest(r io.Reader, manifest map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	lastKey := ""
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// manifest lines longer than 72 bytes continue on the next line after a single space
+		if strings.HasPrefix(line, " ") && lastKey != "" {
+			if _, ok := manifest[lastKey]; ok {
+				manifest[lastKey] += line[1:]
+			}
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			lastKey = ""
+			continue
+		}
+		lastKey = parts[0]
+		if tasks.ContainsString(manifestKeys, parts[0]) {
+			manifest[parts[0]] = strings.TrimSpace(parts[1])
+		}
+	}
+	// read the remainder so the checksum is verified
+	_, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return err
+	}
+	return scanner.Err()
+}
+
+func (p JavaAgentJar) inspectExtensions(dir string) []ExtensionFile {
+	entries, err := p.readDir(dir)
+	if err != nil {
+		log.Debug("No extensions directory found at", dir)
+		return nil
+	}
+
+	var extensions []ExtensionFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		extension := ExtensionFile{Path: path}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".xml":
+			extension.Type = "xml"
+			content, err := os.ReadFile(path)
+			if err != nil {
+				extension.ValidationErrors = append(extension.ValidationErrors, err.Error())
+			} else {
+				extension.ValidationErrors = validateExtensionXML(content)
+			}
+		case ".jar":
+			extension.Type = "jar"
+			jar := inspectJar(path)
+			if !jar.IsIntact && jar.IntegrityError != "" {
+				extension.ValidationErrors = append(extension.ValidationErrors, jar.IntegrityError)
+			}
+		default:
+			extension.Type = "other"
+			extension.ValidationErrors = append(extension.ValidationErrors, "only .xml and .jar files are loaded from the extensions directory")
+		}
+		extensions = append(extensions, extension)
+	}
+	return extensions
+}
+
+type extensionXML struct {
+	XMLName         xml.Name                  `xml:"extension"`
+	Name            string                    `xml:"name,attr"`
+	Instrumentation *extensionInstrumentation `xml:"instrumentation"`
+}
+
+type extensionInstrumentation struct {
+	Pointcuts []extensionPointcut `xml:"pointcut"`
+}
+
+type extensionPointcut struct {
+	ClassName         string            `xml:"className"`
+	InterfaceName     string            `xml:"interfaceName"`
+	Methods           []extensionMethod `xml:"method"`
+	MethodAnnotations []string          `xml:"methodAnnotation"`
+}
+
+type extensionMethod struct {
+	Name string `xml:"name"`
+}
+
+// validateExtensionXML checks the structure required by the Java agent's extension.xsd
+func validateExtensionXML(content []byte) []string {
+	var extension extensionXML
+	err := xml.Unmarshal(content, &extension)
+	if err != nil {
+		return []string{"not a valid extension XML file: " + err.Error()}
+	}
+
+	var validationErrors []string
+	if extension.XMLName.Space != extensionXMLNamespace {
+		validationErrors = append(validationErrors, fmt.Sprintf("the extension element must use the namespace %s", extensionXMLNamespace))
+	}
+	if extension.Name == "" {
+		validationErrors = append(validationErrors, "the extension element is missing the required name attribute")
+	}
+	if extension.Instrumentation == nil {
+		return append(validationErrors, "the instrumentation element is missing")
+	}
+	if len(extension.Instrumentation.Pointcuts) == 0 {
+		validationErrors = append(validationErrors, "the instrumentation element has no pointcuts")
+	}
+	for i, pointcut := range extension.Instrumentation.Pointcuts {
+		className := strings.TrimSpace(pointcut.ClassName)
+		interfaceName := strings.TrimSpace(pointcut.InterfaceName)
+		if (className == "") == (interfaceName == "") {
+			validationErrors = append(validationErrors, fmt.Sprintf("pointcut %d must have exactly one className or interfaceName", i+1))
+		}
+		if len(pointcut.Methods) == 0 && len(pointcut.MethodAnnotations) == 0 {
+			validationErrors = append(validationErrors, fmt.Sprintf("pointcut %d has no method or methodAnnotation", i+1))
+		}
+		for _, method := range pointcut.Methods {
+			if strings.TrimSpace(method.Name) == "" {
+				validationErrors = append(validationErrors, fmt.Sprintf("pointcut %d has a method without a name", i+1))
+			}
+		}
+	}
+	return validationErrors
+}
@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	javaEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/java/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const validExtensionXML = `<?xml version="1.0" encoding="UTF-8"?>
+<extension xmlns="https://newrelic.com/docs/java/xsd/v1.0" name="customExtension">
+	<instrumentation>
+		<pointcut transactionStartPoint="true">
+			<className>com.example.Worker</className>
+			<method>
+				<name>run</name>
+			</method>
+		</pointcut>
+	</instrumentation>
+</extension>`
+
+func writeTestJar(path string, manifest string) {
+	file, err := os.Create(path)
+	Expect(err).ToNot(HaveOccurred())
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	entry, err := writer.Create("META-INF/MANIFEST.MF")
+	Expect(err).ToNot(HaveOccurred())
+	_, err = entry.Write([]byte(manifest))
+	Expect(err).ToNot(HaveOccurred())
+	entry, err = writer.Create("com/newrelic/bootstrap/BootstrapAgent.class")
+	Expect(err).ToNot(HaveOccurred())
+	_, err = entry.Write([]byte("not really bytecode"))
+	Expect(err).ToNot(HaveOccurred())
+	Expect(writer.Close()).To(Succeed())
+}
+
+func agentManifest(version string) string {
+	return "Manifest-Version: 1.0\r\nImplementation-Title: New Relic Java Agent\r\nImplementation-Version: " + version + "\r\nPremain-Class: com.newrelic.bootstrap.BootstrapAgent\r\n\r\n"
+}
+
+var _ = Describe("Java/Agent/Jar", func() {
+	var p JavaAgentJar
+
+	Describe("Identifier()", func() {
+		It("Should return the identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Java", Subcategory: "Agent", Name: "Jar"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			options  tasks.Options
+			upstream map[string]tasks.Result
+			result   tasks.Result
+			dir      string
+		)
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			p = JavaAgentJar{
+				wdGetter:     func() (string, error) { return dir, nil },
+				findTheFiles: tasks.FindFiles,
+				readDir:      os.ReadDir,
+			}
+		})
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When the Java agent was not detected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Java/Config/Agent": {Status: tasks.Failure},
+				}
+			})
+
+			It("Should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the agent jar and its extensions are valid", func() {
+			BeforeEach(func() {
+				writeTestJar(filepath.Join(dir, "newrelic.jar"), agentManifest("8.10.0"))
+				writeTestJar(filepath.Join(dir, "newrelic-api.jar"), "Implementation-Version: 8.10.0\n")
+				Expect(os.Mkdir(filepath.Join(dir, "extensions"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(dir, "extensions", "custom.xml"), []byte(validExtensionXML), 0644)).To(Succeed())
+				upstream = map[string]tasks.Result{
+					"Java/Config/Agent": {Status: tasks.Success},
+					"Java/Env/Process":  {Status: tasks.Failure},
+				}
+			})
+
+			It("Should return a Success result with the manifest details", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				payload := result.Payload.(JavaAgentJarPayload)
+				Expect(payload.Agents).To(HaveLen(1))
+				Expect(payload.Agents[0].AgentJar.Version).To(Equal("8.10.0"))
+				Expect(payload.Agents[0].AgentJar.Manifest["Premain-Class"]).To(Equal("com.newrelic.bootstrap.BootstrapAgent"))
+				Expect(payload.Agents[0].APIJar.Version).To(Equal("8.10.0"))
+				Expect(payload.Agents[0].Extensions).To(Equal([]ExtensionFile{{Path: filepath.Join(dir, "extensions", "custom.xml"), Type: "xml"}}))
+			})
+		})
+
+		Context("When the agent jar is truncated", func() {
+			BeforeEach(func() {
+				jarPath := filepath.Join(dir, "newrelic.jar")
+				writeTestJar(jarPath, agentManifest("8.10.0"))
+				content, err := os.ReadFile(jarPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(os.WriteFile(jarPath, content[:len(content)/2], 0644)).To(Succeed())
+				upstream = map[string]tasks.Result{
+					"Java/Config/Agent": {Status: tasks.Success},
+				}
+			})
+
+			It("Should return a Failure result", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("is corrupt or incomplete"))
+			})
+		})
+
+		Context("When running JVMs use different agent versions and multiple agents", func() {
+			BeforeEach(func() {
+				Expect(os.Mkdir(filepath.Join(dir, "app1"), 0755)).To(Succeed())
+				Expect(os.Mkdir(filepath.Join(dir, "app2"), 0755)).To(Succeed())
+				writeTestJar(filepath.Join(dir, "app1", "newrelic.jar"), agentManifest("8.10.0"))
+				writeTestJar(filepath.Join(dir, "app2", "newrelic.jar"), agentManifest("7.11.1"))
+				upstream = map[string]tasks.Result{
+					"Java/Config/Agent": {Status: tasks.Success},
+					"Java/Env/Process": {Status: tasks.Success, Payload: []javaEnv.ProcIdAndArgs{
+						{
+							Proc:        process.Process{Pid: 100},
+							CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "-jar", "app.jar"},
+							Cwd:         filepath.Join(dir, "app1"),
+							JarPath:     "newrelic.jar",
+						},
+						{
+							Proc:        process.Process{Pid: 200},
+							CmdLineArgs: []string{"java", "-javaagent:" + filepath.Join(dir, "app2", "newrelic.jar"), "-javaagent:/opt/other/agent.jar", "-jar", "app.jar"},
+							JarPath:     filepath.Join(dir, "app2", "newrelic.jar"),
+						},
+					}},
+				}
+			})
+
+			It("Should return a Warning result", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("Running JVMs use different newrelic.jar versions: 7.11.1, 8.10.0"))
+				Expect(result.Summary).To(ContainSubstring("JVM 200 has multiple -javaagent arguments"))
+			})
+		})
+
+		Context("When the working directory holds different agent versions and no JVM is running", func() {
+			BeforeEach(func() {
+				Expect(os.Mkdir(filepath.Join(dir, "app1"), 0755)).To(Succeed())
+				Expect(os.Mkdir(filepath.Join(dir, "app2"), 0755)).To(Succeed())
+				writeTestJar(filepath.Join(dir, "app1", "newrelic.jar"), agentManifest("8.10.0"))
+				writeTestJar(filepath.Join(dir, "app2", "newrelic.jar"), agentManifest("7.11.1"))
+				upstream = map[string]tasks.Result{
+					"Java/Config/Agent": {Status: tasks.Success},
+					"Java/Env/Process":  {Status: tasks.Failure},
+				}
+			})
+
+			It("Should return a Warning result that does not blame running JVMs", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("The newrelic.jar files found in the working directory have different versions: 7.11.1, 8.10.0"))
+				Expect(result.Summary).ToNot(ContainSubstring("Running JVMs"))
+			})
+		})
+	})
+
+	Describe("validateExtensionXML()", func() {
+		It("Should return no errors for a valid extension", func() {
+			Expect(validateExtensionXML([]byte(validExtensionXML))).To(BeEmpty())
+		})
+
+		It("Should report a missing name and incomplete pointcuts", func() {
+			content := `<extension xmlns="https://newrelic.com/docs/java/xsd/v1.0"><instrumentation><pointcut><method></method></pointcut></instrumentation></extension>`
+			Expect(validateExtensionXML([]byte(content))).To(Equal([]string{
+				"the extension element is missing the required name attribute",
+				"pointcut 1 must have exactly one className or interfaceName",
+				"pointcut 1 has a method without a name",
+			}))
+		})
+
+		It("Should report malformed XML", func() {
+			Expect(validateExtensionXML([]byte("<extension>"))[0]).To(ContainSubstring("not a valid extension XML file"))
+		})
+	})
+})
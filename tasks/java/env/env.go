@@ -14,4 +14,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		findProcByName: tasks.FindProcessByName,
 		getCmdLineArgs: getCmdLineArgs,
 		getCwd:         getCwd}, true)
+	registrationFunc(JavaEnvTrustStore{
+		getExecutable: getExecutable,
+		listKeystore:  listKeystore,
+		fileExists:    tasks.FileExists,
+	}, true)
 }
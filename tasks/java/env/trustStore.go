@@ -0,0 +1,186 @@
+package env
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/collector"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// JavaEnvTrustStore - checks the trust store of each running JVM against the New Relic endpoint certificates
+type JavaEnvTrustStore struct {
+	getExecutable func(*process.Process) (string, error)
+	listKeystore  keystoreLister
+	fileExists    tasks.FileExistsFunc
+}
+
+// keystoreLister runs keytool with the store password written to its stdin, keeping it off the command line
+type keystoreLister func(keytool string, password string, args ...string) ([]byte, error)
+
+// JVMTrustStore - the trust store a JVM uses and whether it trusts each endpoint
+type JVMTrustStore struct {
+	PID    int32
+	Path   string
+	Checks []httpHelper.TrustCheck
+	Error  string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p JavaEnvTrustStore) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Java/Env/TrustStore")
+}
+
+// Explain - Returns the help text for each individual task
+func (p JavaEnvTrustStore) Explain() string {
+	return "Check that the trust store of each running JVM trusts the New Relic endpoint certificates"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p JavaEnvTrustStore) Dependencies() []string {
+	return []string{
+		"Java/Env/Process",
+		"Base/Collector/TrustStore",
+	}
+}
+
+// Execute - The core work within each task
+func (p JavaEnvTrustStore) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	processes, ok := upstream["Java/Env/Process"].Payload.([]ProcIdAndArgs)
+	if !ok || len(processes) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Java processes running the New Relic Java agent were found. This task did not run.",
+		}
+	}
+	trustPayload, ok := upstream["Base/Collector/TrustStore"].Payload.(collector.TrustStorePayload)
+	if !ok || len(trustPayload.Chains) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic endpoint certificate chains were retrieved. This task did not run.",
+		}
+	}
+
+	var stores []JVMTrustStore
+	var rejections []string
+	var errs []string
+	for i := range processes {
+		proc := &processes[i]
+		store := p.checkJVM(proc, trustPayload.Chains)
+		stores = append(stores, store)
+		if store.Error != "" {
+			errs = append(errs, fmt.Sprintf("JVM %d: %s", store.PID, store.Error))
+		}
+		for _, check := range store.Checks {
+			if !check.Trusted {
+				rejections = append(rejections, fmt.Sprintf("JVM %d (%s) would reject %s: %s", store.PID, store.Path, check.ServerName, check.Reason))
+			}
+		}
+	}
+
+	if len(rejections) > 0 {
+		summary := "The following JVMs would reject the New Relic endpoint certificates:\n\t" + strings.Join(rejections, "\n\t")
+		summary += "\nImport the missing certificate authority into the JVM trust store or set ca_bundle_path in newrelic.yml."
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: summary,
+			URL:     "https://docs.newrelic.com/docs/apm/agents/java-agent/configuration/java-agent-configuration-config-file/#cfg-ca_bundle_path",
+			Payload: stores,
+		}
+	}
+	if len(errs) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Unable to check the trust store of some JVMs:\n\t" + strings.Join(errs, "\n\t"),
+			Payload: stores,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: fmt.Sprintf("The trust stores of %d JVM(s) trust the New Relic endpoint certificates.", len(stores)),
+		Payload: stores,
+	}
+}
+
+func (p JavaEnvTrustStore) checkJVM(proc *ProcIdAndArgs, chains []httpHelper.EndpointChain) JVMTrustStore {
+	store := JVMTrustStore{PID: proc.Proc.Pid}
+
+	path := findSysPropValue(proc.CmdLineArgs, "javax.net.ssl.trustStore")
+	password := findSysPropValue(proc.CmdLineArgs, "javax.net.ssl.trustStorePassword")
+	storeType := findSysPropValue(proc.CmdLineArgs, "javax.net.ssl.trustStoreType")
+
+	keytool := "keytool"
+	exe, err := p.getExecutable(&proc.Proc)
+	if err != nil {
+		log.Debug("Unable to find the executable of JVM", proc.Proc.Pid, err)
+	} else {
+		javaHome := filepath.Dir(filepath.Dir(exe))
+		if p.fileExists(filepath.Join(javaHome, "bin", "keytool")) {
+			keytool = filepath.Join(javaHome, "bin", "keytool")
+		}
+		if path == "" {
+			path = filepath.Join(javaHome, "lib", "security", "cacerts")
+		}
+	}
+
+	if path == "" {
+		store.Error = "unable to locate the JVM trust store"
+		return store
+	}
+	if !filepath.IsAbs(path) && proc.Cwd != "" {
+		path = filepath.Join(proc.Cwd, path)
+	}
+	store.Path = path
+
+	args := []string{"-list", "-rfc", "-keystore", path}
+	if storeType != "" {
+		args = append(args, "-storetype", storeType)
+	}
+	output, err := p.listKeystore(keytool, password, args...)
+	certs := httpHelper.ParsePEMCertificates(output)
+	if err != nil || len(certs) == 0 {
+		// without its password keytool cannot read the certificates of a protected PKCS12 store, so nothing can be said about the CAs it holds
+		store.Error = fmt.Sprintf("the trust store %s could not be listed with keytool", path)
+		if err != nil {
+			store.Error += ": " + err.Error()
+		}
+		if password == "" {
+			store.Error += ". The JVM sets no javax.net.ssl.trustStorePassword, a password protected store cannot be read without it"
+		}
+		return store
+	}
+
+	pool := x509.NewCertPool()
+	for _, cert := range certs {
+		pool.AddCert(cert)
+	}
+	store.Checks = httpHelper.CheckTrust(fmt.Sprintf("JVM %d", store.PID), path, pool, chains)
+	return store
+}
+
+func listKeystore(keytool string, password string, args ...string) ([]byte, error) {
+	cmd := exec.Command(keytool, args...)
+	// keytool reads the password from stdin when it has no console, an empty line lets it list the store without one
+	cmd.Stdin = strings.NewReader(password + "\n")
+	return cmd.CombinedOutput()
+}
+
+func getExecutable(proc *process.Process) (string, error) {
+	return proc.Exe()
+}
+
+func findSysPropValue(cmdLineArgs []string, property string) string {
+	prefix := "-D" + property + "="
+	for _, arg := range cmdLineArgs {
+		if strings.HasPrefix(arg, prefix) {
+			return strings.TrimPrefix(arg, prefix)
+		}
+	}
+	return ""
+}
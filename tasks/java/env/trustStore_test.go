@@ -0,0 +1,159 @@
+package env
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/collector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// selfSignedPEM - a CA certificate that did not sign the test server certificate
+func selfSignedPEM() string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Other CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+var _ = Describe("Java/Env/TrustStore", func() {
+	var (
+		p         JavaEnvTrustStore
+		upstream  map[string]tasks.Result
+		result    tasks.Result
+		serverPEM string
+		otherPEM  string
+		keytool   []string
+		passwords map[string]string
+	)
+
+	BeforeEach(func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		DeferCleanup(server.Close)
+		serverPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+		otherPEM = selfSignedPEM()
+		keytool = nil
+		passwords = map[string]string{}
+
+		upstream = map[string]tasks.Result{
+			"Java/Env/Process": {Status: tasks.Success, Payload: []ProcIdAndArgs{
+				{Proc: process.Process{Pid: 10}, CmdLineArgs: []string{"java", "-javaagent:newrelic.jar", "-jar", "app.jar"}},
+				{Proc: process.Process{Pid: 20}, CmdLineArgs: []string{"java", "-Djavax.net.ssl.trustStore=/opt/app/truststore.jks", "-Djavax.net.ssl.trustStorePassword=secret", "-jar", "app.jar"}},
+			}},
+			"Base/Collector/TrustStore": {Status: tasks.Success, Payload: collector.TrustStorePayload{
+				Chains: []httpHelper.EndpointChain{{ServerName: "example.com", PEM: []string{serverPEM}}},
+			}},
+		}
+		p = JavaEnvTrustStore{
+			getExecutable: func(*process.Process) (string, error) { return "/usr/lib/jvm/java-17/bin/java", nil },
+			fileExists:    func(path string) bool { return path == "/usr/lib/jvm/java-17/bin/keytool" },
+			listKeystore: func(name string, password string, args ...string) ([]byte, error) {
+				keytool = append([]string{name}, args...)
+				passwords[args[3]] = password
+				if args[3] == "/opt/app/truststore.jks" {
+					return []byte("Alias name: other\n" + otherPEM), nil
+				}
+				return []byte("Alias name: mitm\n" + serverPEM), nil
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("When no Java processes were found", func() {
+		BeforeEach(func() {
+			upstream["Java/Env/Process"] = tasks.Result{Status: tasks.Warning}
+		})
+
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+
+	Context("When a JVM uses a custom trust store without the root", func() {
+		It("should return a Failure result naming the JVM", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("JVM 20 (/opt/app/truststore.jks) would reject example.com"))
+			Expect(result.Summary).ToNot(ContainSubstring("JVM 10"))
+			Expect(keytool).To(Equal([]string{"/usr/lib/jvm/java-17/bin/keytool", "-list", "-rfc", "-keystore", "/opt/app/truststore.jks"}))
+			Expect(keytool).ToNot(ContainElement("secret"))
+			Expect(passwords).To(HaveKeyWithValue("/opt/app/truststore.jks", "secret"))
+		})
+
+		It("should check the default cacerts of the other JVM", func() {
+			stores := result.Payload.([]JVMTrustStore)
+			Expect(stores[0].Path).To(Equal("/usr/lib/jvm/java-17/lib/security/cacerts"))
+			Expect(stores[0].Checks[0].Trusted).To(BeTrue())
+		})
+	})
+
+	Context("When keytool cannot read the trust store", func() {
+		BeforeEach(func() {
+			p.listKeystore = func(string, string, ...string) ([]byte, error) {
+				return nil, errors.New("keytool error: java.io.IOException: Keystore was tampered with, or password was incorrect")
+			}
+		})
+
+		It("should return a Warning result", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("password was incorrect"))
+		})
+	})
+
+	Context("When a JVM sets a relative trust store path", func() {
+		BeforeEach(func() {
+			upstream["Java/Env/Process"] = tasks.Result{Status: tasks.Success, Payload: []ProcIdAndArgs{
+				{Proc: process.Process{Pid: 30}, Cwd: "/opt/app", CmdLineArgs: []string{"java", "-Djavax.net.ssl.trustStore=truststore.jks", "-Djavax.net.ssl.trustStorePassword=secret", "-jar", "app.jar"}},
+			}}
+		})
+
+		It("should resolve it against the working directory of the JVM", func() {
+			stores := result.Payload.([]JVMTrustStore)
+			Expect(stores[0].Path).To(Equal("/opt/app/truststore.jks"))
+			Expect(passwords).To(HaveKeyWithValue("/opt/app/truststore.jks", "secret"))
+		})
+	})
+
+	Context("When a protected PKCS12 store lists no certificates without its password", func() {
+		BeforeEach(func() {
+			upstream["Java/Env/Process"] = tasks.Result{Status: tasks.Success, Payload: []ProcIdAndArgs{
+				{Proc: process.Process{Pid: 40}, CmdLineArgs: []string{"java", "-Djavax.net.ssl.trustStore=/opt/app/truststore.p12", "-Djavax.net.ssl.trustStoreType=PKCS12", "-jar", "app.jar"}},
+			}}
+			p.listKeystore = func(string, string, ...string) ([]byte, error) {
+				return []byte("Keystore type: PKCS12\nYour keystore contains 0 entries\n"), nil
+			}
+		})
+
+		It("should report that the store could not be listed instead of a missing CA", func() {
+			Expect(result.Status).To(Equal(tasks.Warning))
+			Expect(result.Summary).To(ContainSubstring("the trust store /opt/app/truststore.p12 could not be listed with keytool"))
+			Expect(result.Summary).To(ContainSubstring("javax.net.ssl.trustStorePassword"))
+			Expect(result.Summary).ToNot(ContainSubstring("would reject"))
+		})
+	})
+})
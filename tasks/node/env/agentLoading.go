@@ -0,0 +1,371 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// NodeEnvAgentLoading - Determines how the Node agent is loaded into the application
+type NodeEnvAgentLoading struct {
+	getNodeProcesses func() []NodeProcessLoading
+	readFile         func(string) ([]byte, error)
+}
+
+// NodeAppLoading - how the agent is loaded by an application described by a package.json file
+type NodeAppLoading struct {
+	PackageJSON     string
+	IsESM           bool
+	EntryFile       string
+	FirstModule     string            // first module required or imported by the entry file
+	RequiredInEntry bool              // the entry file requires or imports newrelic
+	ScriptMethods   map[string]string // package.json script running the entry file => agent load method
+}
+
+// NodeProcessLoading - how the agent is loaded by a running node process
+type NodeProcessLoading struct {
+	PID         int32
+	CmdLine     []string
+	Cwd         string
+	EntryFile   string // script file of the command line, relative to Cwd when not absolute
+	NodeOptions string
+	Method      string
+}
+
+// NodeAgentLoadingPayload - payload for Node/Env/AgentLoading
+type NodeAgentLoadingPayload struct {
+	NodeOptions       string
+	NodeOptionsMethod string
+	Apps              []NodeAppLoading
+	Processes         []NodeProcessLoading
+}
+
+type packageJSON struct {
+	Type    string            `json:"type"`
+	Main    string            `json:"main"`
+	Scripts map[string]string `json:"scripts"`
+}
+
+// Agent load methods
+const (
+	LoadMethodRequireFlag = "-r newrelic"
+	LoadMethodImportAgent = "--import newrelic"
+	LoadMethodImportFlag  = "--import newrelic/esm-loader.mjs"
+	LoadMethodLoaderFlag  = "--experimental-loader newrelic/esm-loader.mjs"
+)
+
+var (
+	importFlagRegex = regexp.MustCompile(`--import[= ]+["']?newrelic/esm-loader\.mjs`)
+	loaderFlagRegex = regexp.MustCompile(`--(?:experimental-)?loader[= ]+["']?newrelic/esm-loader\.mjs`)
+	// the flags may follow NODE_OPTIONS= or its opening quote in a script
+	requireFlagRegex = regexp.MustCompile(`(?:^|[\s"'=])(?:-r|--require)[= ]+["']?newrelic(?:/index\.js)?(?:["'\s]|$)`)
+	importAgentRegex = regexp.MustCompile(`(?:^|[\s"'=])--import[= ]+["']?newrelic(?:/index\.js)?(?:["'\s]|$)`)
+	scriptEntryRegex = regexp.MustCompile(`^[^-].*\.[cm]?[jt]s$`)
+	preloadFlags     = []string{"-r", "--require", "--import", "--loader", "--experimental-loader"}
+	moduleLoadRegex  = regexp.MustCompile(`(?:require\(\s*['"]([^'"]+)['"]\s*\)|^\s*import\s+(?:[^'"]+\s+from\s+)?['"]([^'"]+)['"])`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p NodeEnvAgentLoading) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Node/Env/AgentLoading")
+}
+
+// Explain - Returns the help text for each individual task
+func (p NodeEnvAgentLoading) Explain() string {
+	return "Determine how the Node agent is loaded and whether it loads before the application"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p NodeEnvAgentLoading) Dependencies() []string {
+	return []string{
+		"Node/Config/Agent",
+		"Node/Env/NpmPackage",
+		"Base/Env/CollectEnvVars",
+	}
+}
+
+// Execute - The core work within each task
+func (p NodeEnvAgentLoading) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Node/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Node agent config file not detected. This task did not run",
+		}
+	}
+
+	var payload NodeAgentLoadingPayload
+	if envVars, ok := upstream["Base/Env/CollectEnvVars"].Payload.(map[string]string); ok {
+		payload.NodeOptions = envVars["NODE_OPTIONS"]
+		payload.NodeOptionsMethod = getLoadMethod(payload.NodeOptions)
+	}
+
+	if packageFiles, ok := upstream["Node/Env/NpmPackage"].Payload.([]PackageJsonElement); ok {
+		for _, packageFile := range packageFiles {
+			if packageFile.FileName != "package.json" {
+				continue
+			}
+			app, err := p.inspectPackageJSON(filepath.Join(packageFile.FilePath, packageFile.FileName))
+			if err != nil {
+				log.Debug("Unable to inspect package.json", err)
+				continue
+			}
+			payload.Apps = append(payload.Apps, app)
+		}
+	}
+
+	payload.Processes = p.getNodeProcesses()
+
+	if len(payload.Apps) == 0 && len(payload.Processes) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No package.json file or running node process was found to determine how the agent is loaded.",
+		}
+	}
+
+	return evaluateAgentLoading(payload)
+}
+
+func evaluateAgentLoading(payload NodeAgentLoadingPayload) tasks.Result {
+	status := tasks.Success
+	var summaries []string
+
+	for _, proc := range payload.Processes {
+		if proc.Method != "" {
+			summaries = append(summaries, fmt.Sprintf("Node process %d loads the agent with %s.", proc.PID, proc.Method))
+		}
+	}
+
+	for _, app := range payload.Apps {
+		hasESMLoader, hasPreload := appPreload(app, payload)
+
+		switch {
+		case app.IsESM && !hasESMLoader:
+			status = tasks.Failure
+			summaries = append(summaries, fmt.Sprintf("%s describes an ES module application, but the agent is not loaded with %s. ES module imports are hoisted, so the agent cannot instrument them unless it is loaded as a loader.", app.PackageJSON, LoadMethodImportFlag))
+		case hasPreload:
+			summaries = append(summaries, fmt.Sprintf("%s: the agent is preloaded before the application starts.", app.PackageJSON))
+		case app.RequiredInEntry && app.FirstModule == "newrelic":
+			summaries = append(summaries, fmt.Sprintf("%s: newrelic is the first module required by %s.", app.PackageJSON, app.EntryFile))
+		case app.RequiredInEntry:
+			if status != tasks.Failure {
+				status = tasks.Warning
+			}
+			summaries = append(summaries, fmt.Sprintf("%s: %s requires %s before newrelic. Modules loaded before the agent will not be instrumented; require newrelic first or start node with %s.", app.PackageJSON, app.EntryFile, app.FirstModule, LoadMethodRequireFlag))
+		default:
+			if status != tasks.Failure {
+				status = tasks.Warning
+			}
+			summaries = append(summaries, fmt.Sprintf("%s: we could not find where the agent is loaded. Add require('newrelic') as the first line of your entry file or start node with %s.", app.PackageJSON, LoadMethodRequireFlag))
+		}
+	}
+
+	if len(summaries) == 0 {
+		summaries = append(summaries, "No running node process loads the agent with -r or --import.")
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: payload,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/apm/agents/nodejs-agent/installation-configuration/install-nodejs-agent"
+	}
+	return result
+}
+
+// appPreload reports whether an app preloads the agent, and with the ESM loader. The node processes running the app
+// show how it was started, each of them must preload the agent. When none of them is running, the package.json
+// scripts that start the app and NODE_OPTIONS count instead.
+func appPreload(app NodeAppLoading, payload NodeAgentLoadingPayload) (bool, bool) {
+	var processMethods [][]string
+	for _, proc := range payload.Processes {
+		if processRunsApp(proc, app) {
+			processMethods = append(processMethods, []string{proc.Method})
+		}
+	}
+	if len(processMethods) == 0 {
+		methods := []string{payload.NodeOptionsMethod}
+		for _, method := range app.ScriptMethods {
+			methods = append(methods, method)
+		}
+		processMethods = [][]string{methods}
+	}
+
+	hasESMLoader, hasPreload := true, true
+	for _, methods := range processMethods {
+		esmLoader := tasks.ContainsString(methods, LoadMethodImportFlag) || tasks.ContainsString(methods, LoadMethodLoaderFlag)
+		hasESMLoader = hasESMLoader && esmLoader
+		hasPreload = hasPreload && (esmLoader || tasks.ContainsString(methods, LoadMethodRequireFlag) || tasks.ContainsString(methods, LoadMethodImportAgent))
+	}
+	return hasESMLoader, hasPreload
+}
+
+// processRunsApp matches a node process to the app whose directory it runs in or whose entry file it runs
+func processRunsApp(proc NodeProcessLoading, app NodeAppLoading) bool {
+	appDir := filepath.Dir(app.PackageJSON)
+	if proc.Cwd != "" && filepath.Clean(proc.Cwd) == appDir {
+		return true
+	}
+	if proc.EntryFile == "" {
+		return false
+	}
+	entry := proc.EntryFile
+	if !filepath.IsAbs(entry) {
+		entry = filepath.Join(proc.Cwd, entry)
+	}
+	return entry == app.EntryFile || strings.HasPrefix(entry, appDir+string(filepath.Separator))
+}
+
+func (p NodeEnvAgentLoading) inspectPackageJSON(path string) (NodeAppLoading, error) {
+	app := NodeAppLoading{
+		PackageJSON:   path,
+		ScriptMethods: make(map[string]string),
+	}
+
+	content, err := p.readFile(path)
+	if err != nil {
+		return app, err
+	}
+	var pkg packageJSON
+	err = json.Unmarshal(content, &pkg)
+	if err != nil {
+		return app, err
+	}
+
+	app.IsESM = pkg.Type == "module"
+	app.EntryFile = pkg.Main
+
+	scriptNames := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		scriptNames = append(scriptNames, name)
+	}
+	sort.Strings(scriptNames)
+	if entry := findScriptEntryFile(pkg.Scripts["start"]); entry != "" {
+		app.EntryFile = entry
+	}
+	if app.EntryFile == "" {
+		app.EntryFile = "index.js"
+	}
+	app.EntryFile = filepath.Join(filepath.Dir(path), app.EntryFile)
+
+	// only the scripts that start the app tell how it loads the agent, not those running tests or tools
+	for _, name := range scriptNames {
+		script := pkg.Scripts[name]
+		entry := findScriptEntryFile(script)
+		if name != "start" && (entry == "" || filepath.Join(filepath.Dir(path), entry) != app.EntryFile) {
+			continue
+		}
+		if method := getLoadMethod(script); method != "" {
+			app.ScriptMethods[name] = method
+		}
+	}
+	if ext := filepath.Ext(app.EntryFile); ext == ".mjs" || ext == ".mts" {
+		app.IsESM = true
+	}
+
+	entryContent, err := p.readFile(app.EntryFile)
+	if err != nil {
+		log.Debug("Unable to read entry file", app.EntryFile, err)
+		return app, nil
+	}
+	app.FirstModule, app.RequiredInEntry = findFirstModule(string(entryContent))
+	return app, nil
+}
+
+// findScriptEntryFile returns the first script file in a command, skipping the values of preload flags
+func findScriptEntryFile(script string) string {
+	args := strings.Fields(script)
+	for i, arg := range args {
+		if i > 0 && tasks.ContainsString(preloadFlags, args[i-1]) {
+			continue
+		}
+		if scriptEntryRegex.MatchString(arg) {
+			return arg
+		}
+	}
+	return ""
+}
+
+// findFirstModule returns the first module the source loads and whether newrelic is loaded anywhere in it
+func findFirstModule(source string) (string, bool) {
+	firstModule := ""
+	loadsAgent := false
+	for _, line := range strings.Split(source, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "*") {
+			continue
+		}
+		for _, matches := range moduleLoadRegex.FindAllStringSubmatch(line, -1) {
+			module := matches[1]
+			if module == "" {
+				module = matches[2]
+			}
+			if firstModule == "" {
+				firstModule = module
+			}
+			if module == "newrelic" {
+				loadsAgent = true
+			}
+		}
+	}
+	return firstModule, loadsAgent
+}
+
+// getLoadMethod returns the agent load method found in a node command line or NODE_OPTIONS value
+func getLoadMethod(args string) string {
+	switch {
+	case importFlagRegex.MatchString(args):
+		return LoadMethodImportFlag
+	case loaderFlagRegex.MatchString(args):
+		return LoadMethodLoaderFlag
+	case requireFlagRegex.MatchString(args):
+		return LoadMethodRequireFlag
+	case importAgentRegex.MatchString(args):
+		return LoadMethodImportAgent
+	}
+	return ""
+}
+
+func getNodeProcesses() []NodeProcessLoading {
+	var nodeProcesses []NodeProcessLoading
+
+	processes, err := tasks.FindProcessByName("node")
+	if err != nil {
+		log.Debug("Failed to get list of node processes", err)
+		return nodeProcesses
+	}
+
+	for i := range processes {
+		proc := &processes[i]
+		cmdLine, err := proc.CmdlineSlice()
+		if err != nil {
+			log.Debug("Unable to get command line for", proc.Pid, err)
+			continue
+		}
+		nodeProcess := NodeProcessLoading{
+			PID:       proc.Pid,
+			CmdLine:   cmdLine,
+			EntryFile: findScriptEntryFile(strings.Join(cmdLine, " ")),
+		}
+		if cwd, err := proc.Cwd(); err == nil {
+			nodeProcess.Cwd = cwd
+		}
+		envVars, err := tasks.GetProcessEnvVars(proc.Pid)
+		if err == nil {
+			nodeProcess.NodeOptions = envVars.All["NODE_OPTIONS"]
+		}
+		nodeProcess.Method = getLoadMethod(strings.Join(cmdLine, " "))
+		if nodeProcess.Method == "" {
+			nodeProcess.Method = getLoadMethod(nodeProcess.NodeOptions)
+		}
+		nodeProcesses = append(nodeProcesses, nodeProcess)
+	}
+	return nodeProcesses
+}
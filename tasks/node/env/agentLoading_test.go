@@ -0,0 +1,298 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func mockReadFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+		return []byte(content), nil
+	}
+}
+
+func noNodeProcesses() []NodeProcessLoading {
+	return []NodeProcessLoading{}
+}
+
+var _ = Describe("Node/Env/AgentLoading", func() {
+	var p NodeEnvAgentLoading
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Node", Subcategory: "Env", Name: "AgentLoading"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			options  tasks.Options
+			upstream map[string]tasks.Result
+			result   tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When the Node agent was not detected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent": {Status: tasks.None},
+				}
+			})
+
+			It("Should return a None result status", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the entry file requires newrelic first", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"main": "server.js", "scripts": {"test": "mocha"}}`,
+						"/app/server.js":    "// start the agent\n'use strict'\nconst newrelic = require('newrelic')\nconst express = require('express')\n",
+					}),
+				}
+			})
+
+			It("Should return a Success result status", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				payload := result.Payload.(NodeAgentLoadingPayload)
+				Expect(payload.Apps[0].EntryFile).To(Equal("/app/server.js"))
+				Expect(payload.Apps[0].FirstModule).To(Equal("newrelic"))
+			})
+		})
+
+		Context("When the entry file requires other modules before newrelic", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"scripts": {"start": "node --max-old-space-size=2048 src/index.js"}}`,
+						"/app/src/index.js": "const express = require('express')\nrequire('newrelic')\n",
+					}),
+				}
+			})
+
+			It("Should return a Warning result naming the first module", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("requires express before newrelic"))
+			})
+		})
+
+		Context("When the agent is preloaded through a package.json script", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"scripts": {"start": "node -r newrelic dist/index.js"}}`,
+					}),
+				}
+			})
+
+			It("Should return a Success result and record the script method", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				payload := result.Payload.(NodeAgentLoadingPayload)
+				Expect(payload.Apps[0].ScriptMethods).To(Equal(map[string]string{"start": LoadMethodRequireFlag}))
+				Expect(payload.Apps[0].EntryFile).To(Equal("/app/dist/index.js"))
+			})
+		})
+
+		Context("When only a script that does not start the application preloads the agent", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"main": "server.js", "scripts": {"start": "node server.js", "test": "node -r newrelic test/run.js"}}`,
+						"/app/server.js":    "const express = require('express')\n",
+					}),
+				}
+			})
+
+			It("Should return a Warning result", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Payload.(NodeAgentLoadingPayload).Apps[0].ScriptMethods).To(BeEmpty())
+				Expect(result.Summary).To(ContainSubstring("we could not find where the agent is loaded"))
+			})
+		})
+
+		Context("When the start script preloads the agent through NODE_OPTIONS", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"scripts": {"start": "NODE_OPTIONS='--require newrelic' node server.js"}}`,
+					}),
+				}
+			})
+
+			It("Should return a Success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Payload.(NodeAgentLoadingPayload).Apps[0].ScriptMethods).To(Equal(map[string]string{"start": LoadMethodRequireFlag}))
+			})
+		})
+
+		Context("When the running application was not started with its preloading script", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: func() []NodeProcessLoading {
+						return []NodeProcessLoading{{PID: 43, Cwd: "/app", EntryFile: "server.js"}}
+					},
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"scripts": {"start": "node -r newrelic server.js"}}`,
+						"/app/server.js":    "const express = require('express')\n",
+					}),
+				}
+			})
+
+			It("Should judge the application by its process", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("we could not find where the agent is loaded"))
+			})
+		})
+
+		Context("When an ES module application is not loaded with the ESM loader", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":       {Status: tasks.Success},
+					"Node/Env/NpmPackage":     {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+					"Base/Env/CollectEnvVars": {Status: tasks.Info, Payload: map[string]string{"NODE_OPTIONS": "-r newrelic"}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: noNodeProcesses,
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"type": "module", "main": "index.js"}`,
+						"/app/index.js":     "import newrelic from 'newrelic'\nimport express from 'express'\n",
+					}),
+				}
+			})
+
+			It("Should return a Failure result status", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+			})
+		})
+
+		Context("When a running ES module application uses the ESM loader", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: func() []NodeProcessLoading {
+						return []NodeProcessLoading{{PID: 42, CmdLine: []string{"node", "--import", "newrelic/esm-loader.mjs", "index.mjs"}, Cwd: "/app", EntryFile: "index.mjs", Method: getLoadMethod("node --import newrelic/esm-loader.mjs index.mjs")}}
+					},
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"main": "index.mjs"}`,
+					}),
+				}
+			})
+
+			It("Should return a Success result status", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				Expect(result.Summary).To(ContainSubstring("Node process 42 loads the agent with --import newrelic/esm-loader.mjs."))
+			})
+		})
+		Context("When only another application's process uses the ESM loader", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent": {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{
+						{FileName: "package.json", FilePath: "/app/"},
+						{FileName: "package.json", FilePath: "/api/"},
+					}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: func() []NodeProcessLoading {
+						return []NodeProcessLoading{
+							{PID: 42, Cwd: "/srv", EntryFile: "/app/index.mjs", Method: LoadMethodImportFlag},
+							{PID: 43, Cwd: "/api", EntryFile: "server.js"},
+						}
+					},
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"main": "index.mjs"}`,
+						"/api/package.json": `{"type": "module", "main": "server.js"}`,
+						"/api/server.js":    "import express from 'express'\nimport newrelic from 'newrelic'\n",
+					}),
+				}
+			})
+
+			It("Should evaluate each application with its own process", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+				Expect(result.Summary).To(ContainSubstring("/app/package.json: the agent is preloaded before the application starts."))
+				Expect(result.Summary).To(ContainSubstring("/api/package.json describes an ES module application, but the agent is not loaded with --import newrelic/esm-loader.mjs."))
+			})
+		})
+
+		Context("When the process running an application does not preload the agent", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Config/Agent":   {Status: tasks.Success},
+					"Node/Env/NpmPackage": {Status: tasks.Success, Payload: []PackageJsonElement{{FileName: "package.json", FilePath: "/app/"}}},
+				}
+				p = NodeEnvAgentLoading{
+					getNodeProcesses: func() []NodeProcessLoading {
+						return []NodeProcessLoading{
+							{PID: 42, Cwd: "/other", EntryFile: "worker.js", Method: LoadMethodRequireFlag},
+							{PID: 43, Cwd: "/app", EntryFile: "server.js"},
+						}
+					},
+					readFile: mockReadFiles(map[string]string{
+						"/app/package.json": `{"main": "server.js"}`,
+						"/app/server.js":    "const express = require('express')\nrequire('newrelic')\n",
+					}),
+				}
+			})
+
+			It("Should return a Warning naming the module required first", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("requires express before newrelic"))
+			})
+		})
+	})
+
+	Describe("getLoadMethod()", func() {
+		It("Should detect each supported load method", func() {
+			Expect(getLoadMethod("--require newrelic")).To(Equal(LoadMethodRequireFlag))
+			Expect(getLoadMethod("--experimental-loader=newrelic/esm-loader.mjs")).To(Equal(LoadMethodLoaderFlag))
+			Expect(getLoadMethod("--import newrelic/esm-loader.mjs -r newrelic")).To(Equal(LoadMethodImportFlag))
+			Expect(getLoadMethod("--import newrelic")).To(Equal(LoadMethodImportAgent))
+			Expect(getLoadMethod("NODE_OPTIONS='-r newrelic' node server.js")).To(Equal(LoadMethodRequireFlag))
+			Expect(getLoadMethod("-r newrelic-custom")).To(Equal(""))
+		})
+	})
+})
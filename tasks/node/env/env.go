@@ -26,4 +26,9 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 		Getwd:      os.Getwd,
 		fileFinder: tasks.FindFiles,
 	}, true)
+
+	registrationFunc(NodeEnvAgentLoading{
+		getNodeProcesses: getNodeProcesses,
+		readFile:         os.ReadFile,
+	}, true)
 }
@@ -0,0 +1,125 @@
+package requirements
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	nodeEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
+)
+
+var bundlerModules = []string{"webpack", "esbuild", "rollup", "parcel", "@vercel/ncc", "vite"}
+
+var transpilerModules = []string{"typescript", "ts-node", "tsx", "@babel/core", "@babel/node", "@swc/core"}
+
+// bundlerConfigFiles are the config files in which bundlers let you mark modules as external
+var bundlerConfigFiles = []string{"webpack.config.js", "webpack.config.ts", "webpack.config.cjs", "esbuild.config.js", "esbuild.config.mjs", "rollup.config.js", "rollup.config.mjs", "vite.config.js", "vite.config.ts"}
+
+// NodeRequirementsBundlers - Detects bundlers and transpilers that break the agent's instrumentation
+type NodeRequirementsBundlers struct {
+	findStringInFile tasks.FindStringInFileFunc
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p NodeRequirementsBundlers) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Node/Requirements/Bundlers")
+}
+
+// Explain - Returns the help text for each individual task
+func (p NodeRequirementsBundlers) Explain() string {
+	return "Detect bundlers and transpilers that interfere with Node agent instrumentation"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p NodeRequirementsBundlers) Dependencies() []string {
+	return []string{
+		"Node/Env/Dependencies",
+		"Node/Env/AgentLoading",
+	}
+}
+
+// Execute - The core work within each task
+func (p NodeRequirementsBundlers) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	foundNodeDependencies := initializeTaskDependencies(upstream)
+	if len(foundNodeDependencies) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "A list of Node modules was not found. This task did not run",
+		}
+	}
+
+	bundlers := findModules(foundNodeDependencies, bundlerModules)
+	transpilers := findModules(foundNodeDependencies, transpilerModules)
+	if len(bundlers) == 0 && len(transpilers) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "No bundlers or transpilers that interfere with the Node agent were found",
+		}
+	}
+
+	loading, _ := upstream["Node/Env/AgentLoading"].Payload.(nodeEnv.NodeAgentLoadingPayload)
+
+	var warnings []string
+	if len(bundlers) > 0 && !p.isAgentExternal(loading.Apps) {
+		warnings = append(warnings, fmt.Sprintf("- We detected the bundler(s) %s. Bundling newrelic or the modules it instruments into a single file prevents the agent from hooking into them. Mark newrelic and your instrumented modules as externals in your bundler configuration.", strings.Join(bundlers, ", ")))
+	}
+	if len(transpilers) > 0 && requiresAgentInCode(loading) {
+		warnings = append(warnings, fmt.Sprintf("- We detected the transpiler(s) %s while the agent is loaded with a require or import in your code. Transpilers can reorder imports so that other modules load before the agent. Start node with %s instead.", strings.Join(transpilers, ", "), nodeEnv.LoadMethodRequireFlag))
+	}
+
+	if len(warnings) == 0 {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: fmt.Sprintf("Found %s, but the agent is loaded in a way that is not affected by them", strings.Join(append(bundlers, transpilers...), ", ")),
+		}
+	}
+
+	return tasks.Result{
+		Status:  tasks.Warning,
+		Summary: strings.Join(warnings, "\n"),
+		URL:     "https://docs.newrelic.com/docs/agents/nodejs-agent/getting-started/compatibility-requirements-nodejs-agent",
+	}
+}
+
+// isAgentExternal checks the bundler config files next to each package.json for newrelic being declared as external
+func (p NodeRequirementsBundlers) isAgentExternal(apps []nodeEnv.NodeAppLoading) bool {
+	for _, app := range apps {
+		dir := filepath.Dir(app.PackageJSON)
+		for _, configFile := range bundlerConfigFiles {
+			path := filepath.Join(dir, configFile)
+			if p.findStringInFile(`external.*newrelic|newrelic.*external`, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// requiresAgentInCode returns true when no app or process preloads the agent with a command line flag
+func requiresAgentInCode(loading nodeEnv.NodeAgentLoadingPayload) bool {
+	if loading.NodeOptionsMethod != "" {
+		return false
+	}
+	for _, proc := range loading.Processes {
+		if proc.Method != "" {
+			return false
+		}
+	}
+	for _, app := range loading.Apps {
+		if len(app.ScriptMethods) == 0 && app.RequiredInEntry {
+			return true
+		}
+	}
+	return false
+}
+
+func findModules(foundDependencies []nodeEnv.NodeModuleVersion, moduleNames []string) []string {
+	var found []string
+	for _, dependency := range foundDependencies {
+		if tasks.ContainsString(moduleNames, dependency.Module) {
+			found = append(found, dependency.Module)
+		}
+	}
+	return found
+}
@@ -0,0 +1,100 @@
+package requirements
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	nodeEnv "github.com/newrelic/newrelic-diagnostics-cli/tasks/node/env"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Node/Requirements/Bundlers", func() {
+	var p NodeRequirementsBundlers
+
+	Describe("Identifier()", func() {
+		It("should return expected identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Node", Subcategory: "Requirements", Name: "Bundlers"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			options  tasks.Options
+			upstream map[string]tasks.Result
+			result   tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When no dependencies were found", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {Status: tasks.Error},
+				}
+			})
+
+			It("should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When webpack is used without marking newrelic as external", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {Status: tasks.Info, Payload: []nodeEnv.NodeModuleVersion{{Module: "express", Version: "4.17.1"}, {Module: "webpack", Version: "5.0.0"}}},
+					"Node/Env/AgentLoading": {Status: tasks.Success, Payload: nodeEnv.NodeAgentLoadingPayload{Apps: []nodeEnv.NodeAppLoading{{PackageJSON: "/app/package.json"}}}},
+				}
+				p = NodeRequirementsBundlers{findStringInFile: func(string, string) bool { return false }}
+			})
+
+			It("should return a Warning result about bundling", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("webpack"))
+			})
+		})
+
+		Context("When webpack marks newrelic as external", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {Status: tasks.Info, Payload: []nodeEnv.NodeModuleVersion{{Module: "webpack", Version: "5.0.0"}}},
+					"Node/Env/AgentLoading": {Status: tasks.Success, Payload: nodeEnv.NodeAgentLoadingPayload{Apps: []nodeEnv.NodeAppLoading{{PackageJSON: "/app/package.json"}}}},
+				}
+				p = NodeRequirementsBundlers{findStringInFile: func(search string, path string) bool { return path == "/app/webpack.config.js" }}
+			})
+
+			It("should return a Success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+			})
+		})
+
+		Context("When TypeScript is used and the agent is required in code", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {Status: tasks.Info, Payload: []nodeEnv.NodeModuleVersion{{Module: "typescript", Version: "5.1.0"}}},
+					"Node/Env/AgentLoading": {Status: tasks.Success, Payload: nodeEnv.NodeAgentLoadingPayload{Apps: []nodeEnv.NodeAppLoading{{PackageJSON: "/app/package.json", RequiredInEntry: true, FirstModule: "newrelic"}}}},
+				}
+				p = NodeRequirementsBundlers{findStringInFile: func(string, string) bool { return false }}
+			})
+
+			It("should return a Warning result recommending -r newrelic", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("-r newrelic"))
+			})
+		})
+
+		Context("When TypeScript is used and the agent is preloaded", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Node/Env/Dependencies": {Status: tasks.Info, Payload: []nodeEnv.NodeModuleVersion{{Module: "typescript", Version: "5.1.0"}}},
+					"Node/Env/AgentLoading": {Status: tasks.Success, Payload: nodeEnv.NodeAgentLoadingPayload{NodeOptionsMethod: nodeEnv.LoadMethodRequireFlag}},
+				}
+				p = NodeRequirementsBundlers{findStringInFile: func(string, string) bool { return false }}
+			})
+
+			It("should return a Success result", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+			})
+		})
+	})
+})
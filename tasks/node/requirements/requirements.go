@@ -10,4 +10,7 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	log.Debug("Registering Node/Requirements/*")
 
 	registrationFunc(NodeRequirementsProblematicModules{}, true)
+	registrationFunc(NodeRequirementsBundlers{
+		findStringInFile: tasks.FindStringInFile,
+	}, true)
 }
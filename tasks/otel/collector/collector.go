@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// collectorBinaries are the OpenTelemetry collector distributions, with the config their packages install
+var collectorBinaries = []struct {
+	name          string
+	defaultConfig string
+}{
+	{"otelcol", "/etc/otelcol/config.yaml"},
+	{"otelcol-contrib", "/etc/otelcol-contrib/config.yaml"},
+	{"otelcol-k8s", ""},
+	{"nrdot-collector", "/etc/nrdot-collector/config.yaml"},
+	{"nrdot-collector-host", "/etc/nrdot-collector-host/config.yaml"},
+	{"nrdot-collector-k8s", ""},
+}
+
+// Collector is an OpenTelemetry collector process, or an installed collector that is not running
+type Collector struct {
+	Pid    int32
+	Binary string
+	// ConfigPaths are the config files given with --config, merged in order, or the package default
+	ConfigPaths []string
+	// ConfigURIs are --config values read by other providers, such as env: or https:
+	ConfigURIs []string
+}
+
+// sensitiveKeyRgx matches the config keys holding license keys and credentials, with their value
+var sensitiveKeyRgx = regexp.MustCompile(`(?im)^(\s*(?:-\s*)?["']?[\w.-]*(?:api[-_]?key|license[-_]?key|authorization|password|token|secret)["']?\s*:[ \t]*)(\S.*)$`)
+
+// redactConfig replaces license keys and credentials in a collector config. References to
+// environment variables are kept, they show where the value comes from without disclosing it.
+func redactConfig(config string) string {
+	return sensitiveKeyRgx.ReplaceAllStringFunc(config, func(line string) string {
+		match := sensitiveKeyRgx.FindStringSubmatch(line)
+		if strings.HasPrefix(strings.Trim(match[2], `"'`), "${") {
+			return line
+		}
+		return match[1] + "_REDACTED_"
+	})
+}
+
+// parseConfigArgs reads the --config flags of a collector command line
+func parseConfigArgs(args []string) (paths []string, uris []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		value := ""
+		switch {
+		case strings.HasPrefix(arg, "--config="):
+			value = strings.TrimPrefix(arg, "--config=")
+		case arg == "--config" && i+1 < len(args):
+			i++
+			value = args[i]
+		default:
+			continue
+		}
+		value = strings.Trim(value, `"'`)
+		switch {
+		case strings.HasPrefix(value, "file:"):
+			paths = append(paths, strings.TrimPrefix(value, "file:"))
+		case isProviderURI(value):
+			uris = append(uris, value)
+		default:
+			paths = append(paths, value)
+		}
+	}
+	return paths, uris
+}
+
+var providerRgx = regexp.MustCompile(`^[a-z][a-z0-9+.-]*:`)
+
+// isProviderURI reports whether a config value is read by a provider other than file, Windows drive letters are files
+func isProviderURI(value string) bool {
+	return providerRgx.MatchString(value) && !(len(value) > 2 && value[1] == ':' && (value[2] == '\\' || value[2] == '/'))
+}
+
+// outputName names the copy of a config file in the output
+func outputName(path string) string {
+	return filepath.Base(filepath.Dir(path)) + "-" + filepath.Base(path)
+}
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering OTel/Collector/*")
+
+	registrationFunc(OTelCollectorDetect{
+		findProcessByName: tasks.FindProcessByName,
+		cmdLineArgs:       (*process.Process).CmdlineSlice,
+		fileExists:        tasks.FileExists,
+		readFile:          os.ReadFile,
+	}, true)
+	registrationFunc(OTelCollectorConfig{
+		readFile:   os.ReadFile,
+		processEnv: tasks.GetProcessEnvVars,
+	}, true)
+}
@@ -0,0 +1,348 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel"
+)
+
+// pipelineTypes are the signals a pipeline id can start with
+var pipelineTypes = map[string]bool{"traces": true, "metrics": true, "logs": true, "profiles": true}
+
+// collectorConfig mirrors the parts of a collector config the task validates
+type collectorConfig struct {
+	Receivers  map[string]interface{} `yaml:"receivers"`
+	Processors map[string]interface{} `yaml:"processors"`
+	Exporters  map[string]interface{} `yaml:"exporters"`
+	Connectors map[string]interface{} `yaml:"connectors"`
+	Extensions map[string]interface{} `yaml:"extensions"`
+	Service    struct {
+		Extensions []string `yaml:"extensions"`
+		Pipelines  map[string]struct {
+			Receivers  []string `yaml:"receivers"`
+			Processors []string `yaml:"processors"`
+			Exporters  []string `yaml:"exporters"`
+		} `yaml:"pipelines"`
+	} `yaml:"service"`
+}
+
+// OTelCollectorConfig validates the config of each detected collector
+type OTelCollectorConfig struct {
+	readFile   func(string) ([]byte, error)
+	processEnv func(int32) (tasks.EnvironmentVariables, error)
+}
+
+// CollectorConfig is the validation result of one collector's config
+type CollectorConfig struct {
+	Binary      string
+	Pid         int32
+	ConfigPaths []string
+	Pipelines   []string
+	// Exporters are the otlp and otlphttp exporters sending to New Relic
+	Exporters []NewRelicExporter
+	Problems  []string
+}
+
+// NewRelicExporter is an exporter sending to a New Relic OTLP endpoint
+type NewRelicExporter struct {
+	Name     string
+	Endpoint string
+	Host     string
+	Port     string
+}
+
+func (p OTelCollectorConfig) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("OTel/Collector/Config")
+}
+
+func (p OTelCollectorConfig) Explain() string {
+	return "Validate OpenTelemetry collector pipelines and New Relic OTLP exporter settings"
+}
+
+func (p OTelCollectorConfig) Dependencies() []string {
+	return []string{"OTel/Collector/Detect", "Base/Config/RegionDetect"}
+}
+
+func (p OTelCollectorConfig) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	collectors, ok := upstream["OTel/Collector/Detect"].Payload.([]Collector)
+	if !ok || len(collectors) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No OpenTelemetry collector detected on system.",
+		}
+	}
+	regions := otel.Regions(upstream)
+
+	status := tasks.None
+	var summary []string
+	var results []CollectorConfig
+	for _, collector := range collectors {
+		result, resultStatus := p.validate(collector, regions)
+		results = append(results, result)
+		if resultStatus > status {
+			status = resultStatus
+		}
+		name := collector.Binary
+		if collector.Pid != 0 {
+			name = fmt.Sprintf("%s (pid %d)", collector.Binary, collector.Pid)
+		}
+		if resultStatus == tasks.None {
+			summary = append(summary, name+": "+strings.Join(result.Problems, " "))
+			continue
+		}
+		if len(result.Problems) == 0 {
+			summary = append(summary, fmt.Sprintf("%s: %d pipeline(s) are valid, %d exporter(s) send to New Relic.", name, len(result.Pipelines), len(result.Exporters)))
+			continue
+		}
+		for _, problem := range result.Problems {
+			summary = append(summary, name+": "+problem)
+		}
+	}
+
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: results,
+	}
+}
+
+// validate reads and merges the config files of a collector and checks its pipelines and New Relic exporters
+func (p OTelCollectorConfig) validate(collector Collector, regions []string) (CollectorConfig, tasks.Status) {
+	result := CollectorConfig{Binary: collector.Binary, Pid: collector.Pid, ConfigPaths: collector.ConfigPaths}
+	if len(collector.ConfigPaths) == 0 {
+		result.Problems = append(result.Problems, "The config is loaded from "+strings.Join(collector.ConfigURIs, ", ")+" and was not checked.")
+		return result, tasks.None
+	}
+
+	merged := map[string]interface{}{}
+	for _, path := range collector.ConfigPaths {
+		data, err := p.readFile(path)
+		if err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("Could not read %s: %s", path, err))
+			return result, tasks.Failure
+		}
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			result.Problems = append(result.Problems, fmt.Sprintf("%s is not valid YAML, the collector does not start: %s", path, err))
+			return result, tasks.Failure
+		}
+		mergeConfig(merged, values)
+	}
+	var config collectorConfig
+	if raw, err := yaml.Marshal(merged); err == nil {
+		if err := yaml.Unmarshal(raw, &config); err != nil {
+			result.Problems = append(result.Problems, "The config does not have the collector config structure: "+err.Error())
+			return result, tasks.Failure
+		}
+	}
+
+	var env map[string]string
+	if collector.Pid != 0 {
+		envVars, err := p.processEnv(collector.Pid)
+		if err != nil {
+			log.Debug(fmt.Sprintf("could not read the environment of pid %d: %s", collector.Pid, err))
+		}
+		env = envVars.All
+	}
+
+	status := tasks.Success
+	problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+		if problemStatus > status {
+			status = problemStatus
+		}
+		result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if len(config.Service.Pipelines) == 0 {
+		problem(tasks.Failure, "service.pipelines is empty, the collector does not start without a pipeline.")
+	}
+	used := map[string]bool{}
+	for _, id := range sortedKeys(config.Service.Pipelines) {
+		pipeline := config.Service.Pipelines[id]
+		result.Pipelines = append(result.Pipelines, id)
+		if !pipelineTypes[componentType(id)] {
+			problem(tasks.Failure, "Pipeline %s is not a valid pipeline, its id must start with traces, metrics, logs or profiles.", id)
+		}
+		if len(pipeline.Receivers) == 0 {
+			problem(tasks.Failure, "Pipeline %s has no receivers.", id)
+		}
+		if len(pipeline.Exporters) == 0 {
+			problem(tasks.Failure, "Pipeline %s has no exporters.", id)
+		}
+		for _, receiver := range pipeline.Receivers {
+			if !defined(receiver, config.Receivers, config.Connectors) {
+				problem(tasks.Failure, "Pipeline %s uses receiver %s which is not defined under receivers or connectors.", id, receiver)
+			}
+		}
+		for _, processor := range pipeline.Processors {
+			if !defined(processor, config.Processors) {
+				problem(tasks.Failure, "Pipeline %s uses processor %s which is not defined under processors.", id, processor)
+			}
+		}
+		for _, exporter := range pipeline.Exporters {
+			used[exporter] = true
+			if !defined(exporter, config.Exporters, config.Connectors) {
+				problem(tasks.Failure, "Pipeline %s uses exporter %s which is not defined under exporters or connectors.", id, exporter)
+			}
+		}
+	}
+	for _, extension := range config.Service.Extensions {
+		if !defined(extension, config.Extensions) {
+			problem(tasks.Failure, "service.extensions uses extension %s which is not defined under extensions.", extension)
+		}
+	}
+
+	for _, id := range sortedKeys(config.Exporters) {
+		exporterType := componentType(id)
+		if exporterType != "otlp" && exporterType != "otlphttp" && exporterType != "otlp_http" {
+			continue
+		}
+		settings, _ := config.Exporters[id].(map[string]interface{})
+		for _, endpoint := range exporterEndpoints(settings) {
+			resolved, ok := expandEnv(endpoint, env)
+			if !ok {
+				log.Debug("could not resolve the endpoint of exporter " + id + ": " + endpoint)
+				continue
+			}
+			host, port, scheme := otel.SplitEndpoint(resolved)
+			if !otel.IsNewRelicHost(host) {
+				continue
+			}
+			result.Exporters = append(result.Exporters, NewRelicExporter{Name: id, Endpoint: resolved, Host: host, Port: port})
+			if !used[id] {
+				problem(tasks.Warning, "Exporter %s sends to New Relic but no pipeline uses it.", id)
+			}
+			if scheme == "http" {
+				problem(tasks.Failure, "Exporter %s sends to %s over plain HTTP, New Relic only accepts OTLP over TLS, use https.", id, resolved)
+			}
+			if exporterType == "otlp" && port == otel.PortHTTP {
+				problem(tasks.Failure, "Exporter %s sends gRPC to port %s, which only accepts OTLP/HTTP. Use port %s or 443, or the otlphttp exporter.", id, otel.PortHTTP, otel.PortGRPC)
+			}
+			if exporterType != "otlp" {
+				if port == otel.PortGRPC {
+					problem(tasks.Failure, "Exporter %s sends OTLP/HTTP to port %s, which only accepts gRPC. Use port %s or 443, or the otlp exporter.", id, otel.PortGRPC, otel.PortHTTP)
+				}
+				if scheme == "" {
+					problem(tasks.Failure, "Exporter %s has the endpoint %s, the otlphttp exporter needs a URL such as https://%s:%s.", id, resolved, host, otel.PortHTTP)
+				}
+			}
+
+			expected := regions
+			apiKey, found := header(settings, "api-key")
+			switch {
+			case !found:
+				problem(tasks.Failure, "Exporter %s sends to New Relic without an api-key header, New Relic rejects the data.", id)
+			default:
+				if key, ok := expandEnv(apiKey, env); ok {
+					if strings.TrimSpace(key) == "" {
+						problem(tasks.Failure, "Exporter %s sends an empty api-key header, check the environment variable it is read from.", id)
+					} else {
+						expected = []string{otel.KeyRegion(key)}
+					}
+				}
+			}
+			checkRegion(id, host, expected, problem)
+		}
+	}
+	return result, status
+}
+
+// checkRegion reports an exporter host that does not match the region of the license key
+func checkRegion(id string, host string, regions []string, problem func(tasks.Status, string, ...interface{})) {
+	hostRegion, known := otel.HostRegion(host)
+	if !known {
+		problem(tasks.Failure, "Exporter %s sends to %s, which is not a New Relic OTLP endpoint. Use %s.", id, host, otel.OTLPHost(regions[0]))
+		return
+	}
+	for _, region := range regions {
+		if region == hostRegion {
+			return
+		}
+	}
+	problem(tasks.Failure, "Exporter %s sends to the %s endpoint %s but the license key is for the %s region, use %s.", id, hostRegion, host, regions[0], otel.OTLPHost(regions[0]))
+}
+
+// exporterEndpoints returns the endpoint of an exporter, or the per signal endpoints of otlphttp
+func exporterEndpoints(settings map[string]interface{}) []string {
+	var endpoints []string
+	for _, key := range []string{"endpoint", "traces_endpoint", "metrics_endpoint", "logs_endpoint"} {
+		if endpoint, ok := settings[key].(string); ok && endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// header returns an exporter header by name, header names are case insensitive
+func header(settings map[string]interface{}, name string) (string, bool) {
+	headers, _ := settings["headers"].(map[string]interface{})
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return fmt.Sprint(value), true
+		}
+	}
+	return "", false
+}
+
+var envRefRgx = regexp.MustCompile(`\$\{(?:env:)?([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv resolves ${env:NAME} references the way the collector does, using the collector's environment.
+// ok is false when a referenced variable is not known, as when the collector environment could not be read.
+func expandEnv(value string, env map[string]string) (string, bool) {
+	ok := true
+	expanded := envRefRgx.ReplaceAllStringFunc(value, func(ref string) string {
+		match := envRefRgx.FindStringSubmatch(ref)
+		if envValue, found := env[match[1]]; found {
+			return envValue
+		}
+		if strings.Contains(ref, ":-") {
+			return match[2]
+		}
+		ok = false
+		return ref
+	})
+	return expanded, ok
+}
+
+// mergeConfig merges src into dst as the collector merges several --config files: maps are merged, other values replaced
+func mergeConfig(dst map[string]interface{}, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeConfig(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// componentType returns the type of a component id such as otlphttp/newrelic
+func componentType(id string) string {
+	return strings.SplitN(id, "/", 2)[0]
+}
+
+func defined(id string, sections ...map[string]interface{}) bool {
+	for _, section := range sections {
+		if _, ok := section[id]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,231 @@
+package collector
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const validConfig = `
+receivers:
+  otlp:
+    protocols:
+      grpc:
+        endpoint: 0.0.0.0:4317
+processors:
+  batch: {}
+exporters:
+  otlphttp/newrelic:
+    endpoint: https://otlp.eu01.nr-data.net:4318
+    headers:
+      api-key: ${env:NEW_RELIC_LICENSE_KEY}
+extensions:
+  health_check: {}
+service:
+  extensions: [health_check]
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlphttp/newrelic]
+`
+
+func fakeFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if content, ok := files[path]; ok {
+			return []byte(content), nil
+		}
+		return nil, errors.New("open " + path + ": no such file or directory")
+	}
+}
+
+func fakeEnv(env map[string]string) func(int32) (tasks.EnvironmentVariables, error) {
+	return func(int32) (tasks.EnvironmentVariables, error) {
+		return tasks.EnvironmentVariables{All: env}, nil
+	}
+}
+
+func detected(collectors ...Collector) map[string]tasks.Result {
+	return map[string]tasks.Result{
+		"OTel/Collector/Detect": {Status: tasks.Info, Payload: collectors},
+	}
+}
+
+func TestConfig_valid(t *testing.T) {
+	p := OTelCollectorConfig{
+		readFile:   fakeFiles(map[string]string{"/etc/otelcol-contrib/config.yaml": validConfig}),
+		processEnv: fakeEnv(map[string]string{"NEW_RELIC_LICENSE_KEY": "eu01xx0123456789abcdef0123456789abcdNRAL"}),
+	}
+	result := p.Execute(tasks.Options{}, detected(Collector{Pid: 42, Binary: "otelcol-contrib", ConfigPaths: []string{"/etc/otelcol-contrib/config.yaml"}}))
+
+	if result.Status != tasks.Success {
+		t.Fatalf("Status = %v, want Success: %s", result.Status, result.Summary)
+	}
+	configs := result.Payload.([]CollectorConfig)
+	want := []NewRelicExporter{{Name: "otlphttp/newrelic", Endpoint: "https://otlp.eu01.nr-data.net:4318", Host: "otlp.eu01.nr-data.net", Port: "4318"}}
+	if !reflect.DeepEqual(configs[0].Exporters, want) {
+		t.Errorf("Exporters = %+v, want %+v", configs[0].Exporters, want)
+	}
+}
+
+func TestConfig_problems(t *testing.T) {
+	config := `
+receivers:
+  otlp: {}
+exporters:
+  otlp/newrelic:
+    endpoint: otlp.nr-data.net:4318
+  otlphttp/unused:
+    endpoint: https://otlp.nr-data.net
+    headers:
+      api-key: eu01xx0123456789abcdef0123456789abcdNRAL
+service:
+  extensions: [zpages]
+  pipelines:
+    traces:
+      receivers: [otlp, jaeger]
+      processors: [batch]
+      exporters: [otlp/newrelic]
+    spans/extra:
+      receivers: [otlp]
+`
+	p := OTelCollectorConfig{
+		readFile:   fakeFiles(map[string]string{"/etc/otelcol/config.yaml": config}),
+		processEnv: fakeEnv(nil),
+	}
+	result := p.Execute(tasks.Options{}, detected(Collector{Binary: "otelcol", ConfigPaths: []string{"/etc/otelcol/config.yaml"}}))
+
+	if result.Status != tasks.Failure {
+		t.Errorf("Status = %v, want Failure", result.Status)
+	}
+	for _, expected := range []string{
+		"Pipeline spans/extra is not a valid pipeline",
+		"Pipeline spans/extra has no exporters.",
+		"Pipeline traces uses receiver jaeger which is not defined under receivers or connectors.",
+		"Pipeline traces uses processor batch which is not defined under processors.",
+		"service.extensions uses extension zpages which is not defined under extensions.",
+		"Exporter otlp/newrelic sends gRPC to port 4318, which only accepts OTLP/HTTP.",
+		"Exporter otlp/newrelic sends to New Relic without an api-key header",
+		"Exporter otlphttp/unused sends to New Relic but no pipeline uses it.",
+		"Exporter otlphttp/unused sends to the us01 endpoint otlp.nr-data.net but the license key is for the eu01 region, use otlp.eu01.nr-data.net.",
+	} {
+		if !strings.Contains(result.Summary, expected) {
+			t.Errorf("Summary does not contain %q:\n%s", expected, result.Summary)
+		}
+	}
+}
+
+func TestConfig_mergesConfigFiles(t *testing.T) {
+	override := "exporters:\n  otlphttp/newrelic:\n    endpoint: https://otlp.nr-data.net\n"
+	p := OTelCollectorConfig{
+		readFile: fakeFiles(map[string]string{"base.yaml": validConfig, "override.yaml": override}),
+		processEnv: fakeEnv(map[string]string{
+			"NEW_RELIC_LICENSE_KEY": "0123456789abcdef0123456789abcdef01NRAL",
+		}),
+	}
+	result := p.Execute(tasks.Options{}, detected(Collector{Pid: 7, Binary: "otelcol", ConfigPaths: []string{"base.yaml", "override.yaml"}}))
+
+	if result.Status != tasks.Success {
+		t.Fatalf("Status = %v, want Success: %s", result.Status, result.Summary)
+	}
+	if got := result.Payload.([]CollectorConfig)[0].Exporters[0].Host; got != "otlp.nr-data.net" {
+		t.Errorf("exporter host = %q, want otlp.nr-data.net", got)
+	}
+}
+
+func TestConfig_invalidYAML(t *testing.T) {
+	p := OTelCollectorConfig{readFile: fakeFiles(map[string]string{"c.yaml": "receivers: [unclosed\n"}), processEnv: fakeEnv(nil)}
+	result := p.Execute(tasks.Options{}, detected(Collector{Binary: "otelcol", ConfigPaths: []string{"c.yaml"}}))
+
+	if result.Status != tasks.Failure || !strings.Contains(result.Summary, "c.yaml is not valid YAML") {
+		t.Errorf("Status = %v, Summary = %q", result.Status, result.Summary)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"KEY": "abc"}
+	for value, want := range map[string]string{
+		"${env:KEY}":                            "abc",
+		"${KEY}":                                "abc",
+		"https://${env:HOST:-otlp.nr-data.net}": "https://otlp.nr-data.net",
+	} {
+		if got, ok := expandEnv(value, env); !ok || got != want {
+			t.Errorf("expandEnv(%q) = %q, %v, want %q", value, got, ok, want)
+		}
+	}
+	if _, ok := expandEnv("${env:MISSING}", env); ok {
+		t.Error("expandEnv() resolved a variable that is not set")
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	config := "headers:\n  api-key: eu01xx0123456789abcdef0123456789abcdNRAL\n  x-license-key: \"${env:KEY}\"\nauth:\n  password: hunter2\nendpoint: https://otlp.nr-data.net\n"
+	want := "headers:\n  api-key: _REDACTED_\n  x-license-key: \"${env:KEY}\"\nauth:\n  password: _REDACTED_\nendpoint: https://otlp.nr-data.net\n"
+	if got := redactConfig(config); got != want {
+		t.Errorf("redactConfig() = %q, want %q", got, want)
+	}
+}
+
+func TestParseConfigArgs(t *testing.T) {
+	paths, uris := parseConfigArgs([]string{"/usr/bin/otelcol-contrib", "--config=/etc/otelcol-contrib/config.yaml", "--config", "file:/etc/otelcol-contrib/extra.yaml", "--config=env:OTEL_CONFIG", `--config=C:\otel\config.yaml`})
+
+	if want := []string{"/etc/otelcol-contrib/config.yaml", "/etc/otelcol-contrib/extra.yaml", `C:\otel\config.yaml`}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v", paths, want)
+	}
+	if want := []string{"env:OTEL_CONFIG"}; !reflect.DeepEqual(uris, want) {
+		t.Errorf("uris = %v, want %v", uris, want)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	p := OTelCollectorDetect{
+		findProcessByName: func(name string) ([]process.Process, error) {
+			if name == "otelcol-contrib" {
+				return []process.Process{{Pid: 42}}, nil
+			}
+			return nil, nil
+		},
+		cmdLineArgs: func(*process.Process) ([]string, error) {
+			return []string{"otelcol-contrib", "--config=/opt/otel/config.yaml"}, nil
+		},
+		fileExists: func(path string) bool { return path == "/etc/nrdot-collector-host/config.yaml" },
+		readFile:   fakeFiles(map[string]string{"/opt/otel/config.yaml": "exporters:\n  otlp:\n    headers:\n      api-key: secret\n"}),
+	}
+	result := p.Execute(tasks.Options{}, nil)
+
+	if result.Status != tasks.Info {
+		t.Fatalf("Status = %v, want Info", result.Status)
+	}
+	want := []Collector{
+		{Pid: 42, Binary: "otelcol-contrib", ConfigPaths: []string{"/opt/otel/config.yaml"}},
+		{Binary: "nrdot-collector-host", ConfigPaths: []string{"/etc/nrdot-collector-host/config.yaml"}},
+	}
+	if got := result.Payload.([]Collector); !reflect.DeepEqual(got, want) {
+		t.Errorf("Payload = %+v, want %+v", got, want)
+	}
+	if len(result.FilesToCopy) != 1 || result.FilesToCopy[0].Path != "otel-collector/otel-config.yaml" {
+		t.Fatalf("unexpected files %+v", result.FilesToCopy)
+	}
+	var file strings.Builder
+	for line := range result.FilesToCopy[0].Stream {
+		file.WriteString(line)
+	}
+	if strings.Contains(file.String(), "secret") {
+		t.Errorf("the copied config is not redacted: %q", file.String())
+	}
+}
+
+func TestDetect_noCollector(t *testing.T) {
+	p := OTelCollectorDetect{
+		findProcessByName: func(string) ([]process.Process, error) { return nil, nil },
+		fileExists:        func(string) bool { return false },
+	}
+	if result := p.Execute(tasks.Options{}, nil); result.Status != tasks.None {
+		t.Errorf("Status = %v, want None", result.Status)
+	}
+}
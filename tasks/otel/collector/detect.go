@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// OTelCollectorDetect finds OpenTelemetry collector processes and the config files they load
+type OTelCollectorDetect struct {
+	findProcessByName tasks.FindProcessByNameFunc
+	cmdLineArgs       func(*process.Process) ([]string, error)
+	fileExists        tasks.FileExistsFunc
+	readFile          func(string) ([]byte, error)
+}
+
+func (p OTelCollectorDetect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("OTel/Collector/Detect")
+}
+
+func (p OTelCollectorDetect) Explain() string {
+	return "Detect OpenTelemetry collector processes and their config files"
+}
+
+func (p OTelCollectorDetect) Dependencies() []string {
+	return []string{}
+}
+
+func (p OTelCollectorDetect) Execute(_ tasks.Options, _ map[string]tasks.Result) tasks.Result {
+	collectors := p.findCollectors()
+	if len(collectors) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No OpenTelemetry collector detected on system.",
+		}
+	}
+
+	var summary []string
+	var filesToCopy []tasks.FileCopyEnvelope
+	copied := make(map[string]bool)
+	for _, collector := range collectors {
+		if collector.Pid == 0 {
+			summary = append(summary, fmt.Sprintf("%s is installed but not running, config: %s", collector.Binary, strings.Join(collector.ConfigPaths, ", ")))
+		} else {
+			summary = append(summary, fmt.Sprintf("%s is running with pid %d, config: %s", collector.Binary, collector.Pid, strings.Join(append(collector.ConfigPaths, collector.ConfigURIs...), ", ")))
+		}
+		for _, path := range collector.ConfigPaths {
+			if copied[path] {
+				continue
+			}
+			copied[path] = true
+			data, err := p.readFile(path)
+			if err != nil {
+				log.Debug("could not read collector config " + path + ": " + err.Error())
+				continue
+			}
+			stream := make(chan string)
+			go tasks.StreamBlob(redactConfig(string(data)), stream)
+			filesToCopy = append(filesToCopy, tasks.FileCopyEnvelope{
+				Path:       "otel-collector/" + outputName(path),
+				Stream:     stream,
+				Identifier: p.Identifier().String(),
+			})
+		}
+	}
+
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     strings.Join(summary, "\n"),
+		Payload:     collectors,
+		FilesToCopy: filesToCopy,
+	}
+}
+
+// findCollectors returns the running collectors, or the installed collectors whose default config exists
+func (p OTelCollectorDetect) findCollectors() []Collector {
+	var collectors []Collector
+	for _, binary := range collectorBinaries {
+		procs, err := p.findProcessByName(binary.name)
+		if err != nil {
+			log.Debug("could not search for " + binary.name + " processes: " + err.Error())
+		}
+		for i := range procs {
+			proc := &procs[i]
+			collector := Collector{Pid: proc.Pid, Binary: binary.name}
+			args, err := p.cmdLineArgs(proc)
+			if err != nil {
+				log.Debug(fmt.Sprintf("could not read the command line of pid %d: %s", proc.Pid, err))
+			}
+			collector.ConfigPaths, collector.ConfigURIs = parseConfigArgs(args)
+			if len(collector.ConfigPaths) == 0 && len(collector.ConfigURIs) == 0 && binary.defaultConfig != "" {
+				collector.ConfigPaths = []string{binary.defaultConfig}
+			}
+			collectors = append(collectors, collector)
+		}
+		if len(procs) == 0 && binary.defaultConfig != "" && p.fileExists(binary.defaultConfig) {
+			collectors = append(collectors, Collector{Binary: binary.name, ConfigPaths: []string{binary.defaultConfig}})
+		}
+	}
+	return collectors
+}
@@ -0,0 +1,81 @@
+// Package otel holds the helpers shared by the OTel/* tasks, which check data sent to New Relic over OTLP
+package otel
+
+import (
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// OTLP ports, New Relic also accepts both protocols on 443
+const (
+	PortGRPC = "4317"
+	PortHTTP = "4318"
+)
+
+var licenseRegionRgx = regexp.MustCompile(`^([a-z]{2,3}[0-9]{2})x{1,2}`)
+
+// KeyRegion returns the region of a license key, keys without a region prefix are US keys
+func KeyRegion(licenseKey string) string {
+	if m := licenseRegionRgx.FindStringSubmatch(licenseKey); len(m) > 1 {
+		return tasks.NormalizeRegion(m[1])
+	}
+	return tasks.RegionUS
+}
+
+// Regions returns the region given with -region or NEW_RELIC_REGION, or the regions detected from license keys, defaulting to US
+func Regions(upstream map[string]tasks.Result) []string {
+	if region := tasks.RequestedRegion(); region != "" {
+		return []string{region}
+	}
+	regions, ok := upstream["Base/Config/RegionDetect"].Payload.([]string)
+	if !ok || len(regions) == 0 {
+		return []string{tasks.RegionUS}
+	}
+	normalized := make([]string, 0, len(regions))
+	for _, region := range regions {
+		normalized = append(normalized, tasks.NormalizeRegion(region))
+	}
+	return normalized
+}
+
+// OTLPHost returns the New Relic OTLP host of a region
+func OTLPHost(region string) string {
+	endpoint, _ := tasks.GetEndpoint(region, tasks.ProductOTLP)
+	return endpoint.Host
+}
+
+// IsNewRelicHost reports whether an OTLP endpoint host belongs to New Relic
+func IsNewRelicHost(host string) bool {
+	host = strings.ToLower(host)
+	return host == "nr-data.net" || strings.HasSuffix(host, ".nr-data.net")
+}
+
+// HostRegion returns the region of a New Relic OTLP host, ok is false for hosts outside the endpoint catalog
+func HostRegion(host string) (string, bool) {
+	for _, region := range []string{tasks.RegionUS, tasks.RegionEU, tasks.RegionJP, tasks.RegionFedRAMP} {
+		if strings.EqualFold(host, OTLPHost(region)) {
+			return region, true
+		}
+	}
+	return "", false
+}
+
+// SplitEndpoint returns the host and port of an OTLP endpoint, given as a URL or as host:port.
+// The port is empty when the endpoint does not set one.
+func SplitEndpoint(endpoint string) (host string, port string, scheme string) {
+	endpoint = strings.TrimSpace(endpoint)
+	if strings.Contains(endpoint, "://") {
+		if u, err := url.Parse(endpoint); err == nil {
+			return u.Hostname(), u.Port(), u.Scheme
+		}
+	}
+	hostPort := strings.SplitN(endpoint, "/", 2)[0]
+	if h, p, err := net.SplitHostPort(hostPort); err == nil {
+		return h, p, ""
+	}
+	return hostPort, "", ""
+}
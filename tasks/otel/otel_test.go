@@ -0,0 +1,61 @@
+package otel
+
+import (
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func TestKeyRegion(t *testing.T) {
+	for key, want := range map[string]string{
+		"eu01xx0123456789abcdef0123456789abcdNRAL": tasks.RegionEU,
+		"0123456789abcdef0123456789abcdef01NRAL":   tasks.RegionUS,
+		"gov01x0123456789abcdef0123456789abcdNRAL": tasks.RegionFedRAMP,
+	} {
+		if got := KeyRegion(key); got != want {
+			t.Errorf("KeyRegion(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestSplitEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		endpoint, host, port, scheme string
+	}{
+		{"https://otlp.nr-data.net:4318", "otlp.nr-data.net", "4318", "https"},
+		{"https://otlp.eu01.nr-data.net/v1/traces", "otlp.eu01.nr-data.net", "", "https"},
+		{"otlp.nr-data.net:4317", "otlp.nr-data.net", "4317", ""},
+		{"otlp.nr-data.net", "otlp.nr-data.net", "", ""},
+	} {
+		host, port, scheme := SplitEndpoint(tc.endpoint)
+		if host != tc.host || port != tc.port || scheme != tc.scheme {
+			t.Errorf("SplitEndpoint(%q) = %q, %q, %q", tc.endpoint, host, port, scheme)
+		}
+	}
+}
+
+func TestHostRegion(t *testing.T) {
+	if region, ok := HostRegion("OTLP.EU01.nr-data.net"); !ok || region != tasks.RegionEU {
+		t.Errorf("HostRegion() = %q, %v", region, ok)
+	}
+	if _, ok := HostRegion("otlp.example.nr-data.net"); ok {
+		t.Error("HostRegion() found a region for a host outside the catalog")
+	}
+}
+
+func TestRegions(t *testing.T) {
+	upstream := map[string]tasks.Result{"Base/Config/RegionDetect": {Payload: []string{"eu01"}}}
+	if got := Regions(upstream); len(got) != 1 || got[0] != tasks.RegionEU {
+		t.Errorf("Regions() = %v", got)
+	}
+	config.RequestedRegion = config.JPRegion
+	got := Regions(upstream)
+	config.RequestedRegion = config.NoRegion
+	if got[0] != tasks.RegionJP {
+		t.Errorf("Regions() with a requested region = %v", got)
+	}
+	if got := Regions(nil); got[0] != tasks.RegionUS {
+		t.Errorf("Regions() without upstream = %v", got)
+	}
+}
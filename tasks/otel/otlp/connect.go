@@ -0,0 +1,278 @@
+package otlp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/collector"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/sdk"
+)
+
+const dialTimeout = 10 * time.Second
+
+// OTelOTLPConnect checks that the New Relic OTLP endpoints answer OTLP/HTTP and gRPC from this host
+type OTelOTLPConnect struct {
+	httpRequest tasks.HTTPRequestFunc
+	// dialTLS returns the application protocol negotiated with addr, gRPC needs h2
+	dialTLS func(addr string) (string, error)
+}
+
+// ConnectResult is the outcome of one protocol and port of an OTLP endpoint
+type ConnectResult struct {
+	Host       string
+	Protocol   string
+	Port       string
+	StatusCode int
+	Error      string
+}
+
+func (p OTelOTLPConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("OTel/OTLP/Connect")
+}
+
+func (p OTelOTLPConnect) Explain() string {
+	return "Check OTLP/HTTP and gRPC connectivity to the New Relic OTLP endpoint"
+}
+
+func (p OTelOTLPConnect) Dependencies() []string {
+	return []string{
+		"Base/Config/ProxyDetect", //we are not using the payload of this task, but we want to make sure that it was already detected and set before running any HTTP request
+		"Base/Config/RegionDetect",
+		"OTel/Collector/Config",
+		"OTel/SDK/Env",
+	}
+}
+
+// RequiresNetwork - this task connects to the New Relic OTLP endpoints, so it is skipped in offline mode
+func (p OTelOTLPConnect) RequiresNetwork() bool {
+	return true
+}
+
+func (p OTelOTLPConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["OTel/Collector/Config"].Status == tasks.None && upstream["OTel/SDK/Env"].Status == tasks.None {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No OpenTelemetry collector or SDK detected on system.",
+		}
+	}
+
+	var summary []string
+	var results []ConnectResult
+	status := tasks.Success
+	for _, host := range otlpHosts(options, upstream) {
+		hostResults := []ConnectResult{
+			p.connectHTTP(host, otel.PortHTTP),
+			p.connectHTTP(host, "443"),
+			p.connectGRPC(host, otel.PortGRPC),
+			p.connectGRPC(host, "443"),
+		}
+		results = append(results, hostResults...)
+
+		var failed []string
+		for _, result := range hostResults {
+			if result.Error != "" {
+				failed = append(failed, fmt.Sprintf("%s on port %s: %s", result.Protocol, result.Port, result.Error))
+			}
+		}
+		switch {
+		case len(failed) == 0:
+			summary = append(summary, host+": OTLP/HTTP and gRPC are reachable on ports "+otel.PortHTTP+", "+otel.PortGRPC+" and 443.")
+			continue
+		case len(failed) == len(hostResults):
+			status = tasks.Failure
+			summary = append(summary, host+": the OTLP endpoint is not reachable, check network and proxy settings.")
+		default:
+			if status < tasks.Warning {
+				status = tasks.Warning
+			}
+			summary = append(summary, host+": the OTLP endpoint is only reachable with some protocols and ports, make sure exporters use one that is.")
+		}
+		for _, failure := range failed {
+			summary = append(summary, "\t"+failure)
+		}
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: results,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/opentelemetry/best-practices/opentelemetry-otlp/"
+	}
+	return result
+}
+
+// otlpHosts returns the New Relic hosts the collectors and SDKs export to, or the OTLP host of the detected regions
+func otlpHosts(options tasks.Options, upstream map[string]tasks.Result) []string {
+	var hosts []string
+	add := func(host string) {
+		host = strings.ToLower(host)
+		for _, h := range hosts {
+			if h == host {
+				return
+			}
+		}
+		hosts = append(hosts, host)
+	}
+	configs, _ := upstream["OTel/Collector/Config"].Payload.([]collector.CollectorConfig)
+	for _, config := range configs {
+		for _, exporter := range config.Exporters {
+			add(exporter.Host)
+		}
+	}
+	processes, _ := upstream["OTel/SDK/Env"].Payload.([]sdk.SDKProcess)
+	for _, process := range processes {
+		for _, host := range process.Hosts {
+			add(host)
+		}
+	}
+	if len(hosts) == 0 {
+		for _, region := range otel.Regions(upstream) {
+			if host := otel.OTLPHost(region); host != "" {
+				add(host)
+			}
+		}
+	}
+	return hosts
+}
+
+// connectHTTP posts an empty unauthenticated export request, any HTTP response means the endpoint is reachable
+func (p OTelOTLPConnect) connectHTTP(host string, port string) ConnectResult {
+	result := ConnectResult{Host: host, Protocol: "OTLP/HTTP", Port: port}
+	url := "https://" + net.JoinHostPort(host, port) + "/v1/traces"
+	resp, err := p.httpRequest(httpHelper.RequestWrapper{
+		Method:         "POST",
+		URL:            url,
+		Headers:        map[string]string{"Content-Type": "application/x-protobuf"},
+		TimeoutSeconds: 30,
+	})
+	if err != nil {
+		log.Debug("Error connecting to", url, err)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	return result
+}
+
+// connectGRPC completes a TLS handshake and checks the endpoint negotiates HTTP/2, which gRPC runs on
+func (p OTelOTLPConnect) connectGRPC(host string, port string) ConnectResult {
+	result := ConnectResult{Host: host, Protocol: "gRPC", Port: port}
+	protocol, err := p.dialTLS(net.JoinHostPort(host, port))
+	switch {
+	case err != nil:
+		log.Debug("Error connecting to", host, port, err)
+		result.Error = err.Error()
+	case protocol != "h2":
+		result.Error = fmt.Sprintf("the endpoint negotiated %q instead of HTTP/2", protocol)
+	}
+	return result
+}
+
+// dialTLS connects through the proxy http.DefaultTransport uses, like nrdiag's other connect tasks, since gRPC exporters honor HTTPS_PROXY too
+func dialTLS(addr string) (string, error) {
+	proxy := http.ProxyFromEnvironment
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok && transport.Proxy != nil {
+		proxy = transport.Proxy
+	}
+	return dialTLSWithProxy(proxy, addr)
+}
+
+// dialTLSWithProxy returns the application protocol negotiated with addr, tunneling with CONNECT when proxy returns a proxy for it
+func dialTLSWithProxy(proxy func(*http.Request) (*url.URL, error), addr string) (string, error) {
+	proxyURL, err := proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+	if err != nil {
+		return "", err
+	}
+	var conn net.Conn
+	if proxyURL == nil {
+		conn, err = net.DialTimeout("tcp", addr, dialTimeout)
+	} else {
+		conn, err = dialProxyTunnel(proxyURL, addr)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	host, _, _ := net.SplitHostPort(addr)
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName: host,
+		NextProtos: []string{"h2"},
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	return tlsConn.ConnectionState().NegotiatedProtocol, nil
+}
+
+// dialProxyTunnel opens a CONNECT tunnel to addr through proxyURL
+func dialProxyTunnel(proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	conn, err := net.DialTimeout("tcp", proxyAddr, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+	}
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	connect := &http.Request{
+		Method: "CONNECT",
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		connect.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s answered CONNECT %s with %s", proxyURL.Redacted(), addr, resp.Status)
+	}
+	return conn, nil
+}
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering OTel/OTLP/*")
+
+	registrationFunc(OTelOTLPConnect{
+		httpRequest: httpHelper.MakeHTTPRequest,
+		dialTLS:     dialTLS,
+	}, true)
+}
@@ -0,0 +1,112 @@
+package otlp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/collector"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel/sdk"
+)
+
+func respond(status int) tasks.HTTPRequestFunc {
+	return func(httpHelper.RequestWrapper) (*http.Response, error) {
+		return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+}
+
+var collectorDetected = map[string]tasks.Result{
+	"OTel/Collector/Config": {Status: tasks.Success, Payload: []collector.CollectorConfig{{
+		Exporters: []collector.NewRelicExporter{{Name: "otlphttp", Host: "otlp.eu01.nr-data.net"}},
+	}}},
+	"OTel/SDK/Env": {Status: tasks.None},
+}
+
+func TestConnect_reachable(t *testing.T) {
+	var urls []string
+	p := OTelOTLPConnect{
+		httpRequest: func(wrapper httpHelper.RequestWrapper) (*http.Response, error) {
+			urls = append(urls, wrapper.URL)
+			return respond(403)(wrapper)
+		},
+		dialTLS: func(string) (string, error) { return "h2", nil },
+	}
+	result := p.Execute(tasks.Options{}, collectorDetected)
+
+	if result.Status != tasks.Success {
+		t.Fatalf("Status = %v, want Success: %s", result.Status, result.Summary)
+	}
+	want := []string{"https://otlp.eu01.nr-data.net:4318/v1/traces", "https://otlp.eu01.nr-data.net:443/v1/traces"}
+	if !reflect.DeepEqual(urls, want) {
+		t.Errorf("requested %v, want %v", urls, want)
+	}
+}
+
+func TestConnect_grpcBlocked(t *testing.T) {
+	p := OTelOTLPConnect{
+		httpRequest: respond(200),
+		dialTLS: func(addr string) (string, error) {
+			if strings.HasSuffix(addr, ":4317") {
+				return "", errors.New("i/o timeout")
+			}
+			return "h2", nil
+		},
+	}
+	result := p.Execute(tasks.Options{}, collectorDetected)
+
+	if result.Status != tasks.Warning || !strings.Contains(result.Summary, "gRPC on port 4317: i/o timeout") {
+		t.Errorf("Status = %v, Summary = %q", result.Status, result.Summary)
+	}
+}
+
+func TestConnect_unreachableUsesSDKHosts(t *testing.T) {
+	p := OTelOTLPConnect{
+		httpRequest: func(httpHelper.RequestWrapper) (*http.Response, error) { return nil, errors.New("connection refused") },
+		dialTLS:     func(string) (string, error) { return "", errors.New("connection refused") },
+	}
+	upstream := map[string]tasks.Result{
+		"OTel/Collector/Config": {Status: tasks.None},
+		"OTel/SDK/Env":          {Status: tasks.Success, Payload: []sdk.SDKProcess{{Hosts: []string{"gov-otlp.nr-data.net"}}}},
+	}
+	result := p.Execute(tasks.Options{}, upstream)
+
+	if result.Status != tasks.Failure || !strings.HasPrefix(result.Summary, "gov-otlp.nr-data.net: the OTLP endpoint is not reachable") {
+		t.Errorf("Status = %v, Summary = %q", result.Status, result.Summary)
+	}
+}
+
+func TestConnect_nothingDetected(t *testing.T) {
+	upstream := map[string]tasks.Result{
+		"OTel/Collector/Config": {Status: tasks.None},
+		"OTel/SDK/Env":          {Status: tasks.None},
+	}
+	if result := (OTelOTLPConnect{}).Execute(tasks.Options{}, upstream); result.Status != tasks.None {
+		t.Errorf("Status = %v, want None", result.Status)
+	}
+}
+
+func TestDialTLSWithProxy_tunnelsThroughProxy(t *testing.T) {
+	var connected string
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connected = r.Method + " " + r.Host
+		w.WriteHeader(http.StatusProxyAuthRequired)
+	}))
+	defer proxyServer.Close()
+	proxyURL, _ := url.Parse(proxyServer.URL)
+
+	_, err := dialTLSWithProxy(http.ProxyURL(proxyURL), "otlp.nr-data.net:4317")
+
+	if connected != "CONNECT otlp.nr-data.net:4317" {
+		t.Errorf("proxy received %q, want the CONNECT tunnel request", connected)
+	}
+	if err == nil || !strings.Contains(err.Error(), "answered CONNECT otlp.nr-data.net:4317 with 407") {
+		t.Errorf("err = %v, want the proxy's refusal", err)
+	}
+}
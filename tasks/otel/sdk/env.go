@@ -0,0 +1,291 @@
+package sdk
+
+import (
+	"fmt"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/otel"
+)
+
+const envPrefix = "OTEL_"
+
+// signals are the signal names used in the per signal OTLP exporter variables
+var signals = []string{"TRACES", "METRICS", "LOGS"}
+
+// OTelSDKEnv finds processes configured with the OpenTelemetry SDK environment variables and checks them
+type OTelSDKEnv struct {
+	goos        string
+	pids        func() ([]int32, error)
+	processEnv  func(int32) (tasks.EnvironmentVariables, error)
+	processName func(int32) string
+}
+
+// SDKProcess is a process with OpenTelemetry SDK environment variables, header values are redacted
+type SDKProcess struct {
+	Pid  int32
+	Name string
+	Env  map[string]string
+	// Hosts are the New Relic OTLP hosts the process exports to
+	Hosts    []string
+	Problems []string
+}
+
+func (p OTelSDKEnv) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("OTel/SDK/Env")
+}
+
+func (p OTelSDKEnv) Explain() string {
+	return "Check OpenTelemetry SDK environment variables of running processes"
+}
+
+func (p OTelSDKEnv) Dependencies() []string {
+	return []string{"Base/Config/RegionDetect"}
+}
+
+func (p OTelSDKEnv) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if p.goos != "linux" {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "The environment of running processes is only read on Linux.",
+		}
+	}
+	pids, err := p.pids()
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Could not list running processes: " + err.Error(),
+		}
+	}
+	regions := otel.Regions(upstream)
+
+	status := tasks.None
+	var summary []string
+	var processes []SDKProcess
+	for _, pid := range pids {
+		envVars, err := p.processEnv(pid)
+		if err != nil {
+			continue
+		}
+		env := otelEnv(envVars.All)
+		if len(env) == 0 {
+			continue
+		}
+		sdkProcess, processStatus := checkEnv(env, regions)
+		sdkProcess.Pid = pid
+		sdkProcess.Name = p.processName(pid)
+		processes = append(processes, sdkProcess)
+		if processStatus > status {
+			status = processStatus
+		}
+		name := fmt.Sprintf("%s (pid %d)", sdkProcess.Name, pid)
+		if len(sdkProcess.Problems) == 0 {
+			summary = append(summary, name+": the OpenTelemetry SDK environment variables are valid.")
+			continue
+		}
+		for _, problem := range sdkProcess.Problems {
+			summary = append(summary, name+": "+problem)
+		}
+	}
+	if len(processes) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No process with OpenTelemetry SDK environment variables found.",
+		}
+	}
+
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		Payload: processes,
+	}
+}
+
+// otelEnv returns the OTEL_ variables of an environment
+func otelEnv(all map[string]string) map[string]string {
+	env := make(map[string]string)
+	for key, value := range all {
+		if strings.HasPrefix(key, envPrefix) {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// checkEnv validates the exporter settings of one process, per signal as the SDKs resolve them
+func checkEnv(env map[string]string, regions []string) (SDKProcess, tasks.Status) {
+	var result SDKProcess
+	status := tasks.Success
+	problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+		if problemStatus > status {
+			status = problemStatus
+		}
+		result.Problems = append(result.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if env["OTEL_SERVICE_NAME"] == "" && parseList(env["OTEL_RESOURCE_ATTRIBUTES"])["service.name"] == "" {
+		problem(tasks.Warning, "Neither OTEL_SERVICE_NAME nor service.name in OTEL_RESOURCE_ATTRIBUTES is set, the entity shows as unknown_service in New Relic.")
+	}
+
+	if env["OTEL_EXPORTER_OTLP_ENDPOINT"] == "" && hasAPIKey(parseList(env["OTEL_EXPORTER_OTLP_HEADERS"])) && !hasSignalEndpoint(env) {
+		problem(tasks.Warning, "OTEL_EXPORTER_OTLP_HEADERS has an api-key but OTEL_EXPORTER_OTLP_ENDPOINT is not set, the SDK exports to localhost.")
+	}
+
+	checked := make(map[string]bool)
+	for _, signal := range signals {
+		endpointVar := "OTEL_EXPORTER_OTLP_" + signal + "_ENDPOINT"
+		if env[endpointVar] == "" {
+			endpointVar = "OTEL_EXPORTER_OTLP_ENDPOINT"
+		}
+		headersVar := "OTEL_EXPORTER_OTLP_" + signal + "_HEADERS"
+		if env[headersVar] == "" {
+			headersVar = "OTEL_EXPORTER_OTLP_HEADERS"
+		}
+		protocolVar := "OTEL_EXPORTER_OTLP_" + signal + "_PROTOCOL"
+		if env[protocolVar] == "" {
+			protocolVar = "OTEL_EXPORTER_OTLP_PROTOCOL"
+		}
+		endpoint := env[endpointVar]
+		if endpoint == "" || checked[endpointVar+headersVar+protocolVar] {
+			continue
+		}
+		checked[endpointVar+headersVar+protocolVar] = true
+
+		host, port, scheme := otel.SplitEndpoint(endpoint)
+		if !otel.IsNewRelicHost(host) {
+			continue
+		}
+		result.Hosts = appendUnique(result.Hosts, host)
+		if scheme == "http" {
+			problem(tasks.Failure, "%s uses plain HTTP, New Relic only accepts OTLP over TLS, use https.", endpointVar)
+		}
+		switch protocol := env[protocolVar]; {
+		case protocol == "grpc" && port == otel.PortHTTP:
+			problem(tasks.Failure, "%s is grpc but %s uses port %s, which only accepts OTLP/HTTP. Use port %s or 443.", protocolVar, endpointVar, otel.PortHTTP, otel.PortGRPC)
+		case strings.HasPrefix(protocol, "http/") && port == otel.PortGRPC:
+			problem(tasks.Failure, "%s is %s but %s uses port %s, which only accepts gRPC. Use port %s or 443.", protocolVar, protocol, endpointVar, otel.PortGRPC, otel.PortHTTP)
+		}
+
+		expected := regions
+		headers := parseList(env[headersVar])
+		if key := apiKey(headers); key == "" {
+			problem(tasks.Failure, "%s points to New Relic but %s has no api-key header, New Relic rejects the data.", endpointVar, headersVar)
+		} else {
+			expected = []string{otel.KeyRegion(key)}
+		}
+		hostRegion, known := otel.HostRegion(host)
+		switch {
+		case !known:
+			problem(tasks.Failure, "%s points to %s, which is not a New Relic OTLP endpoint. Use %s.", endpointVar, host, otel.OTLPHost(expected[0]))
+		case !contains(expected, hostRegion):
+			problem(tasks.Failure, "%s points to the %s endpoint %s but the license key is for the %s region, use %s.", endpointVar, hostRegion, host, expected[0], otel.OTLPHost(expected[0]))
+		}
+	}
+
+	result.Env = redactEnv(env)
+	return result, status
+}
+
+func hasSignalEndpoint(env map[string]string) bool {
+	for _, signal := range signals {
+		if env["OTEL_EXPORTER_OTLP_"+signal+"_ENDPOINT"] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseList parses the key=value,key=value format of OTEL_EXPORTER_OTLP_HEADERS and OTEL_RESOURCE_ATTRIBUTES
+func parseList(value string) map[string]string {
+	list := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		decoded, err := url.QueryUnescape(strings.TrimSpace(kv[1]))
+		if err != nil {
+			decoded = strings.TrimSpace(kv[1])
+		}
+		list[strings.TrimSpace(kv[0])] = decoded
+	}
+	return list
+}
+
+func apiKey(headers map[string]string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, "api-key") {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+func hasAPIKey(headers map[string]string) bool {
+	return apiKey(headers) != ""
+}
+
+// redactEnv replaces header values, they hold license keys and other credentials
+func redactEnv(env map[string]string) map[string]string {
+	redacted := make(map[string]string, len(env))
+	for key, value := range env {
+		if !strings.HasSuffix(key, "_HEADERS") {
+			redacted[key] = value
+			continue
+		}
+		headers := parseList(value)
+		names := make([]string, 0, len(headers))
+		for name := range headers {
+			names = append(names, name+"=_REDACTED_")
+		}
+		sort.Strings(names)
+		redacted[key] = strings.Join(names, ",")
+	}
+	return redacted
+}
+
+func appendUnique(values []string, value string) []string {
+	if contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func processName(pid int32) string {
+	proc, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+	name, err := proc.Name()
+	if err != nil {
+		log.Debug(fmt.Sprintf("could not read the name of pid %d: %s", pid, err))
+	}
+	return name
+}
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering OTel/SDK/*")
+
+	registrationFunc(OTelSDKEnv{
+		goos:        runtime.GOOS,
+		pids:        process.Pids,
+		processEnv:  tasks.GetProcessEnvVars,
+		processName: processName,
+	}, true)
+}
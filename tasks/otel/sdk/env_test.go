@@ -0,0 +1,113 @@
+package sdk
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+func fakeSDK(envs map[int32]map[string]string) OTelSDKEnv {
+	return OTelSDKEnv{
+		goos: "linux",
+		pids: func() ([]int32, error) { return []int32{1, 10, 20}, nil },
+		processEnv: func(pid int32) (tasks.EnvironmentVariables, error) {
+			env, ok := envs[pid]
+			if !ok {
+				return tasks.EnvironmentVariables{}, errors.New("permission denied")
+			}
+			return tasks.EnvironmentVariables{All: env, PID: pid}, nil
+		},
+		processName: func(int32) string { return "java" },
+	}
+}
+
+func TestEnv_valid(t *testing.T) {
+	p := fakeSDK(map[int32]map[string]string{
+		10: {"PATH": "/usr/bin"},
+		20: {
+			"OTEL_SERVICE_NAME":           "checkout",
+			"OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp.eu01.nr-data.net:4318",
+			"OTEL_EXPORTER_OTLP_PROTOCOL": "http/protobuf",
+			"OTEL_EXPORTER_OTLP_HEADERS":  "api-key=eu01xx0123456789abcdef0123456789abcdNRAL",
+		},
+	})
+	result := p.Execute(tasks.Options{}, nil)
+
+	if result.Status != tasks.Success {
+		t.Fatalf("Status = %v, want Success: %s", result.Status, result.Summary)
+	}
+	processes := result.Payload.([]SDKProcess)
+	if len(processes) != 1 || processes[0].Pid != 20 {
+		t.Fatalf("unexpected payload %+v", processes)
+	}
+	if got := processes[0].Env["OTEL_EXPORTER_OTLP_HEADERS"]; got != "api-key=_REDACTED_" {
+		t.Errorf("headers not redacted: %q", got)
+	}
+	if want := []string{"otlp.eu01.nr-data.net"}; !reflect.DeepEqual(processes[0].Hosts, want) {
+		t.Errorf("Hosts = %v, want %v", processes[0].Hosts, want)
+	}
+}
+
+func TestCheckEnv_problems(t *testing.T) {
+	_, status := checkEnv(map[string]string{"OTEL_SERVICE_NAME": "a"}, []string{tasks.RegionUS})
+	if status != tasks.Success {
+		t.Errorf("status without an exporter = %v, want Success", status)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "no service name",
+			env:      map[string]string{"OTEL_TRACES_EXPORTER": "otlp"},
+			expected: "the entity shows as unknown_service",
+		},
+		{
+			name:     "no api-key",
+			env:      map[string]string{"OTEL_SERVICE_NAME": "a", "OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp.nr-data.net"},
+			expected: "OTEL_EXPORTER_OTLP_ENDPOINT points to New Relic but OTEL_EXPORTER_OTLP_HEADERS has no api-key header",
+		},
+		{
+			name: "region mismatch",
+			env: map[string]string{
+				"OTEL_RESOURCE_ATTRIBUTES":           "service.name=a",
+				"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT": "https://otlp.nr-data.net:4318/v1/traces",
+				"OTEL_EXPORTER_OTLP_HEADERS":         "api-key=eu01xx0123456789abcdef0123456789abcdNRAL",
+			},
+			expected: "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT points to the us01 endpoint otlp.nr-data.net but the license key is for the eu01 region, use otlp.eu01.nr-data.net.",
+		},
+		{
+			name: "grpc on the http port",
+			env: map[string]string{
+				"OTEL_SERVICE_NAME":           "a",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "https://otlp.nr-data.net:4318",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "grpc",
+				"OTEL_EXPORTER_OTLP_HEADERS":  "api-key=abc",
+			},
+			expected: "OTEL_EXPORTER_OTLP_PROTOCOL is grpc but OTEL_EXPORTER_OTLP_ENDPOINT uses port 4318",
+		},
+		{
+			name:     "headers without endpoint",
+			env:      map[string]string{"OTEL_SERVICE_NAME": "a", "OTEL_EXPORTER_OTLP_HEADERS": "api-key=abc"},
+			expected: "OTEL_EXPORTER_OTLP_ENDPOINT is not set, the SDK exports to localhost.",
+		},
+	} {
+		result, status := checkEnv(tc.env, []string{tasks.RegionUS})
+		if status == tasks.Success || !strings.Contains(strings.Join(result.Problems, "\n"), tc.expected) {
+			t.Errorf("%s: status = %v, problems = %v", tc.name, status, result.Problems)
+		}
+	}
+}
+
+func TestEnv_notLinux(t *testing.T) {
+	p := fakeSDK(nil)
+	p.goos = "windows"
+	if result := p.Execute(tasks.Options{}, nil); result.Status != tasks.None {
+		t.Errorf("Status = %v, want None", result.Status)
+	}
+}
@@ -1,6 +1,8 @@
 package env
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks/python/repository"
@@ -17,4 +19,13 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(PythonEnvDependencies{
 		iPipEnvVersion: pipEnv,
 	}, true)
+	registrationFunc(PythonEnvProcess{
+		getPythonProcesses: getPythonProcesses,
+		cmdExec:            tasks.CmdExecutor,
+		findStringInFile:   tasks.FindStringInFile,
+	}, true)
+	registrationFunc(PythonEnvTrustStore{
+		cmdExec:  tasks.CmdExecutor,
+		readFile: os.ReadFile,
+	}, true)
 }
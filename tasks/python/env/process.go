@@ -0,0 +1,330 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// PythonEnvProcess - This struct defines the task that inspects running Python processes instrumented by the agent.
+type PythonEnvProcess struct {
+	getPythonProcesses func() []pythonProcessCandidate
+	cmdExec            tasks.CmdExecFunc
+	findStringInFile   tasks.FindStringInFileFunc
+}
+
+// PythonProcess - the runtime environment of a Python process that loads the New Relic agent
+type PythonProcess struct {
+	PID              int32
+	CmdLine          []string
+	LaunchedBy       string
+	Interpreter      string
+	Prefix           string
+	VirtualEnv       string
+	ConfigFile       string
+	PythonPath       string
+	SSLCertFile      string
+	RequestsCABundle string
+	SysPath          []string
+	AgentVersion     string
+	PipVersion       string
+	DiffersFromPip   bool
+	VersionMismatch  bool
+	InspectionErrors []string
+}
+
+type pythonProcessCandidate struct {
+	PID     int32
+	Exe     string
+	Cwd     string
+	CmdLine []string
+	EnvVars map[string]string
+}
+
+type pythonRuntimeInfo struct {
+	Prefix   string   `json:"prefix"`
+	Path     []string `json:"path"`
+	Newrelic string   `json:"newrelic"`
+}
+
+const (
+	launchedByAdmin  = "newrelic-admin"
+	launchedByImport = "import newrelic.agent"
+)
+
+// pythonInspectScript prints the interpreter prefix, sys.path and the installed newrelic version as JSON.
+// The first argument is the PYTHONPATH of the inspected process, which is prepended to sys.path the same way the interpreter would.
+const pythonInspectScript = `import json, os, sys
+extra = [p for p in sys.argv[1].split(os.pathsep) if p] if len(sys.argv) > 1 else []
+sys.path[1:1] = extra
+version = ""
+try:
+    import newrelic
+    version = getattr(newrelic, "version", "") or getattr(newrelic, "__version__", "")
+except Exception:
+    pass
+if not isinstance(version, str):
+    version = ""
+print(json.dumps({"prefix": sys.prefix, "path": sys.path, "newrelic": version}))`
+
+var (
+	agentImportRegex   = `^\s*(import newrelic\.agent|from newrelic import agent|from newrelic\.agent import)`
+	pipLocationRegex   = regexp.MustCompile(`from (.+?)[\\/]pip \(python`)
+	pipFreezeNewrelic  = "newrelic=="
+	pythonProcessRegex = regexp.MustCompile(`^python[0-9.]*(\.exe)?$`)
+)
+
+// Identifier - This returns the Category, Subcategory and Name of this task.
+func (t PythonEnvProcess) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Python/Env/Process")
+}
+
+// Explain - Returns the help text for the Python/Env/Process task.
+func (t PythonEnvProcess) Explain() string {
+	return "Inspect the runtime environment of Python processes running the New Relic agent"
+}
+
+// Dependencies - Returns the dependencies for this task.
+func (t PythonEnvProcess) Dependencies() []string {
+	return []string{
+		"Python/Config/Agent",
+		"Python/Env/Dependencies",
+	}
+}
+
+// Execute - The core work within this task
+func (t PythonEnvProcess) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Python/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Python Agent not installed. This task didn't run.",
+		}
+	}
+
+	var processes []PythonProcess
+	for _, candidate := range t.getPythonProcesses() {
+		launchedBy := t.getLaunchMethod(candidate)
+		if launchedBy == "" {
+			continue
+		}
+		processes = append(processes, t.inspectProcess(candidate, launchedBy))
+	}
+
+	if len(processes) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No running Python processes using the New Relic agent were found.",
+		}
+	}
+
+	pipLocations := t.getPipLocations()
+	pipVersion := getNewrelicVersionFromPipFreeze(upstream["Python/Env/Dependencies"])
+
+	status := tasks.Success
+	var summaries []string
+	for i := range processes {
+		p := &processes[i]
+		p.PipVersion = pipVersion
+		p.DiffersFromPip = len(pipLocations) > 0 && p.Prefix != "" && !isPrefixOfAny(p.Prefix, pipLocations)
+		p.VersionMismatch = pipVersion != "" && p.AgentVersion != "" && pipVersion != p.AgentVersion
+
+		summary := fmt.Sprintf("PID %d launched by %s using %s", p.PID, p.LaunchedBy, p.Interpreter)
+		if p.AgentVersion == "" {
+			status = tasks.Warning
+			summary += "\n\tThe newrelic package could not be imported from this interpreter's environment."
+		} else {
+			summary += fmt.Sprintf(" with newrelic %s", p.AgentVersion)
+		}
+		if p.DiffersFromPip {
+			status = tasks.Warning
+			summary += fmt.Sprintf("\n\tThis process runs in %s, but pip inspected %s. Python/Env/Dependencies does not reflect this application's packages.", p.Prefix, strings.Join(pipLocations, ", "))
+		}
+		if p.VersionMismatch {
+			status = tasks.Warning
+			summary += fmt.Sprintf("\n\tpip reports newrelic %s, but this process's environment has newrelic %s installed.", pipVersion, p.AgentVersion)
+		}
+		summaries = append(summaries, summary)
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: processes,
+	}
+	if status == tasks.Warning {
+		result.URL = "https://docs.newrelic.com/install/python/"
+	}
+	return result
+}
+
+// getLaunchMethod returns how the agent was loaded into the process, or an empty string if it wasn't
+func (t PythonEnvProcess) getLaunchMethod(candidate pythonProcessCandidate) string {
+	for _, arg := range candidate.CmdLine {
+		if filepath.Base(arg) == "newrelic-admin" {
+			return launchedByAdmin
+		}
+	}
+	// newrelic-admin run-program execs the program with the agent's bootstrap directory prepended to PYTHONPATH
+	if strings.Contains(filepath.ToSlash(candidate.EnvVars["PYTHONPATH"]), "newrelic/bootstrap") {
+		return launchedByAdmin
+	}
+
+	script := findPythonScript(candidate)
+	if script != "" && t.findStringInFile(agentImportRegex, script) {
+		return launchedByImport
+	}
+	return ""
+}
+
+func (t PythonEnvProcess) inspectProcess(candidate pythonProcessCandidate, launchedBy string) PythonProcess {
+	p := PythonProcess{
+		PID:              candidate.PID,
+		CmdLine:          candidate.CmdLine,
+		LaunchedBy:       launchedBy,
+		Interpreter:      findInterpreter(candidate),
+		VirtualEnv:       candidate.EnvVars["VIRTUAL_ENV"],
+		ConfigFile:       resolveAgainstCwd(candidate.EnvVars["NEW_RELIC_CONFIG_FILE"], candidate.Cwd),
+		PythonPath:       candidate.EnvVars["PYTHONPATH"],
+		SSLCertFile:      resolveAgainstCwd(candidate.EnvVars["SSL_CERT_FILE"], candidate.Cwd),
+		RequestsCABundle: resolveAgainstCwd(candidate.EnvVars["REQUESTS_CA_BUNDLE"], candidate.Cwd),
+	}
+
+	output, err := t.cmdExec(p.Interpreter, "-c", pythonInspectScript, candidate.EnvVars["PYTHONPATH"])
+	if err != nil {
+		log.Debug("Unable to inspect Python interpreter", p.Interpreter, err)
+		p.InspectionErrors = append(p.InspectionErrors, "Unable to run "+p.Interpreter+": "+err.Error())
+		return p
+	}
+
+	var info pythonRuntimeInfo
+	err = json.Unmarshal(output, &info)
+	if err != nil {
+		log.Debug("Unable to parse Python interpreter output", string(output), err)
+		p.InspectionErrors = append(p.InspectionErrors, "Unable to parse interpreter output: "+err.Error())
+		return p
+	}
+	p.Prefix = info.Prefix
+	p.SysPath = info.Path
+	p.AgentVersion = info.Newrelic
+
+	if p.VirtualEnv == "" && tasks.FileExists(filepath.Join(info.Prefix, "pyvenv.cfg")) {
+		p.VirtualEnv = info.Prefix
+	}
+	return p
+}
+
+// getPipLocations returns the site-packages directories that pip and pip3 report they are installed in
+func (t PythonEnvProcess) getPipLocations() []string {
+	var locations []string
+	for _, pipCmd := range []string{"pip", "pip3"} {
+		output, err := t.cmdExec(pipCmd, "--version")
+		if err != nil {
+			continue
+		}
+		matches := pipLocationRegex.FindStringSubmatch(string(output))
+		if len(matches) > 1 && !tasks.ContainsString(locations, matches[1]) {
+			locations = append(locations, matches[1])
+		}
+	}
+	return locations
+}
+
+func getNewrelicVersionFromPipFreeze(dependencies tasks.Result) string {
+	packages, ok := dependencies.Payload.([]string)
+	if !ok {
+		return ""
+	}
+	for _, pkg := range packages {
+		if strings.HasPrefix(pkg, pipFreezeNewrelic) {
+			return strings.TrimPrefix(pkg, pipFreezeNewrelic)
+		}
+	}
+	return ""
+}
+
+func isPrefixOfAny(prefix string, locations []string) bool {
+	prefix = filepath.Clean(prefix)
+	for _, location := range locations {
+		if strings.HasPrefix(filepath.Clean(location), prefix+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// findInterpreter prefers the interpreter path from the command line since the executable reported by the OS
+// has its symlinks resolved, which hides virtualenvs that link back to the system Python
+func findInterpreter(candidate pythonProcessCandidate) string {
+	if len(candidate.CmdLine) > 0 && pythonProcessRegex.MatchString(filepath.Base(candidate.CmdLine[0])) {
+		interpreter := candidate.CmdLine[0]
+		if !filepath.IsAbs(interpreter) && strings.ContainsRune(interpreter, filepath.Separator) && candidate.Cwd != "" {
+			interpreter = filepath.Join(candidate.Cwd, interpreter)
+		}
+		return interpreter
+	}
+	return candidate.Exe
+}
+
+// resolveAgainstCwd resolves a relative path the process was given against its working directory
+func resolveAgainstCwd(path string, cwd string) string {
+	if path != "" && !filepath.IsAbs(path) && cwd != "" {
+		return filepath.Join(cwd, path)
+	}
+	return path
+}
+
+// findPythonScript returns the first .py file on the command line, resolved against the process working directory
+func findPythonScript(candidate pythonProcessCandidate) string {
+	for _, arg := range candidate.CmdLine {
+		if filepath.Ext(arg) != ".py" {
+			continue
+		}
+		if !filepath.IsAbs(arg) && candidate.Cwd != "" {
+			return filepath.Join(candidate.Cwd, arg)
+		}
+		return arg
+	}
+	return ""
+}
+
+func getPythonProcesses() []pythonProcessCandidate {
+	var candidates []pythonProcessCandidate
+
+	processes, err := process.Processes()
+	if err != nil {
+		log.Debug("Failed to get list of processes", err)
+		return candidates
+	}
+
+	for _, proc := range processes {
+		exe, err := proc.Exe()
+		if err != nil || !pythonProcessRegex.MatchString(filepath.Base(exe)) {
+			continue
+		}
+		cmdLine, err := proc.CmdlineSlice()
+		if err != nil {
+			log.Debug("Unable to get command line for", proc.Pid, err)
+		}
+		cwd, _ := proc.Cwd()
+		envVars, err := tasks.GetProcessEnvVars(proc.Pid)
+		if err != nil {
+			log.Debug("Unable to get environment variables for", proc.Pid, err)
+			envVars.All = map[string]string{}
+		}
+		candidates = append(candidates, pythonProcessCandidate{
+			PID:     proc.Pid,
+			Exe:     exe,
+			Cwd:     cwd,
+			CmdLine: cmdLine,
+			EnvVars: envVars.All,
+		})
+	}
+	return candidates
+}
@@ -0,0 +1,155 @@
+package env
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func mockPythonInspect(prefix string, version string, pipLocation string) tasks.CmdExecFunc {
+	return func(name string, arg ...string) ([]byte, error) {
+		if len(arg) > 0 && arg[0] == "--version" {
+			if pipLocation == "" {
+				return nil, errors.New("pip not found")
+			}
+			return []byte("pip 23.0.1 from " + pipLocation + "/pip (python 3.11)"), nil
+		}
+		return []byte(`{"prefix": "` + prefix + `", "path": ["", "` + prefix + `/lib/python3.11/site-packages"], "newrelic": "` + version + `"}`), nil
+	}
+}
+
+var _ = Describe("Python/Env/Process", func() {
+	var p PythonEnvProcess
+
+	Describe("Identifier()", func() {
+		It("Should return correct identifier", func() {
+			Expect(p.Identifier()).To(Equal(tasks.Identifier{Category: "Python", Subcategory: "Env", Name: "Process"}))
+		})
+	})
+
+	Describe("Dependencies()", func() {
+		It("Should return an expected slice of dependencies", func() {
+			Expect(p.Dependencies()).To(Equal([]string{"Python/Config/Agent", "Python/Env/Dependencies"}))
+		})
+	})
+
+	Describe("Execute()", func() {
+		var (
+			result   tasks.Result
+			options  tasks.Options
+			upstream map[string]tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When the Python agent was not detected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Python/Config/Agent": {Status: tasks.None},
+				}
+			})
+
+			It("should return an expected none result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+				Expect(result.Summary).To(Equal("Python Agent not installed. This task didn't run."))
+			})
+		})
+
+		Context("When no Python process loads the agent", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Python/Config/Agent": {Status: tasks.Success},
+				}
+				p = PythonEnvProcess{
+					getPythonProcesses: func() []pythonProcessCandidate {
+						return []pythonProcessCandidate{
+							{PID: 10, Exe: "/usr/bin/python3.11", CmdLine: []string{"python3", "app.py"}, Cwd: "/srv/app"},
+						}
+					},
+					cmdExec:          mockPythonInspect("/usr", "9.0.0", "/usr/lib/python3/dist-packages"),
+					findStringInFile: func(string, string) bool { return false },
+				}
+			})
+
+			It("should return an expected none result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When a process launched by newrelic-admin runs in the environment pip inspected", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Python/Config/Agent":     {Status: tasks.Success},
+					"Python/Env/Dependencies": {Status: tasks.Success, Payload: []string{"flask==2.3.0", "newrelic==9.0.0"}},
+				}
+				p = PythonEnvProcess{
+					getPythonProcesses: func() []pythonProcessCandidate {
+						return []pythonProcessCandidate{
+							{
+								PID:     20,
+								Exe:     "/usr/bin/python3.11",
+								Cwd:     "/srv/app",
+								CmdLine: []string{"/srv/venv/bin/python", "/srv/venv/bin/gunicorn", "app:app"},
+								EnvVars: map[string]string{
+									"PYTHONPATH":            "/srv/venv/lib/python3.11/site-packages/newrelic/bootstrap",
+									"NEW_RELIC_CONFIG_FILE": "/etc/newrelic.ini",
+									"VIRTUAL_ENV":           "/srv/venv",
+									"SSL_CERT_FILE":         "certs/ca.pem",
+								},
+							},
+						}
+					},
+					cmdExec:          mockPythonInspect("/srv/venv", "9.0.0", "/srv/venv/lib/python3.11/site-packages"),
+					findStringInFile: func(string, string) bool { return false },
+				}
+			})
+
+			It("should return a success result with the process environment", func() {
+				Expect(result.Status).To(Equal(tasks.Success))
+				processes := result.Payload.([]PythonProcess)
+				Expect(processes).To(HaveLen(1))
+				Expect(processes[0].LaunchedBy).To(Equal("newrelic-admin"))
+				Expect(processes[0].Interpreter).To(Equal("/srv/venv/bin/python"))
+				Expect(processes[0].VirtualEnv).To(Equal("/srv/venv"))
+				Expect(processes[0].ConfigFile).To(Equal("/etc/newrelic.ini"))
+				Expect(processes[0].SSLCertFile).To(Equal("/srv/app/certs/ca.pem"))
+				Expect(processes[0].AgentVersion).To(Equal("9.0.0"))
+				Expect(processes[0].DiffersFromPip).To(BeFalse())
+			})
+		})
+
+		Context("When a process importing newrelic.agent runs in a different virtualenv than pip", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Python/Config/Agent":     {Status: tasks.Success},
+					"Python/Env/Dependencies": {Status: tasks.Success, Payload: []string{"newrelic==8.0.0"}},
+				}
+				p = PythonEnvProcess{
+					getPythonProcesses: func() []pythonProcessCandidate {
+						return []pythonProcessCandidate{
+							{PID: 30, Exe: "/usr/bin/python3.11", CmdLine: []string{"/srv/venv/bin/python3", "app.py"}, Cwd: "/srv/app"},
+						}
+					},
+					cmdExec:          mockPythonInspect("/srv/venv", "9.0.0", "/usr/lib/python3/dist-packages"),
+					findStringInFile: func(search string, file string) bool { return file == "/srv/app/app.py" },
+				}
+			})
+
+			It("should return a warning result", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+			})
+
+			It("should flag the virtualenv and version differences", func() {
+				processes := result.Payload.([]PythonProcess)
+				Expect(processes[0].LaunchedBy).To(Equal("import newrelic.agent"))
+				Expect(processes[0].DiffersFromPip).To(BeTrue())
+				Expect(processes[0].VersionMismatch).To(BeTrue())
+				Expect(processes[0].PipVersion).To(Equal("8.0.0"))
+			})
+		})
+	})
+})
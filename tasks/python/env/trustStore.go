@@ -0,0 +1,191 @@
+package env
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/collector"
+)
+
+// pythonTrustScript prints the CA bundle vendored by the agent and the OpenSSL default bundle the interpreter was built with.
+// The first argument is the PYTHONPATH of the inspected process, so the agent is imported from that process's environment.
+// The OpenSSL default is read without the SSL_CERT_FILE of nrdiag's environment, the one of the process is applied by the task.
+const pythonTrustScript = `import json, os, sys, ssl
+extra = [p for p in sys.argv[1].split(os.pathsep) if p] if len(sys.argv) > 1 else []
+sys.path[1:1] = extra
+result = {"agent": "", "cafile": ""}
+try:
+    from newrelic.common import certs
+    result["agent"] = certs.where()
+except Exception:
+    try:
+        from newrelic.packages import certifi
+        result["agent"] = certifi.where()
+    except Exception:
+        pass
+result["cafile"] = ssl.get_default_verify_paths().openssl_cafile or ""
+print(json.dumps(result))`
+
+// PythonEnvTrustStore - checks the CA bundles of the Python processes running the agent against the New Relic endpoint certificates
+type PythonEnvTrustStore struct {
+	cmdExec  tasks.CmdExecFunc
+	readFile func(string) ([]byte, error)
+}
+
+// PythonTrustStore - a CA bundle used by a Python interpreter and whether it trusts each endpoint
+type PythonTrustStore struct {
+	Interpreter string
+	Bundle      string
+	Path        string
+	Checks      []httpHelper.TrustCheck
+	Error       string
+}
+
+type pythonTrustPaths struct {
+	Agent  string `json:"agent"`
+	CAFile string `json:"cafile"`
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p PythonEnvTrustStore) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Python/Env/TrustStore")
+}
+
+// Explain - Returns the help text for each individual task
+func (p PythonEnvTrustStore) Explain() string {
+	return "Check that the agent, SSL_CERT_FILE and REQUESTS_CA_BUNDLE CA bundles of Python processes trust the New Relic endpoint certificates"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p PythonEnvTrustStore) Dependencies() []string {
+	return []string{
+		"Python/Config/Agent",
+		"Python/Env/Process",
+		"Base/Collector/TrustStore",
+	}
+}
+
+// Execute - The core work within each task
+func (p PythonEnvTrustStore) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Python/Config/Agent"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Python agent not detected. This task did not run.",
+		}
+	}
+	trustPayload, ok := upstream["Base/Collector/TrustStore"].Payload.(collector.TrustStorePayload)
+	if !ok || len(trustPayload.Chains) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No New Relic endpoint certificate chains were retrieved. This task did not run.",
+		}
+	}
+
+	var stores []PythonTrustStore
+	for _, proc := range getTrustProcesses(upstream) {
+		stores = append(stores, p.checkProcess(proc, trustPayload.Chains)...)
+	}
+
+	var rejections []string
+	var errs []string
+	for _, store := range stores {
+		if store.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", store.Interpreter, store.Error))
+		}
+		for _, check := range store.Checks {
+			if !check.Trusted {
+				rejections = append(rejections, fmt.Sprintf("%s %s bundle (%s) would reject %s: %s", store.Interpreter, store.Bundle, store.Path, check.ServerName, check.Reason))
+			}
+		}
+	}
+
+	if len(rejections) > 0 {
+		return tasks.Result{
+			Status:  tasks.Failure,
+			Summary: "The following Python CA bundles would reject the New Relic endpoint certificates:\n\t" + strings.Join(rejections, "\n\t") + "\nAdd the missing certificate authority to the bundle or set ca_bundle_path in newrelic.ini.",
+			URL:     "https://docs.newrelic.com/docs/apm/agents/python-agent/configuration/python-agent-configuration/#ca_bundle_path",
+			Payload: stores,
+		}
+	}
+	if len(errs) > 0 {
+		return tasks.Result{
+			Status:  tasks.Warning,
+			Summary: "Unable to check the CA bundles of some Python interpreters:\n\t" + strings.Join(errs, "\n\t"),
+			Payload: stores,
+		}
+	}
+	return tasks.Result{
+		Status:  tasks.Success,
+		Summary: "The Python CA bundles trust the New Relic endpoint certificates.",
+		Payload: stores,
+	}
+}
+
+// getTrustProcesses returns the agent processes with distinct interpreters and CA settings, falling back to the interpreter on the PATH
+func getTrustProcesses(upstream map[string]tasks.Result) []PythonProcess {
+	var procs []PythonProcess
+	seen := make(map[string]bool)
+	processes, _ := upstream["Python/Env/Process"].Payload.([]PythonProcess)
+	for _, proc := range processes {
+		key := strings.Join([]string{proc.Interpreter, proc.PythonPath, proc.SSLCertFile, proc.RequestsCABundle}, "\x00")
+		if proc.Interpreter != "" && !seen[key] {
+			seen[key] = true
+			procs = append(procs, proc)
+		}
+	}
+	if len(procs) == 0 {
+		return []PythonProcess{{Interpreter: "python3"}}
+	}
+	return procs
+}
+
+func (p PythonEnvTrustStore) checkProcess(proc PythonProcess, chains []httpHelper.EndpointChain) []PythonTrustStore {
+	interpreter := proc.Interpreter
+	if proc.PID != 0 {
+		interpreter = fmt.Sprintf("PID %d (%s)", proc.PID, proc.Interpreter)
+	}
+	output, err := p.cmdExec(proc.Interpreter, "-c", pythonTrustScript, proc.PythonPath)
+	if err != nil {
+		log.Debug("Unable to inspect the CA bundles of", proc.Interpreter, err)
+		return []PythonTrustStore{{Interpreter: interpreter, Error: "unable to run the interpreter: " + err.Error()}}
+	}
+	var paths pythonTrustPaths
+	if err := json.Unmarshal(output, &paths); err != nil {
+		return []PythonTrustStore{{Interpreter: interpreter, Error: "unable to parse the interpreter output: " + err.Error()}}
+	}
+
+	var stores []PythonTrustStore
+	if paths.Agent != "" {
+		stores = append(stores, p.checkBundle(interpreter, "agent", paths.Agent, chains))
+	}
+	// SSL_CERT_FILE replaces the OpenSSL default bundle of the process
+	if proc.SSLCertFile != "" {
+		stores = append(stores, p.checkBundle(interpreter, "SSL_CERT_FILE", proc.SSLCertFile, chains))
+	} else if paths.CAFile != "" && paths.CAFile != paths.Agent {
+		stores = append(stores, p.checkBundle(interpreter, "OpenSSL default", paths.CAFile, chains))
+	}
+	if proc.RequestsCABundle != "" && proc.RequestsCABundle != proc.SSLCertFile {
+		stores = append(stores, p.checkBundle(interpreter, "REQUESTS_CA_BUNDLE", proc.RequestsCABundle, chains))
+	}
+	return stores
+}
+
+func (p PythonEnvTrustStore) checkBundle(interpreter string, bundle string, path string, chains []httpHelper.EndpointChain) PythonTrustStore {
+	store := PythonTrustStore{Interpreter: interpreter, Bundle: bundle, Path: path}
+	content, err := p.readFile(path)
+	if err != nil {
+		store.Error = fmt.Sprintf("unable to read the %s bundle %s: %s", bundle, path, err.Error())
+		return store
+	}
+	pool := x509.NewCertPool()
+	for _, cert := range httpHelper.ParsePEMCertificates(content) {
+		pool.AddCert(cert)
+	}
+	store.Checks = httpHelper.CheckTrust("Python "+bundle, path, pool, chains)
+	return store
+}
@@ -0,0 +1,111 @@
+package env
+
+import (
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/base/collector"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Python/Env/TrustStore", func() {
+	var (
+		p        PythonEnvTrustStore
+		upstream map[string]tasks.Result
+		result   tasks.Result
+		files    map[string]string
+	)
+
+	BeforeEach(func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		DeferCleanup(server.Close)
+		serverPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+		files = map[string]string{
+			"/venv/lib/newrelic/common/cacert.pem": serverPEM,
+			"/usr/lib/ssl/cert.pem":                serverPEM,
+		}
+		upstream = map[string]tasks.Result{
+			"Python/Config/Agent": {Status: tasks.Success},
+			"Python/Env/Process":  {Status: tasks.Success, Payload: []PythonProcess{{PID: 1, Interpreter: "/venv/bin/python", PythonPath: "/venv/lib/newrelic/bootstrap"}}},
+			"Base/Collector/TrustStore": {Status: tasks.Success, Payload: collector.TrustStorePayload{
+				Chains: []httpHelper.EndpointChain{{ServerName: "example.com", PEM: []string{serverPEM}}},
+			}},
+		}
+		p = PythonEnvTrustStore{
+			cmdExec: func(name string, args ...string) ([]byte, error) {
+				Expect(name).To(Equal("/venv/bin/python"))
+				Expect(args[len(args)-1]).To(Equal("/venv/lib/newrelic/bootstrap"))
+				return []byte(`{"agent": "/venv/lib/newrelic/common/cacert.pem", "cafile": "/usr/lib/ssl/cert.pem"}`), nil
+			},
+			readFile: func(path string) ([]byte, error) {
+				content, ok := files[path]
+				if !ok {
+					return nil, errors.New("file not found")
+				}
+				return []byte(content), nil
+			},
+		}
+	})
+
+	JustBeforeEach(func() {
+		result = p.Execute(tasks.Options{}, upstream)
+	})
+
+	Context("When both bundles trust the chain", func() {
+		It("should return a Success result", func() {
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload.([]PythonTrustStore)).To(HaveLen(2))
+		})
+	})
+
+	Context("When the agent bundle does not contain the root", func() {
+		BeforeEach(func() {
+			files["/venv/lib/newrelic/common/cacert.pem"] = ""
+		})
+
+		It("should return a Failure result naming the bundle", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("PID 1 (/venv/bin/python) agent bundle (/venv/lib/newrelic/common/cacert.pem) would reject example.com"))
+		})
+	})
+
+	Context("When the process sets SSL_CERT_FILE and REQUESTS_CA_BUNDLE", func() {
+		BeforeEach(func() {
+			upstream["Python/Env/Process"] = tasks.Result{Status: tasks.Success, Payload: []PythonProcess{{
+				PID:              1,
+				Interpreter:      "/venv/bin/python",
+				PythonPath:       "/venv/lib/newrelic/bootstrap",
+				SSLCertFile:      "/srv/app/ca.pem",
+				RequestsCABundle: "/srv/app/requests.pem",
+			}}}
+			files["/srv/app/ca.pem"] = ""
+			files["/srv/app/requests.pem"] = files["/usr/lib/ssl/cert.pem"]
+		})
+
+		It("should check the bundles of the process instead of the OpenSSL default", func() {
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("SSL_CERT_FILE bundle (/srv/app/ca.pem) would reject example.com"))
+			var bundles []string
+			for _, store := range result.Payload.([]PythonTrustStore) {
+				bundles = append(bundles, store.Bundle)
+			}
+			Expect(bundles).To(Equal([]string{"agent", "SSL_CERT_FILE", "REQUESTS_CA_BUNDLE"}))
+		})
+	})
+
+	Context("When the Python agent was not detected", func() {
+		BeforeEach(func() {
+			upstream["Python/Config/Agent"] = tasks.Result{Status: tasks.Failure}
+		})
+
+		It("should return a None result", func() {
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+
 	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
 	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
 )
@@ -12,4 +14,8 @@ func RegisterWith(registrationFunc func(tasks.Task, bool)) {
 	registrationFunc(RubyConfigAgent{}, true)
 	registrationFunc(RubyConfigCollect{}, true)
 	registrationFunc(RubyConfigIncompatibleGems{}, true)
+	registrationFunc(RubyConfigGemfileLock{
+		readFile: os.ReadFile,
+		getenv:   os.Getenv,
+	}, true)
 }
@@ -0,0 +1,367 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks/compatibilityVars"
+)
+
+const (
+	agentGemName           = "newrelic_rpm"
+	infiniteTracingGem     = "newrelic-infinite_tracing"
+	gemfileLockName        = "Gemfile.lock"
+	defaultBundlerGroup    = "default"
+	productionBundlerGroup = "production"
+)
+
+// Third party gems whose instrumentation is now built into newrelic_rpm and conflicts with it
+var conflictingAgentGems = []string{
+	"rpm_contrib",
+	"newrelic-redis",
+	"newrelic_moped",
+	"newrelic-grape",
+	"newrelic-faraday",
+}
+
+var (
+	lockSpecRegex     = regexp.MustCompile(`^    ([^ ]+) \(([^)]+)\)$`)
+	gemDeclRegex      = regexp.MustCompile(`^\s*gem\s+['"]([^'"]+)['"](.*)$`)
+	groupBlockRegex   = regexp.MustCompile(`^\s*groups?\s*\(?\s*(.+?)\)?\s+do\s*(\|.*\|)?\s*$`)
+	inlineGroupRegex  = regexp.MustCompile(`groups?:\s*(\[[^\]]*\]|:[\w]+|['"][\w]+['"])`)
+	blockStartRegex   = regexp.MustCompile(`\bdo\s*(\|.*\|)?\s*$`)
+	blockEndRegex     = regexp.MustCompile(`^\s*end\b`)
+	groupNameRegex    = regexp.MustCompile(`[:'"]?(\w+)['"]?`)
+	bundleWithoutRegx = regexp.MustCompile(`^BUNDLE_WITHOUT:\s*['"]?([^'"]*)['"]?`)
+)
+
+// RubyConfigGemfileLock - Reports the gem versions resolved by Bundler in Gemfile.lock
+type RubyConfigGemfileLock struct {
+	readFile func(string) ([]byte, error)
+	getenv   func(string) string
+}
+
+// ResolvedGem - a gem and the version Bundler resolved for it
+type ResolvedGem struct {
+	Name              string
+	Version           string
+	SupportedVersions []string
+	IsSupported       bool
+}
+
+// GemfileLockInfo - the agent related information found in one Gemfile.lock
+type GemfileLockInfo struct {
+	Path                 string
+	AgentVersion         string
+	AgentGroups          []string
+	BundleWithout        []string
+	InstrumentedGems     []ResolvedGem
+	ConflictingAgentGems []string
+	Issues               []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (t RubyConfigGemfileLock) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Ruby/Config/GemfileLock")
+}
+
+// Explain - Returns the help text for each individual task
+func (t RubyConfigGemfileLock) Explain() string {
+	return "Check gem versions resolved in Gemfile.lock against New Relic Ruby agent requirements"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (t RubyConfigGemfileLock) Dependencies() []string {
+	return []string{
+		"Ruby/Config/Collect",
+	}
+}
+
+// Execute - The core work within each task
+func (t RubyConfigGemfileLock) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Ruby/Config/Collect"].Status != tasks.Success {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "Either no Gemfile or newrelic.yml was found",
+		}
+	}
+
+	gemfiles, ok := upstream["Ruby/Config/Collect"].Payload.([]string)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Error getting Gemfile list; expecting type of Slice of Strings.",
+		}
+	}
+
+	var lockInfos []GemfileLockInfo
+	for _, gemfile := range gemfiles {
+		if filepath.Base(gemfile) != gemfileLockName {
+			continue
+		}
+		lockInfo, err := t.inspectGemfileLock(gemfile)
+		if err != nil {
+			log.Debug("Unable to read", gemfile, err)
+			continue
+		}
+		lockInfos = append(lockInfos, lockInfo)
+	}
+
+	if len(lockInfos) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Gemfile.lock was found. Run bundle install so the resolved gem versions can be checked.",
+		}
+	}
+
+	status := tasks.Success
+	agentFound := false
+	var summaries []string
+	for _, lockInfo := range lockInfos {
+		summary := lockInfo.Path + ":"
+		if lockInfo.AgentVersion != "" {
+			summary += fmt.Sprintf("\n\t%s %s", agentGemName, lockInfo.AgentVersion)
+		}
+		for _, gem := range lockInfo.InstrumentedGems {
+			summary += fmt.Sprintf("\n\t%s %s", gem.Name, gem.Version)
+			if !gem.IsSupported {
+				summary += fmt.Sprintf(" (supported versions: %s)", strings.Join(gem.SupportedVersions, ", "))
+			}
+		}
+		for _, issue := range lockInfo.Issues {
+			summary += "\n\t- " + issue
+		}
+		if len(lockInfo.Issues) > 0 {
+			status = tasks.Warning
+		}
+		if lockInfo.AgentVersion != "" {
+			agentFound = true
+		}
+		summaries = append(summaries, summary)
+	}
+	if !agentFound {
+		status = tasks.Failure
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summaries, "\n"),
+		Payload: lockInfos,
+	}
+	if status != tasks.Success {
+		result.URL = "https://docs.newrelic.com/docs/agents/ruby-agent/getting-started/ruby-agent-requirements-supported-frameworks"
+	}
+	return result
+}
+
+func (t RubyConfigGemfileLock) inspectGemfileLock(path string) (GemfileLockInfo, error) {
+	lockInfo := GemfileLockInfo{Path: path}
+
+	content, err := t.readFile(path)
+	if err != nil {
+		return lockInfo, err
+	}
+	resolved := parseGemfileLock(string(content))
+
+	lockInfo.AgentVersion = resolved[agentGemName]
+	if lockInfo.AgentVersion == "" {
+		lockInfo.Issues = append(lockInfo.Issues, agentGemName+" is not part of this bundle, so Bundler will not load the agent.")
+	}
+
+	if tracingVersion, ok := resolved[infiniteTracingGem]; ok && lockInfo.AgentVersion != "" && tracingVersion != lockInfo.AgentVersion {
+		lockInfo.ConflictingAgentGems = append(lockInfo.ConflictingAgentGems, fmt.Sprintf("%s %s", infiniteTracingGem, tracingVersion))
+		lockInfo.Issues = append(lockInfo.Issues, fmt.Sprintf("%s %s must match the %s version %s.", infiniteTracingGem, tracingVersion, agentGemName, lockInfo.AgentVersion))
+	}
+	for _, gemName := range conflictingAgentGems {
+		if version, ok := resolved[gemName]; ok {
+			lockInfo.ConflictingAgentGems = append(lockInfo.ConflictingAgentGems, fmt.Sprintf("%s %s", gemName, version))
+			lockInfo.Issues = append(lockInfo.Issues, fmt.Sprintf("%s duplicates instrumentation that is built into %s and should be removed.", gemName, agentGemName))
+		}
+	}
+
+	lockInfo.InstrumentedGems = checkInstrumentedGems(resolved)
+	for _, gem := range lockInfo.InstrumentedGems {
+		if !gem.IsSupported {
+			lockInfo.Issues = append(lockInfo.Issues, fmt.Sprintf("%s %s is outside the versions supported by the Ruby agent.", gem.Name, gem.Version))
+		}
+	}
+
+	if lockInfo.AgentVersion == "" {
+		return lockInfo, nil
+	}
+
+	dir := filepath.Dir(path)
+	gemfileContent, err := t.readFile(filepath.Join(dir, "Gemfile"))
+	if err != nil {
+		log.Debug("Unable to read Gemfile next to", path, err)
+		return lockInfo, nil
+	}
+	lockInfo.AgentGroups = findGemGroups(string(gemfileContent), agentGemName)
+	lockInfo.BundleWithout = t.getBundleWithout(dir)
+
+	if len(lockInfo.AgentGroups) > 0 && !tasks.ContainsString(lockInfo.AgentGroups, defaultBundlerGroup) && !tasks.ContainsString(lockInfo.AgentGroups, productionBundlerGroup) {
+		lockInfo.Issues = append(lockInfo.Issues, fmt.Sprintf("%s is only declared in the Bundler group(s) %s, so it is not loaded in production.", agentGemName, strings.Join(lockInfo.AgentGroups, ", ")))
+	} else if excluded := excludedGroups(lockInfo.AgentGroups, lockInfo.BundleWithout); len(excluded) == len(lockInfo.AgentGroups) && len(excluded) > 0 {
+		lockInfo.Issues = append(lockInfo.Issues, fmt.Sprintf("%s is declared in the Bundler group(s) %s, which BUNDLE_WITHOUT excludes from the bundle.", agentGemName, strings.Join(excluded, ", ")))
+	}
+	return lockInfo, nil
+}
+
+// getBundleWithout returns the groups Bundler is configured to skip through the environment or the application's .bundle/config
+func (t RubyConfigGemfileLock) getBundleWithout(dir string) []string {
+	without := t.getenv("BUNDLE_WITHOUT")
+	if without == "" {
+		content, err := t.readFile(filepath.Join(dir, ".bundle", "config"))
+		if err == nil {
+			for _, line := range strings.Split(string(content), "\n") {
+				if matches := bundleWithoutRegx.FindStringSubmatch(strings.TrimSpace(line)); len(matches) > 1 {
+					without = matches[1]
+				}
+			}
+		}
+	}
+
+	var groups []string
+	for _, group := range strings.FieldsFunc(without, func(r rune) bool { return r == ':' || r == ' ' || r == ',' }) {
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+func excludedGroups(groups []string, without []string) []string {
+	var excluded []string
+	for _, group := range groups {
+		if tasks.ContainsString(without, group) {
+			excluded = append(excluded, group)
+		}
+	}
+	return excluded
+}
+
+// parseGemfileLock returns the resolved version of every gem listed under a specs: section
+func parseGemfileLock(content string) map[string]string {
+	resolved := make(map[string]string)
+	inSpecs := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.TrimSpace(line) == "specs:":
+			inSpecs = true
+			continue
+		case len(line) > 0 && line[0] != ' ':
+			inSpecs = false
+			continue
+		}
+		if !inSpecs {
+			continue
+		}
+		// dependencies of a spec are indented by 6 spaces and are constraints, not resolved versions
+		if matches := lockSpecRegex.FindStringSubmatch(line); len(matches) > 2 {
+			// platform specific gems are suffixed with the platform, e.g. grpc (1.54.0-x86_64-linux)
+			resolved[matches[1]] = strings.SplitN(matches[2], "-", 2)[0]
+		}
+	}
+	return resolved
+}
+
+func checkInstrumentedGems(resolved map[string]string) []ResolvedGem {
+	var gems []ResolvedGem
+	for name, supportedVersions := range compatibilityVars.RubyInstrumentedGemSupportability {
+		version, ok := resolved[name]
+		if !ok {
+			continue
+		}
+		isSupported, err := tasks.VersionIsCompatible(version, supportedVersions)
+		if err != nil {
+			log.Debug("Unable to check compatibility of", name, version, err)
+			isSupported = true
+		}
+		gems = append(gems, ResolvedGem{
+			Name:              name,
+			Version:           version,
+			SupportedVersions: supportedVersions,
+			IsSupported:       isSupported,
+		})
+	}
+	sort.Slice(gems, func(i, j int) bool { return gems[i].Name < gems[j].Name })
+	return gems
+}
+
+// findGemGroups returns the Bundler groups a gem is declared in, or nil if the Gemfile does not declare it
+func findGemGroups(gemfile string, gemName string) []string {
+	var groupStack [][]string
+	var groups []string
+	found := false
+
+	for _, line := range strings.Split(gemfile, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if matches := groupBlockRegex.FindStringSubmatch(trimmed); len(matches) > 1 && !strings.HasPrefix(trimmed, "gem ") {
+			groupStack = append(groupStack, parseGroupNames(matches[1]))
+			continue
+		}
+		if blockStartRegex.MatchString(trimmed) {
+			groupStack = append(groupStack, nil)
+			continue
+		}
+		if blockEndRegex.MatchString(trimmed) {
+			if len(groupStack) > 0 {
+				groupStack = groupStack[:len(groupStack)-1]
+			}
+			continue
+		}
+
+		matches := gemDeclRegex.FindStringSubmatch(line)
+		if len(matches) < 3 || matches[1] != gemName {
+			continue
+		}
+		found = true
+
+		declGroups := []string{}
+		for _, blockGroups := range groupStack {
+			declGroups = append(declGroups, blockGroups...)
+		}
+		if inline := inlineGroupRegex.FindStringSubmatch(matches[2]); len(inline) > 1 {
+			declGroups = append(declGroups, parseGroupNames(inline[1])...)
+		}
+		if len(declGroups) == 0 {
+			declGroups = append(declGroups, defaultBundlerGroup)
+		}
+		for _, group := range declGroups {
+			if !tasks.ContainsString(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return groups
+}
+
+func parseGroupNames(declaration string) []string {
+	var names []string
+	for _, part := range strings.Split(declaration, ",") {
+		part = strings.TrimSpace(part)
+		// stop at options such as optional: true
+		if strings.Contains(part, ":") && !strings.HasPrefix(strings.Trim(part, "[ "), ":") {
+			continue
+		}
+		if matches := groupNameRegex.FindStringSubmatch(strings.Trim(part, "[] ")); len(matches) > 1 {
+			names = append(names, matches[1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var sampleGemfileLock = `GEM
+  remote: https://rubygems.org/
+  specs:
+    actionpack (7.1.3)
+      rack (>= 2.2.4)
+    grpc (1.54.0-x86_64-linux)
+    newrelic-infinite_tracing (9.6.0)
+      grpc (~> 1.34)
+      newrelic_rpm (= 9.6.0)
+    newrelic_rpm (9.7.0)
+    puma (2.16.0)
+    rails (7.1.3)
+    rpm_contrib (2.2.0)
+    sidekiq (7.2.0)
+
+PLATFORMS
+  x86_64-linux
+
+DEPENDENCIES
+  newrelic_rpm
+  rails (~> 7.1)
+
+BUNDLED WITH
+   2.5.3
+`
+
+func mockGemfileReader(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, errors.New("file not found")
+		}
+		return []byte(content), nil
+	}
+}
+
+func noEnv(string) string {
+	return ""
+}
+
+var _ = Describe("Ruby/Config/GemfileLock", func() {
+	var p RubyConfigGemfileLock
+
+	Describe("Execute()", func() {
+		var (
+			options  tasks.Options
+			upstream map[string]tasks.Result
+			result   tasks.Result
+		)
+
+		JustBeforeEach(func() {
+			result = p.Execute(options, upstream)
+		})
+
+		Context("When Ruby/Config/Collect did not find Gemfiles", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Ruby/Config/Collect": {Status: tasks.Warning},
+				}
+			})
+
+			It("Should return a None result", func() {
+				Expect(result.Status).To(Equal(tasks.None))
+			})
+		})
+
+		Context("When the lockfile has conflicting and unsupported gems", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Ruby/Config/Collect": {Status: tasks.Success, Payload: []string{"/app/Gemfile", "/app/Gemfile.lock"}},
+				}
+				p = RubyConfigGemfileLock{
+					readFile: mockGemfileReader(map[string]string{
+						"/app/Gemfile.lock": sampleGemfileLock,
+						"/app/Gemfile":      "source 'https://rubygems.org'\ngem 'rails', '~> 7.1'\ngem 'newrelic_rpm'\n",
+					}),
+					getenv: noEnv,
+				}
+			})
+
+			It("Should return a Warning result", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+			})
+
+			It("Should report resolved versions and issues", func() {
+				lockInfos := result.Payload.([]GemfileLockInfo)
+				Expect(lockInfos).To(HaveLen(1))
+				Expect(lockInfos[0].AgentVersion).To(Equal("9.7.0"))
+				Expect(lockInfos[0].AgentGroups).To(Equal([]string{"default"}))
+				Expect(lockInfos[0].ConflictingAgentGems).To(Equal([]string{"newrelic-infinite_tracing 9.6.0", "rpm_contrib 2.2.0"}))
+				Expect(lockInfos[0].InstrumentedGems).To(Equal([]ResolvedGem{
+					{Name: "grpc", Version: "1.54.0", SupportedVersions: []string{"1.34+"}, IsSupported: true},
+					{Name: "puma", Version: "2.16.0", SupportedVersions: []string{"3.9-6.*"}, IsSupported: false},
+					{Name: "rails", Version: "7.1.3", SupportedVersions: []string{"4.2-8.*"}, IsSupported: true},
+					{Name: "sidekiq", Version: "7.2.0", SupportedVersions: []string{"5.0-8.*"}, IsSupported: true},
+				}))
+				Expect(lockInfos[0].Issues).To(HaveLen(3))
+			})
+		})
+
+		Context("When newrelic_rpm is only in the development group", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Ruby/Config/Collect": {Status: tasks.Success, Payload: []string{"/app/Gemfile.lock"}},
+				}
+				p = RubyConfigGemfileLock{
+					readFile: mockGemfileReader(map[string]string{
+						"/app/Gemfile.lock": "GEM\n  specs:\n    newrelic_rpm (9.7.0)\n",
+						"/app/Gemfile":      "gem 'rails'\ngroup :development, :test do\n  gem 'newrelic_rpm'\nend\n",
+					}),
+					getenv: noEnv,
+				}
+			})
+
+			It("Should return a Warning result about the Bundler group", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("only declared in the Bundler group(s) development, test"))
+			})
+		})
+
+		Context("When BUNDLE_WITHOUT excludes the agent's group", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Ruby/Config/Collect": {Status: tasks.Success, Payload: []string{"/app/Gemfile.lock"}},
+				}
+				p = RubyConfigGemfileLock{
+					readFile: mockGemfileReader(map[string]string{
+						"/app/Gemfile.lock":   "GEM\n  specs:\n    newrelic_rpm (9.7.0)\n",
+						"/app/Gemfile":        "gem 'newrelic_rpm', group: :production\n",
+						"/app/.bundle/config": "---\nBUNDLE_WITHOUT: \"production:test\"\n",
+					}),
+					getenv: noEnv,
+				}
+			})
+
+			It("Should return a Warning result about BUNDLE_WITHOUT", func() {
+				Expect(result.Status).To(Equal(tasks.Warning))
+				Expect(result.Summary).To(ContainSubstring("which BUNDLE_WITHOUT excludes"))
+			})
+		})
+
+		Context("When no lockfile contains newrelic_rpm", func() {
+			BeforeEach(func() {
+				upstream = map[string]tasks.Result{
+					"Ruby/Config/Collect": {Status: tasks.Success, Payload: []string{"/app/Gemfile.lock"}},
+				}
+				p = RubyConfigGemfileLock{
+					readFile: mockGemfileReader(map[string]string{
+						"/app/Gemfile.lock": "GEM\n  specs:\n    rails (7.1.3)\n",
+					}),
+					getenv: noEnv,
+				}
+			})
+
+			It("Should return a Failure result", func() {
+				Expect(result.Status).To(Equal(tasks.Failure))
+			})
+		})
+	})
+
+	Describe("findGemGroups()", func() {
+		It("Should combine block and inline groups", func() {
+			gemfile := "group :staging do\n  gem 'newrelic_rpm', groups: [:production, :test], require: false\nend\n"
+			Expect(findGemGroups(gemfile, "newrelic_rpm")).To(Equal([]string{"staging", "production", "test"}))
+		})
+
+		It("Should ignore commented declarations", func() {
+			Expect(findGemGroups("# gem 'newrelic_rpm'\n", "newrelic_rpm")).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package jobmanager
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// SyntheticsJobManagerCollectLogs - collects the logs of job managers and their runtime pods
+type SyntheticsJobManagerCollectLogs struct {
+	executeCommand tasks.BufferedCommandExecFunc
+}
+
+// logSource is a container or pod to collect logs from and the command printing them
+type logSource struct {
+	path string
+	cmd  string
+	args []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerCollectLogs) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/CollectLogs")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerCollectLogs) Explain() string {
+	return "Collect logs of Synthetics job managers and their runtimes"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerCollectLogs) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerCollectLogs) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics job managers detected to collect logs from",
+		}
+	}
+	jobManagers, ok := upstream["Synthetics/JobManager/Detect"].Payload.([]JobManager)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	// As in Synthetics/Minion/CollectLogs, the log streams are returned unconsumed and read after all tasks have completed
+	envelopes, cmdErrors := p.streamLogs(logSources(jobManagers))
+	if len(envelopes) == 0 {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: collectErrorsSliceToString(cmdErrors),
+		}
+	}
+	if len(cmdErrors) > 0 {
+		return tasks.Result{
+			Status:      tasks.Warning,
+			Summary:     collectErrorsSliceToString(cmdErrors),
+			FilesToCopy: envelopes,
+		}
+	}
+	return tasks.Result{
+		Status:      tasks.Success,
+		Summary:     fmt.Sprintf("Collected logs from %d job manager and runtime container(s)", len(envelopes)),
+		FilesToCopy: envelopes,
+	}
+}
+
+// logSources lists the job managers and, on Kubernetes, their runtime pods. Docker and Podman runtime containers are removed when their job ends.
+func logSources(jobManagers []JobManager) []logSource {
+	var sources []logSource
+	seen := make(map[string]bool)
+	for _, jobManager := range jobManagers {
+		if jobManager.Platform != PlatformKubernetes {
+			sources = append(sources, logSource{
+				path: fmt.Sprintf("synthetics-job-manager/%s.log", jobManager.Name),
+				cmd:  jobManager.Platform,
+				args: []string{"logs", jobManager.ID},
+			})
+			continue
+		}
+		sources = append(sources, podLogSource(jobManager.Namespace, jobManager.Name))
+		for _, runtime := range jobManager.Runtimes {
+			key := jobManager.Namespace + "/" + runtime.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			sources = append(sources, podLogSource(jobManager.Namespace, runtime.Name))
+		}
+	}
+	return sources
+}
+
+func podLogSource(namespace string, name string) logSource {
+	return logSource{
+		path: fmt.Sprintf("synthetics-job-manager/%s/%s.log", namespace, name),
+		cmd:  kubectlBin,
+		args: []string{"logs", "-n", namespace, name, "--all-containers"},
+	}
+}
+
+// streamLogs starts a command for each source and returns a file for each command that started
+func (p SyntheticsJobManagerCollectLogs) streamLogs(sources []logSource) ([]tasks.FileCopyEnvelope, []error) {
+	var envelopes []tasks.FileCopyEnvelope
+	var cmdErrors []error
+
+	for _, source := range sources {
+		stream := make(chan string)
+		started := make(chan error, 1)
+		go func(source logSource) {
+			defer close(stream)
+			log.Debugf("Collecting logs with %s %s\n", source.cmd, strings.Join(source.args, " "))
+			scanner, err := p.executeCommand(0, source.cmd, source.args...)
+			started <- err
+			if err != nil {
+				return
+			}
+			for scanner.Scan() {
+				stream <- scanner.Text() + "\n"
+			}
+		}(source)
+
+		// wait for the command to start so no file is returned for a command that failed
+		if err := <-started; err != nil {
+			cmdErrors = append(cmdErrors, fmt.Errorf("%s: %w", source.path, err))
+			continue
+		}
+		envelopes = append(envelopes, tasks.FileCopyEnvelope{
+			Path:       source.path,
+			Stream:     stream,
+			Identifier: p.Identifier().String(),
+		})
+	}
+	return envelopes, cmdErrors
+}
+
+func collectErrorsSliceToString(errors []error) string {
+	errorString := ""
+	for _, err := range errors {
+		errorString = errorString + fmt.Sprintf("Error collecting logs: %s\n", err.Error())
+	}
+	return errorString
+}
@@ -0,0 +1,173 @@
+package jobmanager
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const configDocURL = "https://docs.newrelic.com/docs/synthetics/synthetic-monitoring/private-locations/job-manager-configuration"
+
+// legacyMinionSettings maps containerized private minion settings to the job manager settings replacing them
+var legacyMinionSettings = []struct {
+	minion     string
+	jobManager string
+}{
+	{"MINION_PRIVATE_LOCATION_KEY", "PRIVATE_LOCATION_KEY"},
+	{"MINION_API_ENDPOINT", "HORDE_API_ENDPOINT"},
+	{"MINION_API_PROXY", "HORDE_API_PROXY_HOST and HORDE_API_PROXY_PORT"},
+	{"MINION_API_PROXY_AUTH", "HORDE_API_PROXY_USERNAME and HORDE_API_PROXY_PW"},
+	{"MINION_API_PROXY_SELF_SIGNED_CERT", "HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT"},
+	{"MINION_VSE_PASSPHRASE", "VSE_PASSPHRASE"},
+}
+
+// SyntheticsJobManagerConfigValidate - validates the settings of each job manager
+type SyntheticsJobManagerConfigValidate struct {
+}
+
+// JobManagerConfig is the validation result of one job manager
+type JobManagerConfig struct {
+	JobManager string
+	Region     string
+	Proxy      string
+	VSEEnabled bool
+	Status     tasks.Status
+	Problems   []string
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerConfigValidate) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/ConfigValidate")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerConfigValidate) Explain() string {
+	return "Validate the private location key, proxy and verified script execution settings of Synthetics job managers"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerConfigValidate) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerConfigValidate) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics job manager detected",
+		}
+	}
+	jobManagers, ok := upstream["Synthetics/JobManager/Detect"].Payload.([]JobManager)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	status := tasks.Success
+	var summary []string
+	var payload []JobManagerConfig
+	for _, jobManager := range jobManagers {
+		config := validateConfig(jobManager, options)
+		payload = append(payload, config)
+		if config.Status > status {
+			status = config.Status
+		}
+		for _, problem := range config.Problems {
+			summary = append(summary, jobManager.displayName()+": "+problem)
+		}
+	}
+
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "The Synthetics job manager settings are valid",
+			Payload: payload,
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		URL:     configDocURL,
+		Payload: payload,
+	}
+}
+
+func validateConfig(jobManager JobManager, options tasks.Options) JobManagerConfig {
+	config := JobManagerConfig{
+		JobManager: jobManager.displayName(),
+		Region:     jobManager.region(),
+		Status:     tasks.Success,
+	}
+	problem := func(status tasks.Status, format string, args ...interface{}) {
+		if status > config.Status {
+			config.Status = status
+		}
+		config.Problems = append(config.Problems, fmt.Sprintf(format, args...))
+	}
+	env := jobManager.Env
+
+	key, keySet := env["PRIVATE_LOCATION_KEY"]
+	switch {
+	case keySet && strings.TrimSpace(key) == "":
+		problem(tasks.Failure, "PRIVATE_LOCATION_KEY is empty, the job manager cannot get jobs for its private location.")
+	case !jobManager.hasSetting("PRIVATE_LOCATION_KEY") && len(jobManager.EnvSources) > 0:
+		problem(tasks.Warning, "PRIVATE_LOCATION_KEY is not set on the container, make sure %s sets it.", strings.Join(jobManager.EnvSources, " or "))
+	case !jobManager.hasSetting("PRIVATE_LOCATION_KEY"):
+		problem(tasks.Failure, "PRIVATE_LOCATION_KEY is not set, the job manager cannot get jobs for its private location.")
+	}
+
+	for _, legacy := range legacyMinionSettings {
+		if _, ok := env[legacy.minion]; ok {
+			problem(tasks.Warning, "%s is a containerized private minion setting the job manager ignores, use %s.", legacy.minion, legacy.jobManager)
+		}
+	}
+
+	if endpoint, ok := env["HORDE_API_ENDPOINT"]; ok {
+		parsed, err := url.Parse(endpoint)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			problem(tasks.Failure, "HORDE_API_ENDPOINT %q is not an https URL.", endpoint)
+		} else if region := keyRegion(key); region != "" && region != config.Region {
+			problem(tasks.Failure, "PRIVATE_LOCATION_KEY belongs to the %s region but HORDE_API_ENDPOINT is %s.", region, endpoint)
+		}
+	}
+
+	host, port := env["HORDE_API_PROXY_HOST"], env["HORDE_API_PROXY_PORT"]
+	switch {
+	case host != "" && strings.Contains(host, "://"):
+		problem(tasks.Failure, "HORDE_API_PROXY_HOST %q must be a host name without a scheme.", host)
+	case host != "" && port == "":
+		problem(tasks.Failure, "HORDE_API_PROXY_HOST is set without HORDE_API_PROXY_PORT.")
+	case host == "" && port != "":
+		problem(tasks.Warning, "HORDE_API_PROXY_PORT is set without HORDE_API_PROXY_HOST, no proxy is used.")
+	}
+	if port != "" {
+		if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+			problem(tasks.Failure, "HORDE_API_PROXY_PORT %q is not a port number.", port)
+		} else if host != "" {
+			config.Proxy = host + ":" + port
+		}
+	}
+	if jobManager.hasSetting("HORDE_API_PROXY_USERNAME") != jobManager.hasSetting("HORDE_API_PROXY_PW") {
+		problem(tasks.Failure, "HORDE_API_PROXY_USERNAME and HORDE_API_PROXY_PW must be set together.")
+	}
+	if selfSigned, ok := env["HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT"]; ok {
+		if _, err := strconv.ParseBool(selfSigned); err != nil {
+			problem(tasks.Warning, "HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT %q is not true or false.", selfSigned)
+		}
+	}
+
+	passphrase, passphraseSet := env["VSE_PASSPHRASE"]
+	switch {
+	case passphraseSet && strings.TrimSpace(passphrase) == "":
+		problem(tasks.Failure, "VSE_PASSPHRASE is empty, verified script execution needs a passphrase to sign scripted monitors.")
+	case passphraseSet || jobManager.hasSetting("VSE_PASSPHRASE"):
+		config.VSEEnabled = true
+	}
+	return config
+}
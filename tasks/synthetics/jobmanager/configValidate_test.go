@@ -0,0 +1,101 @@
+package jobmanager
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/ConfigValidate", func() {
+	jobManager := func(env map[string]string) JobManager {
+		return JobManager{Platform: PlatformDocker, Name: "sjm", Env: env}
+	}
+
+	DescribeTable("validateConfig()",
+		func(jobManager JobManager, expectedStatus tasks.Status, expectedProblems []string) {
+			config := validateConfig(jobManager, tasks.Options{})
+			Expect(config.Status).To(Equal(expectedStatus))
+			Expect(config.Problems).To(Equal(expectedProblems))
+		},
+		Entry("valid settings", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY": "NRSP-us01key",
+			"HORDE_API_PROXY_HOST": "proxy.example.com",
+			"HORDE_API_PROXY_PORT": "3128",
+		}), tasks.Success, nil),
+		Entry("missing key", jobManager(map[string]string{}), tasks.Failure, []string{
+			"PRIVATE_LOCATION_KEY is not set, the job manager cannot get jobs for its private location.",
+		}),
+		Entry("key from a Secret", JobManager{Platform: PlatformKubernetes, Env: map[string]string{}, EnvFrom: []string{"PRIVATE_LOCATION_KEY"}}, tasks.Success, nil),
+		Entry("key maybe set with envFrom", JobManager{Platform: PlatformKubernetes, Env: map[string]string{}, EnvSources: []string{"Secret sjm"}}, tasks.Warning, []string{
+			"PRIVATE_LOCATION_KEY is not set on the container, make sure Secret sjm sets it.",
+		}),
+		Entry("containerized private minion settings", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY":        "NRSP-us01key",
+			"MINION_PRIVATE_LOCATION_KEY": "NRSP-us01key",
+		}), tasks.Warning, []string{
+			"MINION_PRIVATE_LOCATION_KEY is a containerized private minion setting the job manager ignores, use PRIVATE_LOCATION_KEY.",
+		}),
+		Entry("endpoint in another region than the key", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY": "NRSP-us01key",
+			"HORDE_API_ENDPOINT":   "https://synthetics-horde.eu01.nr-data.net",
+		}), tasks.Failure, []string{
+			"PRIVATE_LOCATION_KEY belongs to the us01 region but HORDE_API_ENDPOINT is https://synthetics-horde.eu01.nr-data.net.",
+		}),
+		Entry("endpoint that is not a URL", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY": "NRSP-us01key",
+			"HORDE_API_ENDPOINT":   "synthetics-horde.nr-data.net",
+		}), tasks.Failure, []string{
+			`HORDE_API_ENDPOINT "synthetics-horde.nr-data.net" is not an https URL.`,
+		}),
+		Entry("proxy settings", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY":                    "NRSP-us01key",
+			"HORDE_API_PROXY_HOST":                    "http://proxy",
+			"HORDE_API_PROXY_PORT":                    "proxy-port",
+			"HORDE_API_PROXY_USERNAME":                "user",
+			"HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT": "yes",
+		}), tasks.Failure, []string{
+			`HORDE_API_PROXY_HOST "http://proxy" must be a host name without a scheme.`,
+			`HORDE_API_PROXY_PORT "proxy-port" is not a port number.`,
+			"HORDE_API_PROXY_USERNAME and HORDE_API_PROXY_PW must be set together.",
+			`HORDE_API_PROXY_ACCEPT_SELF_SIGNED_CERT "yes" is not true or false.`,
+		}),
+		Entry("empty verified script execution passphrase", jobManager(map[string]string{
+			"PRIVATE_LOCATION_KEY": "NRSP-us01key",
+			"VSE_PASSPHRASE":       "",
+		}), tasks.Failure, []string{
+			"VSE_PASSPHRASE is empty, verified script execution needs a passphrase to sign scripted monitors.",
+		}),
+	)
+
+	Describe("Execute()", func() {
+		It("should report the region and verified script execution of each job manager", func() {
+			upstream := map[string]tasks.Result{
+				"Synthetics/JobManager/Detect": {
+					Status: tasks.Info,
+					Payload: []JobManager{jobManager(map[string]string{
+						"PRIVATE_LOCATION_KEY": "NRSP-eu01key",
+						"VSE_PASSPHRASE":       "passphrase",
+						"HORDE_API_PROXY_HOST": "proxy",
+						"HORDE_API_PROXY_PORT": "8080",
+					})},
+				},
+			}
+			result := SyntheticsJobManagerConfigValidate{}.Execute(tasks.Options{}, upstream)
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Payload).To(Equal([]JobManagerConfig{{
+				JobManager: "docker sjm",
+				Region:     tasks.RegionEU,
+				Proxy:      "proxy:8080",
+				VSEEnabled: true,
+				Status:     tasks.Success,
+			}}))
+		})
+
+		It("should not run without a job manager", func() {
+			result := SyntheticsJobManagerConfigValidate{}.Execute(tasks.Options{}, map[string]tasks.Result{
+				"Synthetics/JobManager/Detect": {Status: tasks.None},
+			})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+	})
+})
@@ -0,0 +1,340 @@
+package jobmanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+// SyntheticsJobManagerDetect - finds synthetics job managers on Docker, Podman and Kubernetes
+type SyntheticsJobManagerDetect struct {
+	cmdExec tasks.CmdExecFunc
+}
+
+// containerInspect holds the fields of docker and podman inspect output used here
+type containerInspect struct {
+	Id     string
+	Name   string
+	Config struct {
+		Env []string
+	}
+	State struct {
+		Status  string
+		Running bool
+	}
+	RestartCount int
+	HostConfig   struct {
+		Memory   int64
+		NanoCpus int64
+	}
+}
+
+type podList struct {
+	Items []pod
+}
+
+type pod struct {
+	Metadata struct {
+		Name      string
+		Namespace string
+	}
+	Spec struct {
+		Containers []podContainer
+	}
+	Status struct {
+		Phase             string
+		ContainerStatuses []struct {
+			Name         string
+			RestartCount int
+			State        struct {
+				Waiting *struct {
+					Reason string
+				}
+			}
+		}
+	}
+}
+
+type podContainer struct {
+	Name  string
+	Image string
+	Env   []struct {
+		Name      string
+		Value     string
+		ValueFrom json.RawMessage
+	}
+	EnvFrom []struct {
+		ConfigMapRef *struct{ Name string }
+		SecretRef    *struct{ Name string }
+	}
+	Resources struct {
+		Limits map[string]string
+	}
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerDetect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/Detect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerDetect) Explain() string {
+	return "Detect New Relic Synthetics job managers running on Docker, Podman or Kubernetes"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerDetect) Dependencies() []string {
+	return []string{}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerDetect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	var jobManagers []JobManager
+	seen := make(map[string]bool)
+	for _, cli := range []string{PlatformDocker, PlatformPodman} {
+		found, err := p.detectContainers(cli)
+		if err != nil {
+			// the CLI is not installed or its daemon is not running
+			log.Debugf("Unable to list %s containers: %s\n", cli, err)
+			continue
+		}
+		for _, jobManager := range found {
+			// podman-docker makes docker an alias of podman, so the same container can be listed twice
+			if seen[jobManager.ID] {
+				continue
+			}
+			seen[jobManager.ID] = true
+			jobManagers = append(jobManagers, jobManager)
+		}
+	}
+
+	found, err := p.detectPods(options.Options["k8sNamespace"])
+	if err != nil {
+		log.Debug("Unable to list Kubernetes pods:", err)
+	}
+	jobManagers = append(jobManagers, found...)
+
+	if len(jobManagers) == 0 {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics job manager found on Docker, Podman or Kubernetes",
+		}
+	}
+
+	summary := []string{fmt.Sprintf("Found %d Synthetics job manager(s):", len(jobManagers))}
+	for _, jobManager := range jobManagers {
+		summary = append(summary, fmt.Sprintf("%s (%s): %s", jobManager.displayName(), jobManager.Image, jobManager.Status))
+	}
+
+	stream := make(chan string)
+	jobManagersJSON, err := json.MarshalIndent(jobManagers, "", "    ")
+	if err != nil {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: "Unable to write the job manager details: " + err.Error(),
+		}
+	}
+	go tasks.StreamBlob(string(jobManagersJSON), stream)
+
+	return tasks.Result{
+		Status:      tasks.Info,
+		Summary:     strings.Join(summary, "\n"),
+		Payload:     jobManagers,
+		FilesToCopy: []tasks.FileCopyEnvelope{{Path: "synthetics-job-managers.json", Stream: stream, Identifier: p.Identifier().String()}},
+	}
+}
+
+// detectContainers finds job manager containers with the docker or podman CLI, which share the commands used here
+func (p SyntheticsJobManagerDetect) detectContainers(cli string) ([]JobManager, error) {
+	psOutput, err := p.cmdExec(cli, "ps", "-a", "--no-trunc", "--format", "{{.ID}}\t{{.Image}}")
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]string)
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(psOutput)), "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "\t", 2)
+		if len(fields) == 2 && strings.Contains(fields[1], jobManagerImage) {
+			ids = append(ids, fields[0])
+			images[fields[0]] = fields[1]
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	inspectOutput, err := p.cmdExec(cli, append([]string{"inspect"}, ids...)...)
+	if err != nil {
+		return nil, err
+	}
+	var containers []containerInspect
+	if err := json.Unmarshal(inspectOutput, &containers); err != nil {
+		return nil, err
+	}
+
+	runtimes := p.pulledRuntimes(cli)
+	hostCPUs, hostMemory := p.hostResources(cli)
+
+	var jobManagers []JobManager
+	for _, container := range containers {
+		env := make(map[string]string)
+		for _, variable := range container.Config.Env {
+			nameValue := strings.SplitN(variable, "=", 2)
+			if len(nameValue) == 2 {
+				env[nameValue[0]] = nameValue[1]
+			}
+		}
+		jobManagers = append(jobManagers, JobManager{
+			Platform:    cli,
+			ID:          container.Id,
+			Name:        strings.TrimPrefix(container.Name, "/"),
+			Image:       images[container.Id],
+			Status:      container.State.Status,
+			Running:     container.State.Running,
+			Restarts:    container.RestartCount,
+			Env:         env,
+			CPULimit:    float64(container.HostConfig.NanoCpus) / 1e9,
+			MemoryLimit: container.HostConfig.Memory,
+			HostCPUs:    hostCPUs,
+			HostMemory:  hostMemory,
+			Runtimes:    runtimes,
+		})
+	}
+	return jobManagers, nil
+}
+
+// pulledRuntimes returns the runtime images on the host, the job manager starts a container from them for each job
+func (p SyntheticsJobManagerDetect) pulledRuntimes(cli string) []Runtime {
+	output, err := p.cmdExec(cli, "images", "--format", "{{.Repository}}:{{.Tag}}")
+	if err != nil {
+		log.Debugf("Unable to list %s images: %s\n", cli, err)
+		return nil
+	}
+	var runtimes []Runtime
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		image := strings.TrimSpace(line)
+		if runtimeType := runtimeTypeOf(image); runtimeType != "" {
+			runtimes = append(runtimes, Runtime{Type: runtimeType, Image: image, Status: "pulled"})
+		}
+	}
+	return runtimes
+}
+
+// hostResources returns the CPUs and memory available to the container engine
+func (p SyntheticsJobManagerDetect) hostResources(cli string) (int, int64) {
+	format := "{{.NCPU}}\t{{.MemTotal}}"
+	if cli == PlatformPodman {
+		format = "{{.Host.CPUs}}\t{{.Host.MemTotal}}"
+	}
+	output, err := p.cmdExec(cli, "info", "--format", format)
+	if err != nil {
+		log.Debugf("Unable to get %s host resources: %s\n", cli, err)
+		return 0, 0
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0
+	}
+	cpus, _ := strconv.Atoi(fields[0])
+	memory, _ := strconv.ParseInt(fields[1], 10, 64)
+	return cpus, memory
+}
+
+// detectPods finds job manager pods, and the runtime pods in their namespace, in the namespace given or all namespaces
+func (p SyntheticsJobManagerDetect) detectPods(namespace string) ([]JobManager, error) {
+	args := []string{"get", "pods", "-o", "json"}
+	if namespace == "" {
+		args = append(args, "--all-namespaces")
+	} else {
+		args = append(args, "-n", namespace)
+	}
+	output, err := p.cmdExec(kubectlBin, args...)
+	if err != nil {
+		return nil, err
+	}
+	var pods podList
+	if err := json.Unmarshal(output, &pods); err != nil {
+		return nil, err
+	}
+
+	var jobManagers []JobManager
+	runtimesByNamespace := make(map[string][]Runtime)
+	for _, pod := range pods.Items {
+		status, restarts := podStatus(pod)
+		for _, container := range pod.Spec.Containers {
+			if strings.Contains(container.Image, jobManagerImage) {
+				jobManager := JobManager{
+					Platform:    PlatformKubernetes,
+					Name:        pod.Metadata.Name,
+					Namespace:   pod.Metadata.Namespace,
+					Image:       container.Image,
+					Status:      status,
+					Running:     status == "Running",
+					Restarts:    restarts,
+					Env:         make(map[string]string),
+					CPULimit:    parseCPU(container.Resources.Limits["cpu"]),
+					MemoryLimit: parseMemory(container.Resources.Limits["memory"]),
+				}
+				for _, variable := range container.Env {
+					if len(variable.ValueFrom) > 0 {
+						jobManager.EnvFrom = append(jobManager.EnvFrom, variable.Name)
+						continue
+					}
+					jobManager.Env[variable.Name] = variable.Value
+				}
+				for _, source := range container.EnvFrom {
+					if source.SecretRef != nil {
+						jobManager.EnvSources = append(jobManager.EnvSources, "Secret "+source.SecretRef.Name)
+					}
+					if source.ConfigMapRef != nil {
+						jobManager.EnvSources = append(jobManager.EnvSources, "ConfigMap "+source.ConfigMapRef.Name)
+					}
+				}
+				jobManagers = append(jobManagers, jobManager)
+				continue
+			}
+			if runtimeType := runtimeTypeOf(container.Image); runtimeType != "" {
+				runtimesByNamespace[pod.Metadata.Namespace] = append(runtimesByNamespace[pod.Metadata.Namespace], Runtime{
+					Type:        runtimeType,
+					Name:        pod.Metadata.Name,
+					Image:       container.Image,
+					Status:      status,
+					Running:     status == "Running",
+					Restarts:    restarts,
+					CPULimit:    parseCPU(container.Resources.Limits["cpu"]),
+					MemoryLimit: parseMemory(container.Resources.Limits["memory"]),
+				})
+			}
+		}
+	}
+	for i := range jobManagers {
+		jobManagers[i].Runtimes = runtimesByNamespace[jobManagers[i].Namespace]
+	}
+	return jobManagers, nil
+}
+
+// podStatus returns the reason a container is waiting, such as CrashLoopBackOff, or else the pod phase, and the pod restarts
+func podStatus(pod pod) (string, int) {
+	status := pod.Status.Phase
+	restarts := 0
+	for _, container := range pod.Status.ContainerStatuses {
+		restarts += container.RestartCount
+		if container.State.Waiting != nil && container.State.Waiting.Reason != "" {
+			status = container.State.Waiting.Reason
+		}
+	}
+	return status, restarts
+}
+
+func runtimeTypeOf(image string) string {
+	for _, runtimeType := range runtimeTypes {
+		if strings.Contains(image, runtimeImages[runtimeType]) {
+			return runtimeType
+		}
+	}
+	return ""
+}
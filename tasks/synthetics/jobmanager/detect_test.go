@@ -0,0 +1,182 @@
+package jobmanager
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSyntheticsJobManager(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Synthetics/JobManager/* test suite")
+}
+
+// fakeCommands returns the output set for each command line, and an error for any other
+func fakeCommands(outputs map[string]string) tasks.CmdExecFunc {
+	return func(name string, arg ...string) ([]byte, error) {
+		command := strings.Join(append([]string{name}, arg...), " ")
+		if output, ok := outputs[command]; ok {
+			return []byte(output), nil
+		}
+		return nil, errors.New("executable file not found in $PATH")
+	}
+}
+
+const dockerInspect = `[{
+	"Id": "abc123",
+	"Name": "/sjm",
+	"Config": {"Env": ["PRIVATE_LOCATION_KEY=NRSP-eu01secret", "HORDE_API_PROXY_HOST=proxy", "LOG_LEVEL=INFO"]},
+	"State": {"Status": "running", "Running": true},
+	"RestartCount": 2,
+	"HostConfig": {"Memory": 1073741824, "NanoCpus": 1500000000}
+}]`
+
+const kubernetesPods = `{"items": [
+	{
+		"metadata": {"name": "sjm-0", "namespace": "newrelic"},
+		"spec": {"containers": [{
+			"name": "synthetics-job-manager",
+			"image": "newrelic/synthetics-job-manager:release-400",
+			"env": [
+				{"name": "PRIVATE_LOCATION_KEY", "valueFrom": {"secretKeyRef": {"name": "sjm", "key": "privateLocationKey"}}},
+				{"name": "HORDE_API_ENDPOINT", "value": "https://synthetics-horde.nr-data.net"}
+			],
+			"envFrom": [{"configMapRef": {"name": "sjm-settings"}}],
+			"resources": {"limits": {"cpu": "750m", "memory": "1600Mi"}}
+		}]},
+		"status": {"phase": "Running", "containerStatuses": [{"name": "synthetics-job-manager", "restartCount": 0, "state": {"running": {}}}]}
+	},
+	{
+		"metadata": {"name": "sjm-ping-runtime-7d9", "namespace": "newrelic"},
+		"spec": {"containers": [{"name": "ping", "image": "docker.io/newrelic/synthetics-ping-runtime:1.50.0", "resources": {"limits": {"cpu": "0.75", "memory": "1Gi"}}}]},
+		"status": {"phase": "Running", "containerStatuses": [{"name": "ping", "restartCount": 3, "state": {"waiting": {"reason": "CrashLoopBackOff"}}}]}
+	},
+	{
+		"metadata": {"name": "nginx", "namespace": "default"},
+		"spec": {"containers": [{"name": "nginx", "image": "nginx"}]},
+		"status": {"phase": "Running"}
+	}
+]}`
+
+var _ = Describe("Synthetics/JobManager/Detect", func() {
+	Describe("Execute()", func() {
+		It("should return None when no job manager is found", func() {
+			p := SyntheticsJobManagerDetect{cmdExec: fakeCommands(map[string]string{
+				"docker ps -a --no-trunc --format {{.ID}}\t{{.Image}}": "ff00\tnginx\n",
+			})}
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.None))
+		})
+
+		It("should find Docker job managers, their pulled runtimes and the host resources", func() {
+			p := SyntheticsJobManagerDetect{cmdExec: fakeCommands(map[string]string{
+				"docker ps -a --no-trunc --format {{.ID}}\t{{.Image}}": "abc123\tnewrelic/synthetics-job-manager:latest\nff00\tnginx\n",
+				"docker inspect abc123":                                dockerInspect,
+				"docker images --format {{.Repository}}:{{.Tag}}":      "newrelic/synthetics-ping-runtime:latest\nnginx:latest\n",
+				"docker info --format {{.NCPU}}\t{{.MemTotal}}":        "4\t8589934592\n",
+			})}
+			result := p.Execute(tasks.Options{}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Info))
+			Expect(result.FilesToCopy).To(HaveLen(1))
+			go func() {
+				for range result.FilesToCopy[0].Stream {
+				}
+			}()
+
+			jobManagers := result.Payload.([]JobManager)
+			Expect(jobManagers).To(HaveLen(1))
+			Expect(jobManagers[0]).To(Equal(JobManager{
+				Platform:    PlatformDocker,
+				ID:          "abc123",
+				Name:        "sjm",
+				Image:       "newrelic/synthetics-job-manager:latest",
+				Status:      "running",
+				Running:     true,
+				Restarts:    2,
+				Env:         map[string]string{"PRIVATE_LOCATION_KEY": "NRSP-eu01secret", "HORDE_API_PROXY_HOST": "proxy", "LOG_LEVEL": "INFO"},
+				CPULimit:    1.5,
+				MemoryLimit: 1073741824,
+				HostCPUs:    4,
+				HostMemory:  8589934592,
+				Runtimes:    []Runtime{{Type: RuntimePing, Image: "newrelic/synthetics-ping-runtime:latest", Status: "pulled"}},
+			}))
+		})
+
+		It("should find Kubernetes job managers and the runtime pods in their namespace", func() {
+			p := SyntheticsJobManagerDetect{cmdExec: fakeCommands(map[string]string{
+				"kubectl get pods -o json -n newrelic": kubernetesPods,
+			})}
+			result := p.Execute(tasks.Options{Options: map[string]string{"k8sNamespace": "newrelic"}}, map[string]tasks.Result{})
+			Expect(result.Status).To(Equal(tasks.Info))
+			go func() {
+				for range result.FilesToCopy[0].Stream {
+				}
+			}()
+
+			jobManagers := result.Payload.([]JobManager)
+			Expect(jobManagers).To(HaveLen(1))
+			Expect(jobManagers[0]).To(Equal(JobManager{
+				Platform:    PlatformKubernetes,
+				Name:        "sjm-0",
+				Namespace:   "newrelic",
+				Image:       "newrelic/synthetics-job-manager:release-400",
+				Status:      "Running",
+				Running:     true,
+				Env:         map[string]string{"HORDE_API_ENDPOINT": "https://synthetics-horde.nr-data.net"},
+				EnvFrom:     []string{"PRIVATE_LOCATION_KEY"},
+				EnvSources:  []string{"ConfigMap sjm-settings"},
+				CPULimit:    0.75,
+				MemoryLimit: 1600 * mebibyte,
+				Runtimes: []Runtime{{
+					Type:        RuntimePing,
+					Name:        "sjm-ping-runtime-7d9",
+					Image:       "docker.io/newrelic/synthetics-ping-runtime:1.50.0",
+					Status:      "CrashLoopBackOff",
+					Restarts:    3,
+					CPULimit:    0.75,
+					MemoryLimit: 1024 * mebibyte,
+				}},
+			}))
+		})
+	})
+
+	Describe("MarshalJSON()", func() {
+		It("should redact secrets from the environment", func() {
+			jobManager := JobManager{Env: map[string]string{
+				"PRIVATE_LOCATION_KEY": "NRSP-us01secret",
+				"HORDE_API_PROXY_PW":   "hunter2",
+				"LOG_LEVEL":            "DEBUG",
+			}}
+			marshalled, err := json.Marshal(jobManager)
+			Expect(err).To(BeNil())
+			Expect(string(marshalled)).ToNot(ContainSubstring("secret"))
+			Expect(string(marshalled)).ToNot(ContainSubstring("hunter2"))
+			Expect(string(marshalled)).To(ContainSubstring(`"LOG_LEVEL":"DEBUG"`))
+			Expect(jobManager.Env["PRIVATE_LOCATION_KEY"]).To(Equal("NRSP-us01secret"))
+		})
+	})
+
+	DescribeTable("parseMemory()",
+		func(quantity string, expected int64) {
+			Expect(parseMemory(quantity)).To(Equal(expected))
+		},
+		Entry("mebibytes", "800Mi", int64(800*mebibyte)),
+		Entry("gigabytes", "2G", int64(2000000000)),
+		Entry("bytes", "1048576", int64(mebibyte)),
+		Entry("invalid", "lots", int64(0)),
+	)
+
+	DescribeTable("parseCPU()",
+		func(quantity string, expected float64) {
+			Expect(parseCPU(quantity)).To(Equal(expected))
+		},
+		Entry("millicores", "500m", 0.5),
+		Entry("cores", "1.5", 1.5),
+		Entry("unset", "", 0.0),
+	)
+})
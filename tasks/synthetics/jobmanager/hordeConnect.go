@@ -0,0 +1,166 @@
+package jobmanager
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const networkDocURL = "https://docs.newrelic.com/docs/new-relic-solutions/get-started/networks#synthetics-private"
+
+// hordeRequest is a request to the horde with the settings of a job manager
+type hordeRequest struct {
+	URL   string
+	Key   string
+	Proxy *url.URL
+	// KeyFromSecret is set when the key is read from a Kubernetes Secret, so only the connection can be checked
+	KeyFromSecret bool
+}
+
+// SyntheticsJobManagerHordeConnect - connects to the horde endpoint each job manager uses
+type SyntheticsJobManagerHordeConnect struct {
+	httpGet func(hordeRequest) (int, error)
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerHordeConnect) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/HordeConnect")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerHordeConnect) Explain() string {
+	return "Check network connection to the New Relic Synthetics horde endpoint of each Synthetics job manager's region"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerHordeConnect) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// RequiresNetwork - this task connects to the Synthetics horde, so it is skipped in offline mode
+func (p SyntheticsJobManagerHordeConnect) RequiresNetwork() bool {
+	return true
+}
+
+// Execute - requests the horde configuration with each job manager's endpoint, proxy and private location key
+func (p SyntheticsJobManagerHordeConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics job manager detected",
+		}
+	}
+	jobManagers, ok := upstream["Synthetics/JobManager/Detect"].Payload.([]JobManager)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	status := tasks.Success
+	summary := []string{"Connections were made from the host nrdiag ran on, which may reach the network differently than the job manager containers."}
+	for _, jobManager := range jobManagers {
+		request := newHordeRequest(jobManager, options)
+		resultStatus, message := p.checkConnection(request)
+		if resultStatus > status {
+			status = resultStatus
+		}
+		summary = append(summary, jobManager.displayName()+": "+message)
+	}
+
+	result := tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+	}
+	if status != tasks.Success {
+		result.URL = networkDocURL
+	}
+	return result
+}
+
+// newHordeRequest builds the request the job manager would make from its HORDE_API_* settings
+func newHordeRequest(jobManager JobManager, options tasks.Options) hordeRequest {
+	env := jobManager.Env
+	request := hordeRequest{Key: env["PRIVATE_LOCATION_KEY"]}
+	request.KeyFromSecret = request.Key == "" && jobManager.hasSetting("PRIVATE_LOCATION_KEY")
+
+	base := ""
+	if endpoint, err := url.Parse(env["HORDE_API_ENDPOINT"]); err == nil && endpoint.Scheme == "https" && endpoint.Host != "" {
+		base = strings.TrimSuffix(endpoint.String(), "/")
+	} else if horde, ok := tasks.GetEndpoint(jobManager.region(), tasks.ProductSynthetics); ok {
+		base = horde.URL()
+	}
+	request.URL = base + "/api/v1.0/config"
+
+	if host, port := env["HORDE_API_PROXY_HOST"], env["HORDE_API_PROXY_PORT"]; host != "" && port != "" {
+		request.Proxy = &url.URL{Scheme: "http", Host: net.JoinHostPort(host, port)}
+		if username := env["HORDE_API_PROXY_USERNAME"]; username != "" {
+			request.Proxy.User = url.UserPassword(username, env["HORDE_API_PROXY_PW"])
+		}
+	}
+	return request
+}
+
+func (p SyntheticsJobManagerHordeConnect) checkConnection(request hordeRequest) (tasks.Status, string) {
+	via := ""
+	if request.Proxy != nil {
+		via = " through proxy " + request.Proxy.Host
+	}
+	code, err := p.httpGet(request)
+	switch {
+	case err != nil:
+		return tasks.Failure, fmt.Sprintf("Unable to connect to %s%s: %s", request.URL, via, err)
+	case code == http.StatusOK:
+		return tasks.Success, fmt.Sprintf("Connected to %s%s", request.URL, via)
+	case (code == http.StatusUnauthorized || code == http.StatusForbidden) && request.KeyFromSecret:
+		return tasks.Success, fmt.Sprintf("Connected to %s%s, the private location key was not checked as it is set from a Secret", request.URL, via)
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return tasks.Failure, fmt.Sprintf("%s%s rejected the private location key with %d, check PRIVATE_LOCATION_KEY matches the key of the private location and its region", request.URL, via, code)
+	default:
+		return tasks.Warning, fmt.Sprintf("%s%s returned %d, expected 200", request.URL, via, code)
+	}
+}
+
+// hordeGet requests the horde, through the job manager proxy when it has one or else the proxy nrdiag is configured with
+func hordeGet(request hordeRequest) (int, error) {
+	headers := map[string]string{"X-API-Key": request.Key}
+	log.Debug("Attempting connection to:", request.URL)
+	if request.Proxy == nil {
+		resp, err := httpHelper.MakeHTTPRequest(httpHelper.RequestWrapper{
+			Method:  "GET",
+			URL:     request.URL,
+			Headers: headers,
+		})
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	req, err := http.NewRequest("GET", request.URL, nil)
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{Proxy: http.ProxyURL(request.Proxy)},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,70 @@
+package jobmanager
+
+import (
+	"errors"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/HordeConnect", func() {
+	Describe("newHordeRequest()", func() {
+		It("should use the region of the private location key and the job manager proxy", func() {
+			request := newHordeRequest(JobManager{Env: map[string]string{
+				"PRIVATE_LOCATION_KEY":     "NRSP-eu01key",
+				"HORDE_API_PROXY_HOST":     "proxy",
+				"HORDE_API_PROXY_PORT":     "3128",
+				"HORDE_API_PROXY_USERNAME": "user",
+				"HORDE_API_PROXY_PW":       "pw",
+			}}, tasks.Options{})
+			Expect(request.URL).To(Equal("https://synthetics-horde.eu01.nr-data.net/api/v1.0/config"))
+			Expect(request.Key).To(Equal("NRSP-eu01key"))
+			Expect(request.Proxy.String()).To(Equal("http://user:pw@proxy:3128"))
+		})
+
+		It("should prefer HORDE_API_ENDPOINT", func() {
+			request := newHordeRequest(JobManager{Env: map[string]string{
+				"HORDE_API_ENDPOINT": "https://horde.example.com/",
+			}}, tasks.Options{})
+			Expect(request.URL).To(Equal("https://horde.example.com/api/v1.0/config"))
+			Expect(request.Proxy).To(BeNil())
+		})
+	})
+
+	Describe("Execute()", func() {
+		upstream := func(jobManagers ...JobManager) map[string]tasks.Result {
+			return map[string]tasks.Result{
+				"Synthetics/JobManager/Detect": {Status: tasks.Info, Payload: jobManagers},
+			}
+		}
+
+		It("should succeed when the horde accepts the key", func() {
+			p := SyntheticsJobManagerHordeConnect{httpGet: func(hordeRequest) (int, error) { return 200, nil }}
+			result := p.Execute(tasks.Options{}, upstream(JobManager{Platform: PlatformDocker, Name: "sjm", Env: map[string]string{"PRIVATE_LOCATION_KEY": "key"}}))
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(ContainSubstring("docker sjm: Connected to https://synthetics-horde.nr-data.net/api/v1.0/config"))
+		})
+
+		It("should fail when the horde rejects the key", func() {
+			p := SyntheticsJobManagerHordeConnect{httpGet: func(hordeRequest) (int, error) { return 403, nil }}
+			result := p.Execute(tasks.Options{}, upstream(JobManager{Platform: PlatformDocker, Name: "sjm", Env: map[string]string{"PRIVATE_LOCATION_KEY": "key"}}))
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("rejected the private location key with 403"))
+		})
+
+		It("should only check the connection when the key is in a Secret", func() {
+			p := SyntheticsJobManagerHordeConnect{httpGet: func(hordeRequest) (int, error) { return 401, nil }}
+			result := p.Execute(tasks.Options{}, upstream(JobManager{Platform: PlatformKubernetes, Name: "sjm-0", Namespace: "newrelic", EnvFrom: []string{"PRIVATE_LOCATION_KEY"}}))
+			Expect(result.Status).To(Equal(tasks.Success))
+			Expect(result.Summary).To(ContainSubstring("the private location key was not checked"))
+		})
+
+		It("should fail when the horde cannot be reached", func() {
+			p := SyntheticsJobManagerHordeConnect{httpGet: func(hordeRequest) (int, error) { return 0, errors.New("connection refused") }}
+			result := p.Execute(tasks.Options{}, upstream(JobManager{Platform: PlatformDocker, Name: "sjm", Env: map[string]string{"HORDE_API_PROXY_HOST": "proxy", "HORDE_API_PROXY_PORT": "3128"}}))
+			Expect(result.Status).To(Equal(tasks.Failure))
+			Expect(result.Summary).To(ContainSubstring("Unable to connect to https://synthetics-horde.nr-data.net/api/v1.0/config through proxy proxy:3128: connection refused"))
+		})
+	})
+})
@@ -0,0 +1,246 @@
+package jobmanager
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const kubectlBin = "kubectl"
+
+// Platforms the job manager is detected on
+const (
+	PlatformDocker     = "docker"
+	PlatformPodman     = "podman"
+	PlatformKubernetes = "kubernetes"
+)
+
+// Runtime types, named as in the DESIRED_RUNTIMES setting
+const (
+	RuntimePing        = "ping"
+	RuntimeNodeAPI     = "node-api"
+	RuntimeNodeBrowser = "node-browser"
+)
+
+// jobManagerImage is the image name of the job manager, any registry or tag
+const jobManagerImage = "synthetics-job-manager"
+
+// runtimeImages maps each runtime type to its image name
+var runtimeImages = map[string]string{
+	RuntimePing:        "synthetics-ping-runtime",
+	RuntimeNodeAPI:     "synthetics-node-api-runtime",
+	RuntimeNodeBrowser: "synthetics-node-browser-runtime",
+}
+
+var runtimeTypes = []string{RuntimePing, RuntimeNodeAPI, RuntimeNodeBrowser}
+
+// resources is the CPU, in cores, and memory, in bytes, of a container
+type resources struct {
+	cpu    float64
+	memory int64
+}
+
+const mebibyte = 1024 * 1024
+
+// minimumResources are the requests the job manager Helm chart sets by default. A limit below these starves the container.
+var minimumResources = map[string]resources{
+	jobManagerImage:    {cpu: 0.5, memory: 800 * mebibyte},
+	RuntimePing:        {cpu: 0.5, memory: 800 * mebibyte},
+	RuntimeNodeAPI:     {cpu: 0.5, memory: 1250 * mebibyte},
+	RuntimeNodeBrowser: {cpu: 1, memory: 2000 * mebibyte},
+}
+
+// sensitiveEnv are the job manager settings whose values are never written to the output
+var sensitiveEnv = map[string]bool{
+	"PRIVATE_LOCATION_KEY":   true,
+	"VSE_PASSPHRASE":         true,
+	"HORDE_API_PROXY_PW":     true,
+	"USER_DEFINED_VARIABLES": true,
+}
+
+var sensitiveNameRgx = regexp.MustCompile(`(?i)KEY|PASS|SECRET|TOKEN|_PW$`)
+
+// JobManager is a synthetics job manager container or pod
+type JobManager struct {
+	Platform    string
+	ID          string
+	Name        string
+	Namespace   string
+	Image       string
+	Status      string
+	Running     bool
+	Restarts    int
+	Env         map[string]string
+	EnvFrom     []string // Kubernetes env vars set from a Secret or ConfigMap, their values are not read
+	EnvSources  []string // Kubernetes Secrets and ConfigMaps loaded whole with envFrom
+	CPULimit    float64
+	MemoryLimit int64
+	HostCPUs    int   // Docker and Podman only
+	HostMemory  int64 // Docker and Podman only
+	Runtimes    []Runtime
+}
+
+// Runtime is a runtime image pulled on a Docker or Podman host, or a runtime pod on Kubernetes
+type Runtime struct {
+	Type        string
+	Name        string
+	Image       string
+	Status      string
+	Running     bool
+	Restarts    int
+	CPULimit    float64
+	MemoryLimit int64
+}
+
+// MarshalJSON - redacts secrets from the job manager environment
+func (j JobManager) MarshalJSON() ([]byte, error) {
+	type jobManager JobManager
+	redacted := jobManager(j)
+	redacted.Env = redactEnv(j.Env)
+	return json.Marshal(redacted)
+}
+
+func redactEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(env))
+	for name, value := range env {
+		if sensitiveEnv[strings.ToUpper(name)] || sensitiveNameRgx.MatchString(name) {
+			value = "_REDACTED_"
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// displayName is how a job manager is named in summaries
+func (j JobManager) displayName() string {
+	name := j.Name
+	if name == "" {
+		name = j.ID
+	}
+	if j.Namespace != "" {
+		name = j.Namespace + "/" + name
+	}
+	return j.Platform + " " + name
+}
+
+// hasSetting reports whether a setting is present, including Kubernetes settings read from a Secret or ConfigMap
+func (j JobManager) hasSetting(name string) bool {
+	if _, ok := j.Env[name]; ok {
+		return true
+	}
+	for _, ref := range j.EnvFrom {
+		if ref == name {
+			return true
+		}
+	}
+	return false
+}
+
+var desiredRuntimeRgx = regexp.MustCompile(`[a-z][a-z-]*[a-z]`)
+
+// desiredRuntimes returns the runtime types the job manager runs, DESIRED_RUNTIMES limits them on Docker and Podman
+func (j JobManager) desiredRuntimes() []string {
+	setting := strings.ToLower(j.Env["DESIRED_RUNTIMES"])
+	if setting == "" {
+		return runtimeTypes
+	}
+	var desired []string
+	for _, name := range desiredRuntimeRgx.FindAllString(setting, -1) {
+		if _, ok := runtimeImages[name]; ok {
+			desired = append(desired, name)
+		}
+	}
+	if len(desired) == 0 {
+		return runtimeTypes
+	}
+	return desired
+}
+
+var keyRegionRgx = regexp.MustCompile(`(?i)^NRSP-([a-z]{2}[0-9]{2})`)
+
+// keyRegion returns the region a private location key belongs to, empty when the key does not say
+func keyRegion(key string) string {
+	if match := keyRegionRgx.FindStringSubmatch(key); match != nil {
+		return tasks.NormalizeRegion(match[1])
+	}
+	return ""
+}
+
+// region returns the region the job manager connects to: HORDE_API_ENDPOINT, then the location key, then -region or NEW_RELIC_REGION
+func (j JobManager) region() string {
+	if endpoint := j.Env["HORDE_API_ENDPOINT"]; endpoint != "" {
+		for _, region := range []string{tasks.RegionEU, tasks.RegionUS} {
+			if horde, ok := tasks.GetEndpoint(region, tasks.ProductSynthetics); ok && strings.Contains(endpoint, horde.Host) {
+				return region
+			}
+		}
+	}
+	if region := keyRegion(j.Env["PRIVATE_LOCATION_KEY"]); region != "" {
+		return region
+	}
+	if region := tasks.RequestedRegion(); region != "" {
+		return region
+	}
+	return tasks.RegionUS
+}
+
+// parseCPU parses a Kubernetes CPU quantity such as 500m or 1.5 into cores
+func parseCPU(quantity string) float64 {
+	quantity = strings.TrimSpace(quantity)
+	if strings.HasSuffix(quantity, "m") {
+		milli, err := strconv.ParseFloat(strings.TrimSuffix(quantity, "m"), 64)
+		if err != nil {
+			return 0
+		}
+		return milli / 1000
+	}
+	cores, err := strconv.ParseFloat(quantity, 64)
+	if err != nil {
+		return 0
+	}
+	return cores
+}
+
+var memorySuffixes = []struct {
+	suffix     string
+	multiplier float64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"k", 1e3}, {"M", 1e6}, {"G", 1e9}, {"T", 1e12},
+}
+
+// parseMemory parses a Kubernetes memory quantity such as 800Mi or 2G into bytes
+func parseMemory(quantity string) int64 {
+	quantity = strings.TrimSpace(quantity)
+	multiplier := 1.0
+	for _, s := range memorySuffixes {
+		if strings.HasSuffix(quantity, s.suffix) {
+			quantity = strings.TrimSuffix(quantity, s.suffix)
+			multiplier = s.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(quantity, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * multiplier)
+}
+
+// RegisterWith - will register any plugins in this package
+func RegisterWith(registrationFunc func(tasks.Task, bool)) {
+	log.Debug("Registering Synthetics/JobManager/*")
+
+	registrationFunc(SyntheticsJobManagerDetect{cmdExec: tasks.CmdExecutor}, true)
+	registrationFunc(SyntheticsJobManagerConfigValidate{}, true)
+	registrationFunc(SyntheticsJobManagerRuntimes{}, true)
+	registrationFunc(SyntheticsJobManagerCollectLogs{executeCommand: tasks.BufferedCommandExec}, true)
+	registrationFunc(SyntheticsJobManagerHordeConnect{httpGet: hordeGet}, true)
+}
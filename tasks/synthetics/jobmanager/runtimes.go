@@ -0,0 +1,150 @@
+package jobmanager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const requirementsDocURL = "https://docs.newrelic.com/docs/synthetics/synthetic-monitoring/private-locations/install-job-manager#system-requirements"
+
+// SyntheticsJobManagerRuntimes - checks the runtimes each job manager starts jobs in and the resources they have
+type SyntheticsJobManagerRuntimes struct {
+}
+
+// Identifier - This returns the Category, Subcategory and Name of each task
+func (p SyntheticsJobManagerRuntimes) Identifier() tasks.Identifier {
+	return tasks.IdentifierFromString("Synthetics/JobManager/Runtimes")
+}
+
+// Explain - Returns the help text for each individual task
+func (p SyntheticsJobManagerRuntimes) Explain() string {
+	return "Check the runtime images of Synthetics job managers and the resources available to them"
+}
+
+// Dependencies - Returns the dependencies for each task.
+func (p SyntheticsJobManagerRuntimes) Dependencies() []string {
+	return []string{"Synthetics/JobManager/Detect"}
+}
+
+// Execute - The core work within each task
+func (p SyntheticsJobManagerRuntimes) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
+	if upstream["Synthetics/JobManager/Detect"].Status != tasks.Info {
+		return tasks.Result{
+			Status:  tasks.None,
+			Summary: "No Synthetics job manager detected",
+		}
+	}
+	jobManagers, ok := upstream["Synthetics/JobManager/Detect"].Payload.([]JobManager)
+	if !ok {
+		return tasks.Result{
+			Status:  tasks.Error,
+			Summary: tasks.AssertionErrorSummary,
+		}
+	}
+
+	status := tasks.Success
+	var summary []string
+	for _, jobManager := range jobManagers {
+		checkStatus, problems := checkRuntimes(jobManager)
+		if checkStatus > status {
+			status = checkStatus
+		}
+		for _, problem := range problems {
+			summary = append(summary, jobManager.displayName()+": "+problem)
+		}
+	}
+
+	if status == tasks.Success {
+		return tasks.Result{
+			Status:  tasks.Success,
+			Summary: "The Synthetics job manager runtimes are available with enough resources",
+		}
+	}
+	return tasks.Result{
+		Status:  status,
+		Summary: strings.Join(summary, "\n"),
+		URL:     requirementsDocURL,
+	}
+}
+
+func checkRuntimes(jobManager JobManager) (tasks.Status, []string) {
+	status := tasks.Success
+	var problems []string
+	problem := func(problemStatus tasks.Status, format string, args ...interface{}) {
+		if problemStatus > status {
+			status = problemStatus
+		}
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if !jobManager.Running {
+		problem(tasks.Failure, "The job manager is %s, it is not running jobs.", jobManager.Status)
+	}
+	for _, limit := range limitProblems("The job manager", jobManagerImage, jobManager.CPULimit, jobManager.MemoryLimit) {
+		problem(tasks.Warning, "%s", limit)
+	}
+
+	if jobManager.Platform == PlatformKubernetes {
+		for _, runtime := range jobManager.Runtimes {
+			// node runtimes run as a Kubernetes job for each monitor run, so completed pods are expected
+			if !runtime.Running && runtime.Status != "Succeeded" && runtime.Status != "Pending" {
+				problem(tasks.Warning, "The %s runtime pod %s is %s.", runtime.Type, runtime.Name, runtime.Status)
+			}
+			for _, limit := range limitProblems("The "+runtime.Type+" runtime pod "+runtime.Name, runtime.Type, runtime.CPULimit, runtime.MemoryLimit) {
+				problem(tasks.Warning, "%s", limit)
+			}
+		}
+		if !hasRuntime(jobManager.Runtimes, RuntimePing) {
+			problem(tasks.Warning, "No ping runtime pod found in namespace %s, ping monitors will not run.", jobManager.Namespace)
+		}
+		return status, problems
+	}
+
+	required := minimumResources[jobManagerImage]
+	var largest resources
+	for _, runtimeType := range jobManager.desiredRuntimes() {
+		if !hasRuntime(jobManager.Runtimes, runtimeType) {
+			problem(tasks.Warning, "The %s runtime image %s is not pulled. The job manager pulls it before the first %s job, so the host must be able to reach the image registry.", runtimeType, runtimeImages[runtimeType], runtimeType)
+		}
+		minimum := minimumResources[runtimeType]
+		if minimum.cpu > largest.cpu {
+			largest.cpu = minimum.cpu
+		}
+		if minimum.memory > largest.memory {
+			largest.memory = minimum.memory
+		}
+	}
+	required.cpu += largest.cpu
+	required.memory += largest.memory
+	if jobManager.HostCPUs > 0 && float64(jobManager.HostCPUs) < required.cpu {
+		problem(tasks.Warning, "The %s host has %d CPUs, the job manager and its runtimes need at least %.1f.", jobManager.Platform, jobManager.HostCPUs, required.cpu)
+	}
+	if jobManager.HostMemory > 0 && jobManager.HostMemory < required.memory {
+		problem(tasks.Warning, "The %s host has %d MiB of memory, the job manager and its runtimes need at least %d MiB.", jobManager.Platform, jobManager.HostMemory/mebibyte, required.memory/mebibyte)
+	}
+	return status, problems
+}
+
+// limitProblems reports CPU and memory limits below what the container requests by default, no limit is not a problem
+func limitProblems(name string, container string, cpuLimit float64, memoryLimit int64) []string {
+	minimum := minimumResources[container]
+	var problems []string
+	if cpuLimit > 0 && cpuLimit < minimum.cpu {
+		problems = append(problems, fmt.Sprintf("%s CPU limit of %.2f cores is below the %.2f it needs.", name, cpuLimit, minimum.cpu))
+	}
+	if memoryLimit > 0 && memoryLimit < minimum.memory {
+		problems = append(problems, fmt.Sprintf("%s memory limit of %d MiB is below the %d MiB it needs.", name, memoryLimit/mebibyte, minimum.memory/mebibyte))
+	}
+	return problems
+}
+
+func hasRuntime(runtimes []Runtime, runtimeType string) bool {
+	for _, runtime := range runtimes {
+		if runtime.Type == runtimeType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package jobmanager
+
+import (
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Synthetics/JobManager/Runtimes", func() {
+	allRuntimes := []Runtime{
+		{Type: RuntimePing, Image: "newrelic/synthetics-ping-runtime:latest"},
+		{Type: RuntimeNodeAPI, Image: "newrelic/synthetics-node-api-runtime:latest"},
+		{Type: RuntimeNodeBrowser, Image: "newrelic/synthetics-node-browser-runtime:latest"},
+	}
+
+	DescribeTable("checkRuntimes()",
+		func(jobManager JobManager, expectedStatus tasks.Status, expectedProblems []string) {
+			status, problems := checkRuntimes(jobManager)
+			Expect(status).To(Equal(expectedStatus))
+			Expect(problems).To(Equal(expectedProblems))
+		},
+		Entry("docker host with every runtime", JobManager{
+			Platform: PlatformDocker, Running: true, HostCPUs: 4, HostMemory: 8192 * mebibyte, Runtimes: allRuntimes,
+		}, tasks.Success, nil),
+		Entry("docker host missing a runtime it runs", JobManager{
+			Platform: PlatformDocker, Running: true, Env: map[string]string{"DESIRED_RUNTIMES": `["ping","node-api"]`}, Runtimes: allRuntimes[:1],
+		}, tasks.Warning, []string{
+			"The node-api runtime image synthetics-node-api-runtime is not pulled. The job manager pulls it before the first node-api job, so the host must be able to reach the image registry.",
+		}),
+		Entry("small docker host", JobManager{
+			Platform: PlatformDocker, Running: true, HostCPUs: 1, HostMemory: 2048 * mebibyte, Runtimes: allRuntimes,
+		}, tasks.Warning, []string{
+			"The docker host has 1 CPUs, the job manager and its runtimes need at least 1.5.",
+			"The docker host has 2048 MiB of memory, the job manager and its runtimes need at least 2800 MiB.",
+		}),
+		Entry("stopped job manager with a low memory limit", JobManager{
+			Platform: PlatformPodman, Status: "exited", MemoryLimit: 512 * mebibyte, Runtimes: allRuntimes,
+		}, tasks.Failure, []string{
+			"The job manager is exited, it is not running jobs.",
+			"The job manager memory limit of 512 MiB is below the 800 MiB it needs.",
+		}),
+		Entry("kubernetes runtime pods", JobManager{
+			Platform: PlatformKubernetes, Namespace: "newrelic", Running: true, Runtimes: []Runtime{
+				{Type: RuntimePing, Name: "ping-0", Status: "CrashLoopBackOff", CPULimit: 0.25},
+				{Type: RuntimeNodeBrowser, Name: "browser-job", Status: "Succeeded"},
+			},
+		}, tasks.Warning, []string{
+			"The ping runtime pod ping-0 is CrashLoopBackOff.",
+			"The ping runtime pod ping-0 CPU limit of 0.25 cores is below the 0.50 it needs.",
+		}),
+		Entry("kubernetes without a ping runtime", JobManager{
+			Platform: PlatformKubernetes, Namespace: "newrelic", Running: true,
+		}, tasks.Warning, []string{
+			"No ping runtime pod found in namespace newrelic, ping monitors will not run.",
+		}),
+	)
+})
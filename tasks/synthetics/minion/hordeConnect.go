@@ -38,6 +38,11 @@ func (p SyntheticsMinionHordeConnect) Dependencies() []string {
 	}
 }
 
+// RequiresNetwork - this task connects to the Synthetics horde, so it is skipped in offline mode
+func (p SyntheticsMinionHordeConnect) RequiresNetwork() bool {
+	return true
+}
+
 // Execute - Uses parsed private location settings key to perform a simple HTTP request to horde
 func (p SyntheticsMinionHordeConnect) Execute(options tasks.Options, upstream map[string]tasks.Result) tasks.Result {
 	var result tasks.Result
@@ -100,9 +105,10 @@ func hordeRequest(privateLocationKey string) (HTTPResponse, error) {
 
 	log.Debug("Attempting connection to: synthetics-horde.nr-data.net (HTTPS) using location key: " + privateLocationKey)
 
+	horde, _ := tasks.GetEndpoint(tasks.RegionUS, tasks.ProductSynthetics)
 	wrapper := httpHelper.RequestWrapper{
 		Method:  "GET",
-		URL:     "https://synthetics-horde.nr-data.net/api/v1.0/config",
+		URL:     horde.URL() + "/api/v1.0/config",
 		Headers: headers,
 	}
 
@@ -124,6 +124,9 @@ func ReadFile(file string) string {
 	return string(content)
 }
 
+// FileOwnerFunc - allows FileOwner to be dependency injected
+type FileOwnerFunc func(os.FileInfo) (uint32, uint32, bool)
+
 // FileExistsFunc - allows FileExists to be dependency injected
 type FileExistsFunc func(string) bool
 
@@ -617,7 +620,7 @@ var defaultEnvVarFilter = []string{
 	"^COR_ENABLE_PROFILER$",
 	"^CORECLR_ENABLE_PROFILING$",
 	"^CORECLR_PROFILER$",
-	"^CORECLR_PROFILER_PATH$",
+	"^CORECLR_PROFILER_PATH(_64|_32)?$",
 	"^ProgramFiles$",
 	"^ProgramData$",
 	"^APPDATA$",
@@ -626,6 +629,13 @@ var defaultEnvVarFilter = []string{
 	"^KAFKA_HOME$",
 	"^ZOOKEEPER_HOME$",
 	"^JAVA_HOME$",
+	"^NODE_OPTIONS$",
+	"^NODE_EXTRA_CA_CERTS$",
+	"^SSL_CERT_FILE$",
+	"^SSL_CERT_DIR$",
+	"^REQUESTS_CA_BUNDLE$",
+	"^NO_PROXY$",
+	"^no_proxy$",
 }
 
 // GetDefaultFilterRegex - returns the default filter string array with regex included
@@ -718,16 +728,7 @@ func GetProcessEnvVars(pid int32) (envVars EnvironmentVariables, retErr error) {
 			retErr = errors.New(errorString)
 			return
 		}
-		envVarString := string(environFile)
-		lines := strings.Split(envVarString, "\x00")
-		for _, line := range lines {
-			split := strings.Split(line, "=")
-			if len(split) > 1 {
-				name := split[0]
-				val := split[1]
-				envVars.All[name] = val
-			}
-		}
+		envVars.All = parseEnviron(environFile)
 	default:
 		errorString := "GetProcessEnvVars is not implemented for " + runtime.GOOS
 		log.Debug(errorString)
@@ -738,6 +739,18 @@ func GetProcessEnvVars(pid int32) (envVars EnvironmentVariables, retErr error) {
 	return
 }
 
+// parseEnviron - parses the NUL separated NAME=value entries of /proc/<pid>/environ.
+// Only the first = separates the name, values such as JAVA_TOOL_OPTIONS=-Dkey=value keep theirs.
+func parseEnviron(environ []byte) map[string]string {
+	envVars := make(map[string]string)
+	for _, line := range strings.Split(string(environ), "\x00") {
+		if name, val, ok := strings.Cut(line, "="); ok {
+			envVars[name] = val
+		}
+	}
+	return envVars
+}
+
 // GetShellEnvVars - gathers a given process's Env Vars
 func GetShellEnvVars() (envVars EnvironmentVariables, retErr error) {
 	envVars.All = make(map[string]string)
@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+)
+
+// Sources of AgentArtifact evidence
+const (
+	ArtifactMapped = "mapped"
+	ArtifactOpen   = "open"
+)
+
+// AgentArtifact is a New Relic agent file a process has mapped into memory or holds open
+type AgentArtifact struct {
+	Agent  string
+	Path   string
+	Source string
+}
+
+// agentArtifactMatchers identify the agent a file belongs to from its path
+var agentArtifactMatchers = []struct {
+	agent   string
+	matches func(path string) bool
+}{
+	{"Java", func(path string) bool {
+		base := filepath.Base(path)
+		return base == "newrelic.jar" || (strings.HasPrefix(base, "newrelic-agent") && strings.HasSuffix(base, ".jar"))
+	}},
+	{"PHP", func(path string) bool { return filepath.Base(path) == "newrelic.so" }},
+	{".NET", func(path string) bool { return filepath.Base(path) == "libNewRelicProfiler.so" }},
+	// Node requires .js and Python imports .py files by reading and closing them, only their native extensions stay mapped
+	{"Node", func(path string) bool {
+		return filepath.Ext(path) == ".node" && (strings.Contains(path, "/node_modules/newrelic/") || strings.Contains(path, "/node_modules/@newrelic/"))
+	}},
+	{"Python", func(path string) bool {
+		return strings.Contains(path, "/newrelic/") && filepath.Ext(path) == ".so"
+	}},
+}
+
+// MappedFiles returns the files listed in the content of a /proc/<pid>/maps file, in the order they first appear
+func MappedFiles(maps string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(maps, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !strings.HasPrefix(fields[5], "/") {
+			continue
+		}
+		file := strings.TrimSuffix(strings.Join(fields[5:], " "), " (deleted)")
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// AgentForPath returns the New Relic agent a file belongs to, or an empty string if it is not an agent file
+func AgentForPath(path string) string {
+	for _, matcher := range agentArtifactMatchers {
+		if matcher.matches(path) {
+			return matcher.agent
+		}
+	}
+	return ""
+}
+
+// FindLoadedAgentArtifacts - confirms which New Relic agent files a process has actually loaded from its memory mappings and open file descriptors
+func FindLoadedAgentArtifacts(pid int32) ([]AgentArtifact, error) {
+	if runtime.GOOS != "linux" {
+		errorString := "FindLoadedAgentArtifacts is not implemented for " + runtime.GOOS
+		log.Debug(errorString)
+		return nil, errors.New(errorString)
+	}
+	return findLoadedAgentArtifacts("/proc", pid)
+}
+
+// findLoadedAgentArtifacts reads the maps file and fd directory of a process under procRoot. An error is only returned
+// when neither could be read, usually because the process belongs to another user.
+func findLoadedAgentArtifacts(procRoot string, pid int32) ([]AgentArtifact, error) {
+	pidDir := filepath.Join(procRoot, strconv.Itoa(int(pid)))
+	var artifacts []AgentArtifact
+	seen := make(map[string]bool)
+	add := func(path string, source string) {
+		agent := AgentForPath(path)
+		if agent == "" || seen[path] {
+			return
+		}
+		seen[path] = true
+		artifacts = append(artifacts, AgentArtifact{Agent: agent, Path: path, Source: source})
+	}
+
+	maps, mapsErr := os.ReadFile(filepath.Join(pidDir, "maps"))
+	if mapsErr == nil {
+		for _, file := range MappedFiles(string(maps)) {
+			add(file, ArtifactMapped)
+		}
+	}
+
+	fdDir := filepath.Join(pidDir, "fd")
+	fds, fdErr := os.ReadDir(fdDir)
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+		// sockets, pipes and anonymous inodes are not files
+		if err != nil || !strings.HasPrefix(target, "/") {
+			continue
+		}
+		add(strings.TrimSuffix(target, " (deleted)"), ArtifactOpen)
+	}
+
+	if mapsErr != nil && fdErr != nil {
+		log.Debug("Unable to inspect process", pid, mapsErr, fdErr)
+		return nil, mapsErr
+	}
+	return artifacts, nil
+}
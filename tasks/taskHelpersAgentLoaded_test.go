@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMappedFiles(t *testing.T) {
+	maps := `00400000-00401000 r-xp 00000000 08:01 1311 /usr/bin/java
+7f3a1c000000-7f3a1c021000 rw-p 00000000 00:00 0
+7f3a1d200000-7f3a1d4f2000 r--s 00000000 08:01 2040 /opt/newrelic/newrelic.jar
+7f3a1d4f2000-7f3a1d500000 r--s 000f2000 08:01 2040 /opt/newrelic/newrelic.jar
+7f3a1f000000-7f3a1f001000 r-xp 00000000 08:01 9 /tmp/my lib.so (deleted)
+7ffd5a1f0000-7ffd5a211000 rw-p 00000000 00:00 0 [stack]
+`
+	want := []string{"/usr/bin/java", "/opt/newrelic/newrelic.jar", "/tmp/my lib.so"}
+	if got := MappedFiles(maps); !reflect.DeepEqual(got, want) {
+		t.Errorf("MappedFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestAgentForPath(t *testing.T) {
+	tests := map[string]string{
+		"/opt/newrelic/newrelic.jar":                                                   "Java",
+		"/opt/app/lib/newrelic-agent-8.9.0.jar":                                        "Java",
+		"/usr/lib/php/20210902/newrelic.so":                                            "PHP",
+		"/usr/local/newrelic-dotnet-agent/libNewRelicProfiler.so":                      ".NET",
+		"/app/node_modules/newrelic/index.js":                                          "",
+		"/app/node_modules/@newrelic/native-metrics/build/Release/native_metrics.node": "Node",
+		"/usr/lib/python3/site-packages/newrelic/core/_thread_utilization.so":          "Python",
+		"/usr/lib/python3/site-packages/newrelic/config.py":                            "",
+		"/etc/newrelic/newrelic.yml":                                                   "",
+		"/usr/lib/x86_64-linux-gnu/libc.so.6":                                          "",
+	}
+	for path, want := range tests {
+		if got := AgentForPath(path); got != want {
+			t.Errorf("AgentForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFindLoadedAgentArtifacts(t *testing.T) {
+	procRoot := t.TempDir()
+	pidDir := filepath.Join(procRoot, "42")
+	os.MkdirAll(filepath.Join(pidDir, "fd"), 0755)
+	os.WriteFile(filepath.Join(pidDir, "maps"), []byte(
+		"7f3a1d200000-7f3a1d4f2000 r-xp 00000000 08:01 2040 /usr/lib/php/20210902/newrelic.so\n"+
+			"7f3a1e000000-7f3a1e1b7000 r-xp 00000000 08:01 1042 /usr/lib/x86_64-linux-gnu/libc.so.6\n"), 0644)
+	os.Symlink("/opt/newrelic/newrelic.jar", filepath.Join(pidDir, "fd", "3"))
+	os.Symlink("/usr/lib/php/20210902/newrelic.so", filepath.Join(pidDir, "fd", "4"))
+	os.Symlink("socket:[12345]", filepath.Join(pidDir, "fd", "5"))
+	os.Symlink("/var/log/app.log", filepath.Join(pidDir, "fd", "6"))
+
+	got, err := findLoadedAgentArtifacts(procRoot, 42)
+	if err != nil {
+		t.Fatalf("findLoadedAgentArtifacts() error = %v", err)
+	}
+	want := []AgentArtifact{
+		{Agent: "PHP", Path: "/usr/lib/php/20210902/newrelic.so", Source: ArtifactMapped},
+		{Agent: "Java", Path: "/opt/newrelic/newrelic.jar", Source: ArtifactOpen},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findLoadedAgentArtifacts() = %+v, want %+v", got, want)
+	}
+
+	if _, err := findLoadedAgentArtifacts(procRoot, 43); err == nil {
+		t.Errorf("findLoadedAgentArtifacts() of a missing process did not return an error")
+	}
+}
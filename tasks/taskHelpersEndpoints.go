@@ -0,0 +1,155 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+// Region identifiers match the prefixes Base/Config/RegionDetect parses from license keys
+const (
+	RegionUS      = "us01"
+	RegionEU      = "eu01"
+	RegionJP      = "jp01"
+	RegionFedRAMP = "fedramp"
+)
+
+// EndpointProduct - the New Relic product or ingest API an endpoint serves
+type EndpointProduct string
+
+// Products available in the endpoint catalog
+const (
+	ProductAPMCollector   EndpointProduct = "APM collector"
+	ProductInfra          EndpointProduct = "Infrastructure"
+	ProductInfraIdentity  EndpointProduct = "Infrastructure identity"
+	ProductInfraCommand   EndpointProduct = "Infrastructure command"
+	ProductLogs           EndpointProduct = "Log API"
+	ProductMetricAPI      EndpointProduct = "Metric API"
+	ProductEventAPI       EndpointProduct = "Event API"
+	ProductTraceAPI       EndpointProduct = "Trace API"
+	ProductOTLP           EndpointProduct = "OTLP"
+	ProductOpAMP          EndpointProduct = "OpAMP"
+	ProductFleetPublicKey EndpointProduct = "Fleet public keys"
+	ProductSystemIdentity EndpointProduct = "System identity"
+	ProductSynthetics     EndpointProduct = "Synthetics"
+)
+
+// Endpoint - a single New Relic endpoint for a region and product
+type Endpoint struct {
+	Region  string
+	Product EndpointProduct
+	Host    string
+	// Path is appended to the host when checking connectivity, some endpoints only answer on a specific path
+	Path string
+}
+
+// URL - returns the https URL used to check connectivity to the endpoint
+func (e Endpoint) URL() string {
+	return "https://" + e.Host + e.Path
+}
+
+// endpointCatalog - every endpoint the diagnostics tasks know about, keyed by region then product.
+// Regions without an entry for a product do not offer it.
+var endpointCatalog = map[string]map[EndpointProduct]Endpoint{
+	RegionUS: {
+		ProductAPMCollector:   {Host: "collector.newrelic.com", Path: "/status/mongrel"},
+		ProductInfra:          {Host: "infra-api.newrelic.com"},
+		ProductInfraIdentity:  {Host: "identity-api.newrelic.com"},
+		ProductInfraCommand:   {Host: "infrastructure-command-api.newrelic.com"},
+		ProductLogs:           {Host: "log-api.newrelic.com"},
+		ProductMetricAPI:      {Host: "metric-api.newrelic.com"},
+		ProductEventAPI:       {Host: "insights-collector.newrelic.com"},
+		ProductTraceAPI:       {Host: "trace-api.newrelic.com"},
+		ProductOTLP:           {Host: "otlp.nr-data.net"},
+		ProductOpAMP:          {Host: "opamp.service.newrelic.com", Path: "/v1/opamp"},
+		ProductFleetPublicKey: {Host: "publickeys.newrelic.com", Path: "/r/blob-management/global/agentconfiguration/jwks.json"},
+		ProductSystemIdentity: {Host: "system-identity-oauth.service.newrelic.com", Path: "/oauth2/token"},
+		ProductSynthetics:     {Host: "synthetics-horde.nr-data.net"},
+	},
+	RegionEU: {
+		ProductAPMCollector:   {Host: "collector.eu.newrelic.com", Path: "/status/mongrel"},
+		ProductInfra:          {Host: "infra-api.eu.newrelic.com"},
+		ProductInfraIdentity:  {Host: "identity-api.eu.newrelic.com"},
+		ProductInfraCommand:   {Host: "infrastructure-command-api.eu.newrelic.com"},
+		ProductLogs:           {Host: "log-api.eu.newrelic.com"},
+		ProductMetricAPI:      {Host: "metric-api.eu.newrelic.com"},
+		ProductEventAPI:       {Host: "insights-collector.eu01.nr-data.net"},
+		ProductTraceAPI:       {Host: "trace-api.eu.newrelic.com"},
+		ProductOTLP:           {Host: "otlp.eu01.nr-data.net"},
+		ProductOpAMP:          {Host: "opamp.service.eu.newrelic.com", Path: "/v1/opamp"},
+		ProductFleetPublicKey: {Host: "publickeys.eu.newrelic.com", Path: "/r/blob-management/global/agentconfiguration/jwks.json"},
+		ProductSystemIdentity: {Host: "system-identity-oauth.service.eu.newrelic.com", Path: "/oauth2/token"},
+		ProductSynthetics:     {Host: "synthetics-horde.eu01.nr-data.net"},
+	},
+	RegionJP: {
+		ProductAPMCollector:  {Host: "collector.jp.newrelic.com", Path: "/status/mongrel"},
+		ProductInfra:         {Host: "infra-api.jp.newrelic.com"},
+		ProductInfraIdentity: {Host: "identity-api.jp.newrelic.com"},
+		ProductInfraCommand:  {Host: "infrastructure-command-api.jp.newrelic.com"},
+		ProductLogs:          {Host: "log-api.jp.newrelic.com"},
+		ProductMetricAPI:     {Host: "metric-api.jp.newrelic.com"},
+		ProductEventAPI:      {Host: "insights-collector.jp01.nr-data.net"},
+		ProductTraceAPI:      {Host: "trace-api.jp.newrelic.com"},
+		ProductOTLP:          {Host: "otlp.jp01.nr-data.net"},
+	},
+	RegionFedRAMP: {
+		ProductAPMCollector:  {Host: "gov-collector.newrelic.com", Path: "/status/mongrel"},
+		ProductInfra:         {Host: "gov-infra-api.newrelic.com"},
+		ProductInfraIdentity: {Host: "gov-identity-api.newrelic.com"},
+		ProductInfraCommand:  {Host: "gov-infrastructure-command-api.newrelic.com"},
+		ProductLogs:          {Host: "gov-log-api.newrelic.com"},
+		ProductMetricAPI:     {Host: "gov-metric-api.newrelic.com"},
+		ProductEventAPI:      {Host: "gov-insights-collector.newrelic.com"},
+		ProductTraceAPI:      {Host: "gov-trace-api.newrelic.com"},
+		ProductOTLP:          {Host: "gov-otlp.nr-data.net"},
+	},
+}
+
+// GetEndpoint - returns the endpoint for the given region and product, and whether the region offers that product
+func GetEndpoint(region string, product EndpointProduct) (Endpoint, bool) {
+	region = NormalizeRegion(region)
+	endpoint, ok := endpointCatalog[region][product]
+	if !ok {
+		return Endpoint{}, false
+	}
+	endpoint.Region = region
+	endpoint.Product = product
+	return endpoint, true
+}
+
+// GetEndpoints - returns the endpoints of the given products for a region, in the order the products were given.
+// Products the region does not offer are skipped.
+func GetEndpoints(region string, products ...EndpointProduct) []Endpoint {
+	var endpoints []Endpoint
+	for _, product := range products {
+		if endpoint, ok := GetEndpoint(region, product); ok {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// RequestedRegion returns the region given with -region or NEW_RELIC_REGION, or an empty string when neither was given
+func RequestedRegion() string {
+	if config.RequestedRegion == "" || config.RequestedRegion == config.NoRegion {
+		return ""
+	}
+	return NormalizeRegion(string(config.RequestedRegion))
+}
+
+// NormalizeRegion - converts a region flag value (US, EU, JP, FedRAMP/gov) or detected region prefix into a catalog region.
+// An unknown region is returned lower cased and will not match any endpoint.
+func NormalizeRegion(region string) string {
+	r := strings.TrimSpace(strings.ToLower(region))
+	switch r {
+	case "us", RegionUS:
+		return RegionUS
+	case "eu", RegionEU:
+		return RegionEU
+	case "jp", RegionJP:
+		return RegionJP
+	case "gov", "fedramp", "gov01":
+		return RegionFedRAMP
+	}
+	return r
+}
@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+)
+
+func TestGetEndpoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		region  string
+		product EndpointProduct
+		want    string
+		wantOk  bool
+	}{
+		{name: "US collector", region: "us01", product: ProductAPMCollector, want: "https://collector.newrelic.com/status/mongrel", wantOk: true},
+		{name: "EU infra from the region flag value", region: "EU", product: ProductInfra, want: "https://infra-api.eu.newrelic.com", wantOk: true},
+		{name: "JP logs", region: "jp", product: ProductLogs, want: "https://log-api.jp.newrelic.com", wantOk: true},
+		{name: "FedRAMP OTLP", region: "gov", product: ProductOTLP, want: "https://gov-otlp.nr-data.net", wantOk: true},
+		{name: "product not offered in the region", region: "fedramp", product: ProductOpAMP, want: "https://", wantOk: false},
+		{name: "unknown region", region: "ap01", product: ProductAPMCollector, want: "https://", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := GetEndpoint(tt.region, tt.product)
+			if ok != tt.wantOk {
+				t.Errorf("GetEndpoint() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got.URL() != tt.want {
+				t.Errorf("GetEndpoint() URL = %v, want %v", got.URL(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEndpoints(t *testing.T) {
+	got := GetEndpoints("fedramp", ProductAPMCollector, ProductSynthetics, ProductMetricAPI)
+	want := []Endpoint{
+		{Region: RegionFedRAMP, Product: ProductAPMCollector, Host: "gov-collector.newrelic.com", Path: "/status/mongrel"},
+		{Region: RegionFedRAMP, Product: ProductMetricAPI, Host: "gov-metric-api.newrelic.com"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetEndpoints() = %v, want %v", got, want)
+	}
+}
+
+func TestRequestedRegion(t *testing.T) {
+	defer func() { config.RequestedRegion = config.NoRegion }()
+	for region, want := range map[config.Region]string{
+		config.NoRegion: "",
+		config.EURegion: RegionEU,
+		config.JPRegion: RegionJP,
+		config.USRegion: RegionUS,
+	} {
+		config.RequestedRegion = region
+		if got := RequestedRegion(); got != want {
+			t.Errorf("RequestedRegion() with %q = %q, want %q", region, got, want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package tasks
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted - the value credentials are replaced with
+const Redacted = "_REDACTED_"
+
+var (
+	// secretKeyRegex matches the env and argument names whose values are credentials
+	secretKeyRegex = regexp.MustCompile(`(?i)pass|secret|token|key|auth|credential`)
+	// userinfoRegex matches the user:password@ of a URL or DSN in any value, such as mysql://user:pw@host or user:pw@tcp(host)/db
+	userinfoRegex = regexp.MustCompile(`(?:[a-zA-Z][\w+.-]*://|^)([^\s/@:]*:[^\s/@]+)@`)
+)
+
+// IsSecretKey - reports whether an env variable or flag name holds a credential
+func IsSecretKey(name string) bool {
+	return secretKeyRegex.MatchString(name)
+}
+
+// URLUserinfo - the user:password of every URL or DSN in a value
+func URLUserinfo(value string) []string {
+	var found []string
+	for _, match := range userinfoRegex.FindAllStringSubmatch(value, -1) {
+		found = append(found, match[1])
+	}
+	return found
+}
+
+// ArgSecrets - the credentials in a command line: the values of secret flags, given as -flag=value or -flag value,
+// and the user:password of URLs and DSNs
+func ArgSecrets(args []string) []string {
+	var secrets []string
+	for i, arg := range args {
+		if flag, value, ok := strings.Cut(arg, "="); ok && strings.HasPrefix(flag, "-") && IsSecretKey(flag) && value != "" {
+			secrets = append(secrets, value)
+		} else if i > 0 && strings.HasPrefix(args[i-1], "-") && !strings.Contains(args[i-1], "=") && IsSecretKey(args[i-1]) {
+			secrets = append(secrets, arg)
+		}
+		secrets = append(secrets, URLUserinfo(arg)...)
+	}
+	return secrets
+}
+
+// RedactSecrets - replaces every secret found in a value with _REDACTED_
+func RedactSecrets(value string, secrets []string) string {
+	// longest first, so a secret containing another is replaced whole
+	sorted := append([]string(nil), secrets...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	for _, secret := range sorted {
+		if secret != "" {
+			value = strings.ReplaceAll(value, secret, Redacted)
+		}
+	}
+	return value
+}
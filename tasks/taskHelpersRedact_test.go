@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArgSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "flag=value", args: []string{"-password=hunter2", "-verbose=true"}, want: []string{"hunter2"}},
+		{name: "flag value", args: []string{"--api-key", "abc123", "-host", "db"}, want: []string{"abc123"}},
+		{name: "URL userinfo", args: []string{"-dsn", "mysql://root:s3cret@db:3306"}, want: []string{"root:s3cret"}},
+		{name: "DSN userinfo", args: []string{"root:s3cret@tcp(db:3306)/app"}, want: []string{"root:s3cret"}},
+		{name: "no credentials", args: []string{"-metrics", "-hostname", "db"}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ArgSecrets(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ArgSecrets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	got := RedactSecrets("-password=hunter2 -user=hunter -dsn mysql://root:s3cret@db", []string{"hunter", "root:s3cret", "hunter2"})
+	want := "-password=_REDACTED_ -user=_REDACTED_ -dsn mysql://_REDACTED_@db"
+	if got != want {
+		t.Errorf("RedactSecrets() = %q, want %q", got, want)
+	}
+}
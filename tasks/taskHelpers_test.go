@@ -862,3 +862,16 @@ var _ = Describe("Task Helpers", func() {
 	})
 
 })
+
+func Test_parseEnviron(t *testing.T) {
+	environ := []byte("PATH=/usr/bin\x00JAVA_TOOL_OPTIONS=-Dnewrelic.config.app_name=My App\x00OTEL_RESOURCE_ATTRIBUTES=service.name=api,env=prod\x00EMPTY=\x00NOVALUE\x00")
+	want := map[string]string{
+		"PATH":                     "/usr/bin",
+		"JAVA_TOOL_OPTIONS":        "-Dnewrelic.config.app_name=My App",
+		"OTEL_RESOURCE_ATTRIBUTES": "service.name=api,env=prod",
+		"EMPTY":                    "",
+	}
+	if got := parseEnviron(environ); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseEnviron() = %v, want %v", got, want)
+	}
+}
@@ -17,3 +17,6 @@ const NoAgentUpstreamSummary = "This task did not run because the following upst
 
 // UpstreamFailedSummary is the tasks.None summary that we display when we cannot run the current task because the previous one had some sort of failure. Beware! this summary expects a string concatenation at the end
 const UpstreamFailedSummary = "This task did not run because the following upstream task will need to succeed before the current one can run: "
+
+// OfflineModeSummary is the tasks.None summary for network dependent tasks that were skipped because nrdiag is running in offline mode
+const OfflineModeSummary = "Skipped: offline mode. This task requires network access and " + ThisProgramFullName + " was run with '-offline' or was unable to resolve New Relic hostnames."
@@ -94,6 +94,20 @@ type PayloadObfuscator interface {
 	ObfuscatePayload(payload interface{}) interface{}
 }
 
+// NetworkDependent is an optional interface that tasks can implement
+// to declare that they need to reach New Relic or another remote service.
+// When nrdiag runs in offline mode these tasks are not executed and return
+// a None result instead of waiting on network timeouts.
+type NetworkDependent interface {
+	RequiresNetwork() bool
+}
+
+// RequiresNetwork returns true if the task implements NetworkDependent and needs the network
+func RequiresNetwork(t Task) bool {
+	networkTask, ok := t.(NetworkDependent)
+	return ok && networkTask.RequiresNetwork()
+}
+
 // ByIdentifier is a sort helper to sort an array of tasks by their identifiers
 type ByIdentifier []Task
 
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	log "github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/version"
+)
+
+const updateUsage = `Usage:
+  nrdiag update [-allow-unsigned]
+
+Replaces this nrdiag binary with the latest release when it is newer. The download is checked
+against the published release checksums and their signature, and the previous binary is restored
+if the new one does not start. Builds without an update signing key refuse to update unless
+-allow-unsigned is given, which relies on the checksums alone.
+`
+
+// runUpdate - the nrdiag update command, returns the exit code
+func runUpdate(args []string) int {
+	flags := flag.NewFlagSet("update", flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, updateUsage) }
+	allowUnsigned := flags.Bool("allow-unsigned", false, "update even though this build cannot verify the release signature")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	if err := version.Update(log.Log, *allowUnsigned); err != nil {
+		log.Info("Unable to update:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,341 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/newrelic/newrelic-diagnostics-cli/config"
+	"github.com/newrelic/newrelic-diagnostics-cli/helpers/httpHelper"
+	"github.com/newrelic/newrelic-diagnostics-cli/logger"
+	"github.com/newrelic/newrelic-diagnostics-cli/tasks"
+)
+
+const (
+	downloadBaseURL       = `https://download.newrelic.com/nrdiag`
+	downloadTimeout       = 600
+	verifyTimeout         = 30 * time.Second
+	checksumsFileTemplate = "nrdiag_%s_checksums.txt"
+	signatureExtension    = ".sig"
+)
+
+// updater replaces the running binary with the latest release. Its fields are replaced in tests.
+type updater struct {
+	baseURL    string
+	goos       string
+	goarch     string
+	executable string
+	// signingKey is the base64 Ed25519 key the checksums file must be signed with. Builds without one refuse to update
+	// unless allowUnsigned is set, the checksums alone come from the same host as the binary and do not authenticate it.
+	signingKey    string
+	allowUnsigned bool
+	fetch         func(url string) (io.ReadCloser, error)
+	verifyBinary  func(path string, version string) error
+}
+
+// Update - the nrdiag update command, replaces the running binary with the latest release when it is newer.
+// allowUnsigned lets a build without an update signing key update with only the release checksums.
+func Update(log logger.API, allowUnsigned bool) error {
+	executable, err := os.Executable()
+	if err == nil {
+		executable, err = filepath.EvalSymlinks(executable)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to locate the running nrdiag binary: %w", err)
+	}
+	u := updater{
+		baseURL:       downloadBaseURL,
+		goos:          runtime.GOOS,
+		goarch:        runtime.GOARCH,
+		executable:    executable,
+		signingKey:    config.UpdateSigningKey,
+		allowUnsigned: allowUnsigned,
+		fetch:         fetch,
+		verifyBinary:  verifyBinary,
+	}
+	return u.run(log, config.Version)
+}
+
+// isNewer compares versions numerically so that an older online version is not offered as an update
+func isNewer(online string, current string) (bool, error) {
+	onlineVer, err := tasks.ParseVersion(online)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the latest version %q: %w", online, err)
+	}
+	currentVer, err := tasks.ParseVersion(current)
+	if err != nil {
+		// development builds are not numbered, any release is newer
+		return true, nil
+	}
+	return !currentVer.IsGreaterThanEq(onlineVer), nil
+}
+
+func (u updater) run(log logger.API, current string) error {
+	latest, err := u.fetchString(u.baseURL + "/version.txt")
+	if err != nil {
+		return fmt.Errorf("unable to check the latest version: %w", err)
+	}
+	newer, err := isNewer(latest, current)
+	if err != nil {
+		return err
+	}
+	if !newer {
+		log.Info("Already running the latest version", current)
+		return nil
+	}
+
+	archive, member, err := u.archiveName(latest)
+	if err != nil {
+		return err
+	}
+	checksums, err := u.checksums(latest)
+	if err != nil {
+		return err
+	}
+	expected, ok := checksums[archive]
+	if !ok {
+		return fmt.Errorf("no published checksum for %s", archive)
+	}
+
+	log.Infof("Downloading version %s\n", latest)
+	archivePath, err := u.download(u.baseURL+"/"+archive, expected)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archivePath)
+
+	newBinary, err := u.extract(archivePath, member)
+	if err != nil {
+		return err
+	}
+	if err := u.replace(newBinary, latest); err != nil {
+		os.Remove(newBinary)
+		return err
+	}
+	log.Infof("Updated %s from version %s to %s\n", u.executable, current, latest)
+	return nil
+}
+
+// archiveName returns the release archive for the platform and the binary's path inside it, matching scripts/upload.sh
+func (u updater) archiveName(version string) (string, string, error) {
+	arch := map[string]string{"amd64": "x64", "arm64": "arm64", "386": "x86"}[u.goarch]
+	switch {
+	case u.goos == "linux" && (arch == "x64" || arch == "arm64"):
+		return fmt.Sprintf("nrdiag_%s_Linux_%s.tar.gz", version, arch), "nrdiag_" + arch, nil
+	case u.goos == "windows" && arch == "x86":
+		return fmt.Sprintf("nrdiag_%s_Windows_x86.zip", version), "nrdiag.exe", nil
+	case u.goos == "windows" && arch != "":
+		return fmt.Sprintf("nrdiag_%s_Windows_%s.zip", version, arch), "nrdiag_" + arch + ".exe", nil
+	case u.goos == "darwin" && (arch == "x64" || arch == "arm64"):
+		return fmt.Sprintf("nrdiag_%s.zip", version), "nrdiag/mac/nrdiag_" + arch, nil
+	}
+	return "", "", fmt.Errorf("no release is published for %s/%s", u.goos, u.goarch)
+}
+
+// checksums fetches the sha256sum formatted checksums of a release and verifies their signature
+func (u updater) checksums(version string) (map[string]string, error) {
+	if u.signingKey == "" && !u.allowUnsigned {
+		return nil, errors.New("this build has no update signing key, so the release cannot be authenticated. Download the release from " + downloadBaseURL + " or run nrdiag update -allow-unsigned to rely on its checksums only")
+	}
+	checksumsURL := u.baseURL + "/" + fmt.Sprintf(checksumsFileTemplate, version)
+	content, err := u.fetchBytes(checksumsURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download the release checksums: %w", err)
+	}
+	if u.signingKey != "" {
+		key, err := base64.StdEncoding.DecodeString(u.signingKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, errors.New("this build has an invalid update signing key")
+		}
+		signature, err := u.fetchBytes(checksumsURL + signatureExtension)
+		if err != nil {
+			return nil, fmt.Errorf("unable to download the release checksums signature: %w", err)
+		}
+		if !ed25519.Verify(ed25519.PublicKey(key), content, signature) {
+			return nil, errors.New("the release checksums signature is invalid")
+		}
+	}
+
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return checksums, nil
+}
+
+// download saves the archive to a temporary file and checks it against the published checksum
+func (u updater) download(url string, expected string) (string, error) {
+	body, err := u.fetch(url)
+	if err != nil {
+		return "", fmt.Errorf("unable to download %s: %w", url, err)
+	}
+	defer body.Close()
+	out, err := os.CreateTemp("", "nrdiag-update-*")
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && hex.EncodeToString(hash.Sum(nil)) != expected {
+		err = fmt.Errorf("checksum mismatch for %s", path.Base(url))
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// extract writes the binary from the archive next to the running binary so it can be renamed into place
+func (u updater) extract(archivePath string, member string) (string, error) {
+	out, err := os.CreateTemp(filepath.Dir(u.executable), ".nrdiag-update-*")
+	if err != nil {
+		return "", fmt.Errorf("unable to write to %s: %w", filepath.Dir(u.executable), err)
+	}
+	err = extractMember(archivePath, member, out)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(out.Name(), 0755)
+	}
+	if err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// extractMember copies one file out of a zip or tar.gz archive
+func extractMember(archivePath string, member string, out io.Writer) error {
+	if zipReader, err := zip.OpenReader(archivePath); err == nil {
+		defer zipReader.Close()
+		for _, file := range zipReader.File {
+			if path.Clean(file.Name) == member {
+				content, err := file.Open()
+				if err != nil {
+					return err
+				}
+				defer content.Close()
+				_, err = io.Copy(out, content)
+				return err
+			}
+		}
+		return fmt.Errorf("%s is missing from the release archive", member)
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return errors.New("the release archive is not a zip or tar.gz file")
+	}
+	tarReader := tar.NewReader(gz)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("%s is missing from the release archive", member)
+		}
+		if err != nil {
+			return err
+		}
+		if path.Clean(header.Name) == member && header.Typeflag == tar.TypeReg {
+			_, err = io.Copy(out, tarReader)
+			return err
+		}
+	}
+}
+
+// replace swaps the new binary into place and runs it, restoring the previous binary if it does not start.
+// The previous binary is renamed rather than deleted as Windows does not allow deleting a running executable.
+func (u updater) replace(newBinary string, version string) error {
+	previous := u.executable + ".old"
+	os.Remove(previous) // left behind by an earlier update on Windows
+	if err := os.Rename(u.executable, previous); err != nil {
+		return fmt.Errorf("unable to replace %s: %w", u.executable, err)
+	}
+	if err := os.Rename(newBinary, u.executable); err != nil {
+		os.Rename(previous, u.executable)
+		return fmt.Errorf("unable to replace %s: %w", u.executable, err)
+	}
+	if err := u.verifyBinary(u.executable, version); err != nil {
+		os.Remove(u.executable)
+		if rollbackErr := os.Rename(previous, u.executable); rollbackErr != nil {
+			return fmt.Errorf("the new version failed to start (%v) and the previous version could not be restored from %s: %w", err, previous, rollbackErr)
+		}
+		return fmt.Errorf("the new version failed to start, kept version %s: %w", config.Version, err)
+	}
+	os.Remove(previous)
+	return nil
+}
+
+// verifyBinary checks the new binary runs and reports the expected version
+func verifyBinary(binary string, version string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, binary, "-version", "-skip-version-check").CombinedOutput()
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(output), version) {
+		return fmt.Errorf("expected version %s, got: %s", version, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func (u updater) fetchString(url string) (string, error) {
+	content, err := u.fetchBytes(url)
+	return strings.TrimSpace(string(content)), err
+}
+
+func (u updater) fetchBytes(url string) ([]byte, error) {
+	body, err := u.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+func fetch(url string) (io.ReadCloser, error) {
+	wrapper := httpHelper.NewHTTPRequestWrapper()
+	wrapper.URL = url
+	wrapper.TimeoutSeconds = downloadTimeout
+	resp, err := httpHelper.MakeHTTPRequest(wrapper)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.New(resp.Status)
+	}
+	return resp.Body, nil
+}
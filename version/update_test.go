@@ -0,0 +1,249 @@
+package version
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_isNewer(t *testing.T) {
+	tests := []struct {
+		online  string
+		current string
+		want    bool
+		wantErr bool
+	}{
+		{online: "3.8.1", current: "3.8.0", want: true},
+		{online: "3.10.0", current: "3.9.0", want: true},
+		{online: "3.8.0", current: "3.8.0", want: false},
+		{online: "3.7.9", current: "3.8.0", want: false},
+		{online: "3.8.0", current: "DEVELOP", want: true},
+		{online: "<html>", current: "3.8.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.online+" vs "+tt.current, func(t *testing.T) {
+			got, err := isNewer(tt.online, tt.current)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("isNewer() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_archiveName(t *testing.T) {
+	tests := []struct {
+		goos, goarch, archive, member string
+	}{
+		{"linux", "amd64", "nrdiag_3.8.1_Linux_x64.tar.gz", "nrdiag_x64"},
+		{"linux", "arm64", "nrdiag_3.8.1_Linux_arm64.tar.gz", "nrdiag_arm64"},
+		{"windows", "386", "nrdiag_3.8.1_Windows_x86.zip", "nrdiag.exe"},
+		{"windows", "amd64", "nrdiag_3.8.1_Windows_x64.zip", "nrdiag_x64.exe"},
+		{"darwin", "arm64", "nrdiag_3.8.1.zip", "nrdiag/mac/nrdiag_arm64"},
+		{"freebsd", "amd64", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.goos+"/"+tt.goarch, func(t *testing.T) {
+			archive, member, err := updater{goos: tt.goos, goarch: tt.goarch}.archiveName("3.8.1")
+			if archive != tt.archive || member != tt.member || (err != nil) != (tt.archive == "") {
+				t.Errorf("archiveName() = %q, %q, %v", archive, member, err)
+			}
+		})
+	}
+}
+
+func tarGz(name string, content []byte) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "./" + name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg})
+	tw.Write(content)
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func zipArchive(name string, content []byte) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, _ := zw.Create(name)
+	w.Write(content)
+	zw.Close()
+	return buf.Bytes()
+}
+
+// release is a download site serving version.txt, a signed checksums file and the archives
+type release struct {
+	files map[string][]byte
+}
+
+func newRelease(version string, archives map[string][]byte, privateKey ed25519.PrivateKey) release {
+	r := release{files: map[string][]byte{"/version.txt": []byte(version + "\n")}}
+	checksums := new(bytes.Buffer)
+	for name, content := range archives {
+		r.files["/"+name] = content
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(checksums, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+	checksumsName := "/" + fmt.Sprintf(checksumsFileTemplate, version)
+	r.files[checksumsName] = checksums.Bytes()
+	r.files[checksumsName+signatureExtension] = ed25519.Sign(privateKey, checksums.Bytes())
+	return r
+}
+
+func (r release) fetch(url string) (io.ReadCloser, error) {
+	content, ok := r.files[strings.TrimPrefix(url, "https://example.com/nrdiag")]
+	if !ok {
+		return nil, errors.New("404 Not Found")
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func Test_updaterRun(t *testing.T) {
+	publicKey, privateKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	signingKey := base64.StdEncoding.EncodeToString(publicKey)
+	linux := map[string][]byte{"nrdiag_3.8.1_Linux_x64.tar.gz": tarGz("nrdiag_x64", []byte("new binary"))}
+
+	tests := []struct {
+		name         string
+		current      string
+		goos         string
+		release      release
+		tamper       func(release)
+		unsigned     bool
+		allowUnsign  bool
+		verifyErr    error
+		wantErr      string
+		wantContent  string
+		wantVerified bool
+	}{
+		{
+			name:         "replaces the binary from a tar.gz",
+			current:      "3.8.0",
+			goos:         "linux",
+			release:      newRelease("3.8.1", linux, privateKey),
+			wantContent:  "new binary",
+			wantVerified: true,
+		},
+		{
+			name:         "replaces the binary from a zip",
+			current:      "3.8.0",
+			goos:         "windows",
+			release:      newRelease("3.8.1", map[string][]byte{"nrdiag_3.8.1_Windows_x64.zip": zipArchive("nrdiag_x64.exe", []byte("new exe"))}, privateKey),
+			wantContent:  "new exe",
+			wantVerified: true,
+		},
+		{
+			name:        "does not downgrade",
+			current:     "3.9.0",
+			goos:        "linux",
+			release:     newRelease("3.8.1", linux, privateKey),
+			wantContent: "old binary",
+		},
+		{
+			name:        "rejects a modified archive",
+			current:     "3.8.0",
+			goos:        "linux",
+			release:     newRelease("3.8.1", linux, privateKey),
+			tamper:      func(r release) { r.files["/nrdiag_3.8.1_Linux_x64.tar.gz"] = tarGz("nrdiag_x64", []byte("evil")) },
+			wantErr:     "checksum mismatch",
+			wantContent: "old binary",
+		},
+		{
+			name:        "rejects checksums signed with another key",
+			current:     "3.8.0",
+			goos:        "linux",
+			release:     newRelease("3.8.1", linux, otherKey),
+			wantErr:     "signature is invalid",
+			wantContent: "old binary",
+		},
+		{
+			name:        "refuses to update from a build without a signing key",
+			current:     "3.8.0",
+			goos:        "linux",
+			release:     newRelease("3.8.1", linux, privateKey),
+			unsigned:    true,
+			wantErr:     "cannot be authenticated",
+			wantContent: "old binary",
+		},
+		{
+			name:         "updates from a build without a signing key when allowed",
+			current:      "3.8.0",
+			goos:         "linux",
+			release:      newRelease("3.8.1", linux, otherKey),
+			unsigned:     true,
+			allowUnsign:  true,
+			wantContent:  "new binary",
+			wantVerified: true,
+		},
+		{
+			name:         "rolls back when the new binary does not start",
+			current:      "3.8.0",
+			goos:         "linux",
+			release:      newRelease("3.8.1", linux, privateKey),
+			verifyErr:    errors.New("exec format error"),
+			wantErr:      "failed to start",
+			wantContent:  "old binary",
+			wantVerified: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tamper != nil {
+				tt.tamper(tt.release)
+			}
+			dir := t.TempDir()
+			executable := filepath.Join(dir, "nrdiag")
+			os.WriteFile(executable, []byte("old binary"), 0755)
+			verified := false
+			key := signingKey
+			if tt.unsigned {
+				key = ""
+			}
+			u := updater{
+				baseURL:       "https://example.com/nrdiag",
+				goos:          tt.goos,
+				goarch:        "amd64",
+				executable:    executable,
+				signingKey:    key,
+				allowUnsigned: tt.allowUnsign,
+				fetch:         tt.release.fetch,
+				verifyBinary: func(path string, version string) error {
+					verified = true
+					if content, _ := os.ReadFile(path); version != "3.8.1" || !strings.HasPrefix(string(content), "new") {
+						t.Errorf("verifyBinary() called for version %s with %q", version, content)
+					}
+					return tt.verifyErr
+				},
+			}
+
+			runningLog = ""
+			err := u.run(logCapture, tt.current)
+			if (tt.wantErr == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("run() error = %v, want %q", err, tt.wantErr)
+			}
+			if content, _ := os.ReadFile(executable); string(content) != tt.wantContent {
+				t.Errorf("run() left binary %q, want %q", content, tt.wantContent)
+			}
+			if verified != tt.wantVerified {
+				t.Errorf("run() verified the binary = %v, want %v", verified, tt.wantVerified)
+			}
+			if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+				t.Errorf("run() left %d files next to the binary, want 1", len(entries))
+			}
+		})
+	}
+}
@@ -12,17 +12,16 @@ import (
 	"github.com/newrelic/newrelic-diagnostics-cli/output/color"
 )
 
-const versionURL = `http://download.newrelic.com/nrdiag/version.txt`
-const downloadURL = `http://download.newrelic.com/nrdiag/nrdiag_latest.zip`
+const versionURL = downloadBaseURL + `/version.txt`
 
 // ProcessAutoVersionCheck - looks at the program version and warns the user if it is out of date, takes no actions
 func ProcessAutoVersionCheck() bool {
 	return processAutoVersionCheck(logger.Log, getOnlineVersion)
 }
 
-// ProcessVersion - looks at the program version and warns the user if it is out of date, prompts user and is able to download
+// ProcessVersion - looks at the program version and warns the user if it is out of date, prompts user and is able to update
 func ProcessVersion(promptUser func(string) bool) {
-	processVersion(logger.Log, promptUser, getOnlineVersion, getLatestVersion)
+	processVersion(logger.Log, promptUser, getOnlineVersion, func(log logger.API) error { return Update(log, false) })
 }
 
 func getOnlineVersion(log logger.API) string {
@@ -52,39 +51,6 @@ func getOnlineVersion(log logger.API) string {
 	return version
 }
 
-func getLatestVersion(log logger.API) error {
-
-	// Create the file
-	out, err := os.Create("nrdiag_latest.zip")
-	if err != nil {
-		log.Info("Error downloading file,", err)
-		// should panic without `return` here
-		return err
-	}
-	defer out.Close()
-
-	wrapper := httpHelper.RequestWrapper{
-		Method: "GET",
-		URL:    downloadURL,
-	}
-
-	resp, httpErr := httpHelper.MakeHTTPRequest(wrapper)
-	if httpErr != nil {
-		log.Info("Error downloading file,", httpErr)
-		return httpErr
-	}
-	defer resp.Body.Close()
-
-	// Writer the body to file
-	_, err = io.Copy(out, resp.Body)
-	if err != nil {
-		log.Info("Error writing file", err)
-		return err
-	}
-
-	return nil
-}
-
 func logVersionString(log logger.API) {
 	if !config.Flags.VeryQuiet {
 		log.Infof("New Relic Diagnostics - release version - %s - Build timestamp - %s\n", config.Version, config.BuildTimestamp)
@@ -93,7 +59,7 @@ func logVersionString(log logger.API) {
 
 func processAutoVersionCheck(log logger.API, getOnlineVersion func(logger.API) string) bool {
 	onlineVersion := getOnlineVersion(log)
-	if onlineVersion != "" && onlineVersion != config.Version {
+	if onlineVersion != "" && isOutdated(log, onlineVersion) {
 		if !config.Flags.VeryQuiet {
 			logVersionString(log)
 			log.Infof(color.ColorString(color.Yellow, "Version %s has been released and is newer than your version.\n"), onlineVersion)
@@ -101,16 +67,26 @@ func processAutoVersionCheck(log logger.API, getOnlineVersion func(logger.API) s
 			if config.Flags.InNewRelicCLI {
 				command = "newrelic diagnose update"
 			} else {
-				command = fmt.Sprintf("%s -version", os.Args[0])
+				command = fmt.Sprintf("%s update", os.Args[0])
 			}
-			log.Infof("Please run '%s' to download the new release.", command)
+			log.Infof("Please run '%s' to update to the new release.", command)
 		}
 		return false
 	}
 	return true
 }
 
-func processVersion(log logger.API, promptUser func(input string) bool, getOnlineVersion func(logger.API) string, getLatestVersion func(logger.API) error) {
+// isOutdated - whether the online version is newer than this build, an unparseable online version is not treated as an update
+func isOutdated(log logger.API, onlineVersion string) bool {
+	newer, err := isNewer(onlineVersion, config.Version)
+	if err != nil {
+		log.Debug(err)
+		return false
+	}
+	return newer
+}
+
+func processVersion(log logger.API, promptUser func(input string) bool, getOnlineVersion func(logger.API) string, update func(logger.API) error) {
 	logVersionString(log)
 
 	if config.Flags.SkipVersionCheck {
@@ -127,17 +103,15 @@ func processVersion(log logger.API, promptUser func(input string) bool, getOnlin
 			log.Info("Online version found:", onlineVersion)
 		}
 
-		if onlineVersion != config.Version {
+		if onlineVersion != "" && isOutdated(log, onlineVersion) {
 
-			if promptUser("Do you want to download the latest version?") {
+			if promptUser("Do you want to update to the latest version?") {
 				if !config.Flags.Quiet {
-					log.Info("Downloading latest version")
+					log.Info("Updating to the latest version")
 				}
-				err := getLatestVersion(log)
-				if !config.Flags.Quiet {
-					if err == nil {
-						log.Infof("Version %s downloaded and saved as nrdiag_latest.zip\n", onlineVersion)
-					}
+				err := update(log)
+				if err != nil {
+					log.Info("Unable to update:", err)
 				}
 			}
 		} else {
@@ -31,7 +31,8 @@ func (l loggerMethods) Dump(...interface{})             {}
 
 var logCapture = loggerMethods{}
 
-var getOnlineVersionWrong = func(log logger.API) string { return "test.version.wrong" }
+var getOnlineVersionWrong = func(log logger.API) string { return "9.9.9" }
+var getOnlineVersionOlder = func(log logger.API) string { return "1.2.2" }
 var getOnlineVersionCorrect = func(log logger.API) string { return config.Version }
 var getLatestVersionTest = func(logger.API) error { return nil }
 
@@ -60,6 +61,12 @@ func Test_processAutoVersionCheck(t *testing.T) {
 			appVer: "1.2.3",
 			not:    regexp.MustCompile("is newer than your version"),
 		},
+		{
+			name:   "Says nothing when the online version is older",
+			args:   args{logCapture, getOnlineVersionOlder},
+			appVer: "1.2.3",
+			not:    regexp.MustCompile("is newer than your version"),
+		},
 	}
 
 	for _, tt := range tests {
@@ -82,7 +89,7 @@ func Test_processVersion(t *testing.T) {
 		logger           logger.API
 		promptUser       func(string) bool
 		getOnlineVersion func(logger.API) string
-		getLatestVersion func(logger.API) error
+		update           func(logger.API) error
 	}
 
 	tests := []struct {
@@ -102,7 +109,7 @@ func Test_processVersion(t *testing.T) {
 			name:   "Attempts to download",
 			args:   args{logCapture, promptUserAllow, getOnlineVersionWrong, getLatestVersionTest},
 			appVer: "1.2.3",
-			want:   regexp.MustCompile("Downloading latest version"),
+			want:   regexp.MustCompile("Updating to the latest version"),
 		},
 		{
 			name:   "Does not attempt to check for newer version",
@@ -114,13 +121,19 @@ func Test_processVersion(t *testing.T) {
 			name:   "Does not download",
 			args:   args{logCapture, promptUserDeny, getOnlineVersionWrong, getLatestVersionTest},
 			appVer: "1.2.3",
-			not:    regexp.MustCompile("Downloading latest version"),
+			not:    regexp.MustCompile("Updating to the latest version"),
 		},
 		{
 			name:   "Does not download if version matches",
 			args:   args{logCapture, promptUserAllow, getOnlineVersionCorrect, getLatestVersionTest},
 			appVer: "1.2.3",
-			not:    regexp.MustCompile("Downloading latest version"),
+			not:    regexp.MustCompile("Updating to the latest version"),
+		},
+		{
+			name:   "Does not download an older version",
+			args:   args{logCapture, promptUserAllow, getOnlineVersionOlder, getLatestVersionTest},
+			appVer: "1.2.3",
+			not:    regexp.MustCompile("Updating to the latest version"),
 		},
 	}
 
@@ -128,7 +141,7 @@ func Test_processVersion(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			runningLog = ""
 			config.Version = tt.appVer
-			processVersion(tt.args.logger, tt.args.promptUser, tt.args.getOnlineVersion, tt.args.getLatestVersion)
+			processVersion(tt.args.logger, tt.args.promptUser, tt.args.getOnlineVersion, tt.args.update)
 			if tt.want != nil && !tt.want.MatchString(runningLog) {
 				t.Error("Failed on match: '" + tt.want.String() + "' pattern not found.\n" + runningLog)
 			}